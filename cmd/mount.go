@@ -0,0 +1,75 @@
+//go:build fuse
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/bootstrap"
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/fuse"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+var (
+	mountUser        string
+	mountReadOnly    bool
+	mountAttrTimeout time.Duration
+	mountOptions     []string
+)
+
+// MountCmd represents the mount command
+var MountCmd = &cobra.Command{
+	Use:   "mount [src] [mountpoint]",
+	Short: "Mount a path of OpenList as a local file system via FUSE",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		bootstrap.Init()
+		defer bootstrap.Release()
+		user, err := op.GetAdmin()
+		if mountUser != "" {
+			user, err = op.GetUserByName(mountUser)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get user: %+v", err)
+		}
+		if user.Disabled {
+			return fmt.Errorf("user [%s] is disabled", user.Username)
+		}
+		bootstrap.LoadStorages()
+		<-conf.StoragesLoadSignal()
+		opts := make([]string, 0, len(mountOptions)*2)
+		for _, o := range mountOptions {
+			opts = append(opts, "-o", o)
+		}
+		host := fuse.Mount(args[0], args[1], fuse.MountOptions{
+			User:        user,
+			ReadOnly:    mountReadOnly,
+			AttrTimeout: mountAttrTimeout,
+			Options:     opts,
+		})
+		quit := make(chan os.Signal, 1)
+		signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+		go func() {
+			<-quit
+			utils.Log.Println("Unmounting...")
+			host.Unmount()
+		}()
+		utils.Log.Infof("mounted [%s] at [%s] as user [%s]", args[0], args[1], user.Username)
+		return host.Wait()
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(MountCmd)
+	MountCmd.Flags().StringVar(&mountUser, "user", "", "user whose permissions apply to the mount, default is the admin")
+	MountCmd.Flags().BoolVar(&mountReadOnly, "read-only", false, "mount read-only")
+	MountCmd.Flags().DurationVar(&mountAttrTimeout, "attr-timeout", 10*time.Second, "how long directory listings are cached")
+	MountCmd.Flags().StringArrayVarP(&mountOptions, "option", "o", nil, "options passed to FUSE, e.g. allow_other")
+}
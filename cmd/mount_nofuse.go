@@ -0,0 +1,22 @@
+//go:build !fuse
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// MountCmd represents the mount command
+var MountCmd = &cobra.Command{
+	Use:   "mount [src] [mountpoint]",
+	Short: "Mount a path of OpenList as a local file system via FUSE",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return fmt.Errorf("this binary is built without FUSE support, rebuild it with `-tags fuse`")
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(MountCmd)
+}
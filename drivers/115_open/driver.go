@@ -222,15 +222,34 @@ func (d *Open115) Remove(ctx context.Context, obj model.Obj) error {
 }
 
 func (d *Open115) Put(ctx context.Context, dstDir model.Obj, file model.FileStreamer, up driver.UpdateProgress) error {
-	err := d.WaitLimit(ctx)
+	resp, err := d.initUpload(ctx, dstDir, file, up)
+	if err != nil || resp == nil {
+		return err
+	}
+	// 3. get upload token
+	tokenResp, err := d.client.UploadGetToken(ctx)
 	if err != nil {
 		return err
 	}
+	// 4. upload
+	err = d.multpartUpload(ctx, file, up, tokenResp, resp)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// initUpload hashes the file and inits the upload, a nil resp means it's uploaded by rapid upload
+func (d *Open115) initUpload(ctx context.Context, dstDir model.Obj, file model.FileStreamer, up driver.UpdateProgress) (*sdk.UploadInitResp, error) {
+	err := d.WaitLimit(ctx)
+	if err != nil {
+		return nil, err
+	}
 	sha1 := file.GetHash().GetHash(utils.SHA1)
 	if len(sha1) != utils.SHA1.Width {
 		_, sha1, err = stream.CacheFullAndHash(file, &up, utils.SHA1)
 		if err != nil {
-			return err
+			return nil, err
 		}
 	}
 	const PreHashSize int64 = 128 * utils.KB
@@ -240,11 +259,11 @@ func (d *Open115) Put(ctx context.Context, dstDir model.Obj, file model.FileStre
 	}
 	reader, err := file.RangeRead(http_range.Range{Start: 0, Length: hashSize})
 	if err != nil {
-		return err
+		return nil, err
 	}
 	sha1128k, err := utils.HashReader(utils.SHA1, reader)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	// 1. Init
 	resp, err := d.client.UploadInit(ctx, &sdk.UploadInitReq{
@@ -255,30 +274,30 @@ func (d *Open115) Put(ctx context.Context, dstDir model.Obj, file model.FileStre
 		PreID:    strings.ToUpper(sha1128k),
 	})
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if resp.Status == 2 {
 		up(100)
-		return nil
+		return nil, nil
 	}
 	// 2. two way verify
 	if utils.SliceContains([]int{6, 7, 8}, resp.Status) {
 		signCheck := strings.Split(resp.SignCheck, "-") //"sign_check": "2392148-2392298" 取2392148-2392298之间的内容(包含2392148、2392298)的sha1
 		start, err := strconv.ParseInt(signCheck[0], 10, 64)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		end, err := strconv.ParseInt(signCheck[1], 10, 64)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		reader, err = file.RangeRead(http_range.Range{Start: start, Length: end - start + 1})
 		if err != nil {
-			return err
+			return nil, err
 		}
 		signVal, err := utils.HashReader(utils.SHA1, reader)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		resp, err = d.client.UploadInit(ctx, &sdk.UploadInitReq{
 			FileName: file.GetName(),
@@ -290,24 +309,14 @@ func (d *Open115) Put(ctx context.Context, dstDir model.Obj, file model.FileStre
 			SignVal:  strings.ToUpper(signVal),
 		})
 		if err != nil {
-			return err
+			return nil, err
 		}
 		if resp.Status == 2 {
 			up(100)
-			return nil
+			return nil, nil
 		}
 	}
-	// 3. get upload token
-	tokenResp, err := d.client.UploadGetToken(ctx)
-	if err != nil {
-		return err
-	}
-	// 4. upload
-	err = d.multpartUpload(ctx, file, up, tokenResp, resp)
-	if err != nil {
-		return err
-	}
-	return nil
+	return resp, nil
 }
 
 func (d *Open115) OfflineDownload(ctx context.Context, uris []string, dstDir model.Obj) ([]string, error) {
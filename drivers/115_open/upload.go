@@ -3,7 +3,9 @@ package _115_open
 import (
 	"context"
 	"encoding/base64"
+	"encoding/json"
 	"io"
+	"strconv"
 	"time"
 
 	sdk "github.com/OpenListTeam/115-sdk-go"
@@ -83,18 +85,24 @@ func (d *Open115) multpartUpload(ctx context.Context, stream model.FileStreamer,
 	if err != nil {
 		return err
 	}
+	return d.uploadParts(ctx, bucket, imur, initResp.Callback.Value.Callback, initResp.Callback.Value.CallbackVar, stream, nil, up)
+}
 
+// uploadParts uploads the parts of the stream after the uploaded ones and completes the upload
+func (d *Open115) uploadParts(ctx context.Context, bucket *oss.Bucket, imur oss.InitiateMultipartUploadResult, callback, callbackVar string, stream model.FileStreamer, parts []oss.UploadPart, up driver.UpdateProgress) error {
 	fileSize := stream.GetSize()
 	chunkSize := calPartSize(fileSize)
 	ss, err := streamPkg.NewStreamSectionReader(stream, int(chunkSize), &up)
 	if err != nil {
 		return err
 	}
+	offset := min(int64(len(parts))*chunkSize, fileSize)
+	if err = ss.DiscardSection(0, offset); err != nil {
+		return err
+	}
 
 	partNum := (stream.GetSize() + chunkSize - 1) / chunkSize
-	parts := make([]oss.UploadPart, partNum)
-	offset := int64(0)
-	for i := int64(1); i <= partNum; i++ {
+	for i := int64(len(parts)) + 1; i <= partNum; i++ {
 		if utils.IsCanceled(ctx) {
 			return ctx.Err()
 		}
@@ -113,7 +121,7 @@ func (d *Open115) multpartUpload(ctx context.Context, stream model.FileStreamer,
 			if err != nil {
 				return err
 			}
-			parts[i-1] = part
+			parts = append(parts, part)
 			return nil
 		},
 			retry.Context(ctx),
@@ -137,8 +145,8 @@ func (d *Open115) multpartUpload(ctx context.Context, stream model.FileStreamer,
 	_, err = bucket.CompleteMultipartUpload(
 		imur,
 		parts,
-		oss.Callback(base64.StdEncoding.EncodeToString([]byte(initResp.Callback.Value.Callback))),
-		oss.CallbackVar(base64.StdEncoding.EncodeToString([]byte(initResp.Callback.Value.CallbackVar))),
+		oss.Callback(base64.StdEncoding.EncodeToString([]byte(callback))),
+		oss.CallbackVar(base64.StdEncoding.EncodeToString([]byte(callbackVar))),
 		// oss.CallbackResult(&callbackRespBytes),
 	)
 	if err != nil {
@@ -147,3 +155,106 @@ func (d *Open115) multpartUpload(ctx context.Context, stream model.FileStreamer,
 
 	return nil
 }
+
+type uploadState struct {
+	Bucket      string `json:"bucket"`
+	Object      string `json:"object"`
+	UploadID    string `json:"upload_id"`
+	Callback    string `json:"callback"`
+	CallbackVar string `json:"callback_var"`
+}
+
+// bucket gets the oss bucket of the upload with a new token, as the token of the session may expire
+func (d *Open115) bucket(ctx context.Context, name string) (*oss.Bucket, error) {
+	tokenResp, err := d.client.UploadGetToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ossClient, err := oss.New(tokenResp.Endpoint, tokenResp.AccessKeyId, tokenResp.AccessKeySecret, oss.SecurityToken(tokenResp.SecurityToken))
+	if err != nil {
+		return nil, err
+	}
+	return ossClient.Bucket(name)
+}
+
+// uploadedParts returns the parts uploaded from the first one without a gap
+func (d *Open115) uploadedParts(ctx context.Context, s uploadState, fileSize int64) (*oss.Bucket, []oss.UploadPart, error) {
+	bucket, err := d.bucket(ctx, s.Bucket)
+	if err != nil {
+		return nil, nil, err
+	}
+	imur := oss.InitiateMultipartUploadResult{Bucket: s.Bucket, Key: s.Object, UploadID: s.UploadID}
+	chunkSize := calPartSize(fileSize)
+	var parts []oss.UploadPart
+	marker := 0
+	for {
+		res, err := bucket.ListUploadedParts(imur, oss.PartNumberMarker(marker))
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, part := range res.UploadedParts {
+			if part.PartNumber != len(parts)+1 || int64(part.Size) != min(chunkSize, fileSize-int64(len(parts))*chunkSize) {
+				return bucket, parts, nil
+			}
+			parts = append(parts, oss.UploadPart{PartNumber: part.PartNumber, ETag: part.ETag})
+		}
+		if !res.IsTruncated {
+			return bucket, parts, nil
+		}
+		if marker, err = strconv.Atoi(res.NextPartNumberMarker); err != nil {
+			return nil, nil, err
+		}
+	}
+}
+
+func (d *Open115) BeginUpload(ctx context.Context, dstDir model.Obj, file model.FileStreamer, up driver.UpdateProgress) (string, error) {
+	initResp, err := d.initUpload(ctx, dstDir, file, up)
+	if err != nil || initResp == nil {
+		return "", err
+	}
+	bucket, err := d.bucket(ctx, initResp.Bucket)
+	if err != nil {
+		return "", err
+	}
+	imur, err := bucket.InitiateMultipartUpload(initResp.Object, oss.Sequential())
+	if err != nil {
+		return "", err
+	}
+	state, err := json.Marshal(uploadState{
+		Bucket:      initResp.Bucket,
+		Object:      initResp.Object,
+		UploadID:    imur.UploadID,
+		Callback:    initResp.Callback.Value.Callback,
+		CallbackVar: initResp.Callback.Value.CallbackVar,
+	})
+	return string(state), err
+}
+
+func (d *Open115) UploadedOffset(ctx context.Context, dstDir model.Obj, file model.FileStreamer, state string) (int64, error) {
+	var s uploadState
+	if err := json.Unmarshal([]byte(state), &s); err != nil {
+		return 0, err
+	}
+	_, parts, err := d.uploadedParts(ctx, s, file.GetSize())
+	if err != nil {
+		return 0, err
+	}
+	return min(int64(len(parts))*calPartSize(file.GetSize()), file.GetSize()), nil
+}
+
+func (d *Open115) ResumeUpload(ctx context.Context, dstDir model.Obj, file model.FileStreamer, state string, offset int64, save func(string), up driver.UpdateProgress) (model.Obj, error) {
+	var s uploadState
+	if err := json.Unmarshal([]byte(state), &s); err != nil {
+		return nil, err
+	}
+	bucket, parts, err := d.uploadedParts(ctx, s, file.GetSize())
+	if err != nil {
+		return nil, err
+	}
+	chunkSize := calPartSize(file.GetSize())
+	parts = parts[:min(int64(len(parts)), (offset+chunkSize-1)/chunkSize)]
+	imur := oss.InitiateMultipartUploadResult{Bucket: s.Bucket, Key: s.Object, UploadID: s.UploadID}
+	return nil, d.uploadParts(ctx, bucket, imur, s.Callback, s.CallbackVar, file, parts, up)
+}
+
+var _ driver.ResumablePut = (*Open115)(nil)
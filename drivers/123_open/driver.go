@@ -208,29 +208,13 @@ func (d *Open123) Put(ctx context.Context, dstDir model.Obj, file model.FileStre
 	}
 
 	// 2. 上传分片
-	err = d.Upload(ctx, file, createResp, up)
+	err = d.Upload(ctx, file, createResp, 0, nil, up)
 	if err != nil {
 		return nil, err
 	}
 
 	// 3. 上传完毕
-	for range 60 {
-		uploadCompleteResp, err := d.complete(createResp.Data.PreuploadID)
-		// 返回错误代码未知，如：20103，文档也没有具体说
-		if err == nil && uploadCompleteResp.Data.Completed && uploadCompleteResp.Data.FileID != 0 {
-			up(100)
-			return File{
-				FileName: file.GetName(),
-				Size:     file.GetSize(),
-				FileId:   uploadCompleteResp.Data.FileID,
-				Type:     2,
-				Etag:     etag,
-			}, nil
-		}
-		// 若接口返回的completed为 false 时，则需间隔1秒继续轮询此接口，获取上传最终结果。
-		time.Sleep(time.Second)
-	}
-	return nil, fmt.Errorf("upload complete timeout")
+	return d.waitComplete(file, createResp.Data.PreuploadID, etag, up)
 }
 
 func (d *Open123) GetDetails(ctx context.Context) (*model.StorageDetails, error) {
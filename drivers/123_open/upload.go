@@ -10,6 +10,7 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/OpenListTeam/OpenList/v4/drivers/base"
@@ -42,7 +43,9 @@ func (d *Open123) create(parentFileID int64, filename string, etag string, size
 }
 
 // 上传分片 V2
-func (d *Open123) Upload(ctx context.Context, file model.FileStreamer, createResp *UploadCreateResp, up driver.UpdateProgress) error {
+// uploaded is the number of slices uploaded already, and done is called with the number
+// of slices uploaded from the first one without a gap as it grows, if not nil
+func (d *Open123) Upload(ctx context.Context, file model.FileStreamer, createResp *UploadCreateResp, uploaded int64, done func(uploaded int64), up driver.UpdateProgress) error {
 	uploadDomain := createResp.Data.Servers[0]
 	size := file.GetSize()
 	chunkSize := createResp.Data.SliceSize
@@ -51,15 +54,20 @@ func (d *Open123) Upload(ctx context.Context, file model.FileStreamer, createRes
 	if err != nil {
 		return err
 	}
+	if err = ss.DiscardSection(0, min(uploaded*chunkSize, size)); err != nil {
+		return err
+	}
 
 	uploadNums := (size + chunkSize - 1) / chunkSize
-	thread := min(int(uploadNums), d.UploadThread)
+	thread := max(min(int(uploadNums-uploaded), d.UploadThread), 1)
 	threadG, uploadCtx := errgroup.NewOrderedGroupWithContext(ctx, thread,
 		retry.Attempts(3),
 		retry.Delay(time.Second),
 		retry.DelayType(retry.BackOffDelay))
 
-	for partIndex := range uploadNums {
+	var mu sync.Mutex
+	finished := make([]bool, uploadNums)
+	for partIndex := uploaded; partIndex < uploadNums; partIndex++ {
 		if utils.IsCanceled(uploadCtx) {
 			break
 		}
@@ -153,7 +161,17 @@ func (d *Open123) Upload(ctx context.Context, file model.FileStreamer, createRes
 					return fmt.Errorf("slice %d upload failed: %s", partNumber, resp.Message)
 				}
 
-				progress := 100 * float64(threadG.Success()+1) / float64(uploadNums+1)
+				mu.Lock()
+				finished[partIndex] = true
+				n := uploaded
+				for uploaded < uploadNums && finished[uploaded] {
+					uploaded++
+				}
+				if done != nil && uploaded > n {
+					done(uploaded)
+				}
+				progress := 100 * float64(uploaded+1) / float64(uploadNums+1)
+				mu.Unlock()
 				up(progress)
 				return nil
 			},
@@ -183,3 +201,86 @@ func (d *Open123) complete(preuploadID string) (*UploadCompleteResp, error) {
 	}
 	return &resp, nil
 }
+
+// 轮询上传结果
+func (d *Open123) waitComplete(file model.FileStreamer, preuploadID string, etag string, up driver.UpdateProgress) (model.Obj, error) {
+	for range 60 {
+		uploadCompleteResp, err := d.complete(preuploadID)
+		// 返回错误代码未知，如：20103，文档也没有具体说
+		if err == nil && uploadCompleteResp.Data.Completed && uploadCompleteResp.Data.FileID != 0 {
+			up(100)
+			return File{
+				FileName: file.GetName(),
+				Size:     file.GetSize(),
+				FileId:   uploadCompleteResp.Data.FileID,
+				Type:     2,
+				Etag:     etag,
+			}, nil
+		}
+		// 若接口返回的completed为 false 时，则需间隔1秒继续轮询此接口，获取上传最终结果。
+		time.Sleep(time.Second)
+	}
+	return nil, fmt.Errorf("upload complete timeout")
+}
+
+// uploadState is the state of a resumable upload, 123 has no api to list the uploaded
+// slices so they are checkpointed by the number of slices uploaded without a gap
+type uploadState struct {
+	Create   *UploadCreateResp `json:"create"`
+	Etag     string            `json:"etag"`
+	Uploaded int64             `json:"uploaded"`
+}
+
+func (d *Open123) BeginUpload(ctx context.Context, dstDir model.Obj, file model.FileStreamer, up driver.UpdateProgress) (string, error) {
+	parentFileId, err := strconv.ParseInt(dstDir.GetID(), 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("parse parentFileID error: %v", err)
+	}
+	etag := file.GetHash().GetHash(utils.MD5)
+	if len(etag) < utils.MD5.Width {
+		_, etag, err = stream.CacheFullAndHash(file, &up, utils.MD5)
+		if err != nil {
+			return "", err
+		}
+	}
+	createResp, err := d.create(parentFileId, file.GetName(), etag, file.GetSize(), 2, false)
+	if err != nil {
+		return "", err
+	}
+	if createResp.Data.Reuse && createResp.Data.FileID != 0 {
+		up(100)
+		return "", nil
+	}
+	state, err := json.Marshal(uploadState{Create: createResp, Etag: etag})
+	return string(state), err
+}
+
+func (d *Open123) UploadedOffset(ctx context.Context, dstDir model.Obj, file model.FileStreamer, state string) (int64, error) {
+	var s uploadState
+	if err := json.Unmarshal([]byte(state), &s); err != nil {
+		return 0, err
+	}
+	if s.Create == nil || len(s.Create.Data.Servers) == 0 {
+		return 0, fmt.Errorf("invalid upload state")
+	}
+	return min(s.Uploaded*s.Create.Data.SliceSize, file.GetSize()), nil
+}
+
+func (d *Open123) ResumeUpload(ctx context.Context, dstDir model.Obj, file model.FileStreamer, state string, offset int64, save func(string), up driver.UpdateProgress) (model.Obj, error) {
+	var s uploadState
+	if err := json.Unmarshal([]byte(state), &s); err != nil {
+		return nil, err
+	}
+	err := d.Upload(ctx, file, s.Create, offset/s.Create.Data.SliceSize, func(uploaded int64) {
+		s.Uploaded = uploaded
+		if state, err := json.Marshal(s); err == nil {
+			save(string(state))
+		}
+	}, up)
+	if err != nil {
+		return nil, err
+	}
+	return d.waitComplete(file, s.Create.Data.PreuploadID, s.Etag, up)
+}
+
+var _ driver.ResumablePut = (*Open123)(nil)
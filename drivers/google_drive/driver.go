@@ -112,57 +112,17 @@ func (d *GoogleDrive) Remove(ctx context.Context, obj model.Obj) error {
 }
 
 func (d *GoogleDrive) Put(ctx context.Context, dstDir model.Obj, stream model.FileStreamer, up driver.UpdateProgress) error {
-	obj := stream.GetExist()
-	var (
-		e    Error
-		url  string
-		data base.Json
-		res  *resty.Response
-		err  error
-	)
-	if obj != nil {
-		url = fmt.Sprintf("https://www.googleapis.com/upload/drive/v3/files/%s?uploadType=resumable&supportsAllDrives=true", obj.GetID())
-		data = base.Json{}
-	} else {
-		data = base.Json{
-			"name":    stream.GetName(),
-			"parents": []string{dstDir.GetID()},
-		}
-		url = "https://www.googleapis.com/upload/drive/v3/files?uploadType=resumable&supportsAllDrives=true"
-	}
-	req := base.NoRedirectClient.R().
-		SetHeaders(map[string]string{
-			"Authorization":           "Bearer " + d.AccessToken,
-			"X-Upload-Content-Type":   stream.GetMimetype(),
-			"X-Upload-Content-Length": strconv.FormatInt(stream.GetSize(), 10),
-		}).
-		SetError(&e).SetBody(data).SetContext(ctx)
-	if obj != nil {
-		res, err = req.Patch(url)
-	} else {
-		res, err = req.Post(url)
-	}
+	putUrl, err := d.createUploadSession(ctx, dstDir, stream)
 	if err != nil {
 		return err
 	}
-	if e.Error.Code != 0 {
-		if e.Error.Code == 401 {
-			err = d.refreshToken()
-			if err != nil {
-				return err
-			}
-			return d.Put(ctx, dstDir, stream, up)
-		}
-		return fmt.Errorf("%s: %v", e.Error.Message, e.Error.Errors)
-	}
-	putUrl := res.Header().Get("location")
 	if stream.GetSize() < d.ChunkSize*1024*1024 {
 		_, err = d.request(putUrl, http.MethodPut, func(req *resty.Request) {
 			req.SetHeader("Content-Length", strconv.FormatInt(stream.GetSize(), 10)).
 				SetBody(driver.NewLimitedUploadStream(ctx, stream))
 		}, nil)
 	} else {
-		err = d.chunkUpload(ctx, stream, putUrl, up)
+		err = d.chunkUpload(ctx, stream, putUrl, 0, up)
 	}
 	return err
 }
@@ -0,0 +1,22 @@
+package google_drive
+
+import (
+	"context"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/driver"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+)
+
+func (d *GoogleDrive) BeginUpload(ctx context.Context, dstDir model.Obj, file model.FileStreamer, up driver.UpdateProgress) (string, error) {
+	return d.createUploadSession(ctx, dstDir, file)
+}
+
+func (d *GoogleDrive) UploadedOffset(ctx context.Context, dstDir model.Obj, file model.FileStreamer, state string) (int64, error) {
+	return d.uploadedOffset(ctx, state, file.GetSize())
+}
+
+func (d *GoogleDrive) ResumeUpload(ctx context.Context, dstDir model.Obj, file model.FileStreamer, state string, offset int64, save func(string), up driver.UpdateProgress) (model.Obj, error) {
+	return nil, d.chunkUpload(ctx, file, state, offset, up)
+}
+
+var _ driver.ResumablePut = (*GoogleDrive)(nil)
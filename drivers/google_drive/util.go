@@ -10,6 +10,7 @@ import (
 	"os"
 	"regexp"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/OpenListTeam/OpenList/v4/internal/op"
@@ -332,14 +333,97 @@ func (d *GoogleDrive) batchGetTargetFilesInfo(targetIds []string) map[string]Fil
 	return result
 }
 
-func (d *GoogleDrive) chunkUpload(ctx context.Context, file model.FileStreamer, url string, up driver.UpdateProgress) error {
+// createUploadSession returns the url of a resumable upload session of the stream
+func (d *GoogleDrive) createUploadSession(ctx context.Context, dstDir model.Obj, stream model.FileStreamer) (string, error) {
+	obj := stream.GetExist()
+	var (
+		e    Error
+		url  string
+		data base.Json
+		res  *resty.Response
+		err  error
+	)
+	if obj != nil {
+		url = fmt.Sprintf("https://www.googleapis.com/upload/drive/v3/files/%s?uploadType=resumable&supportsAllDrives=true", obj.GetID())
+		data = base.Json{}
+	} else {
+		data = base.Json{
+			"name":    stream.GetName(),
+			"parents": []string{dstDir.GetID()},
+		}
+		url = "https://www.googleapis.com/upload/drive/v3/files?uploadType=resumable&supportsAllDrives=true"
+	}
+	req := base.NoRedirectClient.R().
+		SetHeaders(map[string]string{
+			"Authorization":           "Bearer " + d.AccessToken,
+			"X-Upload-Content-Type":   stream.GetMimetype(),
+			"X-Upload-Content-Length": strconv.FormatInt(stream.GetSize(), 10),
+		}).
+		SetError(&e).SetBody(data).SetContext(ctx)
+	if obj != nil {
+		res, err = req.Patch(url)
+	} else {
+		res, err = req.Post(url)
+	}
+	if err != nil {
+		return "", err
+	}
+	if e.Error.Code != 0 {
+		if e.Error.Code == 401 {
+			err = d.refreshToken()
+			if err != nil {
+				return "", err
+			}
+			return d.createUploadSession(ctx, dstDir, stream)
+		}
+		return "", fmt.Errorf("%s: %v", e.Error.Message, e.Error.Errors)
+	}
+	return res.Header().Get("location"), nil
+}
+
+// uploadedOffset queries how many bytes the upload session has received
+func (d *GoogleDrive) uploadedOffset(ctx context.Context, url string, size int64) (int64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, url, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Authorization", "Bearer "+d.AccessToken)
+	req.Header.Set("Content-Range", fmt.Sprintf("bytes */%d", size))
+	res, err := base.HttpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+	switch res.StatusCode {
+	case http.StatusOK, http.StatusCreated:
+		return size, nil
+	case http.StatusPermanentRedirect:
+		// "Range: bytes=0-42" if any bytes were received
+		r := res.Header.Get("Range")
+		if r == "" {
+			return 0, nil
+		}
+		_, end, _ := strings.Cut(r, "-")
+		last, err := strconv.ParseInt(end, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid range %s: %w", r, err)
+		}
+		return last + 1, nil
+	default:
+		return 0, fmt.Errorf("failed get upload session status: %s", res.Status)
+	}
+}
+
+func (d *GoogleDrive) chunkUpload(ctx context.Context, file model.FileStreamer, url string, offset int64, up driver.UpdateProgress) error {
 	defaultChunkSize := d.ChunkSize * 1024 * 1024
 	ss, err := stream.NewStreamSectionReader(file, int(defaultChunkSize), &up)
 	if err != nil {
 		return err
 	}
+	if err = ss.DiscardSection(0, offset); err != nil {
+		return err
+	}
 
-	var offset int64 = 0
 	url += "?includeItemsFromAllDrives=true&supportsAllDrives=true"
 	for offset < file.GetSize() {
 		if utils.IsCanceled(ctx) {
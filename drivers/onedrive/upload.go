@@ -0,0 +1,62 @@
+package onedrive
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/OpenListTeam/OpenList/v4/drivers/base"
+	"github.com/OpenListTeam/OpenList/v4/internal/driver"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+)
+
+// BeginUpload puts the small files by a simple upload like Put, which leaves nothing to resume
+func (d *Onedrive) BeginUpload(ctx context.Context, dstDir model.Obj, file model.FileStreamer, up driver.UpdateProgress) (string, error) {
+	if file.GetSize() <= 4*1024*1024 {
+		return "", d.upSmall(ctx, dstDir, file)
+	}
+	return d.createUploadSession(ctx, dstDir, file)
+}
+
+// UploadedOffset gets the start of the next expected range of the upload session,
+// the state is the upload url which needs no authorization
+func (d *Onedrive) UploadedOffset(ctx context.Context, dstDir model.Obj, file model.FileStreamer, state string) (int64, error) {
+	var resp struct {
+		NextExpectedRanges []string `json:"nextExpectedRanges"`
+	}
+	res, err := base.RestyClient.R().SetContext(ctx).SetResult(&resp).Get(state)
+	if err != nil {
+		return 0, err
+	}
+	if res.IsError() {
+		return 0, fmt.Errorf("failed get upload session: %s", res.String())
+	}
+	if len(resp.NextExpectedRanges) == 0 {
+		return file.GetSize(), nil
+	}
+	start, _, _ := strings.Cut(resp.NextExpectedRanges[0], "-")
+	return strconv.ParseInt(start, 10, 64)
+}
+
+func (d *Onedrive) ResumeUpload(ctx context.Context, dstDir model.Obj, file model.FileStreamer, state string, offset int64, save func(string), up driver.UpdateProgress) (model.Obj, error) {
+	return nil, d.upChunks(ctx, state, file, offset, up)
+}
+
+// AbortUpload deletes the upload session, whose url needs no authorization either
+func (d *Onedrive) AbortUpload(ctx context.Context, state string) error {
+	res, err := base.RestyClient.R().SetContext(ctx).Delete(state)
+	if err != nil {
+		return err
+	}
+	if res.IsError() && res.StatusCode() != http.StatusNotFound {
+		return fmt.Errorf("failed delete upload session: %s", res.String())
+	}
+	return nil
+}
+
+var (
+	_ driver.ResumablePut  = (*Onedrive)(nil)
+	_ driver.UploadAborter = (*Onedrive)(nil)
+)
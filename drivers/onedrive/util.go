@@ -231,23 +231,38 @@ func toAPIMetadata(stream model.FileStreamer) Metadata {
 	return metadata
 }
 
-func (d *Onedrive) upBig(ctx context.Context, dstDir model.Obj, stream model.FileStreamer, up driver.UpdateProgress) error {
+func (d *Onedrive) createUploadSession(ctx context.Context, dstDir model.Obj, stream model.FileStreamer) (string, error) {
 	url := d.GetMetaUrl(false, stdpath.Join(dstDir.GetPath(), stream.GetName())) + "/createUploadSession"
 	metadata := map[string]any{"item": toAPIMetadata(stream)}
 	res, err := d.Request(url, http.MethodPost, func(req *resty.Request) {
 		req.SetBody(metadata).SetContext(ctx)
 	}, nil)
+	if err != nil {
+		return "", err
+	}
+	return jsoniter.Get(res, "uploadUrl").ToString(), nil
+}
+
+func (d *Onedrive) upBig(ctx context.Context, dstDir model.Obj, stream model.FileStreamer, up driver.UpdateProgress) error {
+	uploadUrl, err := d.createUploadSession(ctx, dstDir, stream)
 	if err != nil {
 		return err
 	}
+	return d.upChunks(ctx, uploadUrl, stream, 0, up)
+}
+
+// upChunks uploads the stream from offset through the upload session
+func (d *Onedrive) upChunks(ctx context.Context, uploadUrl string, stream model.FileStreamer, offset int64, up driver.UpdateProgress) error {
 	DEFAULT := d.ChunkSize * 1024 * 1024
 	ss, err := streamPkg.NewStreamSectionReader(stream, int(DEFAULT), &up)
 	if err != nil {
 		return err
 	}
+	if err = ss.DiscardSection(0, offset); err != nil {
+		return err
+	}
 
-	uploadUrl := jsoniter.Get(res, "uploadUrl").ToString()
-	var finish int64 = 0
+	finish := offset
 	for finish < stream.GetSize() {
 		if utils.IsCanceled(ctx) {
 			return ctx.Err()
@@ -83,6 +83,13 @@ func (d *S3) List(ctx context.Context, dir model.Obj, args model.ListArgs) ([]mo
 	return d.listV1(dir.GetPath(), args)
 }
 
+func (d *S3) ListPage(ctx context.Context, dir model.Obj, args model.ListPageArgs) ([]model.Obj, string, error) {
+	if d.ListObjectVersion == "v2" {
+		return d.listV2Page(dir.GetPath(), args.Cursor, args.Limit, args.ListArgs)
+	}
+	return d.listV1Page(dir.GetPath(), args.Cursor, args.Limit, args.ListArgs)
+}
+
 func (d *S3) Link(ctx context.Context, file model.Obj, args model.LinkArgs) (*model.Link, error) {
 	path := getKey(file.GetPath(), false)
 	fileName := stdpath.Base(path)
@@ -243,4 +250,7 @@ func (d *S3) GetDirectUploadInfo(ctx context.Context, _ string, dstDir model.Obj
 	}, nil
 }
 
-var _ driver.Driver = (*S3)(nil)
+var (
+	_ driver.Driver      = (*S3)(nil)
+	_ driver.PagedLister = (*S3)(nil)
+)
@@ -0,0 +1,176 @@
+package s3
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	stdpath "path"
+	"sort"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/driver"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/stream"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+)
+
+type uploadState struct {
+	Key      string `json:"key"`
+	UploadID string `json:"upload_id"`
+	PartSize int64  `json:"part_size"`
+}
+
+// limitedPart is a rate limited part which stays seekable for signing
+type limitedPart struct {
+	io.ReadSeeker
+	limited io.Reader
+}
+
+func (p *limitedPart) Read(b []byte) (int, error) {
+	return p.limited.Read(b)
+}
+
+func partSize(size int64) int64 {
+	if size > s3manager.MaxUploadParts*s3manager.DefaultUploadPartSize {
+		return size / (s3manager.MaxUploadParts - 1)
+	}
+	return s3manager.DefaultUploadPartSize
+}
+
+// BeginUpload puts the files that fit in one part directly, which leaves nothing to resume
+func (d *S3) BeginUpload(ctx context.Context, dstDir model.Obj, file model.FileStreamer, up driver.UpdateProgress) (string, error) {
+	if file.GetSize() <= s3manager.DefaultUploadPartSize {
+		return "", d.Put(ctx, dstDir, file, up)
+	}
+	key := getKey(stdpath.Join(dstDir.GetPath(), file.GetName()), false)
+	contentType := file.GetMimetype()
+	resp, err := d.client.CreateMultipartUploadWithContext(ctx, &s3.CreateMultipartUploadInput{
+		Bucket:      &d.Bucket,
+		Key:         &key,
+		ContentType: &contentType,
+	})
+	if err != nil {
+		return "", err
+	}
+	state, err := json.Marshal(uploadState{
+		Key:      key,
+		UploadID: *resp.UploadId,
+		PartSize: partSize(file.GetSize()),
+	})
+	return string(state), err
+}
+
+// uploadedParts returns the parts uploaded from the first one without a gap
+func (d *S3) uploadedParts(ctx context.Context, state uploadState) ([]*s3.CompletedPart, error) {
+	var parts []*s3.Part
+	err := d.client.ListPartsPagesWithContext(ctx, &s3.ListPartsInput{
+		Bucket:   &d.Bucket,
+		Key:      &state.Key,
+		UploadId: &state.UploadID,
+	}, func(page *s3.ListPartsOutput, _ bool) bool {
+		parts = append(parts, page.Parts...)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(parts, func(i, j int) bool {
+		return *parts[i].PartNumber < *parts[j].PartNumber
+	})
+	var completed []*s3.CompletedPart
+	for i, part := range parts {
+		if *part.PartNumber != int64(i+1) || *part.Size != state.PartSize {
+			break
+		}
+		completed = append(completed, &s3.CompletedPart{ETag: part.ETag, PartNumber: part.PartNumber})
+	}
+	return completed, nil
+}
+
+func (d *S3) UploadedOffset(ctx context.Context, dstDir model.Obj, file model.FileStreamer, state string) (int64, error) {
+	var s uploadState
+	if err := json.Unmarshal([]byte(state), &s); err != nil {
+		return 0, err
+	}
+	parts, err := d.uploadedParts(ctx, s)
+	if err != nil {
+		return 0, err
+	}
+	return min(int64(len(parts))*s.PartSize, file.GetSize()), nil
+}
+
+func (d *S3) ResumeUpload(ctx context.Context, dstDir model.Obj, file model.FileStreamer, state string, offset int64, save func(string), up driver.UpdateProgress) (model.Obj, error) {
+	var s uploadState
+	if err := json.Unmarshal([]byte(state), &s); err != nil {
+		return nil, err
+	}
+	parts, err := d.uploadedParts(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+	size := file.GetSize()
+	if int64(len(parts))*s.PartSize < offset {
+		return nil, fmt.Errorf("the uploaded parts end before offset %d", offset)
+	}
+	parts = parts[:(offset+s.PartSize-1)/s.PartSize]
+	ss, err := stream.NewStreamSectionReader(file, int(s.PartSize), &up)
+	if err != nil {
+		return nil, err
+	}
+	if err = ss.DiscardSection(0, offset); err != nil {
+		return nil, err
+	}
+	for offset < size {
+		if utils.IsCanceled(ctx) {
+			return nil, ctx.Err()
+		}
+		length := min(s.PartSize, size-offset)
+		rd, err := ss.GetSectionReader(offset, length)
+		if err != nil {
+			return nil, err
+		}
+		partNumber := int64(len(parts) + 1)
+		resp, err := d.client.UploadPartWithContext(ctx, &s3.UploadPartInput{
+			Bucket:        &d.Bucket,
+			Key:           &s.Key,
+			UploadId:      &s.UploadID,
+			PartNumber:    &partNumber,
+			ContentLength: &length,
+			Body:          &limitedPart{ReadSeeker: rd, limited: driver.NewLimitedUploadStream(ctx, rd)},
+		})
+		ss.FreeSectionReader(rd)
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, &s3.CompletedPart{ETag: resp.ETag, PartNumber: &partNumber})
+		offset += length
+		up(float64(offset) * 100 / float64(size))
+	}
+	_, err = d.client.CompleteMultipartUploadWithContext(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          &d.Bucket,
+		Key:             &s.Key,
+		UploadId:        &s.UploadID,
+		MultipartUpload: &s3.CompletedMultipartUpload{Parts: parts},
+	})
+	return nil, err
+}
+
+func (d *S3) AbortUpload(ctx context.Context, state string) error {
+	var s uploadState
+	if err := json.Unmarshal([]byte(state), &s); err != nil {
+		return err
+	}
+	_, err := d.client.AbortMultipartUploadWithContext(ctx, &s3.AbortMultipartUploadInput{
+		Bucket:   &d.Bucket,
+		Key:      &s.Key,
+		UploadId: &s.UploadID,
+	})
+	return err
+}
+
+var (
+	_ driver.ResumablePut  = (*S3)(nil)
+	_ driver.UploadAborter = (*S3)(nil)
+)
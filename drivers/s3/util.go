@@ -99,112 +99,151 @@ func getPlaceholderName(placeholder string) string {
 }
 
 func (d *S3) listV1(dirPath string, args model.ListArgs) ([]model.Obj, error) {
-	prefix := getKey(dirPath, true)
-	log.Debugf("list: %s", prefix)
 	files := make([]model.Obj, 0)
 	marker := ""
 	for {
-		input := &s3.ListObjectsInput{
-			Bucket:    &d.Bucket,
-			Marker:    &marker,
-			Prefix:    &prefix,
-			Delimiter: aws.String("/"),
-		}
-		listObjectsResult, err := d.client.ListObjects(input)
+		page, next, err := d.listV1Page(dirPath, marker, 0, args)
 		if err != nil {
 			return nil, err
 		}
-		for _, object := range listObjectsResult.CommonPrefixes {
-			name := path.Base(strings.Trim(*object.Prefix, "/"))
-			file := model.Object{
-				Path:     path.Join(dirPath, name),
-				Name:     name,
-				Modified: d.Modified,
-				IsFolder: true,
-			}
-			files = append(files, &file)
+		files = append(files, page...)
+		if next == "" {
+			break
 		}
-		for _, object := range listObjectsResult.Contents {
-			name := path.Base(*object.Key)
-			if !args.S3ShowPlaceholder && (name == getPlaceholderName(d.Placeholder) || name == d.Placeholder) {
-				continue
-			}
-			file := model.Object{
-				Path:     path.Join(dirPath, name),
-				Name:     name,
-				Size:     *object.Size,
-				Modified: *object.LastModified,
-			}
-			files = append(files, &file)
+		marker = next
+	}
+	return files, nil
+}
+
+func (d *S3) listV1Page(dirPath, marker string, limit int, args model.ListArgs) ([]model.Obj, string, error) {
+	prefix := getKey(dirPath, true)
+	log.Debugf("list: %s", prefix)
+	files := make([]model.Obj, 0)
+	input := &s3.ListObjectsInput{
+		Bucket:    &d.Bucket,
+		Marker:    &marker,
+		Prefix:    &prefix,
+		Delimiter: aws.String("/"),
+	}
+	if limit > 0 {
+		input.MaxKeys = aws.Int64(int64(limit))
+	}
+	listObjectsResult, err := d.client.ListObjects(input)
+	if err != nil {
+		return nil, "", err
+	}
+	for _, object := range listObjectsResult.CommonPrefixes {
+		name := path.Base(strings.Trim(*object.Prefix, "/"))
+		file := model.Object{
+			Path:     path.Join(dirPath, name),
+			Name:     name,
+			Modified: d.Modified,
+			IsFolder: true,
 		}
-		if listObjectsResult.IsTruncated == nil {
-			return nil, errors.New("IsTruncated nil")
+		files = append(files, &file)
+	}
+	for _, object := range listObjectsResult.Contents {
+		name := path.Base(*object.Key)
+		if !args.S3ShowPlaceholder && (name == getPlaceholderName(d.Placeholder) || name == d.Placeholder) {
+			continue
 		}
-		if *listObjectsResult.IsTruncated {
-			marker = *listObjectsResult.NextMarker
-		} else {
-			break
+		file := model.Object{
+			Path:     path.Join(dirPath, name),
+			Name:     name,
+			Size:     *object.Size,
+			Modified: *object.LastModified,
 		}
+		files = append(files, &file)
 	}
-	return files, nil
+	if listObjectsResult.IsTruncated == nil {
+		return nil, "", errors.New("IsTruncated nil")
+	}
+	if *listObjectsResult.IsTruncated {
+		return files, *listObjectsResult.NextMarker, nil
+	}
+	return files, "", nil
 }
 
 func (d *S3) listV2(dirPath string, args model.ListArgs) ([]model.Obj, error) {
-	prefix := getKey(dirPath, true)
 	files := make([]model.Obj, 0)
-	var continuationToken, startAfter *string
+	cursor := ""
 	for {
-		input := &s3.ListObjectsV2Input{
-			Bucket:            &d.Bucket,
-			ContinuationToken: continuationToken,
-			Prefix:            &prefix,
-			Delimiter:         aws.String("/"),
-			StartAfter:        startAfter,
-		}
-		listObjectsResult, err := d.client.ListObjectsV2(input)
+		page, next, err := d.listV2Page(dirPath, cursor, 0, args)
 		if err != nil {
 			return nil, err
 		}
-		log.Debugf("resp: %+v", listObjectsResult)
-		for _, object := range listObjectsResult.CommonPrefixes {
-			name := path.Base(strings.Trim(*object.Prefix, "/"))
-			file := model.Object{
-				Path:     path.Join(dirPath, name),
-				Name:     name,
-				Modified: d.Modified,
-				IsFolder: true,
-			}
-			files = append(files, &file)
+		files = append(files, page...)
+		if next == "" {
+			break
 		}
-		for _, object := range listObjectsResult.Contents {
-			if strings.HasSuffix(*object.Key, "/") {
-				continue
-			}
-			name := path.Base(*object.Key)
-			if !args.S3ShowPlaceholder && (name == getPlaceholderName(d.Placeholder) || name == d.Placeholder) {
-				continue
-			}
-			file := model.Object{
-				Path:     path.Join(dirPath, name),
-				Name:     name,
-				Size:     *object.Size,
-				Modified: *object.LastModified,
-			}
-			files = append(files, &file)
+		cursor = next
+	}
+	return files, nil
+}
+
+const (
+	// cursors of listV2Page
+	continuationTokenCursor = "token:"
+	startAfterCursor        = "after:"
+)
+
+func (d *S3) listV2Page(dirPath, cursor string, limit int, args model.ListArgs) ([]model.Obj, string, error) {
+	prefix := getKey(dirPath, true)
+	files := make([]model.Obj, 0)
+	input := &s3.ListObjectsV2Input{
+		Bucket:    &d.Bucket,
+		Prefix:    &prefix,
+		Delimiter: aws.String("/"),
+	}
+	if token, ok := strings.CutPrefix(cursor, continuationTokenCursor); ok {
+		input.ContinuationToken = &token
+	} else if key, ok := strings.CutPrefix(cursor, startAfterCursor); ok {
+		input.StartAfter = &key
+	}
+	if limit > 0 {
+		input.MaxKeys = aws.Int64(int64(limit))
+	}
+	listObjectsResult, err := d.client.ListObjectsV2(input)
+	if err != nil {
+		return nil, "", err
+	}
+	log.Debugf("resp: %+v", listObjectsResult)
+	for _, object := range listObjectsResult.CommonPrefixes {
+		name := path.Base(strings.Trim(*object.Prefix, "/"))
+		file := model.Object{
+			Path:     path.Join(dirPath, name),
+			Name:     name,
+			Modified: d.Modified,
+			IsFolder: true,
 		}
-		if !aws.BoolValue(listObjectsResult.IsTruncated) {
-			break
+		files = append(files, &file)
+	}
+	for _, object := range listObjectsResult.Contents {
+		if strings.HasSuffix(*object.Key, "/") {
+			continue
 		}
-		if listObjectsResult.NextContinuationToken != nil {
-			continuationToken = listObjectsResult.NextContinuationToken
+		name := path.Base(*object.Key)
+		if !args.S3ShowPlaceholder && (name == getPlaceholderName(d.Placeholder) || name == d.Placeholder) {
 			continue
 		}
-		if len(listObjectsResult.Contents) == 0 {
-			break
+		file := model.Object{
+			Path:     path.Join(dirPath, name),
+			Name:     name,
+			Size:     *object.Size,
+			Modified: *object.LastModified,
 		}
-		startAfter = listObjectsResult.Contents[len(listObjectsResult.Contents)-1].Key
+		files = append(files, &file)
 	}
-	return files, nil
+	if !aws.BoolValue(listObjectsResult.IsTruncated) {
+		return files, "", nil
+	}
+	if listObjectsResult.NextContinuationToken != nil {
+		return files, continuationTokenCursor + *listObjectsResult.NextContinuationToken, nil
+	}
+	if len(listObjectsResult.Contents) == 0 {
+		return files, "", nil
+	}
+	return files, startAfterCursor + *listObjectsResult.Contents[len(listObjectsResult.Contents)-1].Key, nil
 }
 
 func (d *S3) copy(ctx context.Context, src string, dst string, isDir bool) error {
@@ -0,0 +1,146 @@
+// Package audit records the file operations, logins and admin changes of users
+package audit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	log "github.com/sirupsen/logrus"
+)
+
+// the logs are written in batches by a worker, so that logging an action, like each
+// download, doesn't wait for the database
+const (
+	batchSize     = 100
+	maxPending    = 10000
+	flushInterval = time.Second
+)
+
+var (
+	pending   []*model.AuditLog
+	pendingMu sync.Mutex
+	// flushMu keeps the batches in order
+	flushMu  sync.Mutex
+	wake     = make(chan struct{}, 1)
+	workOnce sync.Once
+)
+
+// Log records the action of the user of ctx with the result err, the paths are
+// the object of the action and the destination of a move or copy
+func Log(ctx context.Context, action string, err error, paths ...string) {
+	LogDetail(ctx, action, "", err, paths...)
+}
+
+// LogDetail is Log with a detail of the action, like the id of a share. Nothing is
+// recorded if ctx skips it, as the action is recorded by its caller
+func LogDetail(ctx context.Context, action, detail string, err error, paths ...string) {
+	if ctx.Value(conf.SkipAuditKey) != nil {
+		return
+	}
+	l := newLog(ctx, action, err)
+	l.Detail = detail
+	if len(paths) > 0 {
+		l.Path = paths[0]
+	}
+	if len(paths) > 1 {
+		l.DstPath = paths[1]
+	}
+	save(l)
+}
+
+// Login records a login of username by the method, such as password, ldap, sso or webauthn,
+// the user of ctx isn't the one logging in so only the username is recorded
+func Login(ctx context.Context, username, method string, err error) {
+	l := newLog(ctx, model.AuditLogin, err)
+	l.UserID, l.Username = 0, username
+	l.Detail = method
+	save(l)
+}
+
+func newLog(ctx context.Context, action string, err error) *model.AuditLog {
+	l := &model.AuditLog{
+		Time:    time.Now(),
+		Action:  action,
+		Success: err == nil,
+	}
+	if err != nil {
+		l.Error = err.Error()
+	}
+	if user, ok := ctx.Value(conf.UserKey).(*model.User); ok {
+		l.UserID, l.Username = user.ID, user.Username
+	}
+	l.IP, _ = ctx.Value(conf.ClientIPKey).(string)
+	l.Protocol, _ = ctx.Value(conf.ProtocolKey).(string)
+	return l
+}
+
+// save queues l for the worker, or writes the queued logs itself if the worker falls behind
+func save(l *model.AuditLog) {
+	workOnce.Do(func() { go work() })
+	pendingMu.Lock()
+	pending = append(pending, l)
+	n := len(pending)
+	pendingMu.Unlock()
+	if n >= maxPending {
+		Flush()
+	} else if n >= batchSize {
+		select {
+		case wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func work() {
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-wake:
+		}
+		Flush()
+	}
+}
+
+// Flush writes the queued logs, it's called before reading the logs and on exit
+func Flush() {
+	flushMu.Lock()
+	defer flushMu.Unlock()
+	pendingMu.Lock()
+	logs := pending
+	pending = nil
+	pendingMu.Unlock()
+	if len(logs) == 0 {
+		return
+	}
+	if err := db.CreateAuditLogs(logs, batchSize); err != nil {
+		log.Errorf("failed save %d audit logs: %+v", len(logs), err)
+	}
+}
+
+func GetLogs(f model.AuditLogFilter, pageIndex, pageSize int) ([]model.AuditLog, int64, error) {
+	Flush()
+	return db.GetAuditLogs(f, pageIndex, pageSize)
+}
+
+// WalkLogs calls fn with the logs matching f in batches, the earliest first
+func WalkLogs(f model.AuditLogFilter, fn func(logs []model.AuditLog) error) error {
+	Flush()
+	return db.WalkAuditLogs(f, fn)
+}
+
+// Purge deletes the logs before t
+func Purge(t time.Time) {
+	Flush()
+	n, err := db.DeleteAuditLogsBefore(t)
+	if err != nil {
+		log.Errorf("failed purge audit logs: %+v", err)
+		return
+	}
+	log.Debugf("purged %d audit logs", n)
+}
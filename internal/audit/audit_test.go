@@ -0,0 +1,72 @@
+package audit_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/audit"
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func init() {
+	dB, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
+	if err != nil {
+		panic("failed to connect database")
+	}
+	conf.Conf = conf.DefaultConfig("data")
+	db.Init(dB)
+}
+
+func TestAuditLog(t *testing.T) {
+	ctx := context.WithValue(context.Background(), conf.UserKey, &model.User{ID: 200, Username: "auditor"})
+	ctx = context.WithValue(ctx, conf.ClientIPKey, "10.0.0.1")
+	ctx = context.WithValue(ctx, conf.ProtocolKey, model.TokenWebdav)
+	audit.Log(ctx, model.AuditRemove, nil, "/audit/a.txt")
+	audit.Log(ctx, model.AuditMove, errors.New("denied"), "/other/b.txt", "/audit/dir")
+	audit.Log(ctx, model.AuditRemove, nil, "/auditing/c.txt")
+	audit.Log(ctx, model.AuditRemove, nil, "/auditX/d.txt")
+	audit.Login(context.Background(), "auditor", "ldap", errors.New("wrong password"))
+
+	logs, total, err := audit.GetLogs(model.AuditLogFilter{Username: "auditor", Path: "/audit"}, 1, 10)
+	if err != nil || total != 2 || len(logs) != 2 {
+		t.Fatalf("expected the 2 logs in /audit, got %d %+v: %v", total, logs, err)
+	}
+	if logs[0].Action != model.AuditMove || logs[0].Success || logs[0].Error != "denied" {
+		t.Errorf("expected the failed move first, got %+v", logs[0])
+	}
+	if logs[1].IP != "10.0.0.1" || logs[1].Protocol != model.TokenWebdav || logs[1].UserID != 200 {
+		t.Errorf("expected the client of ctx to be recorded, got %+v", logs[1])
+	}
+
+	// the wildcards of LIKE in the path are matched as they are
+	for _, p := range []string{"/audit_", "/audit%"} {
+		if _, total, err = audit.GetLogs(model.AuditLogFilter{Path: p}, 1, 10); err != nil || total != 0 {
+			t.Errorf("expected no logs in %s, got %d: %v", p, total, err)
+		}
+	}
+
+	failed := false
+	_, total, err = audit.GetLogs(model.AuditLogFilter{Username: "auditor", Success: &failed}, 1, 10)
+	if err != nil || total != 2 {
+		t.Errorf("expected 2 failed logs, got %d: %v", total, err)
+	}
+
+	var walked []model.AuditLog
+	if err = audit.WalkLogs(model.AuditLogFilter{Action: model.AuditLogin}, func(logs []model.AuditLog) error {
+		walked = append(walked, logs...)
+		return nil
+	}); err != nil || len(walked) != 1 || walked[0].Detail != "ldap" {
+		t.Errorf("expected the login to be walked, got %+v: %v", walked, err)
+	}
+
+	audit.Purge(time.Now().Add(time.Minute))
+	if _, total, _ = audit.GetLogs(model.AuditLogFilter{}, 1, 10); total != 0 {
+		t.Errorf("expected the logs to be purged, got %d", total)
+	}
+}
@@ -0,0 +1,14 @@
+package bootstrap
+
+import (
+	"github.com/OpenListTeam/OpenList/v4/internal/audit"
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/pkg/cron"
+)
+
+var auditPurgeCron *cron.Cron
+
+// InitAudit purges the audit logs older than the retention every hour
+func InitAudit() {
+	auditPurgeCron = purgeRetainedHourly(conf.AuditRetentionDays, 90, audit.Purge)
+}
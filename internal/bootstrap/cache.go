@@ -0,0 +1,37 @@
+package bootstrap
+
+import (
+	"github.com/OpenListTeam/OpenList/v4/internal/cache"
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+var cacheDB *cache.BoltDB
+
+// InitCache opens the persistent cache if it's enabled. It runs after the settings are
+// initialized, as changing them clears the caches
+func InitCache() {
+	if !conf.Conf.Cache.Persistent || cacheDB != nil {
+		return
+	}
+	db, err := cache.OpenBolt(conf.Conf.Cache.Path, int64(conf.Conf.Cache.MaxSizeMB)*utils.MB)
+	if err != nil {
+		log.Errorf("failed open persistent cache, use memory only: %+v", err)
+		return
+	}
+	cacheDB = db
+	op.Cache.Persist(db)
+	log.Infof("persistent cache: %s, %d bytes", conf.Conf.Cache.Path, db.Size())
+}
+
+func releaseCache() {
+	if cacheDB == nil {
+		return
+	}
+	if err := cacheDB.Close(); err != nil {
+		log.Errorf("failed close persistent cache: %+v", err)
+	}
+	cacheDB = nil
+}
@@ -0,0 +1,13 @@
+package bootstrap
+
+import "github.com/OpenListTeam/OpenList/v4/internal/cluster"
+
+// InitCluster starts receiving the changes of the other instances in cluster mode. It runs
+// before the storages are loaded, so that the changes made meanwhile aren't missed
+func InitCluster() {
+	cluster.Init()
+}
+
+func releaseCluster() {
+	cluster.Stop()
+}
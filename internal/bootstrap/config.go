@@ -134,6 +134,7 @@ func InitConfig() {
 	convertAbsPath(&conf.Conf.TempDir)
 	convertAbsPath(&conf.Conf.BleveDir)
 	convertAbsPath(&conf.Conf.DistDir)
+	convertAbsPath(&conf.Conf.Cache.Path)
 
 	err := os.MkdirAll(conf.Conf.TempDir, 0o777)
 	if err != nil {
@@ -144,6 +145,7 @@ func InitConfig() {
 	// Validate and display proxy configuration status
 	validateProxyConfig()
 
+	initTracing()
 	base.InitClient()
 	initURL()
 }
@@ -1,6 +1,7 @@
 package data
 
 import (
+	"github.com/OpenListTeam/OpenList/v4/internal/cluster"
 	"github.com/OpenListTeam/OpenList/v4/internal/db"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 )
@@ -11,10 +12,12 @@ func initTasks() {
 	InitialTasks()
 
 	for i := range initialTaskItems {
-		item := &initialTaskItems[i]
+		// each instance of a cluster persists its own tasks
+		item := initialTaskItems[i]
+		item.Key = cluster.Key(item.Key)
 		taskitem, _ := db.GetTaskDataByType(item.Key)
 		if taskitem == nil {
-			db.CreateTaskData(item)
+			db.CreateTaskData(&item)
 		}
 	}
 }
@@ -23,6 +26,7 @@ func InitialTasks() []model.TaskItem {
 	initialTaskItems = []model.TaskItem{
 		{Key: "copy", PersistData: "[]"},
 		{Key: "move", PersistData: "[]"},
+		{Key: "sync", PersistData: "[]"},
 		{Key: "download", PersistData: "[]"},
 		{Key: "transfer", PersistData: "[]"},
 	}
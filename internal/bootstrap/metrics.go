@@ -0,0 +1,65 @@
+package bootstrap
+
+import (
+	"sync"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/fs"
+	"github.com/OpenListTeam/OpenList/v4/internal/metrics"
+	"github.com/OpenListTeam/OpenList/v4/internal/offline_download/tool"
+	"github.com/OpenListTeam/OpenList/v4/internal/task"
+	"github.com/OpenListTeam/tache"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var taskStateNames = map[tache.State]string{
+	tache.StatePending:      "pending",
+	tache.StateRunning:      "running",
+	tache.StateSucceeded:    "succeeded",
+	tache.StateCanceling:    "canceling",
+	tache.StateCanceled:     "canceled",
+	tache.StateErrored:      "errored",
+	tache.StateFailing:      "failing",
+	tache.StateFailed:       "failed",
+	tache.StateWaitingRetry: "waiting_retry",
+	tache.StateBeforeRetry:  "before_retry",
+}
+
+var tasksDesc = prometheus.NewDesc("openlist_tasks",
+	"Tasks in the task managers, by manager and state.", []string{"manager", "state"}, nil)
+
+// taskCollector counts the tasks of the managers by state when scraped
+type taskCollector struct{}
+
+func (taskCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- tasksDesc
+}
+
+func (taskCollector) Collect(ch chan<- prometheus.Metric) {
+	collectTasks(ch, "upload", fs.UploadTaskManager)
+	collectTasks(ch, "copy", fs.CopyTaskManager)
+	collectTasks(ch, "move", fs.MoveTaskManager)
+	collectTasks(ch, "sync", fs.SyncTaskManager)
+	collectTasks(ch, "offline_download", tool.DownloadTaskManager)
+	collectTasks(ch, "offline_download_transfer", tool.TransferTaskManager)
+	collectTasks(ch, "decompress", fs.ArchiveDownloadTaskManager)
+	collectTasks(ch, "decompress_upload", fs.ArchiveContentUploadTaskManager)
+}
+
+func collectTasks[T task.TaskExtensionInfo](ch chan<- prometheus.Metric, manager string, m task.Manager[T]) {
+	counts := make(map[tache.State]int, len(taskStateNames))
+	for _, t := range m.GetAll() {
+		counts[t.GetState()]++
+	}
+	for state, name := range taskStateNames {
+		ch <- prometheus.MustNewConstMetric(tasksDesc, prometheus.GaugeValue, float64(counts[state]), manager, name)
+	}
+}
+
+var metricsOnce sync.Once
+
+// InitMetrics adds the collector of the task managers to the metrics, the managers must be initialized
+func InitMetrics() {
+	metricsOnce.Do(func() {
+		metrics.Registry.MustRegister(taskCollector{})
+	})
+}
@@ -0,0 +1,33 @@
+package bootstrap
+
+import (
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/cluster"
+	"github.com/OpenListTeam/OpenList/v4/internal/setting"
+	"github.com/OpenListTeam/OpenList/v4/pkg/cron"
+)
+
+// purgeHourly runs purge every hour, on the leader only in cluster mode
+func purgeHourly(purge func()) *cron.Cron {
+	c := cron.NewCron(time.Hour)
+	c.Do(func() {
+		if cluster.IsLeader() {
+			purge()
+		}
+	})
+	return c
+}
+
+// purgeRetainedHourly runs purge every hour like purgeHourly with the time before which
+// things are out of the retention, which is the setting of key in days. Nothing is purged
+// while the retention isn't positive
+func purgeRetainedHourly(key string, defaultDays int, purge func(before time.Time)) *cron.Cron {
+	return purgeHourly(func() {
+		days := setting.GetInt(key, defaultDays)
+		if days <= 0 {
+			return
+		}
+		purge(time.Now().AddDate(0, 0, -days))
+	})
+}
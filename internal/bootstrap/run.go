@@ -11,10 +11,12 @@ import (
 	"time"
 
 	"github.com/OpenListTeam/OpenList/v4/cmd/flags"
+	"github.com/OpenListTeam/OpenList/v4/internal/audit"
 	"github.com/OpenListTeam/OpenList/v4/internal/bootstrap/data"
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
 	"github.com/OpenListTeam/OpenList/v4/internal/db"
 	"github.com/OpenListTeam/OpenList/v4/internal/fs"
+	"github.com/OpenListTeam/OpenList/v4/internal/scheduler"
 	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
 	"github.com/OpenListTeam/OpenList/v4/server"
 	"github.com/OpenListTeam/OpenList/v4/server/middlewares"
@@ -34,13 +36,18 @@ func Init() {
 	Log()
 	InitDB()
 	data.InitData()
+	InitCache()
 	InitStreamLimit()
 	InitIndex()
 	InitUpgradePatch()
 }
 
 func Release() {
+	releaseCluster()
+	audit.Flush()
 	db.Close()
+	releaseTracing()
+	releaseCache()
 }
 
 var (
@@ -84,18 +91,35 @@ func IsRunning(t string) bool {
 	return running
 }
 
+// setTrustedProxies makes c.ClientIP only take the forwarded ip from the trusted proxies
+func setTrustedProxies(r *gin.Engine) {
+	if err := r.SetTrustedProxies(conf.Conf.TrustedProxies); err != nil {
+		utils.Log.Fatalf("failed to set trusted proxies: %+v", err)
+	}
+}
+
 func Start() {
 	if conf.Conf.DelayedStart != 0 {
 		utils.Log.Infof("delayed start for %d seconds", conf.Conf.DelayedStart)
 		time.Sleep(time.Duration(conf.Conf.DelayedStart) * time.Second)
 	}
 	InitOfflineDownloadTools()
+	InitCluster()
 	LoadStorages()
 	InitTaskManager()
+	InitMetrics()
+	InitTrash()
+	InitAudit()
+	InitSessions()
+	InitSharingStats()
+	InitSigns()
+	InitWebhooks()
+	scheduler.Init()
 	if !flags.Debug && !flags.Dev {
 		gin.SetMode(gin.ReleaseMode)
 	}
 	r := gin.New()
+	setTrustedProxies(r)
 
 	// gin log
 	if conf.Conf.Log.Filter.Enable {
@@ -200,6 +224,7 @@ func Start() {
 	}
 	if conf.Conf.S3.Port != -1 && conf.Conf.S3.Enable {
 		s3r := gin.New()
+		setTrustedProxies(s3r)
 		s3r.Use(gin.LoggerWithWriter(log.StandardLogger().Out), gin.RecoveryWithWriter(log.StandardLogger().Out))
 		server.InitS3(s3r)
 		s3Base := fmt.Sprintf("%s:%d", conf.Conf.Scheme.Address, conf.Conf.S3.Port)
@@ -0,0 +1,18 @@
+package bootstrap
+
+import (
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/pkg/cron"
+	log "github.com/sirupsen/logrus"
+)
+
+var sessionPurgeCron *cron.Cron
+
+// InitSessions deletes the expired and idle sessions every hour
+func InitSessions() {
+	sessionPurgeCron = purgeHourly(func() {
+		if err := op.PurgeSessions(); err != nil {
+			log.Errorf("failed purge sessions: %+v", err)
+		}
+	})
+}
@@ -0,0 +1,21 @@
+package bootstrap
+
+import (
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/pkg/cron"
+	log "github.com/sirupsen/logrus"
+)
+
+var sharingStatsPurgeCron *cron.Cron
+
+// InitSharingStats purges the access logs of sharings older than the retention every hour
+func InitSharingStats() {
+	sharingStatsPurgeCron = purgeRetainedHourly(conf.ShareStatsRetentionDays, 90, func(before time.Time) {
+		if err := op.DeleteSharingAccessLogsBefore(before); err != nil {
+			log.Errorf("failed purge sharing access logs: %+v", err)
+		}
+	})
+}
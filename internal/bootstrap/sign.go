@@ -0,0 +1,18 @@
+package bootstrap
+
+import (
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/pkg/cron"
+	log "github.com/sirupsen/logrus"
+)
+
+var signPurgeCron *cron.Cron
+
+// InitSigns drops the revocations and download counts of expired signs every hour
+func InitSigns() {
+	signPurgeCron = purgeHourly(func() {
+		if err := op.PurgeSigns(); err != nil {
+			log.Errorf("failed purge signs: %+v", err)
+		}
+	})
+}
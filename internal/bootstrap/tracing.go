@@ -0,0 +1,34 @@
+package bootstrap
+
+import (
+	"context"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/tracing"
+	log "github.com/sirupsen/logrus"
+)
+
+var shutdownTracing = func(context.Context) error { return nil }
+
+// initTracing runs before the http clients of the drivers are created, so that they trace their requests
+func initTracing() {
+	shutdown, err := tracing.Init(conf.Conf.Tracing)
+	if err != nil {
+		log.Errorf("failed init tracing: %+v", err)
+		return
+	}
+	shutdownTracing = shutdown
+	if tracing.Enabled() {
+		log.Infof("export traces to %s", conf.Conf.Tracing.Endpoint)
+	}
+}
+
+// releaseTracing flushes the spans not exported yet
+func releaseTracing() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := shutdownTracing(ctx); err != nil {
+		log.Errorf("failed shutdown tracing: %+v", err)
+	}
+}
@@ -0,0 +1,21 @@
+package bootstrap
+
+import (
+	"context"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/pkg/cron"
+	log "github.com/sirupsen/logrus"
+)
+
+var trashPurgeCron *cron.Cron
+
+// InitTrash purges the trash items older than the retention every hour
+func InitTrash() {
+	trashPurgeCron = purgeRetainedHourly(conf.TrashRetentionDays, 30, func(before time.Time) {
+		log.Debugf("purge trash items deleted before %s", before)
+		op.PurgeExpiredTrash(context.Background(), before)
+	})
+}
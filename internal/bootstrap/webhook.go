@@ -0,0 +1,24 @@
+package bootstrap
+
+import (
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/webhook"
+	"github.com/OpenListTeam/OpenList/v4/pkg/cron"
+	log "github.com/sirupsen/logrus"
+)
+
+var webhookPurgeCron *cron.Cron
+
+// InitWebhooks starts posting the events to the webhooks, and purges the finished
+// deliveries older than the retention every hour
+func InitWebhooks() {
+	webhook.Init()
+	webhookPurgeCron = purgeRetainedHourly(conf.WebhookRetentionDays, 7, func(before time.Time) {
+		if _, err := op.DeleteWebhookDeliveriesBefore(before); err != nil {
+			log.Errorf("failed purge webhook deliveries: %+v", err)
+		}
+	})
+}
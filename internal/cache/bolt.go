@@ -0,0 +1,240 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"go.etcd.io/bbolt"
+)
+
+// BoltDB is a bbolt file holding the stores of the caches, one bucket each.
+// The total size of the entries is kept under maxSize by evicting the ones expiring first
+type BoltDB struct {
+	db      *bbolt.DB
+	maxSize int64
+	size    atomic.Int64
+	evictMu sync.Mutex
+}
+
+// OpenBolt opens the file at path, maxSize <= 0 means no limit.
+// The writes are not synced to disk, a file broken by a crash is recreated
+func OpenBolt(path string, maxSize int64) (*BoltDB, error) {
+	opts := &bbolt.Options{Timeout: time.Second, NoSync: true}
+	db, err := bbolt.Open(path, 0o600, opts)
+	if err != nil && !errors.Is(err, bbolt.ErrTimeout) {
+		log.Warnf("failed open cache file %s, recreate it: %+v", path, err)
+		if err = os.Remove(path); err == nil {
+			db, err = bbolt.Open(path, 0o600, opts)
+		}
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed open cache file %s", path)
+	}
+	b := &BoltDB{db: db, maxSize: maxSize}
+	err = db.View(func(tx *bbolt.Tx) error {
+		return tx.ForEach(func(_ []byte, bucket *bbolt.Bucket) error {
+			return bucket.ForEach(func(k, v []byte) error {
+				b.size.Add(int64(len(k) + len(v)))
+				return nil
+			})
+		})
+	})
+	if err != nil {
+		_ = db.Close()
+		return nil, errors.Wrapf(err, "failed read cache file %s", path)
+	}
+	return b, nil
+}
+
+func (b *BoltDB) Close() error {
+	return b.db.Close()
+}
+
+// Size is the total size of the keys and values of the entries
+func (b *BoltDB) Size() int64 {
+	return b.size.Load()
+}
+
+// Store returns the store of the bucket name
+func (b *BoltDB) Store(name string) Store {
+	return &boltStore{b: b, bucket: []byte(name)}
+}
+
+// evict deletes the entries expiring first until the size is a tenth under the limit
+func (b *BoltDB) evict() {
+	if !b.evictMu.TryLock() {
+		return
+	}
+	defer b.evictMu.Unlock()
+	type entry struct {
+		bucket, key []byte
+		exp         int64
+		size        int64
+	}
+	var entries []entry
+	_ = b.db.View(func(tx *bbolt.Tx) error {
+		return tx.ForEach(func(name []byte, bucket *bbolt.Bucket) error {
+			return bucket.ForEach(func(k, v []byte) error {
+				exp, _ := decodeBoltValue(v)
+				entries = append(entries, entry{
+					bucket: bytes.Clone(name), key: bytes.Clone(k),
+					exp: exp.UnixNano(), size: int64(len(k) + len(v)),
+				})
+				return nil
+			})
+		})
+	})
+	sort.Slice(entries, func(i, j int) bool { return entries[i].exp < entries[j].exp })
+	target := b.maxSize - b.maxSize/10
+	err := b.db.Update(func(tx *bbolt.Tx) error {
+		for _, e := range entries {
+			if b.size.Load() <= target {
+				break
+			}
+			if bucket := tx.Bucket(e.bucket); bucket != nil && bucket.Get(e.key) != nil {
+				if err := bucket.Delete(e.key); err != nil {
+					return err
+				}
+				b.size.Add(-e.size)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Warnf("failed evict cache entries: %+v", err)
+	}
+}
+
+// the values are stored after their expiration in unix nanoseconds
+func encodeBoltValue(value []byte, expiration time.Time) []byte {
+	v := make([]byte, 8+len(value))
+	binary.BigEndian.PutUint64(v, uint64(expiration.UnixNano()))
+	copy(v[8:], value)
+	return v
+}
+
+func decodeBoltValue(v []byte) (time.Time, []byte) {
+	if len(v) < 8 {
+		return time.Time{}, nil
+	}
+	return time.Unix(0, int64(binary.BigEndian.Uint64(v))), v[8:]
+}
+
+type boltStore struct {
+	b      *BoltDB
+	bucket []byte
+}
+
+func (s *boltStore) Get(key string) ([]byte, time.Time, bool) {
+	var value []byte
+	var exp time.Time
+	_ = s.b.db.View(func(tx *bbolt.Tx) error {
+		if bucket := tx.Bucket(s.bucket); bucket != nil {
+			if v := bucket.Get([]byte(key)); v != nil {
+				var data []byte
+				exp, data = decodeBoltValue(v)
+				// the data is only valid during the transaction
+				value = bytes.Clone(data)
+			}
+		}
+		return nil
+	})
+	return value, exp, value != nil
+}
+
+func (s *boltStore) Set(key string, value []byte, expiration time.Time) {
+	v := encodeBoltValue(value, expiration)
+	err := s.b.db.Update(func(tx *bbolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists(s.bucket)
+		if err != nil {
+			return err
+		}
+		delta := int64(len(key) + len(v))
+		if old := bucket.Get([]byte(key)); old != nil {
+			delta -= int64(len(key) + len(old))
+		}
+		if err = bucket.Put([]byte(key), v); err != nil {
+			return err
+		}
+		s.b.size.Add(delta)
+		return nil
+	})
+	if err != nil {
+		log.Warnf("failed set cache entry %s: %+v", key, err)
+		return
+	}
+	if s.b.maxSize > 0 && s.b.size.Load() > s.b.maxSize {
+		go s.b.evict()
+	}
+}
+
+// deleteWhere deletes the entries of the bucket from the first key >= seek while match is true
+func (s *boltStore) deleteWhere(seek []byte, match func(k, v []byte) bool) {
+	err := s.b.db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(s.bucket)
+		if bucket == nil {
+			return nil
+		}
+		// deleting while iterating with a cursor may skip keys, so collect them first
+		var keys [][]byte
+		var size int64
+		c := bucket.Cursor()
+		k, v := c.First()
+		if seek != nil {
+			k, v = c.Seek(seek)
+		}
+		for ; k != nil; k, v = c.Next() {
+			if !match(k, v) {
+				if seek != nil {
+					break
+				}
+				continue
+			}
+			keys = append(keys, bytes.Clone(k))
+			size += int64(len(k) + len(v))
+		}
+		for _, k := range keys {
+			if err := bucket.Delete(k); err != nil {
+				return err
+			}
+		}
+		s.b.size.Add(-size)
+		return nil
+	})
+	if err != nil {
+		log.Warnf("failed delete cache entries: %+v", err)
+	}
+}
+
+func (s *boltStore) Delete(key string) {
+	s.deleteWhere([]byte(key), func(k, _ []byte) bool {
+		return string(k) == key
+	})
+}
+
+func (s *boltStore) DeletePrefix(prefix string) {
+	s.deleteWhere([]byte(prefix), func(k, _ []byte) bool {
+		return bytes.HasPrefix(k, []byte(prefix))
+	})
+}
+
+func (s *boltStore) Clear() {
+	s.deleteWhere(nil, func(_, _ []byte) bool {
+		return true
+	})
+}
+
+func (s *boltStore) GC() {
+	now := time.Now()
+	s.deleteWhere(nil, func(_, v []byte) bool {
+		exp, _ := decodeBoltValue(v)
+		return exp.Before(now)
+	})
+}
@@ -0,0 +1,101 @@
+package cache
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var stringCodec = Codec[string]{
+	Encode: func(v string) ([]byte, bool) {
+		return []byte(v), !strings.HasPrefix(v, "memory")
+	},
+	Decode: func(data []byte) (string, error) {
+		return string(data), nil
+	},
+}
+
+func TestKeyedCachePersist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.db")
+	db, err := OpenBolt(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := NewKeyedCache[string](time.Minute)
+	c.Persist(db.Store("test"), stringCodec, nil)
+	c.Set("/a", "a")
+	c.Set("/a/b", "b")
+	c.Set("/a/b/c", "c")
+	c.Set("/ab", "ab")
+	c.Set("/memory", "memory only")
+	c.SetWithTTL("/expired", "expired", -time.Second)
+	if err = db.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// restart
+	if db, err = OpenBolt(path, 0); err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	var restored []string
+	c = NewKeyedCache[string](time.Minute)
+	c.Persist(db.Store("test"), stringCodec, func(key string, _ string) {
+		restored = append(restored, key)
+	})
+	if v, ok := c.Get("/a"); !ok || v != "a" {
+		t.Errorf("expected /a to be restored, got %q %v", v, ok)
+	}
+	if _, ok := c.Get("/a"); !ok || len(restored) != 1 {
+		t.Errorf("expected /a to be restored once, got %v", restored)
+	}
+	for _, key := range []string{"/memory", "/expired"} {
+		if _, ok := c.Get(key); ok {
+			t.Errorf("expected %s not to be restored", key)
+		}
+	}
+	if v, ok := c.Pop("/a/b"); !ok || v != "b" {
+		t.Errorf("expected to pop /a/b from the store, got %q %v", v, ok)
+	}
+	if _, ok := c.Get("/a/b"); ok {
+		t.Errorf("expected /a/b to be deleted from the store")
+	}
+	c.DeleteStoredPrefix("/a/")
+	if _, ok := c.Get("/a/b/c"); ok {
+		t.Errorf("expected /a/b/c to be deleted with its prefix")
+	}
+	if _, ok := c.Get("/ab"); !ok {
+		t.Errorf("expected /ab to be kept")
+	}
+}
+
+func TestBoltMaxSize(t *testing.T) {
+	const maxSize = 64 * 1024
+	db, err := OpenBolt(filepath.Join(t.TempDir(), "cache.db"), maxSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	store := db.Store("test")
+	value := make([]byte, 1024)
+	now := time.Now()
+	for i := 0; i < 128; i++ {
+		store.Set(fmt.Sprintf("/%03d", i), value, now.Add(time.Duration(i)*time.Minute))
+	}
+	// the eviction runs in background
+	for i := 0; i < 100 && db.Size() > maxSize; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	db.evict()
+	if db.Size() > maxSize {
+		t.Fatalf("expected the size to be limited to %d, got %d", maxSize, db.Size())
+	}
+	if _, _, ok := store.Get("/000"); ok {
+		t.Errorf("expected the entry expiring first to be evicted")
+	}
+	if _, _, ok := store.Get("/127"); !ok {
+		t.Errorf("expected the entry expiring last to be kept")
+	}
+}
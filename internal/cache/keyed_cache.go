@@ -3,12 +3,20 @@ package cache
 import (
 	"sync"
 	"time"
+
+	log "github.com/sirupsen/logrus"
 )
 
 type KeyedCache[T any] struct {
 	entries map[string]*CacheEntry[T]
 	mu      sync.RWMutex
 	ttl     time.Duration
+
+	store    Store
+	codec    Codec[T]
+	restored func(key string, value T)
+	// storeMu orders the writes to store, which are done out of mu not to block the reads
+	storeMu sync.Mutex
 }
 
 func NewKeyedCache[T any](ttl time.Duration) *KeyedCache[T] {
@@ -20,6 +28,16 @@ func NewKeyedCache[T any](ttl time.Duration) *KeyedCache[T] {
 	return c
 }
 
+// Persist makes the cache write its entries with an expiration time through to store,
+// and look for the ones missing in memory there, so that they survive restarts.
+// restored, if not nil, is called with the entries read back from store.
+// It should be called before the cache is used
+func (c *KeyedCache[T]) Persist(store Store, codec Codec[T], restored func(key string, value T)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.store, c.codec, c.restored = store, codec, restored
+}
+
 func (c *KeyedCache[T]) Set(key string, value T) {
 	c.SetWithExpirable(key, value, ExpirationTime(time.Now().Add(c.ttl)))
 }
@@ -29,13 +47,41 @@ func (c *KeyedCache[T]) SetWithTTL(key string, value T, ttl time.Duration) {
 }
 
 func (c *KeyedCache[T]) SetWithExpirable(key string, value T, exp Expirable) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	c.entries[key] = &CacheEntry[T]{
+	entry := &CacheEntry[T]{
 		data:      value,
 		Expirable: exp,
 	}
+	c.mu.Lock()
+	c.entries[key] = entry
+	store, codec := c.store, c.codec
+	c.mu.Unlock()
+	if store == nil {
+		return
+	}
+	// values expiring with something else than time can't outlive the process
+	var data []byte
+	t, persist := exp.(ExpirationTime)
+	if persist {
+		data, persist = codec.Encode(value)
+	}
+	c.storeMu.Lock()
+	defer c.storeMu.Unlock()
+	// the entry replaced or deleted meanwhile is stored by the one that did it
+	if !c.current(key, entry) {
+		return
+	}
+	if persist {
+		store.Set(key, data, time.Time(t))
+		return
+	}
+	store.Delete(key)
+}
+
+// current reports whether entry is still the entry of key in memory
+func (c *KeyedCache[T]) current(key string, entry *CacheEntry[T]) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.entries[key] == entry
 }
 
 func (c *KeyedCache[T]) Get(key string) (T, bool) {
@@ -43,7 +89,7 @@ func (c *KeyedCache[T]) Get(key string) (T, bool) {
 	entry, exists := c.entries[key]
 	if !exists {
 		c.mu.RUnlock()
-		return *new(T), false
+		return c.restore(key)
 	}
 
 	expired := entry.Expired()
@@ -63,32 +109,121 @@ func (c *KeyedCache[T]) Get(key string) (T, bool) {
 	return *new(T), false
 }
 
-func (c *KeyedCache[T]) Delete(key string) {
+// restore reads the entry missing in memory from the store and puts it back in memory
+func (c *KeyedCache[T]) restore(key string) (T, bool) {
+	data, exp, ok := c.load(key)
+	if !ok {
+		return *new(T), false
+	}
 	c.mu.Lock()
-	defer c.mu.Unlock()
+	if entry, exists := c.entries[key]; exists {
+		// set meanwhile
+		c.mu.Unlock()
+		if entry.Expired() {
+			return *new(T), false
+		}
+		return entry.data, true
+	}
+	c.entries[key] = &CacheEntry[T]{
+		data:      data,
+		Expirable: ExpirationTime(exp),
+	}
+	restored := c.restored
+	c.mu.Unlock()
+	if restored != nil {
+		restored(key, data)
+	}
+	return data, true
+}
 
+// load reads the unexpired entry from the store
+func (c *KeyedCache[T]) load(key string) (T, time.Time, bool) {
+	c.mu.RLock()
+	store, codec := c.store, c.codec
+	c.mu.RUnlock()
+	if store == nil {
+		return *new(T), time.Time{}, false
+	}
+	raw, exp, ok := store.Get(key)
+	if !ok {
+		return *new(T), time.Time{}, false
+	}
+	if time.Now().After(exp) {
+		store.Delete(key)
+		return *new(T), time.Time{}, false
+	}
+	data, err := codec.Decode(raw)
+	if err != nil {
+		log.Warnf("failed decode cache entry %s, drop it: %+v", key, err)
+		store.Delete(key)
+		return *new(T), time.Time{}, false
+	}
+	return data, exp, true
+}
+
+func (c *KeyedCache[T]) Delete(key string) {
+	c.mu.Lock()
 	delete(c.entries, key)
+	store := c.store
+	c.mu.Unlock()
+	if store != nil {
+		c.storeMu.Lock()
+		defer c.storeMu.Unlock()
+		store.Delete(key)
+	}
 }
 
+// Pop deletes the entry and returns it, reading it from the store if it's not in memory
 func (c *KeyedCache[T]) Pop(key string) (T, bool) {
 	c.mu.Lock()
-	defer c.mu.Unlock()
-	if entry, exists := c.entries[key]; exists {
-		delete(c.entries, key)
+	entry, exists := c.entries[key]
+	delete(c.entries, key)
+	c.mu.Unlock()
+	if c.store == nil {
+		if exists {
+			return entry.data, true
+		}
+		return *new(T), false
+	}
+	if exists {
+		c.store.Delete(key)
 		return entry.data, true
 	}
-	return *new(T), false
+	data, _, loaded := c.load(key)
+	c.store.Delete(key)
+	return data, loaded
+}
+
+// DeleteStored deletes the entry from the store only, for values changed in place in memory
+// whose stored copy is outdated
+func (c *KeyedCache[T]) DeleteStored(key string) {
+	if c.store != nil {
+		c.store.Delete(key)
+	}
+}
+
+// DeleteStoredPrefix deletes the entries whose key starts with prefix from the store,
+// the ones in memory are left as is
+func (c *KeyedCache[T]) DeleteStoredPrefix(prefix string) {
+	if c.store != nil {
+		c.store.DeletePrefix(prefix)
+	}
 }
 
 func (c *KeyedCache[T]) Clear() {
 	c.mu.Lock()
-	defer c.mu.Unlock()
 	c.entries = make(map[string]*CacheEntry[T])
+	store := c.store
+	c.mu.Unlock()
+	if store != nil {
+		c.storeMu.Lock()
+		defer c.storeMu.Unlock()
+		store.Clear()
+	}
 }
 
 func (c *KeyedCache[T]) GC() {
 	c.mu.Lock()
-	defer c.mu.Unlock()
 	expiredKeys := make([]string, 0, len(c.entries))
 	for key, entry := range c.entries {
 		if entry.Expired() {
@@ -98,4 +233,9 @@ func (c *KeyedCache[T]) GC() {
 	for _, key := range expiredKeys {
 		delete(c.entries, key)
 	}
+	store := c.store
+	c.mu.Unlock()
+	if store != nil {
+		store.GC()
+	}
 }
@@ -0,0 +1,23 @@
+package cache
+
+import "time"
+
+// Store keeps the entries of a cache outside of memory, so that they survive restarts.
+// It handles its errors itself, a failing store only makes the cache colder
+type Store interface {
+	Get(key string) (value []byte, expiration time.Time, ok bool)
+	Set(key string, value []byte, expiration time.Time)
+	Delete(key string)
+	// DeletePrefix deletes the entries whose key starts with prefix
+	DeletePrefix(prefix string)
+	Clear()
+	// GC deletes the expired entries
+	GC()
+}
+
+// Codec converts the values of a cache to and from the bytes kept in a Store
+type Codec[T any] struct {
+	// Encode returns false for the values that can't be stored, they stay in memory only
+	Encode func(value T) ([]byte, bool)
+	Decode func(data []byte) (T, error)
+}
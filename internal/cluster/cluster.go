@@ -0,0 +1,181 @@
+// Package cluster lets several instances of OpenList share a database. They publish the changes
+// of the state kept in memory as events, which the other instances handle by dropping or reloading
+// their copy of it, and elect a leader that is the only one running the scheduled work.
+// Without cluster mode enabled nothing is published and the instance is always the leader
+package cluster
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/cmd/flags"
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils/random"
+	log "github.com/sirupsen/logrus"
+)
+
+// Handler handles an event of another instance, key is the key it was published with
+type Handler func(ctx context.Context, key string) error
+
+// Broker carries the events between the instances
+type Broker interface {
+	Publish(ctx context.Context, kind, key string) error
+	// Run receives the events published by the other instances until ctx is done
+	Run(ctx context.Context, handle func(kind, key string))
+}
+
+var (
+	handlers   = map[string]Handler{}
+	handlersMu sync.RWMutex
+
+	broker  Broker
+	enabled atomic.Bool
+	leader  atomic.Bool
+	cancel  context.CancelFunc
+	done    sync.WaitGroup
+
+	nodeID   string
+	nodeOnce sync.Once
+)
+
+// Handle sets the handler of the events of kind
+func Handle(kind string, h Handler) {
+	handlersMu.Lock()
+	defer handlersMu.Unlock()
+	handlers[kind] = h
+}
+
+// SetBroker replaces the database polled by the instances with another pub/sub, before Init
+func SetBroker(b Broker) {
+	broker = b
+}
+
+// Enabled reports whether the instance runs in cluster mode
+func Enabled() bool {
+	return enabled.Load()
+}
+
+// IsLeader reports whether the instance should run the scheduled work, always true out of cluster mode
+func IsLeader() bool {
+	return !enabled.Load() || leader.Load()
+}
+
+// NodeID is the name of the instance, the configured one. In cluster mode without one it's
+// generated once and kept in the data dir, as the hostname, like the id of a container, may
+// change with an upgrade and orphan the persisted tasks of the instance. Otherwise it's the hostname
+func NodeID() string {
+	nodeOnce.Do(func() {
+		nodeID = conf.Conf.Cluster.NodeID
+		if nodeID == "" && conf.Conf.Cluster.Enable {
+			nodeID = storedNodeID()
+		}
+		if nodeID == "" {
+			nodeID, _ = os.Hostname()
+		}
+		if nodeID == "" {
+			nodeID = random.String(8)
+		}
+	})
+	return nodeID
+}
+
+// storedNodeID returns the node id kept in the data dir, which is generated the first time.
+// It's empty if the id can't be read or stored
+func storedNodeID() string {
+	p := filepath.Join(flags.DataDir, "node_id")
+	data, err := os.ReadFile(p)
+	if err == nil {
+		if id := strings.TrimSpace(string(data)); id != "" {
+			return id
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		log.Errorf("failed read the node id from %s: %+v", p, err)
+		return ""
+	}
+	id := random.String(16)
+	if err = os.WriteFile(p, []byte(id), 0o600); err != nil {
+		log.Errorf("failed store the node id in %s, set cluster.node_id to keep the tasks of the instance: %+v", p, err)
+		return ""
+	}
+	log.Infof("generated the node id %s of the instance, stored in %s", id, p)
+	return id
+}
+
+// Key scopes key to the instance in cluster mode, for the data each instance keeps apart in the shared database
+func Key(key string) string {
+	if !conf.Conf.Cluster.Enable {
+		return key
+	}
+	return key + "@" + NodeID()
+}
+
+// Publish sends an event of kind to the other instances, it does nothing out of cluster mode
+func Publish(kind, key string) {
+	if !enabled.Load() {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := broker.Publish(ctx, kind, key); err != nil {
+		log.Errorf("failed publish cluster event %s %s: %+v", kind, key, err)
+	}
+}
+
+// Apply handles an event of kind as if it was published by another instance
+func Apply(ctx context.Context, kind, key string) error {
+	handlersMu.RLock()
+	h, ok := handlers[kind]
+	handlersMu.RUnlock()
+	if !ok {
+		return nil
+	}
+	return h(ctx, key)
+}
+
+// Init starts receiving the events of the other instances and the leader election if cluster mode is enabled
+func Init() {
+	cfg := conf.Conf.Cluster
+	if !cfg.Enable || enabled.Load() {
+		return
+	}
+	interval := time.Duration(cfg.PollInterval) * time.Millisecond
+	if interval <= 0 {
+		interval = time.Second
+	}
+	if broker == nil {
+		broker = &dbBroker{node: NodeID(), interval: interval}
+	}
+	var ctx context.Context
+	ctx, cancel = context.WithCancel(context.Background())
+	enabled.Store(true)
+	log.Infof("cluster mode enabled, node id: %s", NodeID())
+	done.Add(2)
+	go func() {
+		defer done.Done()
+		broker.Run(ctx, func(kind, key string) {
+			if err := Apply(ctx, kind, key); err != nil {
+				log.Errorf("failed handle cluster event %s %s: %+v", kind, key, err)
+			}
+		})
+	}()
+	go func() {
+		defer done.Done()
+		elect(ctx)
+	}()
+}
+
+// Stop stops receiving the events and gives up the leadership
+func Stop() {
+	if !enabled.Load() {
+		return
+	}
+	cancel()
+	done.Wait()
+	enabled.Store(false)
+}
@@ -0,0 +1,121 @@
+package cluster
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/cmd/flags"
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func init() {
+	dB, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
+	if err != nil {
+		panic("failed to connect database")
+	}
+	conf.Conf = conf.DefaultConfig("data")
+	db.Init(dB)
+}
+
+func TestLease(t *testing.T) {
+	now := time.Now()
+	if ok, err := db.AcquireClusterLease("test", "a", now.Add(time.Minute)); err != nil || !ok {
+		t.Fatalf("expected a to take the free lease, got %v %+v", ok, err)
+	}
+	if ok, _ := db.AcquireClusterLease("test", "b", now.Add(time.Minute)); ok {
+		t.Errorf("expected b not to take the lease held by a")
+	}
+	if ok, _ := db.AcquireClusterLease("test", "a", now.Add(-time.Second)); !ok {
+		t.Errorf("expected a to renew its lease")
+	}
+	if ok, _ := db.AcquireClusterLease("test", "b", now.Add(time.Minute)); !ok {
+		t.Errorf("expected b to take the expired lease")
+	}
+	if err := db.ReleaseClusterLease("test", "b"); err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := db.AcquireClusterLease("test", "a", now.Add(time.Minute)); !ok {
+		t.Errorf("expected a to take the released lease")
+	}
+}
+
+func TestDBBroker(t *testing.T) {
+	a := &dbBroker{node: "a", interval: 10 * time.Millisecond}
+	b := &dbBroker{node: "b", interval: 10 * time.Millisecond}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	received := make(chan string, 10)
+	go b.Run(ctx, func(kind, key string) {
+		received <- kind + ":" + key
+	})
+	// let b start polling after the last event
+	time.Sleep(50 * time.Millisecond)
+	for _, e := range []struct {
+		broker *dbBroker
+		key    string
+	}{{b, "own"}, {a, "1"}, {a, "2"}} {
+		if err := e.broker.Publish(ctx, "test", e.key); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, want := range []string{"test:1", "test:2"} {
+		select {
+		case got := <-received:
+			if got != want {
+				t.Errorf("expected event %s, got %s", want, got)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("expected event %s", want)
+		}
+	}
+	select {
+	case got := <-received:
+		t.Errorf("expected no more events, got %s", got)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestStoredNodeID(t *testing.T) {
+	dataDir := flags.DataDir
+	defer func() { flags.DataDir = dataDir }()
+	flags.DataDir = t.TempDir()
+	id := storedNodeID()
+	if id == "" {
+		t.Fatal("expected a node id to be generated")
+	}
+	if again := storedNodeID(); again != id {
+		t.Errorf("expected the stored node id %s, got %s", id, again)
+	}
+}
+
+func TestLock(t *testing.T) {
+	unlock, ok, err := Lock("test")
+	if err != nil || !ok {
+		t.Fatalf("expected the lock to be taken out of cluster mode, got %v %+v", ok, err)
+	}
+	unlock()
+
+	enabled.Store(true)
+	defer enabled.Store(false)
+	if ok, err = db.AcquireClusterLease("lock:held", "other", time.Now().Add(time.Minute)); err != nil || !ok {
+		t.Fatalf("failed to take the lease: %v %+v", ok, err)
+	}
+	if _, ok, err = Lock("held"); err != nil || ok {
+		t.Errorf("expected the lock held by another instance not to be taken, got %v %+v", ok, err)
+	}
+	unlock, ok, err = Lock("free")
+	if err != nil || !ok {
+		t.Fatalf("expected the free lock to be taken, got %v %+v", ok, err)
+	}
+	if ok, _ = db.AcquireClusterLease("lock:free", "other", time.Now().Add(time.Minute)); ok {
+		t.Errorf("expected another instance not to take the lock")
+	}
+	unlock()
+	if ok, _ = db.AcquireClusterLease("lock:free", "other", time.Now().Add(time.Minute)); !ok {
+		t.Errorf("expected another instance to take the unlocked lock")
+	}
+}
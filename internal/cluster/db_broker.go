@@ -0,0 +1,62 @@
+package cluster
+
+import (
+	"context"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	log "github.com/sirupsen/logrus"
+)
+
+// the events created in the window are read again, as the ones with lower ids may be committed later
+const pollWindow = 30 * time.Second
+
+// dbBroker is the default broker, the events are rows of a table polled by the instances
+type dbBroker struct {
+	node     string
+	interval time.Duration
+}
+
+func (b *dbBroker) Publish(_ context.Context, kind, key string) error {
+	return db.CreateClusterEvent(&model.ClusterEvent{Node: b.node, Kind: kind, Key: key})
+}
+
+func (b *dbBroker) Run(ctx context.Context, handle func(kind, key string)) {
+	lastID, err := db.GetLastClusterEventID()
+	if err != nil {
+		log.Errorf("failed get last cluster event: %+v", err)
+	}
+	// the events of the window seen already, by id
+	seen := map[uint]time.Time{}
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		since := time.Now().Add(-pollWindow)
+		events, err := db.GetClusterEvents(lastID, since)
+		if err != nil {
+			log.Errorf("failed poll cluster events: %+v", err)
+			continue
+		}
+		for _, e := range events {
+			if _, ok := seen[e.ID]; ok {
+				continue
+			}
+			seen[e.ID] = e.CreatedAt
+			lastID = max(lastID, e.ID)
+			if e.Node != b.node {
+				handle(e.Kind, e.Key)
+			}
+		}
+		for id, createdAt := range seen {
+			if createdAt.Before(since) {
+				delete(seen, id)
+			}
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package cluster
+
+import (
+	"context"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	leaseName = "leader"
+	leaseTTL  = 30 * time.Second
+	// the events are only needed until every instance polled them
+	eventRetention = 10 * time.Minute
+)
+
+// elect takes the lease of the leader when it's free and keeps renewing it until ctx is done
+func elect(ctx context.Context) {
+	ticker := time.NewTicker(leaseTTL / 3)
+	defer ticker.Stop()
+	var purged time.Time
+	for {
+		renewLease()
+		if leader.Load() && time.Since(purged) > eventRetention {
+			purged = time.Now()
+			if err := db.DeleteClusterEventsBefore(purged.Add(-eventRetention)); err != nil {
+				log.Errorf("failed purge cluster events: %+v", err)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			if leader.Swap(false) {
+				if err := db.ReleaseClusterLease(leaseName, NodeID()); err != nil {
+					log.Errorf("failed release cluster lease: %+v", err)
+				}
+			}
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func renewLease() {
+	ok, err := db.AcquireClusterLease(leaseName, NodeID(), time.Now().Add(leaseTTL))
+	if err != nil {
+		// the lease may expire meanwhile, so stop leading to not run the work twice
+		log.Errorf("failed renew cluster lease: %+v", err)
+		ok = false
+	}
+	if leader.Swap(ok) != ok {
+		if ok {
+			log.Infof("node %s became the leader of the cluster", NodeID())
+		} else {
+			log.Infof("node %s is no longer the leader of the cluster", NodeID())
+		}
+	}
+}
+
+// Lock takes the lease name for the instance and keeps renewing it until unlock is called,
+// so that the work it guards doesn't run on two instances at once, as it may while the
+// leadership is handed over. It reports false if another instance holds the lease.
+// Out of cluster mode it always takes it
+func Lock(name string) (unlock func(), ok bool, err error) {
+	if !enabled.Load() {
+		return func() {}, true, nil
+	}
+	name = "lock:" + name
+	if ok, err = db.AcquireClusterLease(name, NodeID(), time.Now().Add(leaseTTL)); err != nil || !ok {
+		return nil, false, err
+	}
+	stop, stopped := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(leaseTTL / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				if _, err := db.AcquireClusterLease(name, NodeID(), time.Now().Add(leaseTTL)); err != nil {
+					log.Errorf("failed renew cluster lease %s: %+v", name, err)
+				}
+			}
+		}
+	}()
+	return func() {
+		close(stop)
+		<-stopped
+		if err := db.ReleaseClusterLease(name, NodeID()); err != nil {
+			log.Errorf("failed release cluster lease %s: %+v", name, err)
+		}
+	}, true, nil
+}
@@ -71,6 +71,7 @@ type TasksConfig struct {
 	Upload             TaskConfig `json:"upload" envPrefix:"UPLOAD_"`
 	Copy               TaskConfig `json:"copy" envPrefix:"COPY_"`
 	Move               TaskConfig `json:"move" envPrefix:"MOVE_"`
+	Sync               TaskConfig `json:"sync" envPrefix:"SYNC_"`
 	Decompress         TaskConfig `json:"decompress" envPrefix:"DECOMPRESS_"`
 	DecompressUpload   TaskConfig `json:"decompress_upload" envPrefix:"DECOMPRESS_UPLOAD_"`
 	AllowRetryCanceled bool       `json:"allow_retry_canceled" env:"ALLOW_RETRY_CANCELED"`
@@ -106,6 +107,36 @@ type SFTP struct {
 	Listen string `json:"listen" env:"LISTEN"`
 }
 
+type CacheConfig struct {
+	// Persistent keeps the directory listings and storage details in the file at Path too,
+	// so that they're served right after a restart
+	Persistent bool   `json:"persistent" env:"PERSISTENT"`
+	Path       string `json:"path" env:"PATH"`
+	MaxSizeMB  int    `json:"max_size_mb" env:"MAX_SIZE_MB"`
+}
+
+// Tracing exports OpenTelemetry traces over OTLP/HTTP, it's disabled without an endpoint
+type Tracing struct {
+	// Endpoint is the url of the OTLP/HTTP traces endpoint, like http://localhost:4318/v1/traces
+	Endpoint    string            `json:"endpoint" env:"ENDPOINT"`
+	Headers     map[string]string `json:"headers" env:"HEADERS"`
+	ServiceName string            `json:"service_name" env:"SERVICE_NAME"`
+	// SampleRatio is the ratio of the traces started by OpenList to sample, from 0 to 1
+	SampleRatio float64 `json:"sample_ratio" env:"SAMPLE_RATIO"`
+}
+
+// Cluster lets several instances share a database, they tell each other about the changes
+// of the state they keep in memory, and only the leader of them runs the scheduled work
+type Cluster struct {
+	Enable bool `json:"enable" env:"ENABLE"`
+	// NodeID names the instance, if empty one is generated and stored in the data dir.
+	// It must be unique in the cluster and stay the same after a restart or an upgrade,
+	// the persisted tasks of the instance are kept under it
+	NodeID string `json:"node_id" env:"NODE_ID"`
+	// PollInterval is the interval in milliseconds to poll the events of the other instances
+	PollInterval int `json:"poll_interval" env:"POLL_INTERVAL"`
+}
+
 type Config struct {
 	Force                 bool        `json:"force" env:"FORCE"`
 	SiteURL               string      `json:"site_url" env:"SITE_URL"`
@@ -130,8 +161,16 @@ type Config struct {
 	S3                    S3          `json:"s3" envPrefix:"S3_"`
 	FTP                   FTP         `json:"ftp" envPrefix:"FTP_"`
 	SFTP                  SFTP        `json:"sftp" envPrefix:"SFTP_"`
+	Tracing               Tracing     `json:"tracing" envPrefix:"TRACING_"`
+	Cache                 CacheConfig `json:"cache" envPrefix:"CACHE_"`
+	Cluster               Cluster     `json:"cluster" envPrefix:"CLUSTER_"`
 	LastLaunchedVersion   string      `json:"last_launched_version"`
 	ProxyAddress          string      `json:"proxy_address" env:"PROXY_ADDRESS"`
+	// TrustedProxies are the ips and CIDRs of the reverse proxies whose X-Forwarded-For gives the client ip.
+	// Every proxy used to be trusted, which let any client fake its ip, so it's a local proxy by default now,
+	// also for a config written before it was added. Empty trusts no proxy, and a proxy elsewhere must be
+	// added here for the ip rules to see the ips of its clients, an X-Forwarded-For it sends is warned about
+	TrustedProxies []string `json:"trusted_proxies" env:"TRUSTED_PROXIES"`
 }
 
 func DefaultConfig(dataDir string) *Config {
@@ -207,6 +246,11 @@ func DefaultConfig(dataDir string) *Config {
 				MaxRetry: 2,
 				// TaskPersistant: true,
 			},
+			Sync: TaskConfig{
+				Workers:  5,
+				MaxRetry: 2,
+				// TaskPersistant: true,
+			},
 			Decompress: TaskConfig{
 				Workers:  5,
 				MaxRetry: 2,
@@ -244,7 +288,21 @@ func DefaultConfig(dataDir string) *Config {
 			Enable: false,
 			Listen: ":5222",
 		},
+		Cache: CacheConfig{
+			Persistent: false,
+			Path:       filepath.Join(dataDir, "cache.db"),
+			MaxSizeMB:  256,
+		},
+		Tracing: Tracing{
+			ServiceName: "openlist",
+			SampleRatio: 1,
+		},
+		Cluster: Cluster{
+			Enable:       false,
+			PollInterval: 1000,
+		},
 		LastLaunchedVersion: "",
 		ProxyAddress:        "",
+		TrustedProxies:      []string{"127.0.0.1", "::1"},
 	}
 }
@@ -59,6 +59,13 @@ const (
 	HandleHookAfterWriting  = "handle_hook_after_writing"
 	HandleHookRateLimit     = "handle_hook_rate_limit"
 	IgnoreSystemFiles       = "ignore_system_files"
+	TrashRetentionDays      = "trash_retention_days"
+	AuditRetentionDays      = "audit_retention_days"
+	SessionLifetime         = "session_lifetime"
+	SessionIdleTimeout      = "session_idle_timeout"
+	ShareStatsRetentionDays = "share_stats_retention_days"
+	WebhookRetentionDays    = "webhook_retention_days"
+	MetricsToken            = "metrics_token"
 
 	// index
 	SearchIndex     = "search_index"
@@ -113,6 +120,7 @@ const (
 	SSOAutoRegister      = "sso_auto_register"
 	SSODefaultDir        = "sso_default_dir"
 	SSODefaultPermission = "sso_default_permission"
+	SSODefaultGroups     = "sso_default_groups"
 	SSOCompatibilityMode = "sso_compatibility_mode"
 
 	// ldap
@@ -125,6 +133,7 @@ const (
 	LdapUserSearchFilter  = "ldap_user_search_filter"
 	LdapDefaultPermission = "ldap_default_permission"
 	LdapDefaultDir        = "ldap_default_dir"
+	LdapDefaultGroups     = "ldap_default_groups"
 	LdapLoginTips         = "ldap_login_tips"
 
 	// s3
@@ -147,6 +156,7 @@ const (
 	FTPImplicitTLS           = "ftp_implicit_tls"
 	FTPTLSPrivateKeyPath     = "ftp_tls_private_key_path"
 	FTPTLSPublicCertPath     = "ftp_tls_public_cert_path"
+	FTPConflictPolicy        = "ftp_conflict_policy"
 	SFTPDisablePasswordLogin = "sftp_disable_password_login"
 
 	// traffic
@@ -155,6 +165,8 @@ const (
 	TaskUploadThreadsNum                  = "upload_task_threads_num"
 	TaskCopyThreadsNum                    = "copy_task_threads_num"
 	TaskMoveThreadsNum                    = "move_task_threads_num"
+	TaskSyncThreadsNum                    = "sync_task_threads_num"
+	TaskTransferVerify                    = "transfer_verify"
 	TaskDecompressDownloadThreadsNum      = "decompress_download_task_threads_num"
 	TaskDecompressUploadThreadsNum        = "decompress_upload_task_threads_num"
 	StreamMaxClientDownloadSpeed          = "max_client_download_speed"
@@ -191,4 +203,12 @@ const (
 	PathKey
 	SharingIDKey
 	SkipHookKey
+	ConflictPolicyKey
+	UploadSessionKey
+	ResumableUploadKey
+	SkipQuotaKey
+	ProtocolKey
+	SessionIDKey
+	SkipEventKey
+	SkipAuditKey
 )
@@ -0,0 +1,43 @@
+package db
+
+import (
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+)
+
+func GetAccessTokensByUserId(userId uint) (tokens []model.AccessToken, err error) {
+	if err := db.Where(model.AccessToken{UserID: userId}).Order(columnName("id")).Find(&tokens).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find access tokens")
+	}
+	return tokens, nil
+}
+
+func GetAccessTokenById(id uint) (*model.AccessToken, error) {
+	var t model.AccessToken
+	if err := db.First(&t, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get access token")
+	}
+	return &t, nil
+}
+
+func GetAccessTokenByHash(hash string) (*model.AccessToken, error) {
+	t := model.AccessToken{Hash: hash}
+	if err := db.Where(t).First(&t).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find access token")
+	}
+	return &t, nil
+}
+
+func CreateAccessToken(t *model.AccessToken) error {
+	return errors.WithStack(db.Create(t).Error)
+}
+
+func UpdateAccessTokenLastUsed(id uint, lastUsed time.Time) error {
+	return errors.WithStack(db.Model(&model.AccessToken{ID: id}).Update("last_used_at", lastUsed).Error)
+}
+
+func RevokeAccessToken(id uint, revoked time.Time) error {
+	return errors.WithStack(db.Model(&model.AccessToken{ID: id}).Update("revoked_at", revoked).Error)
+}
@@ -0,0 +1,44 @@
+package db
+
+import (
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+)
+
+func GetAclRules(pageIndex, pageSize int) (rules []model.AclRule, count int64, err error) {
+	ruleDB := db.Model(&model.AclRule{})
+	if err := ruleDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get acl rules count")
+	}
+	if err := ruleDB.Order(columnName("id")).Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&rules).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find acl rules")
+	}
+	return rules, count, nil
+}
+
+func GetAllAclRules() (rules []model.AclRule, err error) {
+	if err := db.Find(&rules).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find acl rules")
+	}
+	return rules, nil
+}
+
+func GetAclRuleById(id uint) (*model.AclRule, error) {
+	var r model.AclRule
+	if err := db.First(&r, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get acl rule")
+	}
+	return &r, nil
+}
+
+func CreateAclRule(r *model.AclRule) error {
+	return errors.WithStack(db.Create(r).Error)
+}
+
+func UpdateAclRule(r *model.AclRule) error {
+	return errors.WithStack(db.Save(r).Error)
+}
+
+func DeleteAclRuleById(id uint) error {
+	return errors.WithStack(db.Delete(&model.AclRule{}, id).Error)
+}
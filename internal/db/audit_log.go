@@ -0,0 +1,66 @@
+package db
+
+import (
+	"strings"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+func CreateAuditLogs(logs []*model.AuditLog, batchSize int) error {
+	return errors.WithStack(db.CreateInBatches(logs, batchSize).Error)
+}
+
+// likeEscaper escapes the wildcards of LIKE with !, which means the same in every database
+var likeEscaper = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+
+func filterAuditLogs(f model.AuditLogFilter) *gorm.DB {
+	logDB := db.Model(&model.AuditLog{}).Where(model.AuditLog{
+		Username: f.Username,
+		Action:   f.Action,
+		Protocol: f.Protocol,
+		IP:       f.IP,
+	})
+	if f.Path != "" {
+		// the path of a dir matches the objects in it as well
+		prefix := likeEscaper.Replace(f.Path) + "/%"
+		logDB = logDB.Where(columnName("path")+" = ? OR "+columnName("path")+" LIKE ? ESCAPE '!' OR "+columnName("dst_path")+" = ? OR "+columnName("dst_path")+" LIKE ? ESCAPE '!'",
+			f.Path, prefix, f.Path, prefix)
+	}
+	if f.Success != nil {
+		logDB = logDB.Where(columnName("success")+" = ?", *f.Success)
+	}
+	if f.Start != nil {
+		logDB = logDB.Where(columnName("time")+" >= ?", *f.Start)
+	}
+	if f.End != nil {
+		logDB = logDB.Where(columnName("time")+" < ?", *f.End)
+	}
+	return logDB
+}
+
+// GetAuditLogs returns a page of the audit logs matching f, the latest first
+func GetAuditLogs(f model.AuditLogFilter, pageIndex, pageSize int) (logs []model.AuditLog, count int64, err error) {
+	if err := filterAuditLogs(f).Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get audit logs count")
+	}
+	if err := filterAuditLogs(f).Order(columnName("id") + " DESC").Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&logs).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find audit logs")
+	}
+	return logs, count, nil
+}
+
+// WalkAuditLogs calls fn with the audit logs matching f in batches, the earliest first
+func WalkAuditLogs(f model.AuditLogFilter, fn func(logs []model.AuditLog) error) error {
+	var logs []model.AuditLog
+	return errors.WithStack(filterAuditLogs(f).Order(columnName("id")).FindInBatches(&logs, 500, func(tx *gorm.DB, batch int) error {
+		return fn(logs)
+	}).Error)
+}
+
+func DeleteAuditLogsBefore(t time.Time) (int64, error) {
+	res := db.Where(columnName("time")+" < ?", t).Delete(&model.AuditLog{})
+	return res.RowsAffected, errors.WithStack(res.Error)
+}
@@ -0,0 +1,58 @@
+package db
+
+import (
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+	"gorm.io/gorm/clause"
+)
+
+func CreateClusterEvent(e *model.ClusterEvent) error {
+	return errors.WithStack(db.Create(e).Error)
+}
+
+// GetClusterEvents gets the events after the id or created after since, the ids of concurrent
+// inserts may be committed out of order, so the recent events are read again
+func GetClusterEvents(afterID uint, since time.Time) (events []model.ClusterEvent, err error) {
+	if err := db.Where("id > ? OR created_at > ?", afterID, since).Order(columnName("id")).Find(&events).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find cluster events")
+	}
+	return events, nil
+}
+
+// GetLastClusterEventID gets the id of the latest event, 0 if there's none
+func GetLastClusterEventID() (uint, error) {
+	var e model.ClusterEvent
+	err := db.Order(columnName("id") + " DESC").Limit(1).Find(&e).Error
+	return e.ID, errors.Wrapf(err, "failed get last cluster event")
+}
+
+func DeleteClusterEventsBefore(t time.Time) error {
+	return errors.WithStack(db.Where("created_at < ?", t).Delete(&model.ClusterEvent{}).Error)
+}
+
+// AcquireClusterLease takes or renews the lease name for node until expiresAt,
+// it reports false if another node holds the lease and it hasn't expired
+func AcquireClusterLease(name, node string, expiresAt time.Time) (bool, error) {
+	res := db.Model(&model.ClusterLease{}).
+		Where("name = ? AND (node = ? OR expires_at < ?)", name, node, time.Now()).
+		Updates(map[string]any{"node": node, "expires_at": expiresAt})
+	if res.Error != nil {
+		return false, errors.Wrapf(res.Error, "failed update cluster lease")
+	}
+	if res.RowsAffected > 0 {
+		return true, nil
+	}
+	res = db.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&model.ClusterLease{Name: name, Node: node, ExpiresAt: expiresAt})
+	if res.Error != nil {
+		return false, errors.Wrapf(res.Error, "failed create cluster lease")
+	}
+	return res.RowsAffected > 0, nil
+}
+
+// ReleaseClusterLease gives up the lease name if node holds it
+func ReleaseClusterLease(name, node string) error {
+	return errors.WithStack(db.Where("name = ? AND node = ?", name, node).Delete(&model.ClusterLease{}).Error)
+}
@@ -12,7 +12,7 @@ var db *gorm.DB
 
 func Init(d *gorm.DB) {
 	db = d
-	err := AutoMigrate(new(model.Storage), new(model.User), new(model.Meta), new(model.SettingItem), new(model.SearchNode), new(model.TaskItem), new(model.SSHPublicKey), new(model.SharingDB))
+	err := AutoMigrate(new(model.Storage), new(model.User), new(model.Meta), new(model.SettingItem), new(model.SearchNode), new(model.TaskItem), new(model.SSHPublicKey), new(model.SharingDB), new(model.TrashItem), new(model.ScheduledJob), new(model.ScheduledJobRun), new(model.UserUsage), new(model.Group), new(model.AclRule), new(model.AccessToken), new(model.S3Key), new(model.AuditLog), new(model.IPRule), new(model.Session), new(model.SharingUpload), new(model.SharingAccessLog), new(model.InternalShare), new(model.SignRevocation), new(model.SignUsage), new(model.Webhook), new(model.WebhookDelivery), new(model.ClusterEvent), new(model.ClusterLease))
 	if err != nil {
 		log.Fatalf("failed migrate database: %s", err.Error())
 	}
@@ -0,0 +1,57 @@
+package db
+
+import (
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+)
+
+func GetGroups(pageIndex, pageSize int) (groups []model.Group, count int64, err error) {
+	groupDB := db.Model(&model.Group{})
+	if err := groupDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get groups count")
+	}
+	if err := groupDB.Order(columnName("id")).Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&groups).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find groups")
+	}
+	return groups, count, nil
+}
+
+func GetGroupsByIds(ids []uint) (groups []model.Group, err error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	if err := db.Find(&groups, ids).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find groups")
+	}
+	return groups, nil
+}
+
+func GetGroupsByNames(names []string) (groups []model.Group, err error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+	if err := db.Where(columnName("name")+" IN ?", names).Find(&groups).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find groups")
+	}
+	return groups, nil
+}
+
+func GetGroupById(id uint) (*model.Group, error) {
+	var g model.Group
+	if err := db.First(&g, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get group")
+	}
+	return &g, nil
+}
+
+func CreateGroup(g *model.Group) error {
+	return errors.WithStack(db.Create(g).Error)
+}
+
+func UpdateGroup(g *model.Group) error {
+	return errors.WithStack(db.Save(g).Error)
+}
+
+func DeleteGroupById(id uint) error {
+	return errors.WithStack(db.Delete(&model.Group{}, id).Error)
+}
@@ -0,0 +1,55 @@
+package db
+
+import (
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+)
+
+func GetInternalShares(pageIndex, pageSize int) (shares []model.InternalShare, count int64, err error) {
+	shareDB := db.Model(&model.InternalShare{})
+	if err := shareDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get internal shares count")
+	}
+	if err := shareDB.Order(columnName("id")).Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&shares).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find internal shares")
+	}
+	return shares, count, nil
+}
+
+func GetInternalSharesByCreatorId(creatorId uint, pageIndex, pageSize int) (shares []model.InternalShare, count int64, err error) {
+	shareDB := db.Model(&model.InternalShare{}).Where(model.InternalShare{CreatorID: creatorId})
+	if err := shareDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get internal shares count")
+	}
+	if err := shareDB.Order(columnName("id")).Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&shares).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find internal shares")
+	}
+	return shares, count, nil
+}
+
+func GetAllInternalShares() (shares []model.InternalShare, err error) {
+	if err := db.Order(columnName("id")).Find(&shares).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find internal shares")
+	}
+	return shares, nil
+}
+
+func GetInternalShareById(id uint) (*model.InternalShare, error) {
+	var s model.InternalShare
+	if err := db.First(&s, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get internal share")
+	}
+	return &s, nil
+}
+
+func CreateInternalShare(s *model.InternalShare) error {
+	return errors.WithStack(db.Create(s).Error)
+}
+
+func UpdateInternalShare(s *model.InternalShare) error {
+	return errors.WithStack(db.Save(s).Error)
+}
+
+func DeleteInternalShareById(id uint) error {
+	return errors.WithStack(db.Delete(&model.InternalShare{}, id).Error)
+}
@@ -0,0 +1,44 @@
+package db
+
+import (
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+)
+
+func GetIPRules(pageIndex, pageSize int) (rules []model.IPRule, count int64, err error) {
+	ruleDB := db.Model(&model.IPRule{})
+	if err := ruleDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get ip rules count")
+	}
+	if err := ruleDB.Order(columnName("id")).Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&rules).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find ip rules")
+	}
+	return rules, count, nil
+}
+
+func GetAllIPRules() (rules []model.IPRule, err error) {
+	if err := db.Find(&rules).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find ip rules")
+	}
+	return rules, nil
+}
+
+func GetIPRuleById(id uint) (*model.IPRule, error) {
+	var r model.IPRule
+	if err := db.First(&r, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get ip rule")
+	}
+	return &r, nil
+}
+
+func CreateIPRule(r *model.IPRule) error {
+	return errors.WithStack(db.Create(r).Error)
+}
+
+func UpdateIPRule(r *model.IPRule) error {
+	return errors.WithStack(db.Save(r).Error)
+}
+
+func DeleteIPRuleById(id uint) error {
+	return errors.WithStack(db.Delete(&model.IPRule{}, id).Error)
+}
@@ -0,0 +1,62 @@
+package db
+
+import (
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GetUserUsage returns the usage of the user, which is zero if nothing is recorded
+func GetUserUsage(userId uint) (*model.UserUsage, error) {
+	usage := model.UserUsage{UserID: userId}
+	if err := db.Where(usage).Limit(1).Find(&usage).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get user usage")
+	}
+	return &usage, nil
+}
+
+// AddUserUsage adds the deltas to the usage of the user, which doesn't go below zero.
+// It adds in one update so that concurrent changes of the usage aren't lost
+func AddUserUsage(userId uint, bytes, files int64) error {
+	if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&model.UserUsage{UserID: userId}).Error; err != nil {
+		return errors.Wrapf(err, "failed create user usage")
+	}
+	err := db.Model(&model.UserUsage{}).Where(columnName("user_id")+" = ?", userId).Updates(usageDeltas(bytes, files)).Error
+	return errors.Wrapf(err, "failed add user usage")
+}
+
+// ReserveUserUsage adds the deltas to the usage of the user only if needBytes more bytes
+// and the files fit in the quotas, a quota of 0 is unlimited. It checks and adds in one
+// update so that concurrent reservations can't exceed the quotas together
+func ReserveUserUsage(userId uint, bytes, files, needBytes, quotaBytes, quotaFiles int64) (bool, error) {
+	if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&model.UserUsage{UserID: userId}).Error; err != nil {
+		return false, errors.Wrapf(err, "failed create user usage")
+	}
+	bytesColumn, filesColumn := columnName("bytes"), columnName("files")
+	tx := db.Model(&model.UserUsage{}).Where(columnName("user_id")+" = ?", userId)
+	if quotaBytes > 0 && needBytes > 0 {
+		tx = tx.Where(bytesColumn+" + ? <= ?", needBytes, quotaBytes)
+	}
+	if quotaFiles > 0 && files > 0 {
+		tx = tx.Where(filesColumn+" + ? <= ?", files, quotaFiles)
+	}
+	res := tx.Updates(usageDeltas(bytes, files))
+	if res.Error != nil {
+		return false, errors.Wrapf(res.Error, "failed reserve user usage")
+	}
+	return res.RowsAffected > 0, nil
+}
+
+// usageDeltas are the updates adding the deltas to the usage, which doesn't go below zero
+func usageDeltas(bytes, files int64) map[string]any {
+	bytesColumn, filesColumn := columnName("bytes"), columnName("files")
+	return map[string]any{
+		"bytes": gorm.Expr("CASE WHEN "+bytesColumn+" + ? < 0 THEN 0 ELSE "+bytesColumn+" + ? END", bytes, bytes),
+		"files": gorm.Expr("CASE WHEN "+filesColumn+" + ? < 0 THEN 0 ELSE "+filesColumn+" + ? END", files, files),
+	}
+}
+
+func SetUserUsage(usage *model.UserUsage) error {
+	return errors.WithStack(db.Save(usage).Error)
+}
@@ -0,0 +1,50 @@
+package db
+
+import (
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+)
+
+func GetS3Keys() (keys []model.S3Key, err error) {
+	if err := db.Find(&keys).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find s3 keys")
+	}
+	return keys, nil
+}
+
+func GetS3KeysByUserId(userId uint) (keys []model.S3Key, err error) {
+	if err := db.Where(model.S3Key{UserID: userId}).Order(columnName("id")).Find(&keys).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find s3 keys")
+	}
+	return keys, nil
+}
+
+func GetS3KeyById(id uint) (*model.S3Key, error) {
+	var k model.S3Key
+	if err := db.First(&k, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get s3 key")
+	}
+	return &k, nil
+}
+
+func GetS3KeyByAccessKeyId(accessKeyId string) (*model.S3Key, error) {
+	k := model.S3Key{AccessKeyID: accessKeyId}
+	if err := db.Where(k).First(&k).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find s3 key")
+	}
+	return &k, nil
+}
+
+func CreateS3Key(k *model.S3Key) error {
+	return errors.WithStack(db.Create(k).Error)
+}
+
+func UpdateS3KeyLastUsed(id uint, lastUsed time.Time) error {
+	return errors.WithStack(db.Model(&model.S3Key{ID: id}).Update("last_used_at", lastUsed).Error)
+}
+
+func DeleteS3KeyById(id uint) error {
+	return errors.WithStack(db.Delete(&model.S3Key{}, id).Error)
+}
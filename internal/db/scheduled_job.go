@@ -0,0 +1,86 @@
+package db
+
+import (
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+)
+
+func GetScheduledJobById(id uint) (*model.ScheduledJob, error) {
+	var j model.ScheduledJob
+	if err := db.First(&j, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get scheduled job")
+	}
+	return &j, nil
+}
+
+func GetScheduledJobs(pageIndex, pageSize int) (jobs []model.ScheduledJob, count int64, err error) {
+	jobDB := db.Model(&model.ScheduledJob{})
+	if err := jobDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get scheduled jobs count")
+	}
+	if err := jobDB.Order(columnName("id")).Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&jobs).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find scheduled jobs")
+	}
+	return jobs, count, nil
+}
+
+func GetEnabledScheduledJobs() (jobs []model.ScheduledJob, err error) {
+	if err := db.Where(columnName("disabled")+" = ?", false).Find(&jobs).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find enabled scheduled jobs")
+	}
+	return jobs, nil
+}
+
+func CreateScheduledJob(j *model.ScheduledJob) error {
+	return errors.WithStack(db.Create(j).Error)
+}
+
+func UpdateScheduledJob(j *model.ScheduledJob) error {
+	return errors.WithStack(db.Save(j).Error)
+}
+
+func DeleteScheduledJobById(id uint) error {
+	if err := db.Where(columnName("job_id")+" = ?", id).Delete(&model.ScheduledJobRun{}).Error; err != nil {
+		return errors.Wrapf(err, "failed delete runs of scheduled job")
+	}
+	return errors.WithStack(db.Delete(&model.ScheduledJob{}, id).Error)
+}
+
+func GetScheduledJobRuns(jobId uint, pageIndex, pageSize int) (runs []model.ScheduledJobRun, count int64, err error) {
+	runDB := db.Model(&model.ScheduledJobRun{}).Where(columnName("job_id")+" = ?", jobId)
+	if err := runDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get scheduled job runs count")
+	}
+	if err := runDB.Order(columnName("id") + " DESC").Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&runs).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find scheduled job runs")
+	}
+	return runs, count, nil
+}
+
+func CreateScheduledJobRun(r *model.ScheduledJobRun) error {
+	return errors.WithStack(db.Create(r).Error)
+}
+
+func UpdateScheduledJobRun(r *model.ScheduledJobRun) error {
+	return errors.WithStack(db.Save(r).Error)
+}
+
+// PruneScheduledJobRuns keeps only the latest keep runs of the job
+func PruneScheduledJobRuns(jobId uint, keep int) error {
+	var ids []uint
+	err := db.Model(&model.ScheduledJobRun{}).Where(columnName("job_id")+" = ?", jobId).
+		Order(columnName("id")+" DESC").Offset(keep).Limit(1).Pluck("id", &ids).Error
+	if err != nil || len(ids) == 0 {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(db.Where(columnName("job_id")+" = ? AND "+columnName("id")+" <= ?", jobId, ids[0]).
+		Delete(&model.ScheduledJobRun{}).Error)
+}
+
+// FailRunningScheduledJobRuns marks the runs of the node interrupted by a restart as failed,
+// the runs without a node are from before the cluster mode
+func FailRunningScheduledJobRuns(node, msg string) error {
+	return errors.WithStack(db.Model(&model.ScheduledJobRun{}).
+		Where(columnName("status")+" = ? AND "+columnName("node")+" IN ?", model.JobRunRunning, []string{node, ""}).
+		Updates(map[string]any{"status": model.JobRunFailed, "error": msg}).Error)
+}
@@ -0,0 +1,48 @@
+package db
+
+import (
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+)
+
+func GetSessionsByUserId(userId uint) (sessions []model.Session, err error) {
+	if err := db.Where(model.Session{UserID: userId}).Order(columnName("created_at") + " desc").Find(&sessions).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find sessions")
+	}
+	return sessions, nil
+}
+
+func GetSessionById(id string) (*model.Session, error) {
+	var s model.Session
+	if err := db.Where(columnName("id")+" = ?", id).First(&s).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get session")
+	}
+	return &s, nil
+}
+
+func CreateSession(s *model.Session) error {
+	return errors.WithStack(db.Create(s).Error)
+}
+
+func UpdateSessionLastSeen(id string, t time.Time) error {
+	return errors.WithStack(db.Model(&model.Session{}).Where(columnName("id")+" = ?", id).Update("last_seen_at", t).Error)
+}
+
+func DeleteSessionById(id string) error {
+	return errors.WithStack(db.Where(columnName("id")+" = ?", id).Delete(&model.Session{}).Error)
+}
+
+func DeleteSessionsByUserId(userId uint) error {
+	return errors.WithStack(db.Where(model.Session{UserID: userId}).Delete(&model.Session{}).Error)
+}
+
+// DeleteStaleSessions deletes the sessions expired before now, or last seen before idleBefore if it's not nil
+func DeleteStaleSessions(now time.Time, idleBefore *time.Time) error {
+	q := db.Where(columnName("expires_at")+" < ?", now)
+	if idleBefore != nil {
+		q = q.Or(columnName("last_seen_at")+" < ?", *idleBefore)
+	}
+	return errors.WithStack(q.Delete(&model.Session{}).Error)
+}
@@ -1,9 +1,12 @@
 package db
 
 import (
+	"time"
+
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/pkg/utils/random"
 	"github.com/pkg/errors"
+	"gorm.io/gorm"
 )
 
 func GetSharingById(id string) (*model.SharingDB, error) {
@@ -60,15 +63,103 @@ func CreateSharing(s *model.SharingDB) (string, error) {
 	}
 }
 
+// UpdateSharing saves s except the upload and traffic counters, which are only changed by their own functions
 func UpdateSharing(s *model.SharingDB) error {
-	return errors.WithStack(db.Save(s).Error)
+	return errors.WithStack(db.Select("*").Omit("uploads", "uploaded_size", "traffic").Save(s).Error)
+}
+
+// UpdateSharingUploads adds to the upload counters of the sharing
+func UpdateSharingUploads(id string, uploads int, size int64) error {
+	return errors.WithStack(db.Model(&model.SharingDB{ID: id}).Updates(map[string]any{
+		"uploads":       gorm.Expr("uploads + ?", uploads),
+		"uploaded_size": gorm.Expr("uploaded_size + ?", size),
+	}).Error)
 }
 
 func DeleteSharingById(id string) error {
+	if err := db.Where(model.SharingUpload{SharingID: id}).Delete(&model.SharingUpload{}).Error; err != nil {
+		return errors.Wrapf(err, "failed delete sharing uploads")
+	}
+	if err := db.Where(model.SharingAccessLog{SharingID: id}).Delete(&model.SharingAccessLog{}).Error; err != nil {
+		return errors.Wrapf(err, "failed delete sharing access logs")
+	}
 	s := model.SharingDB{ID: id}
 	return errors.WithStack(db.Where(s).Delete(&s).Error)
 }
 
 func DeleteSharingsByCreatorId(creatorId uint) error {
+	ids := db.Model(&model.SharingDB{}).Select("id").Where("creator_id = ?", creatorId)
+	if err := db.Where("sharing_id IN (?)", ids).Delete(&model.SharingUpload{}).Error; err != nil {
+		return errors.Wrapf(err, "failed delete sharing uploads")
+	}
+	if err := db.Where("sharing_id IN (?)", ids).Delete(&model.SharingAccessLog{}).Error; err != nil {
+		return errors.Wrapf(err, "failed delete sharing access logs")
+	}
 	return errors.WithStack(db.Where("creator_id = ?", creatorId).Delete(&model.SharingDB{}).Error)
 }
+
+func GetSharingUploads(sharingId string, pageIndex, pageSize int) (uploads []model.SharingUpload, count int64, err error) {
+	uploadDB := db.Model(&model.SharingUpload{}).Where(model.SharingUpload{SharingID: sharingId})
+	if err := uploadDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get sharing uploads count")
+	}
+	if err := uploadDB.Order(columnName("id") + " desc").Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&uploads).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find sharing uploads")
+	}
+	return uploads, count, nil
+}
+
+func CreateSharingUpload(u *model.SharingUpload) error {
+	return errors.WithStack(db.Create(u).Error)
+}
+
+// AddSharingTraffic adds bytes to the traffic of the sharing,
+// and disables it if the traffic reaches the limit, which is reported by disabled
+func AddSharingTraffic(id string, bytes int64) (disabled bool, err error) {
+	if bytes != 0 {
+		err = db.Model(&model.SharingDB{ID: id}).Update("traffic", gorm.Expr("traffic + ?", bytes)).Error
+		if err != nil {
+			return false, errors.Wrapf(err, "failed update sharing traffic")
+		}
+	}
+	res := db.Model(&model.SharingDB{}).
+		Where("id = ? AND disabled = ? AND max_traffic > 0 AND traffic >= max_traffic", id, false).
+		Update("disabled", true)
+	if res.Error != nil {
+		return false, errors.Wrapf(res.Error, "failed disable sharing")
+	}
+	return res.RowsAffected > 0, nil
+}
+
+// ReserveSharingTraffic adds bytes to the traffic of the sharing only if it stays within the limit
+func ReserveSharingTraffic(id string, bytes int64) (bool, error) {
+	res := db.Model(&model.SharingDB{}).
+		Where("id = ? AND (max_traffic <= 0 OR traffic + ? <= max_traffic)", id, bytes).
+		Update("traffic", gorm.Expr("traffic + ?", bytes))
+	if res.Error != nil {
+		return false, errors.Wrapf(res.Error, "failed reserve sharing traffic")
+	}
+	return res.RowsAffected > 0, nil
+}
+
+func ResetSharingTraffic(id string) error {
+	return errors.WithStack(db.Model(&model.SharingDB{ID: id}).Update("traffic", 0).Error)
+}
+
+func CreateSharingAccessLog(l *model.SharingAccessLog) error {
+	return errors.WithStack(db.Create(l).Error)
+}
+
+// WalkSharingAccessLogs calls fn with the access logs of the sharing in [start, end) in batches
+func WalkSharingAccessLogs(id string, start, end time.Time, fn func([]model.SharingAccessLog) error) error {
+	var batch []model.SharingAccessLog
+	return errors.WithStack(db.Where(model.SharingAccessLog{SharingID: id}).
+		Where(columnName("time")+" >= ? AND "+columnName("time")+" < ?", start, end).
+		FindInBatches(&batch, 500, func(tx *gorm.DB, _ int) error {
+			return fn(batch)
+		}).Error)
+}
+
+func DeleteSharingAccessLogsBefore(t time.Time) error {
+	return errors.WithStack(db.Where(columnName("time")+" < ?", t).Delete(&model.SharingAccessLog{}).Error)
+}
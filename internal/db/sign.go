@@ -0,0 +1,43 @@
+package db
+
+import (
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func GetAllSignRevocations() (rs []model.SignRevocation, err error) {
+	if err := db.Find(&rs).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find sign revocations")
+	}
+	return rs, nil
+}
+
+func CreateSignRevocations(rs []model.SignRevocation) error {
+	return errors.WithStack(db.Clauses(clause.OnConflict{DoNothing: true}).Create(&rs).Error)
+}
+
+// UseSign counts a download by the sign with the key id, and reports false if it has been used max times
+func UseSign(keyID string, max int, expiresAt *time.Time) (bool, error) {
+	err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&model.SignUsage{KeyID: keyID, ExpiresAt: expiresAt}).Error
+	if err != nil {
+		return false, errors.Wrapf(err, "failed create sign usage")
+	}
+	res := db.Model(&model.SignUsage{}).Where("key_id = ? AND uses < ?", keyID, max).
+		Update("uses", gorm.Expr("uses + ?", 1))
+	if res.Error != nil {
+		return false, errors.Wrapf(res.Error, "failed update sign usage")
+	}
+	return res.RowsAffected > 0, nil
+}
+
+// DeleteExpiredSigns deletes the revocations and usages of the signs expired before t
+func DeleteExpiredSigns(t time.Time) error {
+	if err := db.Where("expires_at < ?", t).Delete(&model.SignRevocation{}).Error; err != nil {
+		return errors.Wrapf(err, "failed delete sign revocations")
+	}
+	return errors.WithStack(db.Where("expires_at < ?", t).Delete(&model.SignUsage{}).Error)
+}
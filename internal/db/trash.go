@@ -0,0 +1,55 @@
+package db
+
+import (
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+)
+
+func CreateTrashItem(item *model.TrashItem) error {
+	return errors.WithStack(db.Create(item).Error)
+}
+
+func GetTrashItemById(id uint) (*model.TrashItem, error) {
+	var item model.TrashItem
+	if err := db.First(&item, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get trash item")
+	}
+	return &item, nil
+}
+
+func GetTrashItems(pageIndex, pageSize int) (items []model.TrashItem, count int64, err error) {
+	trashDB := db.Model(&model.TrashItem{})
+	if err := trashDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get trash items count")
+	}
+	if err := trashDB.Order(columnName("deleted_at") + " DESC").Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&items).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find trash items")
+	}
+	return items, count, nil
+}
+
+func GetTrashItemsByDeleterId(deleterId uint, pageIndex, pageSize int) (items []model.TrashItem, count int64, err error) {
+	trashDB := db.Model(&model.TrashItem{})
+	cond := model.TrashItem{DeleterId: deleterId}
+	if err := trashDB.Where(cond).Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get user's trash items count")
+	}
+	if err := trashDB.Where(cond).Order(columnName("deleted_at") + " DESC").Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&items).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find user's trash items")
+	}
+	return items, count, nil
+}
+
+// GetTrashItemsDeletedBefore returns the items removed before t, oldest first
+func GetTrashItemsDeletedBefore(t time.Time) (items []model.TrashItem, err error) {
+	if err := db.Where(columnName("deleted_at")+" < ?", t).Order(columnName("deleted_at")).Find(&items).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find expired trash items")
+	}
+	return items, nil
+}
+
+func DeleteTrashItemById(id uint) error {
+	return errors.WithStack(db.Delete(&model.TrashItem{}, id).Error)
+}
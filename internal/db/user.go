@@ -2,11 +2,13 @@ package db
 
 import (
 	"encoding/base64"
+	"slices"
 
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
 	"github.com/go-webauthn/webauthn/webauthn"
 	"github.com/pkg/errors"
+	"gorm.io/gorm"
 )
 
 func GetUserByRole(role int) (*model.User, error) {
@@ -60,8 +62,60 @@ func GetUsers(pageIndex, pageSize int) (users []model.User, count int64, err err
 	return users, count, nil
 }
 
+func GetAllUsers() (users []model.User, err error) {
+	if err := db.Find(&users).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find users")
+	}
+	return users, nil
+}
+
+// DeleteUserById deletes the user with what's of it or given to it, in one transaction
+// so that nothing is left behind if it fails: its sharings, usage, access tokens, s3 keys,
+// ip rules, sessions, internal shares and acl rules, and it's removed from the internal
+// shares to it, which are deleted if they have no other recipient
 func DeleteUserById(id uint) error {
-	return errors.WithStack(db.Delete(&model.User{}, id).Error)
+	return errors.WithStack(db.Transaction(func(tx *gorm.DB) error {
+		sharings := tx.Model(&model.SharingDB{}).Select("id").Where("creator_id = ?", id)
+		for _, d := range []struct {
+			query *gorm.DB
+			value any
+		}{
+			{tx.Where("sharing_id IN (?)", sharings), &model.SharingUpload{}},
+			{tx.Where("sharing_id IN (?)", sharings), &model.SharingAccessLog{}},
+			{tx.Where("creator_id = ?", id), &model.SharingDB{}},
+			{tx.Where(model.UserUsage{UserID: id}), &model.UserUsage{}},
+			{tx.Where(model.AccessToken{UserID: id}), &model.AccessToken{}},
+			{tx.Where(model.S3Key{UserID: id}), &model.S3Key{}},
+			{tx.Where(model.IPRule{UserID: id}), &model.IPRule{}},
+			{tx.Where(model.Session{UserID: id}), &model.Session{}},
+			{tx.Where(model.InternalShare{CreatorID: id}), &model.InternalShare{}},
+			{tx.Where(columnName("subject_type")+" = ? AND "+columnName("subject_id")+" = ?", model.AclSubjectUser, id), &model.AclRule{}},
+		} {
+			if err := d.query.Delete(d.value).Error; err != nil {
+				return err
+			}
+		}
+		var shares []model.InternalShare
+		if err := tx.Find(&shares).Error; err != nil {
+			return err
+		}
+		for _, s := range shares {
+			if !slices.Contains(s.UserIDs, id) {
+				continue
+			}
+			s.UserIDs = slices.DeleteFunc(s.UserIDs, func(u uint) bool { return u == id })
+			var err error
+			if len(s.UserIDs) == 0 && len(s.GroupIDs) == 0 {
+				err = tx.Delete(&s).Error
+			} else {
+				err = tx.Model(&s).Select("user_ids").Updates(&s).Error
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return tx.Delete(&model.User{}, id).Error
+	}))
 }
 
 func UpdateAuthn(userID uint, authn string) error {
@@ -0,0 +1,95 @@
+package db
+
+import (
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+)
+
+func GetWebhooks(pageIndex, pageSize int) (hooks []model.Webhook, count int64, err error) {
+	hookDB := db.Model(&model.Webhook{})
+	if err := hookDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get webhooks count")
+	}
+	if err := hookDB.Order(columnName("id")).Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&hooks).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find webhooks")
+	}
+	return hooks, count, nil
+}
+
+func GetAllWebhooks() (hooks []model.Webhook, err error) {
+	if err := db.Find(&hooks).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find webhooks")
+	}
+	return hooks, nil
+}
+
+func GetWebhookById(id uint) (*model.Webhook, error) {
+	var w model.Webhook
+	if err := db.First(&w, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get webhook")
+	}
+	return &w, nil
+}
+
+func CreateWebhook(w *model.Webhook) error {
+	return errors.WithStack(db.Create(w).Error)
+}
+
+func UpdateWebhook(w *model.Webhook) error {
+	return errors.WithStack(db.Save(w).Error)
+}
+
+// DeleteWebhookById deletes the webhook with its deliveries
+func DeleteWebhookById(id uint) error {
+	if err := db.Where(model.WebhookDelivery{WebhookID: id}).Delete(&model.WebhookDelivery{}).Error; err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(db.Delete(&model.Webhook{}, id).Error)
+}
+
+// GetWebhookDeliveries gets the deliveries of a webhook, the latest first
+func GetWebhookDeliveries(webhookID uint, pageIndex, pageSize int) (deliveries []model.WebhookDelivery, count int64, err error) {
+	deliveryDB := db.Model(&model.WebhookDelivery{}).Where(model.WebhookDelivery{WebhookID: webhookID})
+	if err := deliveryDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get webhook deliveries count")
+	}
+	if err := deliveryDB.Order(columnName("id") + " DESC").Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&deliveries).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find webhook deliveries")
+	}
+	return deliveries, count, nil
+}
+
+func GetWebhookDeliveryById(id uint) (*model.WebhookDelivery, error) {
+	var d model.WebhookDelivery
+	if err := db.First(&d, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get webhook delivery")
+	}
+	return &d, nil
+}
+
+func CreateWebhookDeliveries(deliveries []model.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return errors.WithStack(db.Create(&deliveries).Error)
+}
+
+func UpdateWebhookDelivery(d *model.WebhookDelivery) error {
+	return errors.WithStack(db.Save(d).Error)
+}
+
+// GetDueWebhookDeliveries gets at most limit pending deliveries whose next attempt is before t
+func GetDueWebhookDeliveries(t time.Time, limit int) (deliveries []model.WebhookDelivery, err error) {
+	err = db.Where(columnName("status")+" = ? AND "+columnName("next_attempt_at")+" <= ?", model.WebhookDeliveryPending, t).
+		Order(columnName("next_attempt_at")).Limit(limit).Find(&deliveries).Error
+	return deliveries, errors.Wrapf(err, "failed find due webhook deliveries")
+}
+
+// DeleteWebhookDeliveriesBefore deletes the finished deliveries created before t
+func DeleteWebhookDeliveriesBefore(t time.Time) (int64, error) {
+	res := db.Where(columnName("status")+" <> ? AND "+columnName("created_at")+" < ?", model.WebhookDeliveryPending, t).
+		Delete(&model.WebhookDelivery{})
+	return res.RowsAffected, errors.WithStack(res.Error)
+}
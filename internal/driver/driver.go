@@ -47,6 +47,13 @@ type Getter interface {
 	Get(ctx context.Context, path string) (model.Obj, error)
 }
 
+type PagedLister interface {
+	// ListPage list one page of files in the dir, starting at args.Cursor.
+	// the empty cursor is the first page, and an empty next cursor means it is the last page.
+	// args.Limit is only a hint, the page may contain more or less objs
+	ListPage(ctx context.Context, dir model.Obj, args model.ListPageArgs) (objs []model.Obj, next string, err error)
+}
+
 //type Writer interface {
 //	Mkdir
 //	Move
@@ -218,3 +225,24 @@ type DirectUploader interface {
 	// return errs.NotImplement if the driver does not support the given direct upload tool
 	GetDirectUploadInfo(ctx context.Context, tool string, dstDir model.Obj, fileName string, fileSize int64) (any, error)
 }
+
+type ResumablePut interface {
+	// BeginUpload starts a chunked upload session of the file and returns its state.
+	// The state is opaque to the caller, it's persisted with the transfer task so that
+	// the upload can be continued after a restart. An empty state means that the file
+	// was already uploaded while beginning, e.g. by rapid upload
+	BeginUpload(ctx context.Context, dstDir model.Obj, file model.FileStreamer, up UpdateProgress) (string, error)
+	// UploadedOffset returns how many bytes of the file were uploaded in the session,
+	// an error means the session can't be continued and a new one should be begun
+	UploadedOffset(ctx context.Context, dstDir model.Obj, file model.FileStreamer, state string) (int64, error)
+	// ResumeUpload uploads the file from offset and completes the session. The drivers
+	// that keep their own checkpoints in the state report the updated state by save
+	ResumeUpload(ctx context.Context, dstDir model.Obj, file model.FileStreamer, state string, offset int64, save func(state string), up UpdateProgress) (model.Obj, error)
+}
+
+// UploadAborter is implemented by the ResumablePut drivers whose upload sessions keep
+// the uploaded data until they're completed or aborted
+type UploadAborter interface {
+	// AbortUpload discards the upload session of the state
+	AbortUpload(ctx context.Context, state string) error
+}
@@ -18,14 +18,19 @@ var (
 	StorageNotInit     = errors.New("storage not init")
 	StreamIncomplete   = errors.New("upload/download stream incomplete, possible network issue")
 	StreamPeekFail     = errors.New("StreamPeekFail")
+	InvalidCursor      = errors.New("invalid list cursor")
+	VerificationFailed = errors.New("dst object doesn't match src object")
 
 	UnknownArchiveFormat      = errors.New("unknown archive format")
 	WrongArchivePassword      = errors.New("wrong archive password")
 	DriverExtractNotSupported = errors.New("driver extraction not supported")
 
-	WrongShareCode  = errors.New("wrong share code")
-	InvalidSharing  = errors.New("invalid sharing")
-	SharingNotFound = errors.New("sharing not found")
+	WrongShareCode      = errors.New("wrong share code")
+	InvalidSharing      = errors.New("invalid sharing")
+	SharingNotFound     = errors.New("sharing not found")
+	FileRequestRefused  = errors.New("the file request refuses the upload")
+	NotAFileRequest     = errors.New("the sharing is not a file request")
+	FileRequestReadOnly = errors.New("files of a file request can't be read")
 )
 
 // NewErr wrap constant error with an extra message
@@ -7,4 +7,8 @@ var (
 	EmptyPassword      = errors.New("password is empty")
 	WrongPassword      = errors.New("password is incorrect")
 	DeleteAdminOrGuest = errors.New("cannot delete admin or guest")
+	QuotaExceeded      = errors.New("storage quota exceeded")
+	AccessTokenInvalid = errors.New("access token is invalid, expired or revoked")
+	ByAccessToken      = errors.New("not allowed with an access token")
+	SessionInvalid     = errors.New("session is expired or revoked, login please")
 )
@@ -0,0 +1,97 @@
+// Package event is an in-process bus of the things happening to files, tasks,
+// shares, storages and users, which consumers like webhooks subscribe to
+package event
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+type Type string
+
+const (
+	ObjectCreated        Type = "object.created"
+	ObjectUpdated        Type = "object.updated"
+	ObjectDeleted        Type = "object.deleted"
+	ObjectMoved          Type = "object.moved"
+	TaskSucceeded        Type = "task.succeeded"
+	TaskFailed           Type = "task.failed"
+	ShareAccessed        Type = "share.accessed"
+	StorageStatusChanged Type = "storage.status_changed"
+	UserLogin            Type = "user.login"
+)
+
+// Types are all the types of events, in the order shown to users
+var Types = []Type{
+	ObjectCreated, ObjectUpdated, ObjectDeleted, ObjectMoved,
+	TaskSucceeded, TaskFailed, ShareAccessed, StorageStatusChanged, UserLogin,
+}
+
+// Event is something that happened. Path is the full path of the object it is about,
+// if any, and DstPath the new path of a moved object
+type Event struct {
+	ID       string         `json:"id"`
+	Type     Type           `json:"type"`
+	Time     time.Time      `json:"time"`
+	Path     string         `json:"path,omitempty"`
+	DstPath  string         `json:"dst_path,omitempty"`
+	Username string         `json:"username,omitempty"`
+	Data     map[string]any `json:"data,omitempty"`
+}
+
+// Handler handles a published event, it must not block for long
+type Handler func(e *Event)
+
+var (
+	mu       sync.RWMutex
+	handlers []Handler
+)
+
+// Subscribe adds h to the handlers of all the events published from now on
+func Subscribe(h Handler) {
+	mu.Lock()
+	defer mu.Unlock()
+	handlers = append(handlers, h)
+}
+
+// Publish passes e to the handlers. The id, time and username are filled if missing,
+// the username is the one of the user of ctx. Events of a ctx with conf.SkipEventKey
+// are dropped, op uses it for its internal operations
+func Publish(ctx context.Context, e *Event) {
+	if ctx.Value(conf.SkipEventKey) != nil {
+		return
+	}
+	mu.RLock()
+	hs := handlers
+	mu.RUnlock()
+	if len(hs) == 0 {
+		return
+	}
+	if e.ID == "" {
+		e.ID = uuid.NewString()
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	if e.Username == "" {
+		if user, ok := ctx.Value(conf.UserKey).(*model.User); ok {
+			e.Username = user.Username
+		}
+	}
+	for _, h := range hs {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					log.Errorf("event handler panic on %s: %v", e.Type, r)
+				}
+			}()
+			h(e)
+		}()
+	}
+}
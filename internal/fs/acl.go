@@ -0,0 +1,44 @@
+package fs
+
+import (
+	"context"
+	stdpath "path"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+)
+
+// CheckAcl returns errs.PermissionDenied if the acl rules deny the user
+// of ctx to do the operation on any of the paths
+func CheckAcl(ctx context.Context, operation string, paths ...string) error {
+	user, _ := ctx.Value(conf.UserKey).(*model.User)
+	for _, path := range paths {
+		if err := op.CheckAcl(user, operation, path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkAclTree returns errs.PermissionDenied if the acl rules deny the user
+// of ctx to do the operation on path or on anything in it
+func checkAclTree(ctx context.Context, operation, path string) error {
+	user, _ := ctx.Value(conf.UserKey).(*model.User)
+	return op.CheckAclTree(user, operation, path)
+}
+
+// checkTransferAcl checks the operation of moving or copying srcPath into dstDirPath,
+// on srcPath and everything in it, and writing it as dstDirPath/name of srcPath
+func checkTransferAcl(ctx context.Context, operation, srcPath, dstDirPath string) error {
+	if err := checkAclTree(ctx, operation, srcPath); err != nil {
+		return err
+	}
+	return CheckAcl(ctx, model.AclWrite, dstDirPath, stdpath.Join(dstDirPath, stdpath.Base(srcPath)))
+}
+
+// filterAcl hides the objs in path that the user of ctx can't list
+func filterAcl(ctx context.Context, path string, objs []model.Obj) []model.Obj {
+	user, _ := ctx.Value(conf.UserKey).(*model.User)
+	return op.FilterAcl(user, model.AclList, path, objs)
+}
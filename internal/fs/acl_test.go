@@ -0,0 +1,72 @@
+package fs_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/fs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/pkg/errors"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func init() {
+	dB, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
+	if err != nil {
+		panic("failed to connect database")
+	}
+	conf.Conf = conf.DefaultConfig("data")
+	db.Init(dB)
+}
+
+func TestAclDestinationAndTree(t *testing.T) {
+	rules := []model.AclRule{
+		{Path: "/acl/locked", SubjectType: model.AclSubjectUser, SubjectID: 120, Operations: []string{model.AclWrite}},
+		{Path: "/acl/dir/keep", SubjectType: model.AclSubjectUser, SubjectID: 120, Operations: []string{model.AclDelete, model.AclMove, model.AclCopy, model.AclRename}},
+	}
+	for i := range rules {
+		if err := op.CreateAclRule(&rules[i]); err != nil {
+			t.Fatalf("failed to create acl rule: %+v", err)
+		}
+	}
+	defer func() {
+		for _, r := range rules {
+			_ = op.DeleteAclRuleById(r.ID)
+		}
+	}()
+	ctx := context.WithValue(context.Background(), conf.UserKey, &model.User{ID: 120, Username: "acl"})
+	for name, f := range map[string]func() error{
+		"rename to a denied name": func() error { return fs.Rename(ctx, "/acl/file", "locked") },
+		"move to a denied path": func() error {
+			_, err := fs.Move(ctx, "/other/locked", "/acl")
+			return err
+		},
+		"copy to a denied path": func() error {
+			_, err := fs.Copy(ctx, "/other/locked", "/acl")
+			return err
+		},
+		"remove a dir with a denied path": func() error { return fs.Remove(ctx, "/acl/dir") },
+		"move a dir with a denied path": func() error {
+			_, err := fs.Move(ctx, "/acl/dir", "/other")
+			return err
+		},
+		"copy a dir with a denied path": func() error {
+			_, err := fs.Copy(ctx, "/acl/dir", "/other")
+			return err
+		},
+		"rename a dir with a denied path": func() error { return fs.Rename(ctx, "/acl/dir", "renamed") },
+	} {
+		if err := f(); !errors.Is(err, errs.PermissionDenied) {
+			t.Errorf("expected %s to be denied, got %v", name, err)
+		}
+	}
+	// a path out of the denied ones isn't denied by the acl, but isn't found
+	if err := fs.Remove(ctx, "/acl/other"); err == nil || errors.Is(err, errs.PermissionDenied) {
+		t.Errorf("expected removing /acl/other to pass the acl, got %v", err)
+	}
+}
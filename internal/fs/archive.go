@@ -372,6 +372,10 @@ func archiveDecompress(ctx context.Context, srcObjPath, dstDirPath string, args
 	if err != nil {
 		return nil, errors.WithMessage(err, "failed get dst storage")
 	}
+	// the decompressed size is unknown, each file is checked again when it's put
+	if err = op.CheckQuota(ctx, dstDirPath, op.UnknownBytes, 1); err != nil {
+		return nil, err
+	}
 	if srcStorage.GetStorage() == dstStorage.GetStorage() {
 		err = op.ArchiveDecompress(ctx, srcStorage, srcObjActualPath, dstDirActualPath, args, lazyCache...)
 		if !errors.Is(err, errs.NotImplement) {
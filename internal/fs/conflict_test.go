@@ -0,0 +1,64 @@
+package fs
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+)
+
+func TestTransferReplaceRestore(t *testing.T) {
+	root, storage := createLocalStorage(t, "/replace_test", map[string]string{
+		"src/a":          "abc",
+		"dst/a/keep.txt": "abc",
+	})
+	dstEntries := func() []string {
+		entries, _ := os.ReadDir(filepath.Join(root, "dst"))
+		var names []string
+		for _, e := range entries {
+			names = append(names, e.Name())
+		}
+		return names
+	}
+
+	// the dir replaced by the file is kept until the task is done
+	for _, failed := range []bool{true, false} {
+		task := &FileTransferTask{
+			TaskData:       TaskData{SrcStorage: storage, DstStorage: storage, SrcActualPath: "/src/a", DstActualPath: "/dst"},
+			ConflictPolicy: model.ConflictOverwrite,
+		}
+		task.SetCtx(context.Background())
+		if skip, err := task.resolveRootConflict(context.Background()); err != nil || skip || task.Replaced == "" {
+			t.Fatalf("expected the dst dir to be backed up, got %q %v: %+v", task.Replaced, skip, err)
+		}
+		if _, err := os.Stat(filepath.Join(root, "dst", "a")); !os.IsNotExist(err) {
+			t.Errorf("expected the dst dir to be moved away, got %v", err)
+		}
+		task.finishReplaced(failed)
+		if failed {
+			if _, err := os.Stat(filepath.Join(root, "dst", "a", "keep.txt")); err != nil {
+				t.Errorf("expected the dst dir to be restored after a failure, got %v", err)
+			}
+		} else if names := dstEntries(); len(names) != 0 {
+			t.Errorf("expected the backup to be removed after a success, got %v", names)
+		}
+	}
+}
+
+func TestFilterTransfers(t *testing.T) {
+	objs := []model.Obj{
+		&model.Object{Name: "a.txt"},
+		&model.Object{Name: stagePrefix + "abcdefgh", IsFolder: true},
+		&model.Object{Name: replacedPrefix + "abcdefgh_a.txt"},
+		&model.Object{Name: ".openlist"},
+	}
+	filtered := filterTransfers(objs)
+	if len(filtered) != 2 || filtered[0].GetName() != "a.txt" || filtered[1].GetName() != ".openlist" {
+		t.Errorf("expected the staged and replaced objects to be hidden, got %+v", filtered)
+	}
+	if len(objs) != 4 {
+		t.Errorf("expected the objs not to be changed")
+	}
+}
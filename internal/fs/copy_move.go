@@ -10,6 +10,7 @@ import (
 	"github.com/OpenListTeam/OpenList/v4/internal/errs"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/setting"
 	"github.com/OpenListTeam/OpenList/v4/internal/stream"
 	"github.com/OpenListTeam/OpenList/v4/internal/task"
 	"github.com/OpenListTeam/OpenList/v4/internal/task_group"
@@ -29,6 +30,8 @@ func (t taskType) String() string {
 		return "move"
 	case merge:
 		return "merge"
+	case synchronize:
+		return "sync"
 	default:
 		return "unknown"
 	}
@@ -38,12 +41,27 @@ const (
 	copy taskType = iota
 	move
 	merge
+	synchronize
 )
 
 type FileTransferTask struct {
 	TaskData
 	TaskType taskType
-	groupID  string
+	// DeleteExtras removes the dst objects that don't exist in src, sync only
+	DeleteExtras   bool                 `json:"delete_extras"`
+	ConflictPolicy model.ConflictPolicy `json:"conflict_policy"`
+	// DstName is the name of the src object in dst, if it's renamed by the conflict policy
+	DstName string `json:"dst_name,omitempty"`
+	// Replaced is the backup of the dst object overwritten by the task, it's dropped
+	// when the task succeeds and restored when it fails
+	Replaced string `json:"replaced,omitempty"`
+	// Verification is the result of the integrity verification of the uploaded file
+	Verification string `json:"verification,omitempty"`
+	// Resumable is asked by the client, then UploadSession lets the upload continue after
+	// a restart if the dst driver supports it
+	Resumable     bool                 `json:"resumable,omitempty"`
+	UploadSession *model.UploadSession `json:"upload_session,omitempty"`
+	groupID       string
 }
 
 func (t *FileTransferTask) GetName() string {
@@ -69,20 +87,22 @@ func (t *FileTransferTask) Run() error {
 	defer func() { t.SetEndTime(time.Now()) }()
 	return t.RunWithNextTaskCallback(func(nextTask *FileTransferTask) error {
 		task_group.TransferCoordinator.AddTask(t.groupID, nil)
-		if t.TaskType == copy || t.TaskType == merge {
-			CopyTaskManager.Add(nextTask)
-		} else {
-			MoveTaskManager.Add(nextTask)
-		}
+		addTransferTask(nextTask)
 		return nil
 	})
 }
 
 func (t *FileTransferTask) OnSucceeded() {
+	t.finishReplaced(false)
 	task_group.TransferCoordinator.Done(context.WithoutCancel(t.Ctx()), t.groupID, true)
 }
 
 func (t *FileTransferTask) OnFailed() {
+	// a failed or canceled task doesn't continue its upload, unless it's retried from the start
+	if t.UploadSession != nil && t.DstStorage != nil {
+		op.AbortUploadSession(context.WithoutCancel(t.Ctx()), t.DstStorage, t.UploadSession)
+	}
+	t.finishReplaced(true)
 	task_group.TransferCoordinator.Done(context.WithoutCancel(t.Ctx()), t.groupID, false)
 }
 
@@ -100,7 +120,18 @@ func (t *FileTransferTask) SetRetry(retry int, maxRetry int) {
 	}
 }
 
-func transfer(ctx context.Context, taskType taskType, srcObjPath, dstDirPath string, skipHook ...bool) (task.TaskExtensionInfo, error) {
+func addTransferTask(t *FileTransferTask) {
+	switch t.TaskType {
+	case copy, merge:
+		CopyTaskManager.Add(t)
+	case synchronize:
+		SyncTaskManager.Add(t)
+	default:
+		MoveTaskManager.Add(t)
+	}
+}
+
+func transfer(ctx context.Context, taskType taskType, srcObjPath, dstDirPath string, deleteExtras bool, skipHook ...bool) (task.TaskExtensionInfo, error) {
 	srcStorage, srcObjActualPath, err := op.GetStorageAndActualPath(srcObjPath)
 	if err != nil {
 		return nil, errors.WithMessage(err, "failed get src storage")
@@ -110,7 +141,8 @@ func transfer(ctx context.Context, taskType taskType, srcObjPath, dstDirPath str
 		return nil, errors.WithMessage(err, "failed get dst storage")
 	}
 
-	if srcStorage.GetStorage() == dstStorage.GetStorage() {
+	// sync has to compare every object, so it never takes the shortcut of the driver
+	if srcStorage.GetStorage() == dstStorage.GetStorage() && taskType != synchronize {
 		if utils.IsBool(skipHook...) {
 			ctx = context.WithValue(ctx, conf.SkipHookKey, struct{}{})
 		}
@@ -137,11 +169,23 @@ func transfer(ctx context.Context, taskType taskType, srcObjPath, dstDirPath str
 			SrcStorageMp:  srcStorage.GetStorage().MountPath,
 			DstStorageMp:  dstStorage.GetStorage().MountPath,
 		},
-		TaskType: taskType,
+		TaskType:     taskType,
+		DeleteExtras: deleteExtras,
+		Resumable:    ctx.Value(conf.ResumableUploadKey) != nil,
+	}
+	t.ConflictPolicy, _ = ctx.Value(conf.ConflictPolicyKey).(model.ConflictPolicy)
+	if taskType != synchronize && t.conflictPolicy() != model.ConflictDefault {
+		skip, err := t.resolveRootConflict(ctx)
+		if err != nil || skip {
+			return nil, err
+		}
 	}
 
 	t.groupID = stdpath.Join(t.DstStorageMp, t.DstActualPath)
 	task_group.TransferCoordinator.AddTask(t.groupID, nil)
+	if taskType == move && t.DstName != "" {
+		task_group.TransferCoordinator.AppendPayload(t.groupID, task_group.SrcPathRenamed{SrcPath: srcObjPath, DstName: t.DstName})
+	}
 	if ctx.Value(conf.NoTaskKey) != nil {
 		var callback func(nextTask *FileTransferTask) error
 		hasSuccess := false
@@ -151,6 +195,7 @@ func transfer(ctx context.Context, taskType taskType, srcObjPath, dstDirPath str
 			if err == nil {
 				hasSuccess = true
 			}
+			nextTask.finishReplaced(err != nil)
 			return err
 		}
 		t.Base.SetCtx(ctx)
@@ -158,6 +203,7 @@ func transfer(ctx context.Context, taskType taskType, srcObjPath, dstDirPath str
 		if err == nil {
 			hasSuccess = true
 		}
+		t.finishReplaced(err != nil)
 		if taskType == move {
 			task_group.TransferCoordinator.AppendPayload(t.groupID, task_group.SrcPathToRemove(srcObjPath))
 		}
@@ -167,12 +213,10 @@ func transfer(ctx context.Context, taskType taskType, srcObjPath, dstDirPath str
 
 	t.Creator, _ = ctx.Value(conf.UserKey).(*model.User)
 	t.ApiUrl = common.GetApiUrl(ctx)
-	if taskType == copy || taskType == merge {
-		CopyTaskManager.Add(t)
-	} else {
+	if taskType == move {
 		task_group.TransferCoordinator.AppendPayload(t.groupID, task_group.SrcPathToRemove(srcObjPath))
-		MoveTaskManager.Add(t)
 	}
+	addTransferTask(t)
 	return t, nil
 }
 
@@ -189,39 +233,66 @@ func (t *FileTransferTask) RunWithNextTaskCallback(f func(nextTask *FileTransfer
 		if err != nil {
 			return errors.WithMessagef(err, "failed list src [%s] objs", t.SrcActualPath)
 		}
-		dstActualPath := stdpath.Join(t.DstActualPath, srcObj.GetName())
+		dstActualPath := stdpath.Join(t.DstActualPath, t.dstName(srcObj))
 		task_group.TransferCoordinator.AppendPayload(t.groupID, task_group.DstPathToHook(dstActualPath))
 
-		existedObjs := make(map[string]bool)
-		if t.TaskType == merge {
-			dstObjs, err := op.List(t.Ctx(), t.DstStorage, dstActualPath, model.ListArgs{})
+		var dstObjs map[string]model.Obj
+		if t.TaskType == synchronize {
+			t.Status = "src object is dir, comparing with dst objs"
+			if dstObjs, err = t.syncDir(dstActualPath, objs); err != nil {
+				return err
+			}
+		} else if t.conflictPolicy() != model.ConflictDefault {
+			t.Status = "src object is dir, listing dst objs"
+			dstList, err := op.List(t.Ctx(), t.DstStorage, dstActualPath, model.ListArgs{})
 			if err != nil && !errors.Is(err, errs.ObjectNotFound) {
 				// 目标文件夹不存在的情况不是错误，会在之后新建文件夹
-				// 这种情况显然不需要统计existedObjs，dstObjs保持为nil，下面这个for将不会执行
 				return errors.WithMessagef(err, "failed list dst [%s] objs", dstActualPath)
 			}
-			for _, obj := range dstObjs {
-				if err := t.Ctx().Err(); err != nil {
-					return err
-				}
-				if !obj.IsDir() {
-					existedObjs[obj.GetName()] = true
-				}
+			dstObjs = make(map[string]model.Obj, len(dstList))
+			for _, obj := range dstList {
+				dstObjs[obj.GetName()] = obj
 			}
 		}
 
+		user, _ := t.Ctx().Value(conf.UserKey).(*model.User)
 		for _, obj := range objs {
 			if err := t.Ctx().Err(); err != nil {
 				return err
 			}
+			// what the user can't read isn't copied, and a move can't leave it to be removed with src
+			if err := op.CheckAcl(user, model.AclRead, stdpath.Join(t.SrcStorageMp, t.SrcActualPath, obj.GetName())); err != nil {
+				if t.TaskType == move {
+					return err
+				}
+				continue
+			}
 
-			if t.TaskType == merge && !obj.IsDir() && existedObjs[obj.GetName()] {
-				// skip existed file
+			if dstObj, ok := dstObjs[obj.GetName()]; ok && t.TaskType == synchronize && !obj.IsDir() && isSynced(obj, dstObj) {
+				// skip unchanged file
 				continue
 			}
+			dstName, replaced := obj.GetName(), ""
+			if t.TaskType != synchronize && t.conflictPolicy() != model.ConflictDefault {
+				var skip bool
+				if dstName, replaced, skip, err = t.resolveConflict(obj, dstObjs, dstActualPath); err != nil {
+					return err
+				} else if skip {
+					continue
+				}
+			}
+			renamed := ""
+			if dstName != obj.GetName() {
+				renamed = dstName
+			}
 
 			err = f(&FileTransferTask{
-				TaskType: t.TaskType,
+				TaskType:       t.TaskType,
+				DeleteExtras:   t.DeleteExtras,
+				ConflictPolicy: t.ConflictPolicy,
+				Resumable:      t.Resumable,
+				DstName:        renamed,
+				Replaced:       replaced,
 				TaskData: TaskData{
 					TaskExtension: task.TaskExtension{
 						Creator: t.Creator,
@@ -244,11 +315,22 @@ func (t *FileTransferTask) RunWithNextTaskCallback(f func(nextTask *FileTransfer
 		return nil
 	}
 
+	if t.TaskType == synchronize {
+		dstObj, err := op.Get(t.Ctx(), t.DstStorage, stdpath.Join(t.DstActualPath, t.dstName(srcObj)))
+		if err == nil && isSynced(srcObj, dstObj) {
+			t.Status = "dst object is up to date"
+			return nil
+		}
+	}
+
 	t.Status = "getting src object link"
 	link, srcObj, err := op.Link(t.Ctx(), t.SrcStorage, t.SrcActualPath, model.LinkArgs{})
 	if err != nil {
 		return errors.WithMessagef(err, "failed get [%s] link", t.SrcActualPath)
 	}
+	if t.DstName != "" {
+		srcObj = &model.ObjWrapName{Name: t.DstName, Obj: srcObj}
+	}
 	// any link provided is seekable
 	ss, err := stream.NewSeekableStream(&stream.FileStream{
 		Obj: srcObj,
@@ -260,10 +342,121 @@ func (t *FileTransferTask) RunWithNextTaskCallback(f func(nextTask *FileTransfer
 	}
 	t.SetTotalBytes(ss.GetSize())
 	t.Status = "uploading"
-	return op.Put(context.WithValue(t.Ctx(), conf.SkipHookKey, struct{}{}), t.DstStorage, t.DstActualPath, ss, t.SetProgress)
+	ctx := context.WithValue(t.Ctx(), conf.SkipHookKey, struct{}{})
+	if t.Resumable || t.UploadSession != nil {
+		if t.UploadSession == nil {
+			t.UploadSession = &model.UploadSession{}
+		}
+		t.UploadSession.Save = t.Persist
+		ctx = context.WithValue(ctx, conf.UploadSessionKey, t.UploadSession)
+	}
+	err = op.Put(ctx, t.DstStorage, t.DstActualPath, ss, t.SetProgress)
+	if err != nil || !setting.GetBool(conf.TaskTransferVerify) {
+		return err
+	}
+	t.Status = "verifying"
+	return t.verify(srcObj)
+}
+
+func (t *FileTransferTask) dstName(srcObj model.Obj) string {
+	if t.DstName != "" {
+		return t.DstName
+	}
+	return srcObj.GetName()
+}
+
+// conflictPolicy returns the policy applied by the task, merge is a copy that skips existing files
+func (t *FileTransferTask) conflictPolicy() model.ConflictPolicy {
+	if t.TaskType == merge && t.ConflictPolicy == model.ConflictDefault {
+		return model.ConflictSkip
+	}
+	return t.ConflictPolicy
+}
+
+// resolveRootConflict applies the conflict policy to the src object of the task,
+// the ones of its children are resolved when the task runs
+func (t *FileTransferTask) resolveRootConflict(ctx context.Context) (bool, error) {
+	srcObj, err := op.Get(ctx, t.SrcStorage, t.SrcActualPath)
+	if err != nil {
+		return false, errors.WithMessagef(err, "failed get src [%s] file", t.SrcActualPath)
+	}
+	dstPath := stdpath.Join(t.DstActualPath, srcObj.GetName())
+	dstObj, err := op.Get(ctx, t.DstStorage, dstPath)
+	if err != nil {
+		if errs.IsObjectNotFound(err) {
+			return false, nil
+		}
+		return false, errors.WithMessagef(err, "failed get dst [%s] file", dstPath)
+	}
+	action, name, err := op.ResolveConflict(srcObj, dstObj, t.conflictPolicy(), func(name string) bool {
+		_, err := op.Get(ctx, t.DstStorage, stdpath.Join(t.DstActualPath, name))
+		return err == nil
+	})
+	if err != nil {
+		return false, err
+	}
+	switch action {
+	case op.ConflictSkip:
+		return true, nil
+	case op.ConflictReplace:
+		t.Replaced, err = op.BackupReplaced(ctx, t.DstStorage, dstPath)
+		return false, err
+	case op.ConflictRenameTo:
+		t.DstName = name
+	}
+	return false, nil
+}
+
+// resolveConflict applies the conflict policy to a child of the src dir, dstObjs are
+// the objects in the dst dir. It returns the dst name of the child and the backup of
+// the dst object it replaces if any, or whether it's skipped
+func (t *FileTransferTask) resolveConflict(obj model.Obj, dstObjs map[string]model.Obj, dstDirPath string) (string, string, bool, error) {
+	action, name, err := op.ResolveConflict(obj, dstObjs[obj.GetName()], t.conflictPolicy(), func(name string) bool {
+		_, ok := dstObjs[name]
+		return ok
+	})
+	if err != nil {
+		return "", "", false, err
+	}
+	replaced := ""
+	srcPath := stdpath.Join(t.SrcStorageMp, t.SrcActualPath, obj.GetName())
+	switch action {
+	case op.ConflictSkip:
+		if t.TaskType == move {
+			// the skipped object must stay in src
+			task_group.TransferCoordinator.AppendPayload(t.groupID, task_group.SrcPathToKeep(srcPath))
+		}
+		return "", "", true, nil
+	case op.ConflictReplace:
+		dstPath := stdpath.Join(dstDirPath, name)
+		if replaced, err = op.BackupReplaced(t.Ctx(), t.DstStorage, dstPath); err != nil {
+			return "", "", false, errors.WithMessagef(err, "failed replace dst [%s]", dstPath)
+		}
+	case op.ConflictRenameTo:
+		// reserve the name for the next objects
+		dstObjs[name] = obj
+		if t.TaskType == move {
+			task_group.TransferCoordinator.AppendPayload(t.groupID, task_group.SrcPathRenamed{SrcPath: srcPath, DstName: name})
+		}
+	}
+	return name, replaced, false, nil
+}
+
+// finishReplaced drops the dst object overwritten by the task, or restores it if the task failed
+func (t *FileTransferTask) finishReplaced(restore bool) {
+	if t.Replaced == "" || t.DstStorage == nil {
+		return
+	}
+	name := t.DstName
+	if name == "" {
+		name = stdpath.Base(t.SrcActualPath)
+	}
+	op.FinishReplaced(context.WithoutCancel(t.Ctx()), t.DstStorage, t.Replaced, stdpath.Join(t.DstActualPath, name), restore)
+	t.Replaced = ""
 }
 
 var (
 	CopyTaskManager *tache.Manager[*FileTransferTask]
 	MoveTaskManager *tache.Manager[*FileTransferTask]
+	SyncTaskManager *tache.Manager[*FileTransferTask]
 )
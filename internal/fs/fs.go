@@ -3,9 +3,11 @@ package fs
 import (
 	"context"
 	"io"
+	stdpath "path"
 
 	log "github.com/sirupsen/logrus"
 
+	"github.com/OpenListTeam/OpenList/v4/internal/audit"
 	"github.com/OpenListTeam/OpenList/v4/internal/driver"
 	"github.com/OpenListTeam/OpenList/v4/internal/errs"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
@@ -25,6 +27,9 @@ type ListArgs struct {
 }
 
 func List(ctx context.Context, path string, args *ListArgs) ([]model.Obj, error) {
+	if err := CheckAcl(ctx, model.AclList, path); err != nil {
+		return nil, err
+	}
 	res, err := list(ctx, path, args)
 	if err != nil {
 		if !args.NoLog {
@@ -32,7 +37,60 @@ func List(ctx context.Context, path string, args *ListArgs) ([]model.Obj, error)
 		}
 		return nil, err
 	}
-	return res, nil
+	return filterAcl(ctx, path, res), nil
+}
+
+type ListPageArgs struct {
+	ListArgs
+	// Cursor is the Next of the previous page, empty for the first page
+	Cursor string
+	Limit  int
+}
+
+// ListPage list one page of files, see op.ListPage
+func ListPage(ctx context.Context, path string, args *ListPageArgs) (*model.ObjPage, error) {
+	if err := CheckAcl(ctx, model.AclList, path); err != nil {
+		return nil, err
+	}
+	res, err := listPage(ctx, path, args)
+	if err != nil {
+		if !args.NoLog {
+			log.Errorf("failed list page of %s: %+v", path, err)
+		}
+		return nil, err
+	}
+	return &model.ObjPage{Objs: filterAcl(ctx, path, res.Objs), Next: res.Next}, nil
+}
+
+// WalkPages list the files page by page, and call fn for each page until
+// the last one or an error is returned by fn
+func WalkPages(ctx context.Context, path string, args *ListPageArgs, fn func(objs []model.Obj) error) error {
+	a := *args
+	for {
+		page, err := ListPage(ctx, path, &a)
+		if err != nil {
+			return err
+		}
+		if err = fn(page.Objs); err != nil {
+			return err
+		}
+		if page.Next == "" {
+			return nil
+		}
+		a.Cursor = page.Next
+		a.Refresh = false
+	}
+}
+
+// IsPagedList reports whether the storage of the path can be listed page by page
+// without listing the whole directory first
+func IsPagedList(path string) bool {
+	storage, _, err := op.GetStorageAndActualPath(path)
+	if err != nil {
+		return false
+	}
+	_, ok := storage.(driver.PagedLister)
+	return ok
 }
 
 type GetArgs struct {
@@ -41,6 +99,9 @@ type GetArgs struct {
 }
 
 func Get(ctx context.Context, path string, args *GetArgs) (model.Obj, error) {
+	if err := CheckAcl(ctx, model.AclList, path); err != nil {
+		return nil, err
+	}
 	res, err := get(ctx, path, args)
 	if err != nil {
 		if !args.NoLog {
@@ -51,7 +112,11 @@ func Get(ctx context.Context, path string, args *GetArgs) (model.Obj, error) {
 	return res, nil
 }
 
-func Link(ctx context.Context, path string, args model.LinkArgs) (*model.Link, model.Obj, error) {
+func Link(ctx context.Context, path string, args model.LinkArgs) (_ *model.Link, _ model.Obj, err error) {
+	defer func() { audit.Log(ctx, model.AuditLink, err, path) }()
+	if err := CheckAcl(ctx, model.AclRead, path); err != nil {
+		return nil, nil, err
+	}
 	res, file, err := link(ctx, path, args)
 	if err != nil {
 		log.Errorf("failed link %s: %+v", path, err)
@@ -60,63 +125,129 @@ func Link(ctx context.Context, path string, args model.LinkArgs) (*model.Link, m
 	return res, file, nil
 }
 
-func MakeDir(ctx context.Context, path string) error {
-	err := makeDir(ctx, path)
+func MakeDir(ctx context.Context, path string) (err error) {
+	defer func() { audit.Log(ctx, model.AuditMakeDir, err, path) }()
+	if err := CheckAcl(ctx, model.AclWrite, path); err != nil {
+		return err
+	}
+	err = makeDir(ctx, path)
 	if err != nil {
 		log.Errorf("failed make dir %s: %+v", path, err)
 	}
 	return err
 }
 
-func Move(ctx context.Context, srcPath, dstDirPath string, skipHook ...bool) (task.TaskExtensionInfo, error) {
-	req, err := transfer(ctx, move, srcPath, dstDirPath, skipHook...)
+func Move(ctx context.Context, srcPath, dstDirPath string, skipHook ...bool) (_ task.TaskExtensionInfo, err error) {
+	defer func() { audit.Log(ctx, model.AuditMove, err, srcPath, dstDirPath) }()
+	if err := checkTransferAcl(ctx, model.AclMove, srcPath, dstDirPath); err != nil {
+		return nil, err
+	}
+	req, err := transfer(ctx, move, srcPath, dstDirPath, false, skipHook...)
 	if err != nil {
 		log.Errorf("failed move %s to %s: %+v", srcPath, dstDirPath, err)
 	}
 	return req, err
 }
 
-func Copy(ctx context.Context, srcObjPath, dstDirPath string, skipHook ...bool) (task.TaskExtensionInfo, error) {
-	res, err := transfer(ctx, copy, srcObjPath, dstDirPath, skipHook...)
+func Copy(ctx context.Context, srcObjPath, dstDirPath string, skipHook ...bool) (_ task.TaskExtensionInfo, err error) {
+	defer func() { audit.Log(ctx, model.AuditCopy, err, srcObjPath, dstDirPath) }()
+	if err := checkTransferAcl(ctx, model.AclCopy, srcObjPath, dstDirPath); err != nil {
+		return nil, err
+	}
+	res, err := transfer(ctx, copy, srcObjPath, dstDirPath, false, skipHook...)
 	if err != nil {
 		log.Errorf("failed copy %s to %s: %+v", srcObjPath, dstDirPath, err)
 	}
 	return res, err
 }
 
-func Merge(ctx context.Context, srcObjPath, dstDirPath string, skipHook ...bool) (task.TaskExtensionInfo, error) {
-	res, err := transfer(ctx, merge, srcObjPath, dstDirPath, skipHook...)
+func Merge(ctx context.Context, srcObjPath, dstDirPath string, skipHook ...bool) (_ task.TaskExtensionInfo, err error) {
+	defer func() { audit.Log(ctx, model.AuditCopy, err, srcObjPath, dstDirPath) }()
+	if err := checkTransferAcl(ctx, model.AclCopy, srcObjPath, dstDirPath); err != nil {
+		return nil, err
+	}
+	res, err := transfer(ctx, merge, srcObjPath, dstDirPath, false, skipHook...)
 	if err != nil {
 		log.Errorf("failed merge %s to %s: %+v", srcObjPath, dstDirPath, err)
 	}
 	return res, err
 }
 
-func Rename(ctx context.Context, srcPath, dstName string, skipHook ...bool) error {
-	err := rename(ctx, srcPath, dstName, skipHook...)
+// Sync makes dstDirPath/name of srcObjPath the same as srcObjPath, files that are
+// unchanged are skipped, and with deleteExtras the dst objects missing in src are removed
+func Sync(ctx context.Context, srcObjPath, dstDirPath string, deleteExtras bool, skipHook ...bool) (_ task.TaskExtensionInfo, err error) {
+	defer func() { audit.Log(ctx, model.AuditCopy, err, srcObjPath, dstDirPath) }()
+	if err := checkTransferAcl(ctx, model.AclCopy, srcObjPath, dstDirPath); err != nil {
+		return nil, err
+	}
+	if deleteExtras {
+		if err := checkAclTree(ctx, model.AclDelete, stdpath.Join(dstDirPath, stdpath.Base(srcObjPath))); err != nil {
+			return nil, err
+		}
+	}
+	res, err := transfer(ctx, synchronize, srcObjPath, dstDirPath, deleteExtras, skipHook...)
+	if err != nil {
+		log.Errorf("failed sync %s to %s: %+v", srcObjPath, dstDirPath, err)
+	}
+	return res, err
+}
+
+// PlanSync returns what Sync would do without changing anything
+func PlanSync(ctx context.Context, srcObjPath, dstDirPath string, deleteExtras bool) ([]SyncAction, error) {
+	if err := CheckAcl(ctx, model.AclList, srcObjPath, dstDirPath); err != nil {
+		return nil, err
+	}
+	res, err := planSync(ctx, srcObjPath, dstDirPath, deleteExtras)
+	if err != nil {
+		log.Errorf("failed plan sync %s to %s: %+v", srcObjPath, dstDirPath, err)
+	}
+	return res, err
+}
+
+func Rename(ctx context.Context, srcPath, dstName string, skipHook ...bool) (err error) {
+	defer func() { audit.Log(ctx, model.AuditRename, err, srcPath, stdpath.Join(stdpath.Dir(srcPath), dstName)) }()
+	if err := checkAclTree(ctx, model.AclRename, srcPath); err != nil {
+		return err
+	}
+	if err := CheckAcl(ctx, model.AclWrite, stdpath.Join(stdpath.Dir(srcPath), dstName)); err != nil {
+		return err
+	}
+	err = rename(ctx, srcPath, dstName, skipHook...)
 	if err != nil {
 		log.Errorf("failed rename %s to %s: %+v", srcPath, dstName, err)
 	}
 	return err
 }
 
-func Remove(ctx context.Context, path string) error {
-	err := remove(ctx, path)
+func Remove(ctx context.Context, path string) (err error) {
+	defer func() { audit.Log(ctx, model.AuditRemove, err, path) }()
+	if err := checkAclTree(ctx, model.AclDelete, path); err != nil {
+		return err
+	}
+	err = remove(ctx, path)
 	if err != nil {
 		log.Errorf("failed remove %s: %+v", path, err)
 	}
 	return err
 }
 
-func PutDirectly(ctx context.Context, dstDirPath string, file model.FileStreamer, skipHook ...bool) error {
-	err := putDirectly(ctx, dstDirPath, file, skipHook...)
+func PutDirectly(ctx context.Context, dstDirPath string, file model.FileStreamer, skipHook ...bool) (err error) {
+	defer func() { audit.Log(ctx, model.AuditUpload, err, stdpath.Join(dstDirPath, file.GetName())) }()
+	if err := CheckAcl(ctx, model.AclWrite, stdpath.Join(dstDirPath, file.GetName())); err != nil {
+		return err
+	}
+	err = putDirectly(ctx, dstDirPath, file, skipHook...)
 	if err != nil {
 		log.Errorf("failed put %s: %+v", dstDirPath, err)
 	}
 	return err
 }
 
-func PutAsTask(ctx context.Context, dstDirPath string, file model.FileStreamer) (task.TaskExtensionInfo, error) {
+func PutAsTask(ctx context.Context, dstDirPath string, file model.FileStreamer) (_ task.TaskExtensionInfo, err error) {
+	defer func() { audit.Log(ctx, model.AuditUpload, err, stdpath.Join(dstDirPath, file.GetName())) }()
+	if err := CheckAcl(ctx, model.AclWrite, stdpath.Join(dstDirPath, file.GetName())); err != nil {
+		return nil, err
+	}
 	t, err := putAsTask(ctx, dstDirPath, file)
 	if err != nil {
 		log.Errorf("failed put %s: %+v", dstDirPath, err)
@@ -125,6 +256,9 @@ func PutAsTask(ctx context.Context, dstDirPath string, file model.FileStreamer)
 }
 
 func ArchiveMeta(ctx context.Context, path string, args model.ArchiveMetaArgs) (*model.ArchiveMetaProvider, error) {
+	if err := CheckAcl(ctx, model.AclArchive, path); err != nil {
+		return nil, err
+	}
 	meta, err := archiveMeta(ctx, path, args)
 	if err != nil {
 		log.Errorf("failed get archive meta %s: %+v", path, err)
@@ -133,6 +267,9 @@ func ArchiveMeta(ctx context.Context, path string, args model.ArchiveMetaArgs) (
 }
 
 func ArchiveList(ctx context.Context, path string, args model.ArchiveListArgs) ([]model.Obj, error) {
+	if err := CheckAcl(ctx, model.AclArchive, path); err != nil {
+		return nil, err
+	}
 	objs, err := archiveList(ctx, path, args)
 	if err != nil {
 		log.Errorf("failed list archive [%s]%s: %+v", path, args.InnerPath, err)
@@ -141,6 +278,12 @@ func ArchiveList(ctx context.Context, path string, args model.ArchiveListArgs) (
 }
 
 func ArchiveDecompress(ctx context.Context, srcObjPath, dstDirPath string, args model.ArchiveDecompressArgs, lazyCache ...bool) (task.TaskExtensionInfo, error) {
+	if err := CheckAcl(ctx, model.AclArchive, srcObjPath); err != nil {
+		return nil, err
+	}
+	if err := CheckAcl(ctx, model.AclWrite, dstDirPath); err != nil {
+		return nil, err
+	}
 	t, err := archiveDecompress(ctx, srcObjPath, dstDirPath, args, lazyCache...)
 	if err != nil {
 		log.Errorf("failed decompress [%s]%s: %+v", srcObjPath, args.InnerPath, err)
@@ -149,6 +292,9 @@ func ArchiveDecompress(ctx context.Context, srcObjPath, dstDirPath string, args
 }
 
 func ArchiveDriverExtract(ctx context.Context, path string, args model.ArchiveInnerArgs) (*model.Link, model.Obj, error) {
+	if err := CheckAcl(ctx, model.AclArchive, path); err != nil {
+		return nil, nil, err
+	}
 	l, obj, err := archiveDriverExtract(ctx, path, args)
 	if err != nil {
 		log.Errorf("failed extract [%s]%s: %+v", path, args.InnerPath, err)
@@ -157,6 +303,9 @@ func ArchiveDriverExtract(ctx context.Context, path string, args model.ArchiveIn
 }
 
 func ArchiveInternalExtract(ctx context.Context, path string, args model.ArchiveInnerArgs) (io.ReadCloser, int64, error) {
+	if err := CheckAcl(ctx, model.AclArchive, path); err != nil {
+		return nil, 0, err
+	}
 	l, obj, err := archiveInternalExtract(ctx, path, args)
 	if err != nil {
 		log.Errorf("failed extract [%s]%s: %+v", path, args.InnerPath, err)
@@ -176,6 +325,9 @@ func GetStorage(path string, args *GetStoragesArgs) (driver.Driver, error) {
 }
 
 func Other(ctx context.Context, args model.FsOtherArgs) (interface{}, error) {
+	if err := CheckAcl(ctx, model.AclRead, args.Path); err != nil {
+		return nil, err
+	}
 	res, err := other(ctx, args)
 	if err != nil {
 		log.Errorf("failed get other %s: %+v", args.Path, err)
@@ -183,7 +335,11 @@ func Other(ctx context.Context, args model.FsOtherArgs) (interface{}, error) {
 	return res, err
 }
 
-func PutURL(ctx context.Context, path, dstName, urlStr string) error {
+func PutURL(ctx context.Context, path, dstName, urlStr string) (err error) {
+	defer func() { audit.LogDetail(ctx, model.AuditUpload, urlStr, err, stdpath.Join(path, dstName)) }()
+	if err := CheckAcl(ctx, model.AclWrite, stdpath.Join(path, dstName)); err != nil {
+		return err
+	}
 	storage, dstDirActualPath, err := op.GetStorageAndActualPath(path)
 	if err != nil {
 		return errors.WithMessage(err, "failed get storage")
@@ -200,6 +356,9 @@ func PutURL(ctx context.Context, path, dstName, urlStr string) error {
 }
 
 func GetDirectUploadInfo(ctx context.Context, tool, path, dstName string, fileSize int64) (any, error) {
+	if err := CheckAcl(ctx, model.AclWrite, stdpath.Join(path, dstName)); err != nil {
+		return nil, err
+	}
 	info, err := getDirectUploadInfo(ctx, tool, path, dstName, fileSize)
 	if err != nil {
 		log.Errorf("failed get %s direct upload info for %s(%d bytes): %+v", path, dstName, fileSize, err)
@@ -4,6 +4,8 @@ import (
 	"context"
 	stdpath "path"
 
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/internal/op"
 	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
@@ -12,6 +14,13 @@ import (
 
 func get(ctx context.Context, path string, args *GetArgs) (model.Obj, error) {
 	path = utils.FixAndCleanPath(path)
+	user, _ := ctx.Value(conf.UserKey).(*model.User)
+	if isSharedDir(user, path) {
+		return sharedDirObj(), nil
+	}
+	if isGroupRootsDir(user, path) {
+		return groupRootsDirObj(), nil
+	}
 	// maybe a virtual file
 	if path != "/" {
 		dir, name := stdpath.Split(path)
@@ -34,5 +43,8 @@ func get(ctx context.Context, path string, args *GetArgs) (model.Obj, error) {
 		}
 		return nil, errors.WithMessage(err, "failed get storage")
 	}
+	if isHiddenTrash(storage, actualPath) {
+		return nil, errors.WithStack(errs.ObjectNotFound)
+	}
 	return op.Get(ctx, storage, actualPath)
 }
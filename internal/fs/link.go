@@ -4,6 +4,7 @@ import (
 	"context"
 	"strings"
 
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/internal/op"
 	"github.com/OpenListTeam/OpenList/v4/server/common"
@@ -15,6 +16,9 @@ func link(ctx context.Context, path string, args model.LinkArgs) (*model.Link, m
 	if err != nil {
 		return nil, nil, errors.WithMessage(err, "failed get storage")
 	}
+	if isHiddenTrash(storage, actualPath) {
+		return nil, nil, errors.WithStack(errs.ObjectNotFound)
+	}
 	l, obj, err := op.Link(ctx, storage, actualPath, args)
 	if err != nil {
 		return nil, nil, errors.WithMessage(err, "failed link")
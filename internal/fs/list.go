@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/internal/op"
 	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
@@ -15,12 +16,23 @@ import (
 func list(ctx context.Context, path string, args *ListArgs) ([]model.Obj, error) {
 	meta, _ := ctx.Value(conf.MetaKey).(*model.Meta)
 	user, _ := ctx.Value(conf.UserKey).(*model.User)
+	if isSharedDir(user, path) {
+		return sharedFiles(ctx, user), nil
+	}
+	if isGroupRootsDir(user, path) {
+		return groupRootFiles(ctx, user), nil
+	}
 	virtualFiles := op.GetStorageVirtualFilesWithDetailsByPath(ctx, path, !args.WithStorageDetails, args.Refresh, "")
+	virtualFiles = append(virtualFiles, rootPathFiles(user, path)...)
 	storage, actualPath, err := op.GetStorageAndActualPath(path)
 	if err != nil && len(virtualFiles) == 0 {
 		return nil, errors.WithMessage(err, "failed get storage")
 	}
 
+	if isHiddenTrash(storage, actualPath) {
+		return nil, errors.WithStack(errs.ObjectNotFound)
+	}
+
 	var _objs []model.Obj
 	if storage != nil {
 		_objs, err = op.List(ctx, storage, actualPath, model.ListArgs{
@@ -36,6 +48,7 @@ func list(ctx context.Context, path string, args *ListArgs) ([]model.Obj, error)
 				return nil, errors.WithMessage(err, "failed get objs")
 			}
 		}
+		_objs = filterTransfers(filterTrash(storage, actualPath, _objs))
 	}
 
 	om := model.NewObjMerge()
@@ -46,6 +59,132 @@ func list(ctx context.Context, path string, args *ListArgs) ([]model.Obj, error)
 	return objs, nil
 }
 
+// List one page of files
+func listPage(ctx context.Context, path string, args *ListPageArgs) (*model.ObjPage, error) {
+	meta, _ := ctx.Value(conf.MetaKey).(*model.Meta)
+	user, _ := ctx.Value(conf.UserKey).(*model.User)
+	if isSharedDir(user, path) {
+		return &model.ObjPage{Objs: sharedFiles(ctx, user)}, nil
+	}
+	if isGroupRootsDir(user, path) {
+		return &model.ObjPage{Objs: groupRootFiles(ctx, user)}, nil
+	}
+	virtualFiles := op.GetStorageVirtualFilesWithDetailsByPath(ctx, path, !args.WithStorageDetails, args.Refresh, "")
+	virtualFiles = append(virtualFiles, rootPathFiles(user, path)...)
+	storage, actualPath, err := op.GetStorageAndActualPath(path)
+	if err != nil && len(virtualFiles) == 0 {
+		return nil, errors.WithMessage(err, "failed get storage")
+	}
+
+	if isHiddenTrash(storage, actualPath) {
+		return nil, errors.WithStack(errs.ObjectNotFound)
+	}
+
+	page := &model.ObjPage{}
+	if storage != nil {
+		page, err = op.ListPage(ctx, storage, actualPath, model.ListPageArgs{
+			ListArgs: model.ListArgs{
+				ReqPath:            path,
+				Refresh:            args.Refresh,
+				WithStorageDetails: args.WithStorageDetails,
+			},
+			Cursor: args.Cursor,
+			Limit:  args.Limit,
+		})
+		if err != nil {
+			if !args.NoLog {
+				log.Errorf("fs/list: %+v", err)
+			}
+			if len(virtualFiles) == 0 {
+				return nil, errors.WithMessage(err, "failed get objs")
+			}
+			page = &model.ObjPage{}
+		}
+		page = &model.ObjPage{Objs: filterTransfers(filterTrash(storage, actualPath, page.Objs)), Next: page.Next}
+	}
+
+	om := model.NewObjMerge()
+	if whetherHide(user, meta, path) {
+		om.InitHideReg(meta.Hide)
+	}
+	if args.Cursor == "" {
+		return &model.ObjPage{Objs: om.Merge(page.Objs, virtualFiles...), Next: page.Next}, nil
+	}
+	// virtual files are returned with the first page, skip the objs shadowed by them
+	om.Merge(nil, virtualFiles...)
+	return &model.ObjPage{Objs: om.Merge(page.Objs), Next: page.Next}, nil
+}
+
+// rootPathFiles returns the folders of the root paths granted to the user by groups
+// and of the internal shares to the user, which are shown in the base path
+func rootPathFiles(user *model.User, path string) []model.Obj {
+	if user == nil || !utils.PathEqual(user.BasePath, path) {
+		return nil
+	}
+	var files []model.Obj
+	if len(user.GroupRoots()) > 0 {
+		files = append(files, groupRootsDirObj())
+	}
+	if len(user.InternalShares()) > 0 {
+		files = append(files, sharedDirObj())
+	}
+	return files
+}
+
+func sharedDirObj() model.Obj {
+	return &model.Object{
+		Name:     model.SharedWithMeDir,
+		IsFolder: true,
+		Mask:     model.ReadOnly | model.Virtual,
+	}
+}
+
+func groupRootsDirObj() model.Obj {
+	return &model.Object{
+		Name:     model.GroupRootsDir,
+		IsFolder: true,
+		Mask:     model.ReadOnly | model.Virtual,
+	}
+}
+
+// isGroupRootsDir reports whether path is the folder of the root paths granted to the user
+func isGroupRootsDir(user *model.User, path string) bool {
+	return user != nil && len(user.GroupRoots()) > 0 && utils.PathEqual(user.GroupRootsPath(), path)
+}
+
+// groupRootFiles returns the root paths granted to the user by their names, the ones failed to get are skipped
+func groupRootFiles(ctx context.Context, user *model.User) []model.Obj {
+	var files []model.Obj
+	for _, r := range user.GroupRoots() {
+		obj, err := get(ctx, r.Path, &GetArgs{})
+		if err != nil {
+			log.Debugf("skip group root %s: %+v", r.Path, err)
+			continue
+		}
+		files = append(files, &model.ObjWrapName{Name: r.Name, Obj: obj})
+	}
+	return files
+}
+
+// isSharedDir reports whether path is the folder of the internal shares to the user
+func isSharedDir(user *model.User, path string) bool {
+	return user != nil && len(user.InternalShares()) > 0 && utils.PathEqual(user.SharedDir(), path)
+}
+
+// sharedFiles returns the internal shares to the user by their names, the ones failed to get are skipped
+func sharedFiles(ctx context.Context, user *model.User) []model.Obj {
+	var files []model.Obj
+	for _, s := range user.InternalShares() {
+		obj, err := get(ctx, s.Path, &GetArgs{})
+		if err != nil {
+			log.Debugf("skip internal share %d: %+v", s.ID, err)
+			continue
+		}
+		files = append(files, &model.ObjWrapName{Name: s.Name, Obj: obj})
+	}
+	return files
+}
+
 func whetherHide(user *model.User, meta *model.Meta, path string) bool {
 	// if is admin, don't hide
 	if user == nil || user.CanSeeHides() {
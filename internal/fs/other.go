@@ -5,6 +5,7 @@ import (
 
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
 	"github.com/OpenListTeam/OpenList/v4/internal/driver"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/internal/op"
 	"github.com/OpenListTeam/OpenList/v4/internal/task"
@@ -36,6 +37,9 @@ func remove(ctx context.Context, path string) error {
 	if err != nil {
 		return errors.WithMessage(err, "failed get storage")
 	}
+	if isHiddenTrash(storage, actualPath) {
+		return errors.WithStack(errs.ObjectNotFound)
+	}
 	return op.Remove(ctx, storage, actualPath)
 }
 
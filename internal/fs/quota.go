@@ -0,0 +1,23 @@
+package fs
+
+import (
+	"context"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+)
+
+// CheckQuota checks whether the owner of path can store a file of size at path before it's
+// received, the file it replaces frees its size. A negative size means it's unknown
+func CheckQuota(ctx context.Context, path string, size int64) error {
+	files := int64(1)
+	if obj, err := Get(ctx, path, &GetArgs{NoLog: true}); err == nil && !obj.IsDir() {
+		files = 0
+		if size >= 0 {
+			size = max(size-obj.GetSize(), 0)
+		}
+	}
+	if size < 0 {
+		size = op.UnknownBytes
+	}
+	return op.CheckQuota(ctx, path, size, files)
+}
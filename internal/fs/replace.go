@@ -0,0 +1,128 @@
+package fs
+
+import (
+	"context"
+	stdpath "path"
+	"strings"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils/random"
+	log "github.com/sirupsen/logrus"
+)
+
+// the prefixes of the staging dirs and the renamed away destinations of TransferTo,
+// which aren't listed but are still reachable by their paths
+const (
+	stagePrefix    = ".openlist_stage_"
+	replacedPrefix = ".openlist_replaced_"
+)
+
+// filterTransfers removes the staging dirs and renamed away destinations of TransferTo from a listing
+func filterTransfers(objs []model.Obj) []model.Obj {
+	ret := make([]model.Obj, 0, len(objs))
+	for _, obj := range objs {
+		if name := obj.GetName(); !strings.HasPrefix(name, stagePrefix) && !strings.HasPrefix(name, replacedPrefix) {
+			ret = append(ret, obj)
+		}
+	}
+	return ret
+}
+
+// TransferTo moves or copies src to dst, which may have another name in another dir, without a task.
+// The object is moved or copied under its src name into a staging dir next to dst if the names
+// differ, and renamed there. An existing dst is only renamed away once the object is ready,
+// then removed after the object took its place, or restored if it couldn't. Nothing else is
+// overwritten, and a moved src is put back if the transfer fails
+func TransferTo(ctx context.Context, src, dst string, move bool) error {
+	ctx = context.WithValue(ctx, conf.ConflictPolicyKey, model.ConflictFail)
+	ctx = context.WithValue(ctx, conf.NoTaskKey, struct{}{})
+	srcName, dstDir, dstName := stdpath.Base(src), stdpath.Dir(dst), stdpath.Base(dst)
+	transfer := func(srcPath, dstDirPath string) (err error) {
+		if move {
+			_, err = Move(ctx, srcPath, dstDirPath)
+		} else {
+			_, err = Copy(ctx, srcPath, dstDirPath)
+		}
+		return err
+	}
+
+	switch {
+	case move && stdpath.Dir(src) == dstDir:
+		// renamed in place
+		return replaceDst(ctx, dst, func() error {
+			return Rename(ctx, src, dstName)
+		})
+	case srcName == dstName:
+		return replaceDst(ctx, dst, func() error {
+			err := transfer(src, dstDir)
+			if err != nil && !move {
+				// drop what was copied already
+				_ = Remove(ctx, dst)
+			}
+			return err
+		})
+	}
+
+	stage := stdpath.Join(dstDir, stagePrefix+random.String(8))
+	if err := MakeDir(ctx, stage); err != nil {
+		return err
+	}
+	staged := stdpath.Join(stage, dstName)
+	err := transfer(src, stage)
+	if err == nil {
+		if err = Rename(ctx, stdpath.Join(stage, srcName), dstName); err == nil {
+			err = replaceDst(ctx, dst, func() error {
+				_, err := Move(ctx, staged, dstDir)
+				return err
+			})
+			if err == nil {
+				_ = Remove(ctx, stage)
+				return nil
+			}
+		}
+		if move {
+			// the src was moved already, put it back rather than losing it with the stage
+			if restoreErr := restoreSrc(ctx, stage, srcName, dstName, src); restoreErr != nil {
+				log.Errorf("failed restore %s, it's left in %s: %+v", src, stage, restoreErr)
+				return err
+			}
+		}
+	}
+	_ = Remove(ctx, stage)
+	return err
+}
+
+// replaceDst runs place, which puts the new object at dst, with an existing dst renamed away
+// meanwhile. The old dst is removed if place succeeds, and renamed back otherwise
+func replaceDst(ctx context.Context, dst string, place func() error) error {
+	if _, err := Get(ctx, dst, &GetArgs{NoLog: true}); err != nil {
+		return place()
+	}
+	backupName := replacedPrefix + random.String(8) + "_" + stdpath.Base(dst)
+	backup := stdpath.Join(stdpath.Dir(dst), backupName)
+	if err := Rename(ctx, dst, backupName); err != nil {
+		return err
+	}
+	if err := place(); err != nil {
+		if restoreErr := Rename(ctx, backup, stdpath.Base(dst)); restoreErr != nil {
+			log.Errorf("failed restore %s from %s: %+v", dst, backup, restoreErr)
+		}
+		return err
+	}
+	if err := Remove(ctx, backup); err != nil {
+		log.Warnf("failed remove replaced %s: %+v", backup, err)
+	}
+	return nil
+}
+
+// restoreSrc moves the object moved into stage, renamed to dstName or not yet, back to src
+func restoreSrc(ctx context.Context, stage, srcName, dstName, src string) error {
+	if _, err := Get(ctx, stdpath.Join(stage, srcName), &GetArgs{NoLog: true}); err != nil {
+		if err = Rename(ctx, stdpath.Join(stage, dstName), srcName); err != nil {
+			return err
+		}
+	}
+	_, err := Move(ctx, stdpath.Join(stage, srcName), stdpath.Dir(src))
+	return err
+}
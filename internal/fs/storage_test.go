@@ -0,0 +1,50 @@
+package fs
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/OpenListTeam/OpenList/v4/drivers/local"
+	"github.com/OpenListTeam/OpenList/v4/internal/driver"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+)
+
+// createLocalStorage mounts a Local storage of a temp dir with the files, by slash separated
+// path and content, a path ending with / is an empty dir. It returns the temp dir and the storage
+func createLocalStorage(t *testing.T, mountPath string, files map[string]string) (string, driver.Driver) {
+	root := t.TempDir()
+	for p, content := range files {
+		full := filepath.Join(root, filepath.FromSlash(p))
+		if p[len(p)-1] == '/' {
+			if err := os.MkdirAll(full, 0o755); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	addition, _ := json.Marshal(map[string]string{"root_folder_path": root})
+	id, err := op.CreateStorage(context.Background(), model.Storage{
+		Driver:    "Local",
+		MountPath: mountPath,
+		Addition:  string(addition),
+	})
+	if err != nil {
+		t.Fatalf("failed to create storage: %+v", err)
+	}
+	t.Cleanup(func() { _ = op.DeleteStorageById(context.Background(), id) })
+	storage, err := op.GetStorageByMountPath(mountPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return root, storage
+}
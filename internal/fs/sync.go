@@ -0,0 +1,187 @@
+package fs
+
+import (
+	"context"
+	stdpath "path"
+	"strings"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/driver"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/pkg/errors"
+)
+
+const (
+	SyncActionCopy   = "copy"
+	SyncActionUpdate = "update"
+	SyncActionDelete = "delete"
+	SyncActionMkdir  = "mkdir"
+)
+
+// SyncAction is one step of a sync, Path is relative to the dst dir
+type SyncAction struct {
+	Action string `json:"action"`
+	Path   string `json:"path"`
+	IsDir  bool   `json:"is_dir"`
+	Size   int64  `json:"size"`
+}
+
+// isSynced reports whether the dst file is up to date with the src file.
+// Hashes are compared when both sides have one of the same type, otherwise size and modified time
+func isSynced(src, dst model.Obj) bool {
+	if src.IsDir() != dst.IsDir() {
+		return false
+	}
+	if src.IsDir() {
+		return true
+	}
+	if src.GetSize() != dst.GetSize() {
+		return false
+	}
+	dstHash := dst.GetHash()
+	for ht, h := range src.GetHash().All() {
+		if dh := dstHash.GetHash(ht); dh != "" && h != "" {
+			return strings.EqualFold(h, dh)
+		}
+	}
+	// most storages set the modified time of an uploaded file to the upload time,
+	// so a dst file that is not older than the src file is considered up to date
+	return !dst.ModTime().Before(src.ModTime())
+}
+
+// syncDir prepares the dst dir for the src objs: it's created if missing, the dst objs
+// whose type differs from the src ones are removed, and so are the extras if asked.
+// The remaining dst objs are returned by name
+func (t *FileTransferTask) syncDir(dstActualPath string, srcObjs []model.Obj) (map[string]model.Obj, error) {
+	objs, err := op.List(t.Ctx(), t.DstStorage, dstActualPath, model.ListArgs{})
+	if err != nil {
+		if !errs.IsObjectNotFound(err) {
+			return nil, errors.WithMessagef(err, "failed list dst [%s] objs", dstActualPath)
+		}
+		// create the dir here so that empty dirs are synced too
+		return nil, op.MakeDir(t.Ctx(), t.DstStorage, dstActualPath)
+	}
+	srcObjMap := make(map[string]model.Obj, len(srcObjs))
+	for _, obj := range srcObjs {
+		srcObjMap[obj.GetName()] = obj
+	}
+	dstObjs := make(map[string]model.Obj, len(objs))
+	for _, obj := range objs {
+		if err := t.Ctx().Err(); err != nil {
+			return nil, err
+		}
+		srcObj, ok := srcObjMap[obj.GetName()]
+		if ok && srcObj.IsDir() == obj.IsDir() {
+			dstObjs[obj.GetName()] = obj
+			continue
+		}
+		if !ok && !t.DeleteExtras {
+			continue
+		}
+		if err = op.Remove(t.Ctx(), t.DstStorage, stdpath.Join(dstActualPath, obj.GetName())); err != nil {
+			return nil, errors.WithMessagef(err, "failed remove dst [%s]", stdpath.Join(dstActualPath, obj.GetName()))
+		}
+	}
+	return dstObjs, nil
+}
+
+type syncPlanner struct {
+	ctx          context.Context
+	srcStorage   driver.Driver
+	dstStorage   driver.Driver
+	deleteExtras bool
+	actions      []SyncAction
+}
+
+func planSync(ctx context.Context, srcObjPath, dstDirPath string, deleteExtras bool) ([]SyncAction, error) {
+	srcStorage, srcObjActualPath, err := op.GetStorageAndActualPath(srcObjPath)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed get src storage")
+	}
+	dstStorage, dstDirActualPath, err := op.GetStorageAndActualPath(dstDirPath)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed get dst storage")
+	}
+	srcObj, err := op.Get(ctx, srcStorage, srcObjActualPath)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed get src [%s] file", srcObjActualPath)
+	}
+	dstObjActualPath := stdpath.Join(dstDirActualPath, srcObj.GetName())
+	dstObj, err := op.Get(ctx, dstStorage, dstObjActualPath)
+	if err != nil {
+		if !errs.IsObjectNotFound(err) {
+			return nil, errors.WithMessagef(err, "failed get dst [%s] file", dstObjActualPath)
+		}
+		dstObj = nil
+	}
+	p := &syncPlanner{
+		ctx:          ctx,
+		srcStorage:   srcStorage,
+		dstStorage:   dstStorage,
+		deleteExtras: deleteExtras,
+		actions:      []SyncAction{},
+	}
+	err = p.plan(srcObj, dstObj, srcObjActualPath, dstObjActualPath, srcObj.GetName())
+	return p.actions, err
+}
+
+func (p *syncPlanner) add(action string, obj model.Obj, path string) {
+	p.actions = append(p.actions, SyncAction{
+		Action: action,
+		Path:   path,
+		IsDir:  obj.IsDir(),
+		Size:   obj.GetSize(),
+	})
+}
+
+// plan adds the actions needed to make dst the same as src, dst is nil if it doesn't exist
+func (p *syncPlanner) plan(src, dst model.Obj, srcPath, dstPath, path string) error {
+	if err := p.ctx.Err(); err != nil {
+		return err
+	}
+	if dst != nil && dst.IsDir() != src.IsDir() {
+		p.add(SyncActionDelete, dst, path)
+		dst = nil
+	}
+	if !src.IsDir() {
+		if dst == nil {
+			p.add(SyncActionCopy, src, path)
+		} else if !isSynced(src, dst) {
+			p.add(SyncActionUpdate, src, path)
+		}
+		return nil
+	}
+
+	srcObjs, err := op.List(p.ctx, p.srcStorage, srcPath, model.ListArgs{})
+	if err != nil {
+		return errors.WithMessagef(err, "failed list src [%s] objs", srcPath)
+	}
+	var dstObjs []model.Obj
+	if dst == nil {
+		p.add(SyncActionMkdir, src, path)
+	} else if dstObjs, err = op.List(p.ctx, p.dstStorage, dstPath, model.ListArgs{}); err != nil {
+		return errors.WithMessagef(err, "failed list dst [%s] objs", dstPath)
+	}
+	dstObjMap := make(map[string]model.Obj, len(dstObjs))
+	for _, obj := range dstObjs {
+		dstObjMap[obj.GetName()] = obj
+	}
+	srcNames := make(map[string]struct{}, len(srcObjs))
+	for _, obj := range srcObjs {
+		name := obj.GetName()
+		srcNames[name] = struct{}{}
+		err = p.plan(obj, dstObjMap[name], stdpath.Join(srcPath, name), stdpath.Join(dstPath, name), stdpath.Join(path, name))
+		if err != nil {
+			return err
+		}
+	}
+	if p.deleteExtras {
+		for _, obj := range dstObjs {
+			if _, ok := srcNames[obj.GetName()]; !ok {
+				p.add(SyncActionDelete, obj, stdpath.Join(path, obj.GetName()))
+			}
+		}
+	}
+	return nil
+}
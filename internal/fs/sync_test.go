@@ -0,0 +1,65 @@
+package fs
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestPlanSync(t *testing.T) {
+	files := map[string]string{
+		"src/dir/new.txt":       "new",
+		"src/dir/changed.txt":   "changed",
+		"src/dir/same.txt":      "same",
+		"src/dir/typed":         "now a file",
+		"src/dir/sub/inner.txt": "inner",
+		"src/dir/empty/":        "",
+		"dst/dir/changed.txt":   "old",
+		"dst/dir/same.txt":      "same",
+		"dst/dir/typed/":        "",
+		"dst/dir/extra.txt":     "extra",
+		"dst/dir/extra_dir/":    "",
+	}
+	synced := []string{
+		"copy dir/new.txt",
+		"update dir/changed.txt",
+		"delete dir/typed",
+		"copy dir/typed",
+		"mkdir dir/sub",
+		"copy dir/sub/inner.txt",
+		"mkdir dir/empty",
+	}
+	for _, c := range []struct {
+		name         string
+		deleteExtras bool
+		expected     []string
+	}{
+		{"keep extras", false, synced},
+		{"delete extras", true, append(slices.Clone(synced), "delete dir/extra.txt", "delete dir/extra_dir")},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			root, _ := createLocalStorage(t, "/sync_test", files)
+			// the dst file that is newer with the same size is up to date
+			later := time.Now().Add(time.Hour)
+			if err := os.Chtimes(filepath.Join(root, "dst", "dir", "same.txt"), later, later); err != nil {
+				t.Fatal(err)
+			}
+			actions, err := PlanSync(context.Background(), "/sync_test/src/dir", "/sync_test/dst", c.deleteExtras)
+			if err != nil {
+				t.Fatalf("failed to plan sync: %+v", err)
+			}
+			var got []string
+			for _, a := range actions {
+				got = append(got, a.Action+" "+a.Path)
+			}
+			slices.Sort(got)
+			expected := slices.Sorted(slices.Values(c.expected))
+			if !slices.Equal(got, expected) {
+				t.Errorf("expected actions %v, got %v", expected, got)
+			}
+		})
+	}
+}
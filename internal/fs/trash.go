@@ -0,0 +1,28 @@
+package fs
+
+import (
+	"github.com/OpenListTeam/OpenList/v4/internal/driver"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+)
+
+// isHiddenTrash reports whether the actual path is in the trash of the storage,
+// which is only reachable through the trash APIs
+func isHiddenTrash(storage driver.Driver, actualPath string) bool {
+	return storage != nil && storage.GetStorage().EnableTrash && op.IsTrashPath(actualPath)
+}
+
+// filterTrash removes the trash folder from the listing of the storage root
+func filterTrash(storage driver.Driver, actualPath string, objs []model.Obj) []model.Obj {
+	if storage == nil || !storage.GetStorage().EnableTrash || actualPath != "/" {
+		return objs
+	}
+	for i, obj := range objs {
+		if obj.GetName() == op.TrashDirName {
+			ret := make([]model.Obj, 0, len(objs)-1)
+			ret = append(ret, objs[:i]...)
+			return append(ret, objs[i+1:]...)
+		}
+	}
+	return objs
+}
@@ -0,0 +1,63 @@
+package fs
+
+import (
+	"context"
+	"fmt"
+	stdpath "path"
+	"strings"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// compareObj checks the dst object against the src object, by hash when both
+// have one of the same type, otherwise by size. It returns how they are matched
+func compareObj(src, dst model.Obj) (string, error) {
+	if src.GetSize() != dst.GetSize() {
+		return "", errors.WithMessagef(errs.VerificationFailed, "size %d of dst is not size %d of src", dst.GetSize(), src.GetSize())
+	}
+	dstHash := dst.GetHash()
+	for ht, h := range src.GetHash().All() {
+		dh := dstHash.GetHash(ht)
+		if h == "" || dh == "" {
+			continue
+		}
+		if !strings.EqualFold(h, dh) {
+			return "", errors.WithMessagef(errs.VerificationFailed, "%s %s of dst is not %s of src", ht.Name, dh, h)
+		}
+		return fmt.Sprintf("verified by %s", ht.Name), nil
+	}
+	return "verified by size", nil
+}
+
+// verify re-reads the uploaded dst object and compares it with the src object. A mismatched dst
+// object is removed, so that a move keeps its src object and a retry uploads it again
+func (t *FileTransferTask) verify(srcObj model.Obj) error {
+	dstPath := stdpath.Join(t.DstActualPath, srcObj.GetName())
+	dstObj, err := op.Get(t.Ctx(), t.DstStorage, dstPath)
+	if err == nil && model.ObjHasMask(dstObj, model.Temp) {
+		// the driver didn't return the uploaded object, so what is cached is made of src
+		if _, err = op.List(t.Ctx(), t.DstStorage, t.DstActualPath, model.ListArgs{Refresh: true, SkipHook: true}); err == nil {
+			dstObj, err = op.Get(t.Ctx(), t.DstStorage, dstPath)
+		}
+	}
+	if err != nil {
+		return errors.WithMessagef(err, "failed get dst [%s] to verify", dstPath)
+	}
+	result, err := compareObj(srcObj, dstObj)
+	if err != nil {
+		t.Verification = err.Error()
+		ctx := context.WithValue(t.Ctx(), conf.SkipHookKey, struct{}{})
+		if e := op.Remove(ctx, t.DstStorage, dstPath); e != nil {
+			log.Errorf("failed remove unverified dst [%s]: %+v", dstPath, e)
+		}
+		return errors.WithMessagef(err, "[%s]", dstPath)
+	}
+	t.Verification = result
+	t.Status = result
+	return nil
+}
@@ -0,0 +1,52 @@
+package fs
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+func TestCompareObj(t *testing.T) {
+	hashed := func(size int64, hashes map[*utils.HashType]string) model.Obj {
+		return &model.Object{Name: "a.txt", Size: size, HashInfo: utils.NewHashInfoByMap(hashes)}
+	}
+	for _, c := range []struct {
+		name     string
+		src, dst model.Obj
+		result   string
+	}{
+		{"matching hash", hashed(3, map[*utils.HashType]string{utils.MD5: "ABC"}), hashed(3, map[*utils.HashType]string{utils.MD5: "abc"}), "verified by md5"},
+		{"mismatching hash", hashed(3, map[*utils.HashType]string{utils.MD5: "abc"}), hashed(3, map[*utils.HashType]string{utils.MD5: "abd"}), ""},
+		{"no hash of the same type", hashed(3, map[*utils.HashType]string{utils.MD5: "abc"}), hashed(3, map[*utils.HashType]string{utils.SHA1: "abd"}), "verified by size"},
+		{"no hash support", hashed(3, nil), hashed(3, nil), "verified by size"},
+		{"mismatching size", hashed(3, nil), hashed(4, nil), ""},
+	} {
+		result, err := compareObj(c.src, c.dst)
+		if result != c.result || c.result == "" && !errors.Is(err, errs.VerificationFailed) {
+			t.Errorf("%s: expected %q, got %q: %v", c.name, c.result, result, err)
+		}
+	}
+}
+
+func TestVerify(t *testing.T) {
+	// Local doesn't give hashes, so the uploaded file is verified by size
+	root, dstStorage := createLocalStorage(t, "/verify_test", map[string]string{"a.txt": "abc"})
+	task := &FileTransferTask{TaskData: TaskData{DstStorage: dstStorage, DstActualPath: "/"}}
+	task.SetCtx(context.Background())
+
+	if err := task.verify(&model.Object{Name: "a.txt", Size: 3}); err != nil || task.Verification != "verified by size" {
+		t.Errorf("expected the file to be verified by size, got %q: %v", task.Verification, err)
+	}
+	if err := task.verify(&model.Object{Name: "a.txt", Size: 5}); !errors.Is(err, errs.VerificationFailed) {
+		t.Errorf("expected the verification to fail, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "a.txt")); !os.IsNotExist(err) {
+		t.Errorf("expected the mismatched file to be removed, got %v", err)
+	}
+}
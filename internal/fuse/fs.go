@@ -1,170 +1,367 @@
-package fuse
+//go:build fuse
 
-import "github.com/winfsp/cgofuse/fuse"
+package fuse
 
+import (
+	"context"
+	stdpath "path"
+	"sync"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/cache"
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/fs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/winfsp/cgofuse/fuse"
+)
+
+// Fs exposes the virtual file system of a user as a FUSE file system.
+// Paths received from the kernel are relative to RootFolder, which is
+// itself relative to the user's base path.
 type Fs struct {
-	RootFolder string
 	fuse.FileSystemBase
+	RootFolder string
+	ReadOnly   bool
+
+	user *model.User
+	ctx  context.Context
+
+	// attrs caches directory listings so that the stat storm following
+	// every readdir does not hit the drivers again.
+	attrs *cache.KeyedCache[[]model.Obj]
+
+	mu      sync.Mutex
+	handles map[uint64]*handle
+	nextFh  uint64
+	// pending holds files that are open for writing and not uploaded yet,
+	// so that getattr sees them before they exist in the storage.
+	pending map[string]*handle
+}
+
+func NewFs(user *model.User, rootFolder string, readOnly bool, attrTimeout time.Duration) *Fs {
+	ctx := context.WithValue(context.Background(), conf.UserKey, user)
+	ctx = context.WithValue(ctx, conf.MetaPassKey, "")
+	ctx = context.WithValue(ctx, conf.ProtocolKey, "fuse")
+	// run copy and move synchronously instead of creating tasks
+	ctx = context.WithValue(ctx, conf.NoTaskKey, struct{}{})
+	return &Fs{
+		RootFolder: utils.FixAndCleanPath(rootFolder),
+		ReadOnly:   readOnly,
+		user:       user,
+		ctx:        ctx,
+		attrs:      cache.NewKeyedCache[[]model.Obj](attrTimeout),
+		handles:    make(map[uint64]*handle),
+		pending:    make(map[string]*handle),
+	}
+}
+
+// reqPath converts a path received from the kernel into a path of the virtual file system
+func (f *Fs) reqPath(path string) (string, error) {
+	return f.user.JoinPath(stdpath.Join(f.RootFolder, path))
+}
+
+func (f *Fs) getMeta(path string) (*model.Meta, error) {
+	meta, err := op.GetNearestMeta(path)
+	if err != nil && !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+		return nil, err
+	}
+	return meta, nil
+}
+
+func (f *Fs) canAccess(path string) error {
+	meta, err := f.getMeta(path)
+	if err != nil {
+		return err
+	}
+	if !common.CanAccess(f.user, meta, path, "") {
+		return errs.PermissionDenied
+	}
+	return nil
+}
+
+func (f *Fs) canWrite(path string) error {
+	if f.ReadOnly {
+		return errs.PermissionDenied
+	}
+	meta, err := f.getMeta(stdpath.Dir(path))
+	if err != nil {
+		return err
+	}
+	if !common.CanAccess(f.user, meta, path, "") ||
+		!(f.user.CanWrite() || common.CanWrite(meta, stdpath.Dir(path))) {
+		return errs.PermissionDenied
+	}
+	return nil
+}
+
+func (f *Fs) list(path string) ([]model.Obj, error) {
+	if objs, ok := f.attrs.Get(path); ok {
+		return objs, nil
+	}
+	if err := f.canAccess(path); err != nil {
+		return nil, err
+	}
+	objs, err := fs.List(f.ctx, path, &fs.ListArgs{NoLog: true})
+	if err != nil {
+		return nil, err
+	}
+	f.attrs.Set(path, objs)
+	return objs, nil
+}
+
+func (f *Fs) get(path string) (model.Obj, error) {
+	if h := f.getPending(path); h != nil {
+		return h.obj(), nil
+	}
+	if path == "/" {
+		return &model.Object{Name: "root", IsFolder: true, Modified: time.Now()}, nil
+	}
+	if objs, ok := f.attrs.Get(stdpath.Dir(path)); ok {
+		name := stdpath.Base(path)
+		for _, obj := range objs {
+			if obj.GetName() == name {
+				return obj, nil
+			}
+		}
+		return nil, errs.ObjectNotFound
+	}
+	if err := f.canAccess(path); err != nil {
+		return nil, err
+	}
+	return fs.Get(f.ctx, path, &fs.GetArgs{NoLog: true})
+}
+
+// invalidate drops the cached listings affected by a change of path
+func (f *Fs) invalidate(path string) {
+	f.attrs.Delete(stdpath.Dir(path))
+	f.attrs.Delete(path)
+}
+
+func (f *Fs) getPending(path string) *handle {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.pending[path]
+}
+
+func fillStat(obj model.Obj, stat *fuse.Stat_t) {
+	if obj.IsDir() {
+		stat.Mode = fuse.S_IFDIR | 0o755
+		stat.Nlink = 2
+	} else {
+		stat.Mode = fuse.S_IFREG | 0o644
+		stat.Nlink = 1
+		stat.Size = obj.GetSize()
+		stat.Blocks = (stat.Size + 511) / 512
+	}
+	stat.Blksize = 4096
+	mtime := fuse.NewTimespec(obj.ModTime())
+	stat.Mtim = mtime
+	stat.Atim = mtime
+	stat.Ctim = mtime
+	ctime := obj.CreateTime()
+	if ctime.IsZero() {
+		stat.Birthtim = mtime
+	} else {
+		stat.Birthtim = fuse.NewTimespec(ctime)
+	}
+	uid, gid, _ := fuse.Getcontext()
+	stat.Uid = uint32(uid)
+	stat.Gid = uint32(gid)
+}
+
+func (f *Fs) Statfs(path string, stat *fuse.Statfs_t) int {
+	const blockSize = 4096
+	total, free := uint64(1<<50), uint64(1<<50)
+	reqPath, err := f.reqPath(path)
+	if err == nil {
+		if storage, err := fs.GetStorage(reqPath, &fs.GetStoragesArgs{}); err == nil {
+			if details, err := op.GetStorageDetails(f.ctx, storage); err == nil && details.TotalSpace > 0 {
+				total, free = uint64(details.TotalSpace), uint64(details.FreeSpace())
+			}
+		}
+	}
+	stat.Bsize = blockSize
+	stat.Frsize = blockSize
+	stat.Blocks = total / blockSize
+	stat.Bfree = free / blockSize
+	stat.Bavail = free / blockSize
+	stat.Namemax = 255
+	return 0
+}
+
+func (f *Fs) Getattr(path string, stat *fuse.Stat_t, fh uint64) int {
+	reqPath, err := f.reqPath(path)
+	if err != nil {
+		return errno(err)
+	}
+	obj, err := f.get(reqPath)
+	if err != nil {
+		return errno(err)
+	}
+	fillStat(obj, stat)
+	return 0
+}
+
+func (f *Fs) Opendir(path string) (int, uint64) {
+	reqPath, err := f.reqPath(path)
+	if err != nil {
+		return errno(err), ^uint64(0)
+	}
+	if err = f.canAccess(reqPath); err != nil {
+		return errno(err), ^uint64(0)
+	}
+	return 0, 0
+}
+
+func (f *Fs) Releasedir(path string, fh uint64) int {
+	return 0
+}
+
+func (f *Fs) Readdir(path string, fill func(name string, stat *fuse.Stat_t, ofst int64) bool, ofst int64, fh uint64) int {
+	reqPath, err := f.reqPath(path)
+	if err != nil {
+		return errno(err)
+	}
+	objs, err := f.list(reqPath)
+	if err != nil {
+		return errno(err)
+	}
+	fill(".", nil, 0)
+	fill("..", nil, 0)
+	for _, obj := range objs {
+		stat := &fuse.Stat_t{}
+		fillStat(obj, stat)
+		if !fill(obj.GetName(), stat, 0) {
+			break
+		}
+	}
+	return 0
+}
+
+func (f *Fs) Mkdir(path string, mode uint32) int {
+	reqPath, err := f.reqPath(path)
+	if err != nil {
+		return errno(err)
+	}
+	if err = f.canWrite(reqPath); err != nil {
+		return errno(err)
+	}
+	defer f.invalidate(reqPath)
+	return errno(fs.MakeDir(f.ctx, reqPath))
+}
+
+func (f *Fs) Unlink(path string) int {
+	reqPath, err := f.reqPath(path)
+	if err != nil {
+		return errno(err)
+	}
+	if f.ReadOnly || !f.user.CanRemove() {
+		return -fuse.EACCES
+	}
+	defer f.invalidate(reqPath)
+	return errno(fs.Remove(f.ctx, reqPath))
+}
+
+func (f *Fs) Rmdir(path string) int {
+	reqPath, err := f.reqPath(path)
+	if err != nil {
+		return errno(err)
+	}
+	if f.ReadOnly || !f.user.CanRemove() {
+		return -fuse.EACCES
+	}
+	// fs.Remove deletes recursively, but rmdir must only remove empty directories
+	objs, err := fs.List(f.ctx, reqPath, &fs.ListArgs{NoLog: true, Refresh: true})
+	if err != nil {
+		return errno(err)
+	}
+	if len(objs) > 0 {
+		return -fuse.ENOTEMPTY
+	}
+	defer f.invalidate(reqPath)
+	return errno(fs.Remove(f.ctx, reqPath))
+}
+
+func (f *Fs) Rename(oldpath string, newpath string) int {
+	if f.ReadOnly {
+		return -fuse.EACCES
+	}
+	srcPath, err := f.reqPath(oldpath)
+	if err != nil {
+		return errno(err)
+	}
+	dstPath, err := f.reqPath(newpath)
+	if err != nil {
+		return errno(err)
+	}
+	defer f.invalidate(srcPath)
+	defer f.invalidate(dstPath)
+	srcDir, srcBase := stdpath.Split(srcPath)
+	dstDir, dstBase := stdpath.Split(dstPath)
+	if srcDir != dstDir && !f.user.CanMove() || srcBase != dstBase && !f.user.CanRename() {
+		return -fuse.EACCES
+	}
+	// rename(2) replaces an existing destination file, which is only removed once the
+	// renamed one took its place
+	if dst, err := fs.Get(f.ctx, dstPath, &fs.GetArgs{NoLog: true}); err == nil {
+		if dst.IsDir() || !f.user.CanRemove() {
+			return -fuse.EEXIST
+		}
+	}
+	return errno(fs.TransferTo(f.ctx, srcPath, dstPath, true))
+}
+
+// Chmod, Chown and Utimens are accepted but ignored, since storages have
+// no notion of them. Returning an error would break tools like cp -p.
+
+func (f *Fs) Chmod(path string, mode uint32) int {
+	return 0
+}
+
+func (f *Fs) Chown(path string, uid uint32, gid uint32) int {
+	return 0
+}
+
+func (f *Fs) Utimens(path string, tmsp []fuse.Timespec) int {
+	return 0
+}
+
+func (f *Fs) Destroy() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for fh, h := range f.handles {
+		if err := h.release(); err != nil {
+			log.Errorf("[fuse] failed release [%s]: %+v", h.path, err)
+		}
+		delete(f.handles, fh)
+	}
+}
+
+// errno maps errors of the fs layer to negative FUSE error codes
+func errno(err error) int {
+	if err == nil {
+		return 0
+	}
+	switch {
+	case errs.IsObjectNotFound(err), errors.Is(err, errs.StorageNotFound):
+		return -fuse.ENOENT
+	case errors.Is(err, errs.PermissionDenied), errors.Is(err, errs.RelativePath):
+		return -fuse.EACCES
+	case errors.Is(err, errs.ObjectAlreadyExists):
+		return -fuse.EEXIST
+	case errors.Is(err, errs.NotFolder):
+		return -fuse.ENOTDIR
+	case errors.Is(err, errs.NotFile):
+		return -fuse.EISDIR
+	case errs.IsNotImplementError(err), errs.IsNotSupportError(err), errors.Is(err, errs.UploadNotSupported):
+		return -fuse.ENOSYS
+	}
+	log.Warnf("[fuse] %+v", err)
+	return -fuse.EIO
 }
-
-func (fs *Fs) Init() {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Destroy() {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Statfs(path string, stat *fuse.Statfs_t) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Mknod(path string, mode uint32, dev uint64) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Mkdir(path string, mode uint32) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Unlink(path string) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Rmdir(path string) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Link(oldpath string, newpath string) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Symlink(target string, newpath string) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Readlink(path string) (int, string) {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Rename(oldpath string, newpath string) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Chmod(path string, mode uint32) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Chown(path string, uid uint32, gid uint32) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Utimens(path string, tmsp []fuse.Timespec) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Access(path string, mask uint32) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Create(path string, flags int, mode uint32) (int, uint64) {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Open(path string, flags int) (int, uint64) {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Getattr(path string, stat *fuse.Stat_t, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Truncate(path string, size int64, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Read(path string, buff []byte, ofst int64, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Write(path string, buff []byte, ofst int64, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Flush(path string, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Release(path string, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Fsync(path string, datasync bool, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Opendir(path string) (int, uint64) {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Readdir(path string, fill func(name string, stat *fuse.Stat_t, ofst int64) bool, ofst int64, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Releasedir(path string, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Fsyncdir(path string, datasync bool, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Setxattr(path string, name string, value []byte, flags int) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Getxattr(path string, name string) (int, []byte) {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Removexattr(path string, name string) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Listxattr(path string, fill func(name string) bool) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-var _ fuse.FileSystemInterface = (*Fs)(nil)
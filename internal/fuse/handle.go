@@ -0,0 +1,364 @@
+//go:build fuse
+
+package fuse
+
+import (
+	"io"
+	"os"
+	stdpath "path"
+	"sync"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/fs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/stream"
+	"github.com/OpenListTeam/OpenList/v4/pkg/http_range"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/pkg/errors"
+	"github.com/winfsp/cgofuse/fuse"
+)
+
+// handle is an open file. Reads are served by range requests against the
+// link of the object, writes go to a local spool file which is uploaded
+// when the file is flushed.
+type handle struct {
+	fs   *Fs
+	path string
+	mu   sync.Mutex
+
+	// read side
+	src    model.Obj
+	rr     model.RangeReaderIF
+	link   *model.Link
+	reader io.ReadCloser
+	offset int64
+
+	// write side
+	spool    *os.File
+	size     int64
+	modified time.Time
+	dirty    bool
+}
+
+func (h *handle) obj() model.Obj {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return &model.Object{
+		Name:     stdpath.Base(h.path),
+		Size:     h.size,
+		Modified: h.modified,
+	}
+}
+
+func (h *handle) openRangeReader() error {
+	if h.rr != nil {
+		return nil
+	}
+	link, _, err := fs.Link(h.fs.ctx, h.path, model.LinkArgs{})
+	if err != nil {
+		return err
+	}
+	rr, err := stream.GetRangeReaderFromLink(h.src.GetSize(), link)
+	if err != nil {
+		_ = link.Close()
+		return err
+	}
+	h.link, h.rr = link, rr
+	return nil
+}
+
+func (h *handle) readAt(buff []byte, ofst int64) (int, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.spool != nil {
+		n, err := h.spool.ReadAt(buff, ofst)
+		if err == io.EOF {
+			err = nil
+		}
+		return n, err
+	}
+	size := h.src.GetSize()
+	if ofst >= size {
+		return 0, nil
+	}
+	if err := h.openRangeReader(); err != nil {
+		return 0, err
+	}
+	// keep reading from the current response as long as the kernel reads
+	// sequentially, otherwise start a new range request at the offset
+	if h.reader == nil || h.offset != ofst {
+		if h.reader != nil {
+			_ = h.reader.Close()
+		}
+		reader, err := h.rr.RangeRead(h.fs.ctx, http_range.Range{Start: ofst, Length: size - ofst})
+		if err != nil {
+			h.reader = nil
+			return 0, err
+		}
+		h.reader, h.offset = reader, ofst
+	}
+	n, err := io.ReadFull(h.reader, buff)
+	h.offset += int64(n)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		err = nil
+	}
+	return n, err
+}
+
+// openSpool prepares the handle for writing. Unless truncate is set the
+// current content of the object is copied into the spool file first.
+func (h *handle) openSpool(truncate bool) error {
+	if h.spool != nil {
+		return nil
+	}
+	spool, err := os.CreateTemp(conf.Conf.TempDir, "fuse-*")
+	if err != nil {
+		return err
+	}
+	h.spool = spool
+	h.modified = time.Now()
+	if truncate || h.src == nil || h.src.GetSize() == 0 {
+		h.size = 0
+		h.dirty = truncate || h.src == nil
+		return nil
+	}
+	if err = h.openRangeReader(); err != nil {
+		return err
+	}
+	reader, err := h.rr.RangeRead(h.fs.ctx, http_range.Range{Length: -1})
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	h.size, err = utils.CopyWithBuffer(spool, reader)
+	return err
+}
+
+func (h *handle) writeAt(buff []byte, ofst int64) (int, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.spool == nil {
+		return 0, errs.PermissionDenied
+	}
+	n, err := h.spool.WriteAt(buff, ofst)
+	if end := ofst + int64(n); end > h.size {
+		h.size = end
+	}
+	h.dirty = true
+	h.modified = time.Now()
+	return n, err
+}
+
+func (h *handle) truncate(size int64) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.spool == nil {
+		return errs.PermissionDenied
+	}
+	if err := h.spool.Truncate(size); err != nil {
+		return err
+	}
+	h.size = size
+	h.dirty = true
+	h.modified = time.Now()
+	return nil
+}
+
+// flush uploads the spool file if it was modified since the last flush
+func (h *handle) flush() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.spool == nil || !h.dirty {
+		return nil
+	}
+	if _, err := h.spool.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	dir, name := stdpath.Split(h.path)
+	s := &stream.FileStream{
+		Ctx: h.fs.ctx,
+		Obj: &model.Object{
+			Name:     name,
+			Size:     h.size,
+			Modified: h.modified,
+		},
+		Mimetype: utils.GetMimeType(name),
+		Reader:   h.spool,
+	}
+	err := fs.PutDirectly(h.fs.ctx, dir, s)
+	h.fs.invalidate(h.path)
+	if err != nil {
+		return errors.WithMessagef(err, "failed upload [%s]", h.path)
+	}
+	h.dirty = false
+	return nil
+}
+
+func (h *handle) release() error {
+	err := h.flush()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.reader != nil {
+		_ = h.reader.Close()
+		h.reader = nil
+	}
+	if h.link != nil {
+		_ = h.link.Close()
+		h.link = nil
+	}
+	if h.spool != nil {
+		_ = h.spool.Close()
+		_ = os.Remove(h.spool.Name())
+		h.spool = nil
+	}
+	return err
+}
+
+func (f *Fs) addHandle(h *handle) uint64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.nextFh++
+	f.handles[f.nextFh] = h
+	if h.spool != nil {
+		f.pending[h.path] = h
+	}
+	return f.nextFh
+}
+
+func (f *Fs) getHandle(fh uint64) *handle {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.handles[fh]
+}
+
+func (f *Fs) open(path string, flags int, create bool) (int, uint64) {
+	reqPath, err := f.reqPath(path)
+	if err != nil {
+		return errno(err), ^uint64(0)
+	}
+	write := flags&fuse.O_ACCMODE != fuse.O_RDONLY
+	h := &handle{fs: f, path: reqPath}
+	if !create {
+		if err = f.canAccess(reqPath); err != nil {
+			return errno(err), ^uint64(0)
+		}
+		if p := f.getPending(reqPath); p != nil {
+			// a file being written cannot be read back from the storage yet
+			return -fuse.EBUSY, ^uint64(0)
+		}
+		obj, err := fs.Get(f.ctx, reqPath, &fs.GetArgs{NoLog: true})
+		if err != nil {
+			return errno(err), ^uint64(0)
+		}
+		if obj.IsDir() {
+			return -fuse.EISDIR, ^uint64(0)
+		}
+		h.src, h.size, h.modified = obj, obj.GetSize(), obj.ModTime()
+	}
+	if write || create {
+		if err = f.canWrite(reqPath); err != nil {
+			return errno(err), ^uint64(0)
+		}
+		if err = h.openSpool(create || flags&fuse.O_TRUNC != 0); err != nil {
+			_ = h.release()
+			return errno(err), ^uint64(0)
+		}
+	}
+	return 0, f.addHandle(h)
+}
+
+func (f *Fs) Create(path string, flags int, mode uint32) (int, uint64) {
+	return f.open(path, flags, true)
+}
+
+func (f *Fs) Open(path string, flags int) (int, uint64) {
+	return f.open(path, flags, false)
+}
+
+func (f *Fs) Read(path string, buff []byte, ofst int64, fh uint64) int {
+	h := f.getHandle(fh)
+	if h == nil {
+		return -fuse.EBADF
+	}
+	n, err := h.readAt(buff, ofst)
+	if err != nil {
+		return errno(err)
+	}
+	return n
+}
+
+func (f *Fs) Write(path string, buff []byte, ofst int64, fh uint64) int {
+	h := f.getHandle(fh)
+	if h == nil {
+		return -fuse.EBADF
+	}
+	n, err := h.writeAt(buff, ofst)
+	if err != nil {
+		return errno(err)
+	}
+	return n
+}
+
+// maxTruncateSize is the largest file truncated to a size other than 0 without an open handle,
+// which is downloaded to be cut
+const maxTruncateSize = 64 * utils.MB
+
+func (f *Fs) Truncate(path string, size int64, fh uint64) int {
+	h := f.getHandle(fh)
+	if h != nil {
+		return errno(h.truncate(size))
+	}
+	// truncate(2) without an open handle
+	flags := fuse.O_WRONLY
+	if size == 0 {
+		// nothing is kept, the file is replaced with an empty one without downloading it
+		flags |= fuse.O_TRUNC
+	} else {
+		reqPath, err := f.reqPath(path)
+		if err != nil {
+			return errno(err)
+		}
+		obj, err := fs.Get(f.ctx, reqPath, &fs.GetArgs{NoLog: true})
+		if err != nil {
+			return errno(err)
+		}
+		if obj.GetSize() > maxTruncateSize {
+			// the whole file would be downloaded to the spool just to cut it
+			return -fuse.EFBIG
+		}
+	}
+	code, fh := f.open(path, flags, false)
+	if code != 0 {
+		return code
+	}
+	defer f.Release(path, fh)
+	return errno(f.getHandle(fh).truncate(size))
+}
+
+func (f *Fs) Flush(path string, fh uint64) int {
+	h := f.getHandle(fh)
+	if h == nil {
+		return -fuse.EBADF
+	}
+	return errno(h.flush())
+}
+
+func (f *Fs) Fsync(path string, datasync bool, fh uint64) int {
+	return f.Flush(path, fh)
+}
+
+func (f *Fs) Release(path string, fh uint64) int {
+	f.mu.Lock()
+	h, ok := f.handles[fh]
+	delete(f.handles, fh)
+	if ok && f.pending[h.path] == h {
+		delete(f.pending, h.path)
+	}
+	f.mu.Unlock()
+	if !ok {
+		return -fuse.EBADF
+	}
+	return errno(h.release())
+}
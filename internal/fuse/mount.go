@@ -1,9 +1,55 @@
+//go:build fuse
+
 package fuse
 
-import "github.com/winfsp/cgofuse/fuse"
+import (
+	"fmt"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/winfsp/cgofuse/fuse"
+)
+
+type MountOptions struct {
+	// User whose permissions and base path apply to the mount
+	User *model.User
+	// ReadOnly rejects every modification
+	ReadOnly bool
+	// AttrTimeout is how long directory listings are cached
+	AttrTimeout time.Duration
+	// Options are passed to the FUSE library as is, e.g. "-o", "allow_other"
+	Options []string
+}
+
+type Host struct {
+	host *fuse.FileSystemHost
+	done chan struct{}
+	ok   bool
+}
+
+// Mount mounts mountSrc of the virtual file system at mountDst. It returns
+// once the mount is finished in the background, use Wait to block until it
+// is unmounted.
+func Mount(mountSrc, mountDst string, opts MountOptions) *Host {
+	fs := NewFs(opts.User, mountSrc, opts.ReadOnly, opts.AttrTimeout)
+	h := &Host{host: fuse.NewFileSystemHost(fs), done: make(chan struct{})}
+	h.host.SetCapReaddirPlus(true)
+	go func() {
+		defer close(h.done)
+		h.ok = h.host.Mount(mountDst, opts.Options)
+	}()
+	return h
+}
+
+// Wait blocks until the file system is unmounted
+func (h *Host) Wait() error {
+	<-h.done
+	if !h.ok {
+		return fmt.Errorf("failed to mount, see the log above for details")
+	}
+	return nil
+}
 
-func Mount(mountSrc, mountDst string, opts []string) {
-	fs := &Fs{RootFolder: mountSrc}
-	host := fuse.NewFileSystemHost(fs)
-	go host.Mount(mountDst, opts)
+func (h *Host) Unmount() bool {
+	return h.host.Unmount()
 }
@@ -0,0 +1,108 @@
+// Package metrics keeps the prometheus metrics of OpenList, served on /metrics
+// when a metrics token is set
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "openlist"
+
+// Registry is the registry of all the metrics, other packages add the collectors
+// of their state, like tasks and storages, to it
+var Registry = prometheus.NewRegistry()
+
+var (
+	requests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "requests_total",
+		Help:      "Requests handled, by protocol, route or operation, method and status.",
+	}, []string{"protocol", "route", "method", "status"})
+	requestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "request_duration_seconds",
+		Help:      "Latency of the requests, by protocol and route or operation.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"protocol", "route"})
+	streamBytes = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "stream_bytes_total",
+		Help:      "Bytes passed through the stream limiters, by direction and peer.",
+	}, []string{"direction", "peer"})
+	driverCalls = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "driver_calls_total",
+		Help:      "Calls to the storage drivers, by storage, driver and method.",
+	}, []string{"storage", "driver", "method"})
+	driverErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "driver_errors_total",
+		Help:      "Failed calls to the storage drivers, by storage, driver and method.",
+	}, []string{"storage", "driver", "method"})
+	driverDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "driver_call_duration_seconds",
+		Help:      "Latency of the calls to the storage drivers, by storage, driver and method.",
+		Buckets:   []float64{.01, .05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60},
+	}, []string{"storage", "driver", "method"})
+	cacheLookups = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cache_lookups_total",
+		Help:      "Lookups in the caches of op, by cache and result (hit or miss).",
+	}, []string{"cache", "result"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		requests, requestDuration, streamBytes,
+		driverCalls, driverErrors, driverDuration, cacheLookups,
+	)
+}
+
+var handler = promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+
+// Handler serves the metrics in the prometheus format
+func Handler() http.Handler {
+	return handler
+}
+
+// ObserveRequest records a request of the protocol. For http protocols the route is
+// the route pattern, for ftp and sftp the operation. status is the http status, or ok or error
+func ObserveRequest(protocol, route, method, status string, start time.Time) {
+	requests.WithLabelValues(protocol, route, method, status).Inc()
+	requestDuration.WithLabelValues(protocol, route).Observe(time.Since(start).Seconds())
+}
+
+// StreamCounter returns a func adding the bytes passed in the direction (in or out)
+// with the peer (client or server) to the metrics
+func StreamCounter(direction, peer string) func(n int) {
+	c := streamBytes.WithLabelValues(direction, peer)
+	return func(n int) {
+		c.Add(float64(n))
+	}
+}
+
+// ObserveDriverCall records a call of the method of the driver of the storage mounted at mountPath
+func ObserveDriverCall(mountPath, driver, method string, start time.Time, err error) {
+	driverCalls.WithLabelValues(mountPath, driver, method).Inc()
+	if err != nil {
+		driverErrors.WithLabelValues(mountPath, driver, method).Inc()
+	}
+	driverDuration.WithLabelValues(mountPath, driver, method).Observe(time.Since(start).Seconds())
+}
+
+// CacheLookup records a lookup in the cache
+func CacheLookup(cache string, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	cacheLookups.WithLabelValues(cache, result).Inc()
+}
@@ -0,0 +1,48 @@
+package model
+
+import (
+	"slices"
+	"time"
+)
+
+// protocols an access token is scoped to
+const (
+	TokenApi    = "api"
+	TokenWebdav = "webdav"
+	TokenFtp    = "ftp"
+	TokenSftp   = "sftp"
+	TokenS3     = "s3"
+)
+
+var TokenProtocols = []string{TokenApi, TokenWebdav, TokenFtp, TokenSftp, TokenS3}
+
+// AccessTokenPrefix starts every access token, to tell it from passwords and login tokens
+const AccessTokenPrefix = "olt_"
+
+// AccessToken is a named credential of a user for one protocol,
+// only the hash of it is stored and the token itself is shown once
+type AccessToken struct {
+	ID       uint   `json:"id" gorm:"primaryKey"`
+	UserID   uint   `json:"user_id" gorm:"index"`
+	Name     string `json:"name" binding:"required"`
+	Protocol string `json:"protocol" binding:"required"`
+	ReadOnly bool   `json:"read_only"`
+	// SubPath restricts the token to a path of the user, empty for all of the user's paths
+	SubPath    string     `json:"sub_path"`
+	Hash       string     `json:"-" gorm:"uniqueIndex;size:64"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func (t *AccessToken) Valid() bool {
+	if t.RevokedAt != nil {
+		return false
+	}
+	return t.ExpiresAt == nil || t.ExpiresAt.After(time.Now())
+}
+
+func (t *AccessToken) ValidProtocol() bool {
+	return slices.Contains(TokenProtocols, t.Protocol)
+}
@@ -0,0 +1,100 @@
+package model
+
+import (
+	stdpath "path"
+	"slices"
+	"strings"
+
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+)
+
+const (
+	AclSubjectUser = iota
+	AclSubjectGroup
+	AclSubjectGuest
+)
+
+// operations controlled by acl rules
+const (
+	AclList    = "list"
+	AclRead    = "read"
+	AclWrite   = "write"
+	AclRename  = "rename"
+	AclMove    = "move"
+	AclCopy    = "copy"
+	AclDelete  = "delete"
+	AclShare   = "share"
+	AclArchive = "archive"
+)
+
+var AclOperations = []string{AclList, AclRead, AclWrite, AclRename, AclMove, AclCopy, AclDelete, AclShare, AclArchive}
+
+// AclRule allows or denies a subject to do the operations on a path and everything in it.
+// Of the rules matching a path, the one with the deepest path decides and deny wins a tie.
+type AclRule struct {
+	ID uint `json:"id" gorm:"primaryKey"`
+	// Path is a path prefix, or a pattern of path.Match if it contains any of *?[
+	Path        string   `json:"path" binding:"required"`
+	SubjectType int      `json:"subject_type"`
+	SubjectID   uint     `json:"subject_id"` // id of the user or group, unused for guest
+	Allow       bool     `json:"allow"`
+	Operations  []string `json:"operations" gorm:"serializer:json;type:text"`
+	Remark      string   `json:"remark"`
+}
+
+func (r *AclRule) IsGlob() bool {
+	return strings.ContainsAny(r.Path, "*?[")
+}
+
+// AppliesTo reports whether the rule is about the user
+func (r *AclRule) AppliesTo(user *User) bool {
+	switch r.SubjectType {
+	case AclSubjectUser:
+		return r.SubjectID == user.ID
+	case AclSubjectGroup:
+		return slices.Contains(user.GroupIDs, r.SubjectID)
+	case AclSubjectGuest:
+		return user.IsGuest()
+	}
+	return false
+}
+
+// Match reports whether path is the path of the rule or in it,
+// and returns the depth of the rule to find the most specific one
+func (r *AclRule) Match(path string) (int, bool) {
+	depth := strings.Count(strings.TrimSuffix(r.Path, "/"), "/")
+	if !r.IsGlob() {
+		return depth, utils.IsSubPath(r.Path, path)
+	}
+	for p := utils.FixAndCleanPath(path); ; p = stdpath.Dir(p) {
+		if ok, _ := stdpath.Match(r.Path, p); ok {
+			return depth, true
+		}
+		if p == "/" {
+			return depth, false
+		}
+	}
+}
+
+// Below reports whether the rule may match a path in dirPath without matching dirPath itself,
+// a glob only has to match the first segments of the paths in dirPath as far as dirPath goes
+func (r *AclRule) Below(dirPath string) bool {
+	dirPath = utils.FixAndCleanPath(dirPath)
+	if !r.IsGlob() {
+		return r.Path != dirPath && utils.IsSubPath(dirPath, r.Path)
+	}
+	patterns := strings.Split(strings.Trim(r.Path, "/"), "/")
+	var dirs []string
+	if dirPath != "/" {
+		dirs = strings.Split(strings.Trim(dirPath, "/"), "/")
+	}
+	if len(patterns) <= len(dirs) {
+		return false
+	}
+	for i, dir := range dirs {
+		if ok, _ := stdpath.Match(patterns[i], dir); !ok {
+			return false
+		}
+	}
+	return true
+}
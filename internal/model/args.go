@@ -18,6 +18,19 @@ type ListArgs struct {
 	SkipHook           bool
 }
 
+type ListPageArgs struct {
+	ListArgs
+	Cursor string
+	Limit  int
+}
+
+// ObjPage is one page of a directory listing.
+// Next is the cursor of the following page, empty if it is the last one
+type ObjPage struct {
+	Objs []Obj
+	Next string
+}
+
 type LinkArgs struct {
 	IP       string
 	Header   http.Header
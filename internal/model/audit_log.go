@@ -0,0 +1,69 @@
+package model
+
+import "time"
+
+// actions of audit logs
+const (
+	AuditLogin = "login"
+
+	AuditMakeDir = "mkdir"
+	AuditRename  = "rename"
+	AuditMove    = "move"
+	AuditCopy    = "copy"
+	AuditRemove  = "remove"
+	AuditUpload  = "upload"
+	AuditLink    = "link"
+
+	AuditShareCreate = "share_create"
+	AuditShareUpdate = "share_update"
+	AuditShareAccess = "share_access"
+	AuditShareUpload = "share_upload"
+
+	AuditInternalShareCreate = "internal_share_create"
+	AuditInternalShareUpdate = "internal_share_update"
+	AuditInternalShareDelete = "internal_share_delete"
+
+	AuditStorageCreate  = "storage_create"
+	AuditStorageUpdate  = "storage_update"
+	AuditStorageDelete  = "storage_delete"
+	AuditStorageEnable  = "storage_enable"
+	AuditStorageDisable = "storage_disable"
+	AuditUserCreate     = "user_create"
+	AuditUserUpdate     = "user_update"
+	AuditUserDelete     = "user_delete"
+	AuditSettingSave    = "setting_save"
+	AuditSettingDelete  = "setting_delete"
+	AuditMetaCreate     = "meta_create"
+	AuditMetaUpdate     = "meta_update"
+	AuditMetaDelete     = "meta_delete"
+)
+
+// AuditLog records who did what on which path, by which protocol and from where
+type AuditLog struct {
+	ID       uint      `json:"id" gorm:"primaryKey"`
+	Time     time.Time `json:"time" gorm:"index"`
+	UserID   uint      `json:"user_id"`
+	Username string    `json:"username" gorm:"index"`
+	IP       string    `json:"ip"`
+	Protocol string    `json:"protocol"`
+	Action   string    `json:"action" gorm:"index"`
+	// Path is the object of the action, the paths, a username or the setting keys
+	Path    string `json:"path"`
+	DstPath string `json:"dst_path"`
+	// Detail is the method of a login, the id of a share or the url of an upload
+	Detail  string `json:"detail"`
+	Success bool   `json:"success"`
+	Error   string `json:"error"`
+}
+
+// AuditLogFilter selects audit logs, the empty fields match all
+type AuditLogFilter struct {
+	Username string     `json:"username" form:"username"`
+	Action   string     `json:"action" form:"action"`
+	Protocol string     `json:"protocol" form:"protocol"`
+	IP       string     `json:"ip" form:"ip"`
+	Path     string     `json:"path" form:"path"`
+	Success  *bool      `json:"success" form:"success"`
+	Start    *time.Time `json:"start" form:"start"`
+	End      *time.Time `json:"end" form:"end"`
+}
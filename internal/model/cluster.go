@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+// ClusterEvent is an event published by an instance of the cluster for the other ones
+type ClusterEvent struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Node      string    `json:"node"`
+	Kind      string    `json:"kind"`
+	Key       string    `json:"key"`
+	CreatedAt time.Time `json:"created_at" gorm:"index"`
+}
+
+// ClusterLease is held by the instance of the cluster that is the leader of Name until ExpiresAt
+type ClusterLease struct {
+	Name      string    `json:"name" gorm:"primaryKey;size:64"`
+	Node      string    `json:"node"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
@@ -0,0 +1,24 @@
+package model
+
+// ConflictPolicy decides what a copy or move does when the dst name is taken
+type ConflictPolicy string
+
+const (
+	// ConflictDefault leaves it to the driver, or to Put which writes over files
+	ConflictDefault   ConflictPolicy = ""
+	ConflictSkip      ConflictPolicy = "skip"
+	ConflictOverwrite ConflictPolicy = "overwrite"
+	// ConflictRename writes to a free name like "name (1).ext"
+	ConflictRename ConflictPolicy = "auto-rename"
+	// ConflictNewer writes over the dst object only if the src object is newer
+	ConflictNewer ConflictPolicy = "newer-wins"
+	ConflictFail  ConflictPolicy = "fail"
+)
+
+func (p ConflictPolicy) Valid() bool {
+	switch p {
+	case ConflictDefault, ConflictSkip, ConflictOverwrite, ConflictRename, ConflictNewer, ConflictFail:
+		return true
+	}
+	return false
+}
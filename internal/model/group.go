@@ -0,0 +1,21 @@
+package model
+
+// GroupRootsDir is the virtual folder in the base path of a user holding the root paths granted by the groups
+const GroupRootsDir = "Group folders"
+
+// Group shares permissions and accessible paths with all of its users
+type Group struct {
+	ID   uint   `json:"id" gorm:"primaryKey"`
+	Name string `json:"name" gorm:"unique" binding:"required"`
+	// Permission is granted to the users in addition to their own, see User.Permission for the bits
+	Permission int32 `json:"permission"`
+	// RootPaths are accessible by the users besides their base path,
+	// each of them is shown in GroupRootsDir of the users by its name
+	RootPaths []string `json:"root_paths" gorm:"serializer:json;type:text"`
+}
+
+// GroupRoot is a root path granted to a user by a group, shown in GroupRootsDir by the name
+type GroupRoot struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+}
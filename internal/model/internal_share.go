@@ -0,0 +1,45 @@
+package model
+
+import (
+	"slices"
+	"time"
+)
+
+// SharedWithMeDir is the virtual folder in the base path of a user holding the internal shares to the user
+const SharedWithMeDir = "Shared with me"
+
+// InternalShare shares a path with users and groups of OpenList, it's shown
+// in SharedWithMeDir of them by its name and resolved by User.JoinPath
+type InternalShare struct {
+	ID        uint   `json:"id" gorm:"primaryKey"`
+	CreatorID uint   `json:"creator_id" gorm:"index"`
+	Path      string `json:"path" binding:"required"`
+	// Name is shown in SharedWithMeDir, the name of the path if empty
+	Name     string `json:"name"`
+	UserIDs  []uint `json:"user_ids" gorm:"serializer:json;type:text"`
+	GroupIDs []uint `json:"group_ids" gorm:"serializer:json;type:text"`
+	// Writable allows the recipients to change what's in the path, as far as their permissions allow
+	Writable  bool       `json:"writable"`
+	ExpiresAt *time.Time `json:"expires_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+func (s *InternalShare) Expired() bool {
+	return s.ExpiresAt != nil && !s.ExpiresAt.After(time.Now())
+}
+
+// SharedWith reports whether the user is a recipient of the share, by itself or by a group
+func (s *InternalShare) SharedWith(u *User) bool {
+	if u.ID == s.CreatorID {
+		return false
+	}
+	if slices.Contains(s.UserIDs, u.ID) {
+		return true
+	}
+	for _, gid := range u.GroupIDs {
+		if slices.Contains(s.GroupIDs, gid) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,32 @@
+package model
+
+import (
+	"net/netip"
+	"slices"
+
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+)
+
+// IPRuleDownload is the protocol of ip rules for /d and /p downloads, the others are the ones of access tokens
+const IPRuleDownload = "download"
+
+var IPRuleProtocols = append(slices.Clone(TokenProtocols), IPRuleDownload)
+
+// IPRule allows or denies the clients in CIDRs to use a protocol.
+// A matching deny rule always denies, and with any allow rule of the global or a user's rules
+// the client has to match one of the allow rules there.
+type IPRule struct {
+	ID uint `json:"id" gorm:"primaryKey"`
+	// UserID is the user or guest the rule is for, 0 for all clients
+	UserID uint `json:"user_id" gorm:"index"`
+	// Protocol is one of IPRuleProtocols, empty for all of them
+	Protocol string   `json:"protocol"`
+	Allow    bool     `json:"allow"`
+	CIDRs    []string `json:"cidrs" gorm:"serializer:json;type:text"`
+	Remark   string   `json:"remark"`
+}
+
+// ParseCIDRs parses the CIDRs of the rule, where a single ip is taken as a CIDR of it only
+func (r *IPRule) ParseCIDRs() ([]netip.Prefix, error) {
+	return utils.ParseCIDRs(r.CIDRs)
+}
@@ -0,0 +1,8 @@
+package model
+
+// UserUsage is how much a user stores, it's counted from the files the user writes and removes
+type UserUsage struct {
+	UserID uint  `json:"user_id" gorm:"primaryKey"`
+	Bytes  int64 `json:"bytes"`
+	Files  int64 `json:"files"`
+}
@@ -0,0 +1,15 @@
+package model
+
+import "time"
+
+// S3Key is an access key pair of a user for the s3 server, the secret is
+// stored as it is since the signature of a request is checked with it
+type S3Key struct {
+	ID              uint       `json:"id" gorm:"primaryKey"`
+	UserID          uint       `json:"user_id" gorm:"index"`
+	Name            string     `json:"name" binding:"required"`
+	AccessKeyID     string     `json:"access_key_id" gorm:"uniqueIndex;size:32"`
+	SecretAccessKey string     `json:"-"`
+	LastUsedAt      *time.Time `json:"last_used_at"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
@@ -0,0 +1,32 @@
+package model
+
+import "time"
+
+const (
+	JobRunRunning   = "running"
+	JobRunSucceeded = "succeeded"
+	JobRunFailed    = "failed"
+	JobRunSkipped   = "skipped"
+)
+
+type ScheduledJob struct {
+	ID   uint   `json:"id" gorm:"primaryKey"`
+	Name string `json:"name" binding:"required"`
+	// Spec is a cron expression, like "0 3 * * *" or "@every 6h"
+	Spec string `json:"spec" binding:"required"`
+	Type string `json:"type" binding:"required"`
+	// Params is the json arguments of the job type
+	Params   string `json:"params" gorm:"type:text"`
+	Disabled bool   `json:"disabled"`
+}
+
+type ScheduledJobRun struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	JobID     uint       `json:"job_id" gorm:"index"`
+	Manual    bool       `json:"manual"`
+	Status    string     `json:"status"`
+	Error     string     `json:"error" gorm:"type:text"`
+	StartTime time.Time  `json:"start_time"`
+	EndTime   *time.Time `json:"end_time"`
+	Node      string     `json:"node"` // the instance of the cluster running it
+}
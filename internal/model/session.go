@@ -0,0 +1,15 @@
+package model
+
+import "time"
+
+// Session is a login of a user, whose id is in the login token.
+// The token stops working when the session expires, is idle for too long or is revoked.
+type Session struct {
+	ID         string    `json:"id" gorm:"primaryKey;size:32"`
+	UserID     uint      `json:"user_id" gorm:"index"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time `json:"expires_at" gorm:"index"`
+}
@@ -1,6 +1,20 @@
 package model
 
-import "time"
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+const (
+	SharingTypeFiles = iota
+	// SharingTypeUpload is a file request, where anyone with the link uploads into the folder of it
+	SharingTypeUpload
+)
 
 type SharingDB struct {
 	ID          string     `json:"id" gorm:"type:char(12);primaryKey"`
@@ -15,6 +29,30 @@ type SharingDB struct {
 	Readme      string     `json:"readme" gorm:"type:text"`
 	Header      string     `json:"header" gorm:"type:text"`
 	Sort
+	Type int `json:"type"`
+	// limits of a file request, where 0 is no limit
+	UploadListable bool   `json:"upload_listable"`
+	AllowedExts    string `json:"allowed_exts"` // comma separated, empty for all
+	MaxFileSize    int64  `json:"max_file_size"`
+	MaxTotalSize   int64  `json:"max_total_size"`
+	UploadedSize   int64  `json:"uploaded_size"`
+	MaxUploads     int    `json:"max_uploads"`
+	Uploads        int    `json:"uploads"`
+	// Traffic is the bytes served, and the sharing is disabled when it reaches MaxTraffic
+	MaxTraffic int64 `json:"max_traffic"`
+	Traffic    int64 `json:"traffic"`
+	// MaxSpeed limits the download speed of the sharing in KB/s, 0 for no limit
+	MaxSpeed int `json:"max_speed"`
+}
+
+// SharingUpload records a file uploaded through a file request
+type SharingUpload struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	SharingID string    `json:"sharing_id" gorm:"index;size:12"`
+	Path      string    `json:"path"`
+	Size      int64     `json:"size"`
+	IP        string    `json:"ip"`
+	CreatedAt time.Time `json:"created_at"`
 }
 
 type Sharing struct {
@@ -30,12 +68,18 @@ func (s *Sharing) Valid() bool {
 	if s.MaxAccessed > 0 && s.Accessed >= s.MaxAccessed {
 		return false
 	}
+	if s.MaxTraffic > 0 && s.Traffic >= s.MaxTraffic {
+		return false
+	}
 	if len(s.Files) == 0 {
 		return false
 	}
 	if s.Creator == nil || !s.Creator.CanShare() {
 		return false
 	}
+	if s.IsUpload() && (len(s.Files) != 1 || !s.Creator.CanWrite()) {
+		return false
+	}
 	if s.Expires != nil && !s.Expires.IsZero() && s.Expires.Before(time.Now()) {
 		return false
 	}
@@ -45,3 +89,96 @@ func (s *Sharing) Valid() bool {
 func (s *Sharing) Verify(pwd string) bool {
 	return s.Pwd == "" || s.Pwd == pwd
 }
+
+func (s *Sharing) IsUpload() bool {
+	return s.Type == SharingTypeUpload
+}
+
+// CheckUpload returns errs.FileRequestRefused if the file request doesn't accept the file,
+// size is -1 if it's unknown
+func (s *Sharing) CheckUpload(name string, size int64) error {
+	if s.AllowedExts != "" {
+		exts := strings.Split(strings.ToLower(s.AllowedExts), ",")
+		for i := range exts {
+			exts[i] = strings.TrimPrefix(strings.TrimSpace(exts[i]), ".")
+		}
+		if !slices.Contains(exts, utils.Ext(name)) {
+			return errors.WithMessagef(errs.FileRequestRefused, "the extension of [%s] is not allowed", name)
+		}
+	}
+	if size < 0 && (s.MaxFileSize > 0 || s.MaxTotalSize > 0) {
+		return errors.WithMessage(errs.FileRequestRefused, "the size of the file is required")
+	}
+	if s.MaxFileSize > 0 && size > s.MaxFileSize {
+		return errors.WithMessagef(errs.FileRequestRefused, "the file is larger than %d bytes", s.MaxFileSize)
+	}
+	if s.MaxTotalSize > 0 && s.UploadedSize+size > s.MaxTotalSize {
+		return errors.WithMessage(errs.FileRequestRefused, "the total size of uploads is reached")
+	}
+	if s.MaxUploads > 0 && s.Uploads >= s.MaxUploads {
+		return errors.WithMessage(errs.FileRequestRefused, "the number of uploads is reached")
+	}
+	return nil
+}
+
+// UploadLimit returns the most bytes the next upload may have, which is -1 if it's unlimited
+func (s *Sharing) UploadLimit() int64 {
+	limit := int64(-1)
+	if s.MaxFileSize > 0 {
+		limit = s.MaxFileSize
+	}
+	if s.MaxTotalSize > 0 {
+		left := max(s.MaxTotalSize-s.UploadedSize, 0)
+		if limit < 0 || left < limit {
+			limit = left
+		}
+	}
+	return limit
+}
+
+// types of sharing accesses
+const (
+	SharingAccessView     = "view"
+	SharingAccessDownload = "download"
+)
+
+// SharingAccessLog records a view or download of a sharing
+type SharingAccessLog struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	SharingID string    `json:"sharing_id" gorm:"index;size:12"`
+	Time      time.Time `json:"time" gorm:"index"`
+	Type      string    `json:"type"`
+	Path      string    `json:"path"`
+	IP        string    `json:"ip"`
+	Referer   string    `json:"referer"`
+	Bytes     int64     `json:"bytes"`
+}
+
+type SharingFileStats struct {
+	Path      string `json:"path"`
+	Downloads int64  `json:"downloads"`
+	Bytes     int64  `json:"bytes"`
+}
+
+type SharingRefererStats struct {
+	Referer string `json:"referer"`
+	Count   int64  `json:"count"`
+}
+
+type SharingDayStats struct {
+	Date      string `json:"date"`
+	Views     int64  `json:"views"`
+	Downloads int64  `json:"downloads"`
+	Bytes     int64  `json:"bytes"`
+}
+
+// SharingStats sums up the access logs of a sharing
+type SharingStats struct {
+	Views     int64                 `json:"views"`
+	Downloads int64                 `json:"downloads"`
+	Bytes     int64                 `json:"bytes"`
+	UniqueIPs int                   `json:"unique_ips"`
+	Files     []SharingFileStats    `json:"files"`
+	Referers  []SharingRefererStats `json:"referers"`
+	Timeline  []SharingDayStats     `json:"timeline"`
+}
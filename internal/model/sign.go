@@ -0,0 +1,18 @@
+package model
+
+import "time"
+
+// SignRevocation revokes the scoped signs whose key id or batch is ID
+type SignRevocation struct {
+	ID string `json:"id" gorm:"primaryKey;size:64"`
+	// ExpiresAt is when the revoked signs expire anyway and the revocation can be dropped, nil to keep it
+	ExpiresAt *time.Time `json:"expires_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// SignUsage counts the downloads by a scoped sign limited in them
+type SignUsage struct {
+	KeyID     string `gorm:"primaryKey;size:64"`
+	Uses      int
+	ExpiresAt *time.Time `gorm:"index"`
+}
@@ -21,6 +21,7 @@ type Storage struct {
 	EnableSign          bool      `json:"enable_sign"`
 	Sort
 	Proxy
+	Trash
 }
 
 type Sort struct {
@@ -38,6 +39,11 @@ type Proxy struct {
 	DisableProxySign bool `json:"disable_proxy_sign"`
 }
 
+type Trash struct {
+	// EnableTrash moves removed objects into a hidden trash folder instead of deleting them
+	EnableTrash bool `json:"enable_trash"`
+}
+
 func (s *Storage) GetStorage() *Storage {
 	return s
 }
@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+// TrashItem is an object removed from a storage with trash enabled.
+// The object is kept at TrashPath of the storage until it is restored or purged
+type TrashItem struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	StorageID    uint      `json:"storage_id" gorm:"index"`
+	MountPath    string    `json:"mount_path"`
+	OriginalPath string    `json:"original_path"` // full path of the object before it was removed
+	TrashPath    string    `json:"-"`             // actual path of the object in the storage
+	Name         string    `json:"name"`
+	IsDir        bool      `json:"is_dir"`
+	Size         int64     `json:"size"`
+	DeleterId    uint      `json:"deleter_id" gorm:"index"`
+	Deleter      string    `json:"deleter"`
+	DeletedAt    time.Time `json:"deleted_at" gorm:"index"`
+}
@@ -0,0 +1,12 @@
+package model
+
+// UploadSession is the state of a resumable upload, it's kept by the transfer task
+// so that the upload continues from the uploaded offset after a restart
+type UploadSession struct {
+	// Path and Size are of the dst file, a session of another file is not resumed
+	Path  string `json:"path"`
+	Size  int64  `json:"size"`
+	State string `json:"state"`
+	// Save persists the session after it's changed
+	Save func() `json:"-"`
+}
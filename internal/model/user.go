@@ -4,6 +4,9 @@ import (
 	"encoding/binary"
 	"encoding/json"
 	"fmt"
+	stdpath "path"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/OpenListTeam/OpenList/v4/internal/errs"
@@ -60,6 +63,148 @@ type User struct {
 	SsoID      string `json:"sso_id"` // unique by sso platform
 	Authn      string `gorm:"type:text" json:"-"`
 	AllowLdap  bool   `json:"allow_ldap" gorm:"default:true"`
+	// QuotaBytes and QuotaFiles limit what the user can store, 0 means no limit
+	QuotaBytes int64 `json:"quota_bytes"`
+	QuotaFiles int64 `json:"quota_files"`
+	// GroupIDs are the groups the user belongs to
+	GroupIDs []uint `json:"group_ids" gorm:"serializer:json;type:text"`
+
+	// granted by the groups, see SetGroups
+	groupPermission int32
+	groupRoots      []GroupRoot
+	// shared with the user by others, see SetInternalShares
+	internalShares []InternalShare
+	// set when authenticated by an access token, see WithAccessToken
+	accessToken *AccessToken
+}
+
+// readPermissions are the permission bits a read-only access token keeps
+const readPermissions = 1<<0 | 1<<1 | 1<<8 | 1<<10 | 1<<12
+
+// WithAccessToken returns a copy of the user restricted by the scopes of the access token
+func (u *User) WithAccessToken(t *AccessToken) (*User, error) {
+	res := *u
+	res.accessToken = t
+	if t.ReadOnly {
+		res.Permission &= readPermissions
+		res.groupPermission &= readPermissions
+	}
+	if t.SubPath != "" && t.SubPath != "/" {
+		p, err := u.JoinPath(t.SubPath)
+		if err != nil {
+			return nil, err
+		}
+		res.BasePath = p
+		res.groupRoots = nil
+		res.internalShares = nil
+	}
+	return &res, nil
+}
+
+// AccessToken returns the access token the user is authenticated by, nil for any other way
+func (u *User) AccessToken() *AccessToken {
+	return u.accessToken
+}
+
+// SetGroups grants the permissions and root paths of groups to the user
+func (u *User) SetGroups(groups []Group) {
+	u.groupPermission = 0
+	u.groupRoots = nil
+	names := make(map[string]int)
+	for _, g := range groups {
+		u.groupPermission |= g.Permission
+		for _, root := range g.RootPaths {
+			root = utils.FixAndCleanPath(root)
+			// the root itself can't be shown by a name, and what's in the base path is accessible anyway
+			if root == "/" || utils.IsSubPath(u.BasePath, root) || slices.Contains(u.RootPaths(), root) {
+				continue
+			}
+			r := GroupRoot{Name: stdpath.Base(root), Path: root}
+			names[r.Name]++
+			if n := names[r.Name]; n > 1 {
+				r.Name = fmt.Sprintf("%s (%d)", r.Name, n)
+			}
+			u.groupRoots = append(u.groupRoots, r)
+		}
+	}
+}
+
+// SetInternalShares makes the shares to the user accessible in SharedWithMeDir, their names are made unique
+func (u *User) SetInternalShares(shares []InternalShare) {
+	u.internalShares = nil
+	names := make(map[string]int)
+	for _, s := range shares {
+		s.Path = utils.FixAndCleanPath(s.Path)
+		if s.Name == "" {
+			s.Name = stdpath.Base(s.Path)
+			if s.Name == "/" {
+				s.Name = "root"
+			}
+		}
+		names[s.Name]++
+		if n := names[s.Name]; n > 1 {
+			s.Name = fmt.Sprintf("%s (%d)", s.Name, n)
+		}
+		u.internalShares = append(u.internalShares, s)
+	}
+}
+
+// InternalShares returns the shares to the user that aren't expired
+func (u *User) InternalShares() []InternalShare {
+	var res []InternalShare
+	for _, s := range u.internalShares {
+		if !s.Expired() {
+			res = append(res, s)
+		}
+	}
+	return res
+}
+
+// SharedDir is the real path JoinPath turns SharedWithMeDir into, which is listed as the shares to the user
+func (u *User) SharedDir() string {
+	return stdpath.Join(u.BasePath, SharedWithMeDir)
+}
+
+// InternalShareReadOnly reports whether the real path is accessible only by shares that aren't writable
+func (u *User) InternalShareReadOnly(realPath string) bool {
+	if u.CanAccessOwnPath(realPath) {
+		return false
+	}
+	readOnly := false
+	for _, s := range u.InternalShares() {
+		if utils.IsSubPath(s.Path, realPath) {
+			if s.Writable {
+				return false
+			}
+			readOnly = true
+		}
+	}
+	return readOnly
+}
+
+// EffectivePermission is the union of the permissions of the user and the groups
+func (u *User) EffectivePermission() int32 {
+	return u.Permission | u.groupPermission
+}
+
+// RootPaths returns the paths granted by the groups besides the base path
+func (u *User) RootPaths() []string {
+	return utils.MustSliceConvert(u.groupRoots, func(r GroupRoot) string { return r.Path })
+}
+
+// GroupRoots returns the paths granted by the groups by their names in GroupRootsDir
+func (u *User) GroupRoots() []GroupRoot {
+	return u.groupRoots
+}
+
+// GroupRootsPath is the real path JoinPath turns GroupRootsDir into, which is listed as the group roots
+func (u *User) GroupRootsPath() string {
+	return stdpath.Join(u.BasePath, GroupRootsDir)
+}
+
+// HasQuota reports whether the user is limited by any quota
+func (u *User) HasQuota() bool {
+	return u.QuotaBytes > 0 || u.QuotaFiles > 0
 }
 
 func (u *User) IsGuest() bool {
@@ -96,7 +241,7 @@ func CanSeeHides(permission int32) bool {
 }
 
 func (u *User) CanSeeHides() bool {
-	return CanSeeHides(u.Permission)
+	return CanSeeHides(u.EffectivePermission())
 }
 
 func CanAccessWithoutPassword(permission int32) bool {
@@ -104,7 +249,7 @@ func CanAccessWithoutPassword(permission int32) bool {
 }
 
 func (u *User) CanAccessWithoutPassword() bool {
-	return CanAccessWithoutPassword(u.Permission)
+	return CanAccessWithoutPassword(u.EffectivePermission())
 }
 
 func CanAddOfflineDownloadTasks(permission int32) bool {
@@ -112,7 +257,7 @@ func CanAddOfflineDownloadTasks(permission int32) bool {
 }
 
 func (u *User) CanAddOfflineDownloadTasks() bool {
-	return CanAddOfflineDownloadTasks(u.Permission)
+	return CanAddOfflineDownloadTasks(u.EffectivePermission())
 }
 
 func CanWrite(permission int32) bool {
@@ -120,7 +265,7 @@ func CanWrite(permission int32) bool {
 }
 
 func (u *User) CanWrite() bool {
-	return CanWrite(u.Permission)
+	return CanWrite(u.EffectivePermission())
 }
 
 func CanRename(permission int32) bool {
@@ -128,7 +273,7 @@ func CanRename(permission int32) bool {
 }
 
 func (u *User) CanRename() bool {
-	return CanRename(u.Permission)
+	return CanRename(u.EffectivePermission())
 }
 
 func CanMove(permission int32) bool {
@@ -136,7 +281,7 @@ func CanMove(permission int32) bool {
 }
 
 func (u *User) CanMove() bool {
-	return CanMove(u.Permission)
+	return CanMove(u.EffectivePermission())
 }
 
 func CanCopy(permission int32) bool {
@@ -144,7 +289,7 @@ func CanCopy(permission int32) bool {
 }
 
 func (u *User) CanCopy() bool {
-	return CanCopy(u.Permission)
+	return CanCopy(u.EffectivePermission())
 }
 
 func CanRemove(permission int32) bool {
@@ -152,7 +297,7 @@ func CanRemove(permission int32) bool {
 }
 
 func (u *User) CanRemove() bool {
-	return CanRemove(u.Permission)
+	return CanRemove(u.EffectivePermission())
 }
 
 func CanWebdavRead(permission int32) bool {
@@ -160,7 +305,7 @@ func CanWebdavRead(permission int32) bool {
 }
 
 func (u *User) CanWebdavRead() bool {
-	return CanWebdavRead(u.Permission)
+	return CanWebdavRead(u.EffectivePermission())
 }
 
 func CanWebdavManage(permission int32) bool {
@@ -168,7 +313,7 @@ func CanWebdavManage(permission int32) bool {
 }
 
 func (u *User) CanWebdavManage() bool {
-	return CanWebdavManage(u.Permission)
+	return CanWebdavManage(u.EffectivePermission())
 }
 
 func CanFTPAccess(permission int32) bool {
@@ -176,7 +321,7 @@ func CanFTPAccess(permission int32) bool {
 }
 
 func (u *User) CanFTPAccess() bool {
-	return CanFTPAccess(u.Permission)
+	return CanFTPAccess(u.EffectivePermission())
 }
 
 func CanFTPManage(permission int32) bool {
@@ -184,7 +329,7 @@ func CanFTPManage(permission int32) bool {
 }
 
 func (u *User) CanFTPManage() bool {
-	return CanFTPManage(u.Permission)
+	return CanFTPManage(u.EffectivePermission())
 }
 
 func CanReadArchives(permission int32) bool {
@@ -192,7 +337,7 @@ func CanReadArchives(permission int32) bool {
 }
 
 func (u *User) CanReadArchives() bool {
-	return CanReadArchives(u.Permission)
+	return CanReadArchives(u.EffectivePermission())
 }
 
 func CanDecompress(permission int32) bool {
@@ -200,7 +345,7 @@ func CanDecompress(permission int32) bool {
 }
 
 func (u *User) CanDecompress() bool {
-	return CanDecompress(u.Permission)
+	return CanDecompress(u.EffectivePermission())
 }
 
 func CanShare(permission int32) bool {
@@ -208,13 +353,83 @@ func CanShare(permission int32) bool {
 }
 
 func (u *User) CanShare() bool {
-	return CanShare(u.Permission)
+	return CanShare(u.EffectivePermission())
 }
 
+// JoinPath turns a path of the user into the real path, one in GroupRootsDir is in
+// the root path by the name instead of the base path, and one in SharedWithMeDir
+// is in the path of the internal share by the name
 func (u *User) JoinPath(reqPath string) (string, error) {
+	if len(u.groupRoots) > 0 || len(u.internalShares) > 0 {
+		p, err := utils.JoinBasePath("/", reqPath)
+		if err != nil {
+			return "", err
+		}
+		name, rest, _ := strings.Cut(strings.TrimPrefix(p, "/"), "/")
+		if name == SharedWithMeDir {
+			shareName, shareRest, _ := strings.Cut(rest, "/")
+			for _, s := range u.InternalShares() {
+				if s.Name == shareName {
+					return stdpath.Join(s.Path, shareRest), nil
+				}
+			}
+		}
+		if name == GroupRootsDir {
+			rootName, rootRest, _ := strings.Cut(rest, "/")
+			for _, r := range u.groupRoots {
+				if r.Name == rootName {
+					return stdpath.Join(r.Path, rootRest), nil
+				}
+			}
+		}
+	}
 	return utils.JoinBasePath(u.BasePath, reqPath)
 }
 
+// RelPath is the reverse of JoinPath, it returns the path of the user for a real path
+func (u *User) RelPath(realPath string) string {
+	for _, r := range u.groupRoots {
+		if utils.IsSubPath(r.Path, realPath) {
+			return stdpath.Join("/", GroupRootsDir, r.Name, strings.TrimPrefix(realPath, r.Path))
+		}
+	}
+	if !utils.IsSubPath(u.BasePath, realPath) {
+		for _, s := range u.InternalShares() {
+			if utils.IsSubPath(s.Path, realPath) {
+				return stdpath.Join("/", SharedWithMeDir, s.Name, strings.TrimPrefix(realPath, s.Path))
+			}
+		}
+	}
+	return utils.FixAndCleanPath(strings.TrimPrefix(realPath, u.BasePath))
+}
+
+// CanAccessPath reports whether the real path is in the base path, a root path or an internal share of the user
+func (u *User) CanAccessPath(realPath string) bool {
+	if u.CanAccessOwnPath(realPath) {
+		return true
+	}
+	for _, s := range u.InternalShares() {
+		if utils.IsSubPath(s.Path, realPath) {
+			return true
+		}
+	}
+	return false
+}
+
+// CanAccessOwnPath reports whether realPath is in the base path or a root path of the user,
+// not counting the paths shared with the user by others
+func (u *User) CanAccessOwnPath(realPath string) bool {
+	if utils.IsSubPath(u.BasePath, realPath) {
+		return true
+	}
+	for _, r := range u.groupRoots {
+		if utils.IsSubPath(r.Path, realPath) {
+			return true
+		}
+	}
+	return false
+}
+
 func StaticHash(password string) string {
 	return utils.HashData(utils.SHA256, []byte(fmt.Sprintf("%s-%s", password, StaticHashSalt)))
 }
@@ -0,0 +1,70 @@
+package model
+
+import (
+	"slices"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+)
+
+// Webhook posts the events it subscribes to to URL, signed with Secret
+type Webhook struct {
+	ID     uint   `json:"id" gorm:"primaryKey"`
+	Name   string `json:"name"`
+	URL    string `json:"url" binding:"required"`
+	Secret string `json:"secret"`
+	// Events are the types of events to post, empty for all of them
+	Events []string `json:"events" gorm:"serializer:json;type:text"`
+	// Paths limit the events to the ones about objects in them, empty for all events.
+	// Events not about any object only match webhooks without paths
+	Paths     []string  `json:"paths" gorm:"serializer:json;type:text"`
+	Disabled  bool      `json:"disabled"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Matches reports whether an event of typ about the paths should be posted by the webhook
+func (w *Webhook) Matches(typ string, paths ...string) bool {
+	if w.Disabled {
+		return false
+	}
+	if len(w.Events) > 0 && !slices.Contains(w.Events, typ) {
+		return false
+	}
+	if len(w.Paths) == 0 {
+		return true
+	}
+	for _, p := range paths {
+		if p == "" {
+			continue
+		}
+		for _, prefix := range w.Paths {
+			if utils.IsSubPath(prefix, p) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliveryDelivered = "delivered"
+	WebhookDeliveryFailed    = "failed"
+)
+
+// WebhookDelivery is an event to post by a webhook, kept until it is delivered
+// or runs out of attempts so that it survives restarts
+type WebhookDelivery struct {
+	ID            uint       `json:"id" gorm:"primaryKey"`
+	WebhookID     uint       `json:"webhook_id" gorm:"index"`
+	EventID       string     `json:"event_id"`
+	EventType     string     `json:"event_type"`
+	Payload       string     `json:"payload" gorm:"type:text"`
+	Status        string     `json:"status" gorm:"index"`
+	Attempts      int        `json:"attempts"`
+	NextAttemptAt time.Time  `json:"next_attempt_at" gorm:"index"`
+	LastError     string     `json:"last_error"`
+	ResponseCode  int        `json:"response_code"`
+	CreatedAt     time.Time  `json:"created_at" gorm:"index"`
+	DeliveredAt   *time.Time `json:"delivered_at"`
+}
@@ -16,6 +16,7 @@ import (
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
 	"github.com/OpenListTeam/OpenList/v4/internal/errs"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/tracing"
 	"github.com/OpenListTeam/OpenList/v4/pkg/http_range"
 	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
 	"github.com/pkg/errors"
@@ -292,6 +293,6 @@ func NewHttpClient() *http.Client {
 
 	return &http.Client{
 		Timeout:   time.Hour * 48,
-		Transport: transport,
+		Transport: tracing.Transport(transport),
 	}
 }
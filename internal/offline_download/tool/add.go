@@ -65,6 +65,10 @@ func AddURL(ctx context.Context, args *AddURLArgs) (task.TaskExtensionInfo, erro
 			return nil, errors.WithStack(errs.NotFolder)
 		}
 	}
+	// the size is unknown until downloaded, the file is checked again when it's put
+	if err = op.CheckQuota(ctx, args.DstDirPath, op.UnknownBytes, 1); err != nil {
+		return nil, err
+	}
 	// try putting url
 	if args.Tool == "SimpleHttp" {
 		err = tryPutUrl(ctx, args.DstDirPath, args.URL)
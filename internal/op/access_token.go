@@ -0,0 +1,92 @@
+package op
+
+import (
+	"strings"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils/random"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// the last used time is only written when it's older than this, not on every request
+const tokenLastUsedInterval = time.Minute
+
+func hashAccessToken(token string) string {
+	return utils.HashData(utils.SHA256, []byte(token))
+}
+
+func IsAccessToken(s string) bool {
+	return strings.HasPrefix(s, model.AccessTokenPrefix)
+}
+
+func GetAccessTokensByUserId(userId uint) ([]model.AccessToken, error) {
+	return db.GetAccessTokensByUserId(userId)
+}
+
+// CreateAccessToken creates t for the user and returns the token, which can't be got again
+func CreateAccessToken(user *model.User, t *model.AccessToken) (string, error) {
+	if !t.ValidProtocol() {
+		return "", errors.Errorf("unknown protocol [%s]", t.Protocol)
+	}
+	if t.SubPath != "" {
+		t.SubPath = utils.FixAndCleanPath(t.SubPath)
+		if _, err := user.JoinPath(t.SubPath); err != nil {
+			return "", err
+		}
+	}
+	token := model.AccessTokenPrefix + random.String(40)
+	t.ID = 0
+	t.UserID = user.ID
+	t.Hash = hashAccessToken(token)
+	t.LastUsedAt, t.RevokedAt = nil, nil
+	if err := db.CreateAccessToken(t); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// RevokeAccessToken revokes the token with id of the user, or of any user with userId 0
+func RevokeAccessToken(id, userId uint) error {
+	t, err := db.GetAccessTokenById(id)
+	if err != nil {
+		return err
+	}
+	if userId != 0 && t.UserID != userId {
+		return errors.WithStack(errs.ObjectNotFound)
+	}
+	if t.RevokedAt != nil {
+		return nil
+	}
+	return db.RevokeAccessToken(id, time.Now())
+}
+
+// AuthAccessToken returns the user of the token restricted by it, if the token is valid for the protocol.
+// With a non-empty username, the token has to belong to that user.
+func AuthAccessToken(username, token, protocol string) (*model.User, error) {
+	t, err := db.GetAccessTokenByHash(hashAccessToken(token))
+	if err != nil || !t.Valid() || t.Protocol != protocol {
+		return nil, errors.WithStack(errs.AccessTokenInvalid)
+	}
+	user, err := GetUserById(t.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if username != "" && user.Username != username {
+		return nil, errors.WithStack(errs.AccessTokenInvalid)
+	}
+	if user.Disabled {
+		return nil, errors.New("user is disabled")
+	}
+	now := time.Now()
+	if t.LastUsedAt == nil || now.Sub(*t.LastUsedAt) > tokenLastUsedInterval {
+		if err := db.UpdateAccessTokenLastUsed(t.ID, now); err != nil {
+			log.Warnf("failed update last used time of access token: %+v", err)
+		}
+	}
+	return user.WithAccessToken(t)
+}
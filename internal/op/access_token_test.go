@@ -0,0 +1,76 @@
+package op_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/pkg/errors"
+)
+
+func TestAccessToken(t *testing.T) {
+	user := &model.User{Username: "token_test", BasePath: "/home/b", Permission: 0x7FFF}
+	if err := op.CreateUser(user); err != nil {
+		t.Fatalf("failed to create user: %+v", err)
+	}
+	at := &model.AccessToken{Name: "laptop", Protocol: model.TokenWebdav, ReadOnly: true, SubPath: "/docs"}
+	token, err := op.CreateAccessToken(user, at)
+	if err != nil {
+		t.Fatalf("failed to create access token: %+v", err)
+	}
+	if !op.IsAccessToken(token) {
+		t.Fatalf("expected an access token, got %s", token)
+	}
+
+	if _, err = op.AuthAccessToken("token_test", token, model.TokenFtp); !errors.Is(err, errs.AccessTokenInvalid) {
+		t.Errorf("expected the token to be refused for ftp, got %v", err)
+	}
+	if _, err = op.AuthAccessToken("other", token, model.TokenWebdav); !errors.Is(err, errs.AccessTokenInvalid) {
+		t.Errorf("expected the token to be refused for another user, got %v", err)
+	}
+	tokenUser, err := op.AuthAccessToken("token_test", token, model.TokenWebdav)
+	if err != nil {
+		t.Fatalf("failed to auth access token: %+v", err)
+	}
+	if tokenUser.CanWrite() || !tokenUser.CanWebdavRead() {
+		t.Errorf("expected a read-only user, got %b", tokenUser.EffectivePermission())
+	}
+	// a meta may grant writes without the permission, which the token still refuses
+	if err = op.CheckAcl(tokenUser, model.AclWrite, "/home/b/docs/a.txt"); !errors.Is(err, errs.PermissionDenied) {
+		t.Errorf("expected a read-only token to be refused to write, got %v", err)
+	}
+	if err = op.CheckAcl(tokenUser, model.AclRead, "/home/b/docs/a.txt"); err != nil {
+		t.Errorf("expected a read-only token to read, got %v", err)
+	}
+	if p, _ := tokenUser.JoinPath("/a.txt"); p != "/home/b/docs/a.txt" {
+		t.Errorf("expected the path in the sub path, got %s", p)
+	}
+	if err = op.UpdateUser(tokenUser); !errors.Is(err, errs.ByAccessToken) {
+		t.Errorf("expected the user of an access token not to be saved, got %v", err)
+	}
+	tokens, err := op.GetAccessTokensByUserId(user.ID)
+	if err != nil || len(tokens) != 1 || tokens[0].LastUsedAt == nil {
+		t.Errorf("expected the last used time to be set, got %+v: %v", tokens, err)
+	}
+
+	if err = op.RevokeAccessToken(at.ID, user.ID+1); err == nil {
+		t.Errorf("expected the token of another user not to be revoked")
+	}
+	if err = op.RevokeAccessToken(at.ID, user.ID); err != nil {
+		t.Fatalf("failed to revoke: %+v", err)
+	}
+	if _, err = op.AuthAccessToken("token_test", token, model.TokenWebdav); !errors.Is(err, errs.AccessTokenInvalid) {
+		t.Errorf("expected a revoked token to be refused, got %v", err)
+	}
+
+	expired := time.Now().Add(-time.Minute)
+	token, err = op.CreateAccessToken(user, &model.AccessToken{Name: "old", Protocol: model.TokenApi, ExpiresAt: &expired})
+	if err != nil {
+		t.Fatalf("failed to create access token: %+v", err)
+	}
+	if _, err = op.AuthAccessToken("", token, model.TokenApi); !errors.Is(err, errs.AccessTokenInvalid) {
+		t.Errorf("expected an expired token to be refused, got %v", err)
+	}
+}
@@ -0,0 +1,168 @@
+package op
+
+import (
+	stdpath "path"
+	"slices"
+	"sync"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+// all the acl rules are checked for every operation, so they're kept in memory
+var (
+	aclRules  []model.AclRule
+	aclLoaded bool
+	aclMu     sync.RWMutex
+)
+
+func getAclRules() ([]model.AclRule, error) {
+	aclMu.RLock()
+	if aclLoaded {
+		defer aclMu.RUnlock()
+		return aclRules, nil
+	}
+	aclMu.RUnlock()
+	aclMu.Lock()
+	defer aclMu.Unlock()
+	if !aclLoaded {
+		rules, err := db.GetAllAclRules()
+		if err != nil {
+			return nil, err
+		}
+		aclRules, aclLoaded = rules, true
+	}
+	return aclRules, nil
+}
+
+func clearAclRules() {
+	aclMu.Lock()
+	defer aclMu.Unlock()
+	aclRules, aclLoaded = nil, false
+}
+
+func GetAclRules(pageIndex, pageSize int) ([]model.AclRule, int64, error) {
+	return db.GetAclRules(pageIndex, pageSize)
+}
+
+func GetAclRuleById(id uint) (*model.AclRule, error) {
+	return db.GetAclRuleById(id)
+}
+
+func validateAclRule(r *model.AclRule) error {
+	if !r.IsGlob() {
+		r.Path = utils.FixAndCleanPath(r.Path)
+	}
+	for _, o := range r.Operations {
+		if !slices.Contains(model.AclOperations, o) {
+			return errors.Errorf("unknown operation [%s]", o)
+		}
+	}
+	return nil
+}
+
+func CreateAclRule(r *model.AclRule) error {
+	if err := validateAclRule(r); err != nil {
+		return err
+	}
+	defer changed(clusterACL, "")
+	return db.CreateAclRule(r)
+}
+
+func UpdateAclRule(r *model.AclRule) error {
+	if _, err := db.GetAclRuleById(r.ID); err != nil {
+		return err
+	}
+	if err := validateAclRule(r); err != nil {
+		return err
+	}
+	defer changed(clusterACL, "")
+	return db.UpdateAclRule(r)
+}
+
+func DeleteAclRuleById(id uint) error {
+	defer changed(clusterACL, "")
+	return db.DeleteAclRuleById(id)
+}
+
+// operations allowed on a path the user can only read, by an internal share that isn't writable or a read-only access token
+var readOnlyOperations = []string{model.AclList, model.AclRead, model.AclCopy, model.AclArchive}
+
+// CheckAcl returns errs.PermissionDenied if the acl rules deny the user to do the operation on path,
+// or if path is shared with the user read-only, or the user is authenticated by a read-only access
+// token. Nothing else is denied without a rule, and to the admin or without a user like in internal jobs.
+func CheckAcl(user *model.User, operation, path string) error {
+	if user == nil {
+		return nil
+	}
+	// the permissions of a read-only token are masked, but a meta may still grant writes
+	if t := user.AccessToken(); t != nil && t.ReadOnly && !slices.Contains(readOnlyOperations, operation) {
+		return errors.WithMessagef(errs.PermissionDenied, "%s [%s] by a read-only access token", operation, path)
+	}
+	if user.IsAdmin() {
+		return nil
+	}
+	if user.InternalShareReadOnly(path) && !slices.Contains(readOnlyOperations, operation) {
+		return errors.WithMessagef(errs.PermissionDenied, "[%s] is shared read-only", path)
+	}
+	rules, err := getAclRules()
+	if err != nil {
+		return err
+	}
+	var decisive *model.AclRule
+	decisiveDepth := -1
+	for i := range rules {
+		r := &rules[i]
+		if !slices.Contains(r.Operations, operation) || !r.AppliesTo(user) {
+			continue
+		}
+		depth, ok := r.Match(path)
+		if !ok {
+			continue
+		}
+		if depth > decisiveDepth || depth == decisiveDepth && !r.Allow {
+			decisive, decisiveDepth = r, depth
+		}
+	}
+	if decisive != nil && !decisive.Allow {
+		return errors.WithMessagef(errs.PermissionDenied, "%s [%s] is denied", operation, path)
+	}
+	return nil
+}
+
+// CheckAclTree is CheckAcl for an operation on the dir at path that changes everything in it,
+// which is denied as well if a rule denies the operation on any path in the dir
+func CheckAclTree(user *model.User, operation, path string) error {
+	if err := CheckAcl(user, operation, path); err != nil || user == nil || user.IsAdmin() {
+		return err
+	}
+	rules, err := getAclRules()
+	if err != nil {
+		return err
+	}
+	for i := range rules {
+		r := &rules[i]
+		if !r.Allow && slices.Contains(r.Operations, operation) && r.AppliesTo(user) && r.Below(path) {
+			return errors.WithMessagef(errs.PermissionDenied, "%s [%s] is denied in [%s]", operation, r.Path, path)
+		}
+	}
+	return nil
+}
+
+// FilterAcl drops the objs in dirPath that the user can't do the operation on
+func FilterAcl(user *model.User, operation, dirPath string, objs []model.Obj) []model.Obj {
+	if rules, _ := getAclRules(); len(rules) == 0 || user == nil || user.IsAdmin() {
+		return objs
+	}
+	// objs may be cached, so they're not filtered in place
+	res := make([]model.Obj, 0, len(objs))
+	for _, obj := range objs {
+		if CheckAcl(user, operation, stdpath.Join(dirPath, obj.GetName())) == nil {
+			res = append(res, obj)
+		}
+	}
+	return res
+}
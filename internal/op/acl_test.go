@@ -0,0 +1,105 @@
+package op_test
+
+import (
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/pkg/errors"
+)
+
+func TestCheckAcl(t *testing.T) {
+	rules := []model.AclRule{
+		{Path: "/storage", SubjectType: model.AclSubjectGroup, SubjectID: 7, Operations: model.AclOperations},
+		{Path: "/storage/sub/", SubjectType: model.AclSubjectGroup, SubjectID: 7, Allow: true, Operations: []string{model.AclList, model.AclRead}},
+		{Path: "/storage/*/keep", SubjectType: model.AclSubjectGroup, SubjectID: 7, Operations: []string{model.AclRead}},
+		{Path: "/storage/sub/open", SubjectType: model.AclSubjectUser, SubjectID: 100, Allow: true, Operations: []string{model.AclRead}},
+	}
+	for i := range rules {
+		if err := op.CreateAclRule(&rules[i]); err != nil {
+			t.Fatalf("failed to create acl rule: %+v", err)
+		}
+	}
+	defer func() {
+		for _, r := range rules {
+			_ = op.DeleteAclRuleById(r.ID)
+		}
+	}()
+	contractor := &model.User{ID: 100, GroupIDs: []uint{7}}
+	other := &model.User{ID: 101}
+	admin := &model.User{ID: 102, Role: model.ADMIN, GroupIDs: []uint{7}}
+	for _, c := range []struct {
+		user      *model.User
+		operation string
+		path      string
+		allowed   bool
+	}{
+		{contractor, model.AclRead, "/storage/sub/a.txt", true},
+		{contractor, model.AclList, "/storage/sub", true},
+		{contractor, model.AclWrite, "/storage/sub/a.txt", false},
+		{contractor, model.AclList, "/storage", false},
+		{contractor, model.AclRead, "/storage/other/a.txt", false},
+		{contractor, model.AclRead, "/storage/sub/keep/a.txt", false},
+		{contractor, model.AclRead, "/storage/sub/open/keep", true},
+		{contractor, model.AclRead, "/another/a.txt", true},
+		{other, model.AclWrite, "/storage/a.txt", true},
+		{admin, model.AclWrite, "/storage/a.txt", true},
+	} {
+		err := op.CheckAcl(c.user, c.operation, c.path)
+		if c.allowed && err != nil || !c.allowed && !errors.Is(err, errs.PermissionDenied) {
+			t.Errorf("expected %s [%s] by user %d to be allowed: %v, got %v", c.operation, c.path, c.user.ID, c.allowed, err)
+		}
+	}
+
+	objs := []model.Obj{&model.Object{Name: "sub"}, &model.Object{Name: "other"}}
+	if filtered := op.FilterAcl(contractor, model.AclList, "/storage", objs); len(filtered) != 1 || filtered[0].GetName() != "sub" {
+		t.Errorf("expected only sub to be listed, got %+v", filtered)
+	}
+	if len(objs) != 2 {
+		t.Errorf("expected the objs not to be changed")
+	}
+}
+
+func TestCheckAclTree(t *testing.T) {
+	rules := []model.AclRule{
+		{Path: "/tree/dir/keep", SubjectType: model.AclSubjectUser, SubjectID: 110, Operations: []string{model.AclDelete}},
+		{Path: "/tree/*/sub/*.key", SubjectType: model.AclSubjectUser, SubjectID: 110, Operations: []string{model.AclMove}},
+		{Path: "/tree/open/keep", SubjectType: model.AclSubjectUser, SubjectID: 110, Allow: true, Operations: []string{model.AclDelete}},
+	}
+	for i := range rules {
+		if err := op.CreateAclRule(&rules[i]); err != nil {
+			t.Fatalf("failed to create acl rule: %+v", err)
+		}
+	}
+	defer func() {
+		for _, r := range rules {
+			_ = op.DeleteAclRuleById(r.ID)
+		}
+	}()
+	user := &model.User{ID: 110}
+	for _, c := range []struct {
+		operation string
+		path      string
+		allowed   bool
+	}{
+		{model.AclDelete, "/tree/dir", false},
+		{model.AclDelete, "/tree", false},
+		{model.AclDelete, "/", false},
+		{model.AclDelete, "/tree/dir/keep", false},
+		{model.AclDelete, "/tree/dir/other", true},
+		{model.AclDelete, "/tree/open", true},
+		{model.AclDelete, "/tree/directory", true},
+		{model.AclCopy, "/tree/dir", true},
+		{model.AclMove, "/tree", false},
+		{model.AclMove, "/tree/a", false},
+		{model.AclMove, "/tree/a/sub", false},
+		{model.AclMove, "/tree/a/other", true},
+		{model.AclMove, "/other", true},
+	} {
+		err := op.CheckAclTree(user, c.operation, c.path)
+		if c.allowed && err != nil || !c.allowed && !errors.Is(err, errs.PermissionDenied) {
+			t.Errorf("expected %s the tree of [%s] to be allowed: %v, got %v", c.operation, c.path, c.allowed, err)
+		}
+	}
+}
@@ -512,7 +512,7 @@ func ArchiveDecompress(ctx context.Context, storage driver.Driver, srcPath, dstD
 		if err == nil {
 			if len(newObjs) > 0 {
 				if !storage.Config().NoCache {
-					if cache, exist := Cache.dirCache.Get(Key(storage, dstDirPath)); exist {
+					if cache, exist := Cache.getDirectory(Key(storage, dstDirPath)); exist {
 						for _, newObj := range newObjs {
 							cache.UpdateObject(newObj.GetName(), newObj)
 						}
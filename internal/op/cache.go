@@ -2,6 +2,7 @@ package op
 
 import (
 	stdpath "path"
+	"strings"
 	"sync"
 	"time"
 
@@ -13,6 +14,7 @@ import (
 
 type CacheManager struct {
 	dirCache     *cache.KeyedCache[*directoryCache]       // Cache for directory listings
+	pageCache    *cache.KeyedCache[*directoryPages]       // Cache for partially listed directories
 	linkCache    *cache.TypedCache[*objWithLink]          // Cache for file links
 	userCache    *cache.KeyedCache[*model.User]           // Cache for user data
 	settingCache *cache.KeyedCache[any]                   // Cache for settings
@@ -22,6 +24,7 @@ type CacheManager struct {
 func NewCacheManager() *CacheManager {
 	return &CacheManager{
 		dirCache:     cache.NewKeyedCache[*directoryCache](time.Minute * 5),
+		pageCache:    cache.NewKeyedCache[*directoryPages](time.Minute * 5),
 		linkCache:    cache.NewTypedCache[*objWithLink](time.Minute * 30),
 		userCache:    cache.NewKeyedCache[*model.User](time.Hour),
 		settingCache: cache.NewKeyedCache[any](time.Hour),
@@ -41,11 +44,17 @@ func (cm *CacheManager) DeleteDirectoryTree(storage driver.Driver, dirPath strin
 	if storage.Config().NoCache {
 		return
 	}
-	cm.deleteDirectoryTree(Key(storage, dirPath))
+	key := Key(storage, dirPath)
+	cm.deleteDirectoryTree(key)
+	// the persisted listings of the subdirectories may not be reachable from the tree
+	if !strings.HasSuffix(key, "/") {
+		key += "/"
+	}
+	cm.dirCache.DeleteStoredPrefix(key)
 }
 func (cm *CacheManager) deleteDirectoryTree(key string) {
-	if dirCache, exists := cm.dirCache.Pop(key); exists {
-		for _, obj := range dirCache.objs {
+	deleteChildren := func(objs []model.Obj) {
+		for _, obj := range objs {
 			if obj.IsDir() {
 				cm.deleteDirectoryTree(stdpath.Join(key, obj.GetName()))
 			} else {
@@ -53,6 +62,27 @@ func (cm *CacheManager) deleteDirectoryTree(key string) {
 			}
 		}
 	}
+	if dirCache, exists := cm.dirCache.Pop(key); exists {
+		deleteChildren(dirCache.objs)
+	}
+	if pages, exists := cm.pageCache.Pop(key); exists {
+		pages.mu.Lock()
+		defer pages.mu.Unlock()
+		for _, page := range pages.pages {
+			deleteChildren(page.Objs)
+		}
+	}
+}
+
+// deleteObjectPath drops the link of the object at key, its listings if it's a directory
+// and the listing of its parent, after it was changed by another instance of the cluster
+func (cm *CacheManager) deleteObjectPath(key string) {
+	cm.linkCache.DeleteKey(key)
+	cm.deleteDirectoryTree(key)
+	cm.dirCache.DeleteStoredPrefix(strings.TrimSuffix(key, "/") + "/")
+	parent := stdpath.Dir(key)
+	cm.dirCache.Delete(parent)
+	cm.pageCache.Delete(parent)
 }
 
 // remove directory from dirCache
@@ -60,7 +90,19 @@ func (cm *CacheManager) DeleteDirectory(storage driver.Driver, dirPath string) {
 	if storage.Config().NoCache {
 		return
 	}
-	cm.dirCache.Delete(Key(storage, dirPath))
+	key := Key(storage, dirPath)
+	cm.dirCache.Delete(key)
+	cm.pageCache.Delete(key)
+}
+
+// get the cached listing of a directory to update it in place.
+// partially listed pages can't be patched, so they are dropped instead,
+// and so is the persisted listing, which would be outdated
+func (cm *CacheManager) getDirectory(key string) (*directoryCache, bool) {
+	cm.pageCache.Delete(key)
+	dc, ok := cm.dirCache.Get(key)
+	cm.dirCache.DeleteStored(key)
+	return dc, ok
 }
 
 // remove object from dirCache.
@@ -75,7 +117,7 @@ func (cm *CacheManager) removeDirectoryObject(storage driver.Driver, dirPath str
 	if storage.Config().NoCache {
 		return
 	}
-	if cache, exist := cm.dirCache.Get(key); exist {
+	if cache, exist := cm.getDirectory(key); exist {
 		if obj.IsDir() {
 			cm.deleteDirectoryTree(stdpath.Join(key, obj.GetName()))
 		}
@@ -98,6 +140,11 @@ func (cm *CacheManager) DeleteUser(username string) {
 	cm.userCache.Delete(username)
 }
 
+// remove all users from cache, e.g. when the groups granting them permissions change
+func (cm *CacheManager) ClearUsers() {
+	cm.userCache.Clear()
+}
+
 // caches setting
 func (cm *CacheManager) SetSetting(key string, setting *model.SettingItem) {
 	cm.settingCache.Set(key, setting)
@@ -147,6 +194,7 @@ func (cm *CacheManager) InvalidateStorageDetails(storage driver.Driver) {
 // clears all caches
 func (cm *CacheManager) ClearAll() {
 	cm.dirCache.Clear()
+	cm.pageCache.Clear()
 	cm.linkCache.Clear()
 	cm.userCache.Clear()
 	cm.settingCache.Clear()
@@ -226,3 +274,70 @@ func (dc *directoryCache) GetSortedObjects(meta driver.Meta) []model.Obj {
 	dc.dirtyFlags = 0
 	return sorted
 }
+
+// directoryPages holds the pages of a directory listed by a driver.PagedLister,
+// keyed by the cursor of each page. Once all pages from the first one to the
+// last one are present, they are merged into a directoryCache.
+type directoryPages struct {
+	pages map[string]*model.ObjPage
+	mu    sync.Mutex
+}
+
+// setDirectoryPage caches a page of the directory, and returns all objs of it
+// when the listing became complete
+func (cm *CacheManager) setDirectoryPage(key, cursor string, page *model.ObjPage, ttl time.Duration) ([]model.Obj, bool) {
+	pages, exists := cm.pageCache.Get(key)
+	if !exists {
+		pages = &directoryPages{pages: make(map[string]*model.ObjPage)}
+		cm.pageCache.SetWithTTL(key, pages, ttl)
+	}
+	pages.mu.Lock()
+	defer pages.mu.Unlock()
+	pages.pages[cursor] = page
+
+	var objs []model.Obj
+	next := ""
+	for range len(pages.pages) {
+		p, ok := pages.pages[next]
+		if !ok {
+			return nil, false
+		}
+		objs = append(objs, p.Objs...)
+		if p.Next == "" {
+			cm.pageCache.Delete(key)
+			return objs, true
+		}
+		next = p.Next
+	}
+	// the cursors form a loop, the driver is broken
+	return nil, false
+}
+
+func (cm *CacheManager) getDirectoryPage(key, cursor string) (*model.ObjPage, bool) {
+	pages, exists := cm.pageCache.Get(key)
+	if !exists {
+		return nil, false
+	}
+	pages.mu.Lock()
+	defer pages.mu.Unlock()
+	page, ok := pages.pages[cursor]
+	return page, ok
+}
+
+// findInDirectoryPages looks for an obj in the cached pages of a directory
+func (cm *CacheManager) findInDirectoryPages(key, name string) (model.Obj, bool) {
+	pages, exists := cm.pageCache.Get(key)
+	if !exists {
+		return nil, false
+	}
+	pages.mu.Lock()
+	defer pages.mu.Unlock()
+	for _, page := range pages.pages {
+		for _, obj := range page.Objs {
+			if obj.GetName() == name {
+				return obj, true
+			}
+		}
+	}
+	return nil, false
+}
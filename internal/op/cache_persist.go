@@ -0,0 +1,131 @@
+package op
+
+import (
+	"context"
+	stdpath "path"
+	"strings"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/cache"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// Persist keeps the directory listings and the storage details in db too, so that they're
+// served right after a restart. The links and users aren't, links hold readers and expire
+// soon, and users are read from the database quickly anyway
+func (cm *CacheManager) Persist(db *cache.BoltDB) {
+	cm.dirCache.Persist(db.Store("dir"), cache.Codec[*directoryCache]{
+		Encode: encodeDirectoryCache,
+		Decode: decodeDirectoryCache,
+	}, refreshRestoredDirectory)
+	cm.detailCache.Persist(db.Store("detail"), cache.Codec[*model.StorageDetails]{
+		Encode: func(details *model.StorageDetails) ([]byte, bool) {
+			data, err := utils.Json.Marshal(details)
+			return data, err == nil
+		},
+		Decode: func(data []byte) (*model.StorageDetails, error) {
+			details := new(model.StorageDetails)
+			return details, utils.Json.Unmarshal(data, details)
+		},
+	}, nil)
+}
+
+const (
+	storedObject = iota
+	storedObjThumb
+	storedObjectURL
+	storedObjThumbURL
+)
+
+// storedObj is an obj of a persisted listing. Only the objs of the types of model are stored,
+// the listings of the drivers with their own types stay in memory, as the drivers need them back
+type storedObj struct {
+	model.Object
+	Kind      int    `json:"kind"`
+	Hash      string `json:"hash,omitempty"`
+	Thumbnail string `json:"thumbnail,omitempty"`
+	Url       string `json:"url,omitempty"`
+	// Wrapped is set for the objs whose name was mapped by wrapObjName
+	Wrapped bool `json:"wrapped,omitempty"`
+}
+
+func encodeDirectoryCache(dc *directoryCache) ([]byte, bool) {
+	dc.mu.RLock()
+	defer dc.mu.RUnlock()
+	objs := make([]storedObj, 0, len(dc.objs))
+	for _, obj := range dc.objs {
+		var s storedObj
+		if w, ok := obj.(*model.ObjWrapName); ok {
+			s.Wrapped, obj = true, w.Obj
+		}
+		switch o := obj.(type) {
+		case *model.Object:
+			s.Object, s.Kind = *o, storedObject
+		case *model.ObjThumb:
+			s.Object, s.Thumbnail, s.Kind = o.Object, o.Thumbnail.Thumbnail, storedObjThumb
+		case *model.ObjectURL:
+			s.Object, s.Url, s.Kind = o.Object, o.Url.Url, storedObjectURL
+		case *model.ObjThumbURL:
+			s.Object, s.Thumbnail, s.Url, s.Kind = o.Object, o.Thumbnail.Thumbnail, o.Url.Url, storedObjThumbURL
+		default:
+			return nil, false
+		}
+		if len(s.HashInfo.Export()) > 0 {
+			s.Hash = s.HashInfo.String()
+		}
+		objs = append(objs, s)
+	}
+	data, err := utils.Json.Marshal(objs)
+	return data, err == nil
+}
+
+func decodeDirectoryCache(data []byte) (*directoryCache, error) {
+	var stored []storedObj
+	if err := utils.Json.Unmarshal(data, &stored); err != nil {
+		return nil, err
+	}
+	objs := make([]model.Obj, 0, len(stored))
+	for _, s := range stored {
+		if s.Hash != "" {
+			s.HashInfo = utils.FromString(s.Hash)
+		}
+		var obj model.Obj
+		switch s.Kind {
+		case storedObjThumb:
+			obj = &model.ObjThumb{Object: s.Object, Thumbnail: model.Thumbnail{Thumbnail: s.Thumbnail}}
+		case storedObjectURL:
+			obj = &model.ObjectURL{Object: s.Object, Url: model.Url{Url: s.Url}}
+		case storedObjThumbURL:
+			obj = &model.ObjThumbURL{Object: s.Object, Thumbnail: model.Thumbnail{Thumbnail: s.Thumbnail}, Url: model.Url{Url: s.Url}}
+		default:
+			obj = &s.Object
+		}
+		if s.Wrapped {
+			obj = model.WrapObjName(obj)
+		}
+		objs = append(objs, obj)
+	}
+	return newDirectoryCache(objs), nil
+}
+
+// refreshRestoredDirectory lists the directory read back from the persistent cache again in background,
+// the listing from before the restart is served meanwhile
+func refreshRestoredDirectory(key string, _ *directoryCache) {
+	go func() {
+		// the key starts with the mount path of the storage, balanced ones included
+		mountPath := key
+		storage, err := GetStorageByMountPath(mountPath)
+		for err != nil && mountPath != "/" {
+			mountPath = stdpath.Dir(mountPath)
+			storage, err = GetStorageByMountPath(mountPath)
+		}
+		if err != nil {
+			return
+		}
+		actualPath := utils.FixAndCleanPath(strings.TrimPrefix(key, mountPath))
+		if _, err = List(context.Background(), storage, actualPath, model.ListArgs{Refresh: true}); err != nil {
+			log.Warnf("failed refresh restored listing of %s: %+v", key, err)
+		}
+	}()
+}
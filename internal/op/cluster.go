@@ -0,0 +1,138 @@
+package op
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/cluster"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/driver"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// the kinds of the cluster events about the state kept in memory, which the other instances drop or reload
+const (
+	clusterStorage  = "storage"  // key: id of the storage, reloaded from the database
+	clusterPath     = "path"     // key: full path of a changed object, its listings and links are dropped
+	clusterSettings = "settings" // key: the changed keys separated by commas, their hooks run again
+	clusterUser     = "user"     // key: username
+	clusterUsers    = "users"
+	clusterMeta     = "meta"    // key: path of the meta
+	clusterSharing  = "sharing" // key: id of the sharing
+	clusterACL      = "acl"
+	clusterIPRules  = "ip_rules"
+	clusterWebhooks = "webhooks"
+	clusterSigns    = "sign_revocations"
+	clusterS3Keys   = "s3_keys"
+	clusterSessions = "sessions" // key: id of the revoked session, empty for all
+)
+
+func init() {
+	handle := func(kind string, f func(key string)) {
+		cluster.Handle(kind, func(_ context.Context, key string) error {
+			f(key)
+			return nil
+		})
+	}
+	cluster.Handle(clusterStorage, reloadStorage)
+	cluster.Handle(clusterSettings, reloadSettings)
+	handle(clusterPath, Cache.deleteObjectPath)
+	handle(clusterUser, func(username string) {
+		adminUser, guestUser = nil, nil
+		Cache.DeleteUser(username)
+		clearQuotaOwners()
+	})
+	handle(clusterUsers, func(string) { clearUsers() })
+	handle(clusterMeta, func(path string) { metaCache.Del(path) })
+	handle(clusterSharing, func(sid string) { sharingCache.Del(sid) })
+	handle(clusterACL, func(string) { clearAclRules() })
+	handle(clusterIPRules, func(string) { clearIPRules() })
+	handle(clusterWebhooks, func(string) { clearWebhooks() })
+	handle(clusterSigns, func(string) { clearSignRevocations() })
+	handle(clusterS3Keys, func(string) { s3KeysChanged() })
+	handle(clusterSessions, clearSessionCache)
+}
+
+// changed drops the state of kind in memory after it was written to the database,
+// and tells the other instances of the cluster to drop it too
+func changed(kind, key string) {
+	if err := cluster.Apply(context.Background(), kind, key); err != nil {
+		log.Errorf("failed apply change of %s %s: %+v", kind, key, err)
+	}
+	cluster.Publish(kind, key)
+}
+
+// reloadStorage drops the storage with the id, and loads it again unless it's deleted or disabled
+func reloadStorage(ctx context.Context, key string) error {
+	id, err := strconv.ParseUint(key, 10, 64)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	var old driver.Driver
+	storagesMap.Range(func(_ string, d driver.Driver) bool {
+		if d.GetStorage().ID == uint(id) {
+			old = d
+			return false
+		}
+		return true
+	})
+	if old != nil {
+		if err := old.Drop(ctx); err != nil {
+			log.Warnf("failed drop storage %s: %+v", old.GetStorage().MountPath, err)
+		}
+		storagesMap.Delete(old.GetStorage().MountPath)
+		Cache.DeleteDirectoryTree(old, "/")
+		Cache.InvalidateStorageDetails(old)
+		go callStorageHooks("del", old)
+	}
+	storage, err := db.GetStorageById(uint(id))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		storageStatuses.Delete(uint(id))
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if storage.Disabled {
+		return nil
+	}
+	return LoadStorage(ctx, *storage)
+}
+
+// reloadSettings runs the hooks of the changed settings with their values in the database,
+// as they were run by the instance changing them
+func reloadSettings(_ context.Context, key string) error {
+	for _, k := range strings.Split(key, ",") {
+		if k == "" {
+			continue
+		}
+		item, err := db.GetSettingItemByKey(k)
+		if err != nil {
+			log.Warnf("failed get changed setting %s: %+v", k, err)
+			continue
+		}
+		if _, err := HandleSettingItemHook(item); err != nil {
+			log.Warnf("failed to execute hook on %s: %+v", k, err)
+		}
+	}
+	SettingCacheUpdate()
+	return nil
+}
+
+// publishObjChange tells the other instances of the cluster to drop what they cached about
+// the object at the actual path of storage, the instance itself already updated its caches
+func publishObjChange(storage driver.Driver, path string) {
+	if !cluster.Enabled() || storage.Config().NoCache {
+		return
+	}
+	cluster.Publish(clusterPath, Key(storage, path))
+}
+
+// publishStorageChange tells the other instances of the cluster to reload the storage with the id,
+// not on the changes saved by the drivers themselves, each instance keeps its own driver state
+func publishStorageChange(id uint) {
+	cluster.Publish(clusterStorage, strconv.FormatUint(uint64(id), 10))
+}
@@ -0,0 +1,77 @@
+package op_test
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/cluster"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+)
+
+// the storage is changed in the database as by another instance, then the event of the change is applied
+func TestClusterStorageReload(t *testing.T) {
+	ctx := context.Background()
+	id := createLocalStorage(t, "", model.Storage{MountPath: "/cluster_test"}).GetStorage().ID
+	key := strconv.FormatUint(uint64(id), 10)
+
+	storage, err := db.GetStorageById(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	storage.MountPath = "/cluster_test_renamed"
+	if err = db.UpdateStorage(storage); err != nil {
+		t.Fatal(err)
+	}
+	if err = cluster.Apply(ctx, "storage", key); err != nil {
+		t.Fatalf("failed to apply storage change: %+v", err)
+	}
+	if op.HasStorage("/cluster_test") || !op.HasStorage("/cluster_test_renamed") {
+		t.Errorf("expected the storage to be reloaded at its new mount path")
+	}
+
+	storage.Disabled = true
+	if err = db.UpdateStorage(storage); err != nil {
+		t.Fatal(err)
+	}
+	if err = cluster.Apply(ctx, "storage", key); err != nil {
+		t.Fatalf("failed to apply storage change: %+v", err)
+	}
+	if op.HasStorage("/cluster_test_renamed") {
+		t.Errorf("expected the disabled storage to be dropped")
+	}
+
+	if err = db.DeleteStorageById(id); err != nil {
+		t.Fatal(err)
+	}
+	if err = cluster.Apply(ctx, "storage", key); err != nil {
+		t.Errorf("expected a deleted storage to be ignored, got %+v", err)
+	}
+}
+
+func TestClusterUserInvalidation(t *testing.T) {
+	u := &model.User{Username: "cluster_test", Password: "password", Role: model.GENERAL, BasePath: "/"}
+	if err := op.CreateUser(u); err != nil {
+		t.Fatalf("failed to create user: %+v", err)
+	}
+	defer op.DeleteUserById(u.ID)
+	if _, err := op.GetUserByName(u.Username); err != nil {
+		t.Fatal(err)
+	}
+	u.BasePath = "/cluster"
+	if err := db.UpdateUser(u); err != nil {
+		t.Fatal(err)
+	}
+	if err := cluster.Apply(context.Background(), "user", u.Username); err != nil {
+		t.Fatal(err)
+	}
+	user, err := op.GetUserByName(u.Username)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.BasePath != "/cluster" {
+		t.Errorf("expected the user to be read again, got base path %s", user.BasePath)
+	}
+}
@@ -0,0 +1,169 @@
+package op
+
+import (
+	"context"
+	"fmt"
+	stdpath "path"
+	"strings"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/driver"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils/random"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// ConflictAction is how an object is written to a dst dir, according to the conflict policy
+type ConflictAction uint8
+
+const (
+	// ConflictWrite writes the object, over the existing one if any
+	ConflictWrite ConflictAction = iota
+	// ConflictReplace removes the existing object first, as it's of another type
+	ConflictReplace
+	// ConflictMerge writes the children of the src dir into the existing dir one by one
+	ConflictMerge
+	ConflictSkip
+	// ConflictRenameTo writes the object with the returned name
+	ConflictRenameTo
+)
+
+// ResolveConflict decides how src is written to the dir containing dst, the object with the same name.
+// dst is nil if there is none. taken tells whether a name is used in the dst dir, for auto-rename
+func ResolveConflict(src, dst model.Obj, policy model.ConflictPolicy, taken func(name string) bool) (ConflictAction, string, error) {
+	name := src.GetName()
+	if dst == nil || policy == model.ConflictDefault {
+		return ConflictWrite, name, nil
+	}
+	bothDir := src.IsDir() && dst.IsDir()
+	sameType := src.IsDir() == dst.IsDir()
+	switch policy {
+	case model.ConflictFail:
+		return 0, "", errors.WithMessagef(errs.ObjectAlreadyExists, "[%s]", name)
+	case model.ConflictRename:
+		return ConflictRenameTo, AutoRename(name, src.IsDir(), taken), nil
+	case model.ConflictNewer:
+		if bothDir {
+			return ConflictMerge, name, nil
+		}
+		if !src.ModTime().After(dst.ModTime()) {
+			return ConflictSkip, name, nil
+		}
+	case model.ConflictSkip:
+		if bothDir {
+			return ConflictMerge, name, nil
+		}
+		return ConflictSkip, name, nil
+	}
+	// overwrite, or newer-wins with a newer src
+	if bothDir {
+		return ConflictMerge, name, nil
+	}
+	if !sameType {
+		return ConflictReplace, name, nil
+	}
+	return ConflictWrite, name, nil
+}
+
+// AutoRename returns the first free name of "name (1).ext", "name (2).ext" and so on
+func AutoRename(name string, isDir bool, taken func(name string) bool) string {
+	base, ext := name, ""
+	if !isDir {
+		ext = stdpath.Ext(name)
+		base = strings.TrimSuffix(name, ext)
+	}
+	for i := 1; ; i++ {
+		newName := fmt.Sprintf("%s (%d)%s", base, i, ext)
+		if !taken(newName) {
+			return newName
+		}
+	}
+}
+
+// applyConflictPolicy applies the conflict policy in ctx before a driver copies or moves
+// srcObj into dstDirPath. Drivers can't write to another name or merge dirs,
+// so errs.NotSupport is returned in these cases to fall back to the transfer task.
+// An object overwritten is renamed to a backup, which the caller drops once the
+// transfer succeeded or restores if it failed
+func applyConflictPolicy(ctx context.Context, storage driver.Driver, srcObj model.Obj, dstDirPath string) (skip bool, backup *conflictBackup, err error) {
+	policy, _ := ctx.Value(conf.ConflictPolicyKey).(model.ConflictPolicy)
+	if policy == model.ConflictDefault {
+		return false, nil, nil
+	}
+	dstPath := stdpath.Join(dstDirPath, srcObj.GetName())
+	dstObj, err := Get(ctx, storage, dstPath)
+	if err != nil {
+		if errs.IsObjectNotFound(err) {
+			return false, nil, nil
+		}
+		return false, nil, errors.WithMessage(err, "failed to get dst object")
+	}
+	action, _, err := ResolveConflict(srcObj, dstObj, policy, func(string) bool { return false })
+	if err != nil {
+		return false, nil, err
+	}
+	switch action {
+	case ConflictSkip:
+		return true, nil, nil
+	case ConflictMerge, ConflictRenameTo:
+		return false, nil, errors.WithStack(errs.NotSupport)
+	}
+	path, err := BackupReplaced(ctx, storage, dstPath)
+	if err != nil {
+		return false, nil, err
+	}
+	return false, &conflictBackup{ctx: ctx, storage: storage, path: path, dstPath: dstPath}, nil
+}
+
+// conflictBackup is an object overwritten by a copy or move, renamed until the transfer is done
+type conflictBackup struct {
+	ctx     context.Context
+	storage driver.Driver
+	path    string
+	dstPath string
+}
+
+// finish drops the backup after the transfer succeeded, or restores it if err isn't nil
+func (b *conflictBackup) finish(err error) {
+	if b != nil {
+		FinishReplaced(b.ctx, b.storage, b.path, b.dstPath, err != nil)
+	}
+}
+
+// BackupReplaced renames the object at dstPath, which is about to be overwritten, to a backup
+// next to it, since the driver may keep both objects with the same name. Overwriting removes
+// the object and everything in it, so the user of ctx must be allowed to delete them.
+// It returns the path of the backup, which is dropped or restored by FinishReplaced
+func BackupReplaced(ctx context.Context, storage driver.Driver, dstPath string) (string, error) {
+	user, _ := ctx.Value(conf.UserKey).(*model.User)
+	if err := CheckAclTree(user, model.AclDelete, Key(storage, dstPath)); err != nil {
+		return "", err
+	}
+	backupName := ".openlist_conflict_" + random.String(8) + "_" + stdpath.Base(dstPath)
+	if err := Rename(backupCtx(ctx), storage, dstPath, backupName); err != nil {
+		return "", errors.WithMessage(err, "failed to back up dst object")
+	}
+	return stdpath.Join(stdpath.Dir(dstPath), backupName), nil
+}
+
+// FinishReplaced drops the backup of the object overwritten at dstPath after the transfer
+// succeeded, or renames it back if restore, as the transfer failed
+func FinishReplaced(ctx context.Context, storage driver.Driver, backupPath, dstPath string, restore bool) {
+	ctx = backupCtx(ctx)
+	if restore {
+		if e := Rename(ctx, storage, backupPath, stdpath.Base(dstPath)); e != nil {
+			log.Errorf("failed restore overwritten [%s] from [%s]: %+v", dstPath, backupPath, e)
+		}
+		return
+	}
+	if e := removeObj(ctx, storage, backupPath, false); e != nil {
+		log.Warnf("failed remove overwritten [%s]: %+v", backupPath, e)
+	}
+}
+
+// backupCtx keeps the hooks and events of the objects out of renaming and removing a backup
+func backupCtx(ctx context.Context) context.Context {
+	return context.WithValue(context.WithValue(ctx, conf.SkipHookKey, struct{}{}), conf.SkipEventKey, struct{}{})
+}
@@ -0,0 +1,93 @@
+package op_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/pkg/errors"
+)
+
+func TestResolveConflict(t *testing.T) {
+	now := time.Now()
+	older := &model.Object{Name: "a.txt", Modified: now.Add(-time.Hour)}
+	newer := &model.Object{Name: "a.txt", Modified: now}
+	dir := &model.Object{Name: "a.txt", IsFolder: true}
+	taken := func(name string) bool { return name == "a.txt" || name == "a (1).txt" }
+	tests := []struct {
+		policy   model.ConflictPolicy
+		src, dst model.Obj
+		action   op.ConflictAction
+		name     string
+	}{
+		{model.ConflictSkip, newer, nil, op.ConflictWrite, "a.txt"},
+		{model.ConflictSkip, newer, older, op.ConflictSkip, "a.txt"},
+		{model.ConflictSkip, dir, dir, op.ConflictMerge, "a.txt"},
+		{model.ConflictOverwrite, older, newer, op.ConflictWrite, "a.txt"},
+		{model.ConflictOverwrite, dir, newer, op.ConflictReplace, "a.txt"},
+		{model.ConflictNewer, newer, older, op.ConflictWrite, "a.txt"},
+		{model.ConflictNewer, older, newer, op.ConflictSkip, "a.txt"},
+		{model.ConflictRename, newer, older, op.ConflictRenameTo, "a (2).txt"},
+		{model.ConflictDefault, newer, older, op.ConflictWrite, "a.txt"},
+	}
+	for _, test := range tests {
+		action, name, err := op.ResolveConflict(test.src, test.dst, test.policy, taken)
+		if err != nil || action != test.action || name != test.name {
+			t.Errorf("%s: expected %d %s, got %d %s %v", test.policy, test.action, test.name, action, name, err)
+		}
+	}
+	if _, _, err := op.ResolveConflict(newer, older, model.ConflictFail, taken); err == nil {
+		t.Errorf("expected an error for the fail policy")
+	}
+	if name := op.AutoRename("a.dir", true, taken); name != "a.dir (1)" {
+		t.Errorf("unexpected dir name: %s", name)
+	}
+}
+
+func TestConflictOverwrite(t *testing.T) {
+	storage := createLocalStorage(t, "", model.Storage{MountPath: "/conflict_test"})
+	defer op.DeleteStorageById(context.Background(), storage.GetStorage().ID)
+	ctx := context.WithValue(context.Background(), conf.ConflictPolicyKey, model.ConflictOverwrite)
+	if err := putContent(ctx, storage, "/src", "a.txt", "newer"); err != nil {
+		t.Fatalf("failed to put: %+v", err)
+	}
+	if err := putContent(ctx, storage, "/dst", "a.txt", "old"); err != nil {
+		t.Fatalf("failed to put: %+v", err)
+	}
+	dstSize := func() int64 {
+		objs, err := op.List(ctx, storage, "/dst", model.ListArgs{Refresh: true})
+		if err != nil || len(objs) != 1 || objs[0].GetName() != "a.txt" {
+			t.Fatalf("expected only a.txt in /dst, got %+v: %v", objs, err)
+		}
+		return objs[0].GetSize()
+	}
+
+	// the local driver can't copy, so the overwritten object is restored
+	if err := op.Copy(ctx, storage, "/src/a.txt", "/dst"); !errors.Is(err, errs.NotImplement) {
+		t.Errorf("expected the copy not to be implemented, got %v", err)
+	}
+	if size := dstSize(); size != 3 {
+		t.Errorf("expected the dst to be restored, got size %d", size)
+	}
+
+	rule := &model.AclRule{Path: "/conflict_test/dst", SubjectType: model.AclSubjectUser, SubjectID: 130, Operations: []string{model.AclDelete}}
+	if err := op.CreateAclRule(rule); err != nil {
+		t.Fatalf("failed to create acl rule: %+v", err)
+	}
+	defer op.DeleteAclRuleById(rule.ID)
+	userCtx := context.WithValue(ctx, conf.UserKey, &model.User{ID: 130})
+	if err := op.Move(userCtx, storage, "/src/a.txt", "/dst"); !errors.Is(err, errs.PermissionDenied) {
+		t.Errorf("expected overwriting an object the user can't delete to be denied, got %v", err)
+	}
+
+	if err := op.Move(ctx, storage, "/src/a.txt", "/dst"); err != nil {
+		t.Fatalf("failed to move: %+v", err)
+	}
+	if size := dstSize(); size != 5 {
+		t.Errorf("expected the dst to be overwritten, got size %d", size)
+	}
+}
@@ -173,6 +173,14 @@ func getMainItems(config driver.Config) []driver.Item {
 		Default:  "false",
 		Required: true,
 	})
+	if !config.NoUpload {
+		items = append(items, driver.Item{
+			Name:    "enable_trash",
+			Type:    conf.TypeBool,
+			Default: "false",
+			Help:    "Move removed objects into a hidden trash folder, they can be restored until purged",
+		})
+	}
 	return items
 }
 func getAdditionalItems(t reflect.Type, defaultRoot string) []driver.Item {
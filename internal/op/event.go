@@ -0,0 +1,53 @@
+package op
+
+import (
+	"context"
+	stdpath "path"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/driver"
+	"github.com/OpenListTeam/OpenList/v4/internal/event"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/generic_sync"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+)
+
+// storageStatuses are the last known statuses of the storages by id
+var storageStatuses generic_sync.MapOf[uint, string]
+
+// publishStorageStatus publishes an event if the status of storage changed since
+// it was last seen. Storages seen for the first time only do when they don't work
+func publishStorageStatus(storage *model.Storage) {
+	old, ok := storageStatuses.Load(storage.ID)
+	storageStatuses.Store(storage.ID, storage.Status)
+	if (ok && old == storage.Status) || (!ok && storage.Status == WORK) {
+		return
+	}
+	event.Publish(context.Background(), &event.Event{
+		Type: event.StorageStatusChanged,
+		Path: storage.MountPath,
+		Data: map[string]any{"id": storage.ID, "driver": storage.Driver, "old_status": old, "status": storage.Status},
+	})
+}
+
+// publishObjEvent publishes an event of the object at the actual path of storage,
+// dstPath is the new actual path of a moved object
+func publishObjEvent(ctx context.Context, typ event.Type, storage driver.Driver, path, dstPath string, isDir bool) {
+	publishObjChange(storage, path)
+	if dstPath != "" {
+		publishObjChange(storage, dstPath)
+	}
+	mountPath := storage.GetStorage().MountPath
+	e := &event.Event{
+		Type: typ,
+		Path: utils.GetFullPath(mountPath, path),
+		Data: map[string]any{"is_dir": isDir, "storage": mountPath},
+	}
+	if dstPath != "" {
+		e.DstPath = utils.GetFullPath(mountPath, dstPath)
+	}
+	event.Publish(ctx, e)
+}
+
+func publishMovedEvent(ctx context.Context, storage driver.Driver, srcPath, dstDirPath, dstName string, isDir bool) {
+	publishObjEvent(ctx, event.ObjectMoved, storage, srcPath, stdpath.Join(dstDirPath, dstName), isDir)
+}
@@ -10,8 +10,10 @@ import (
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
 	"github.com/OpenListTeam/OpenList/v4/internal/driver"
 	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/event"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/internal/stream"
+	"github.com/OpenListTeam/OpenList/v4/internal/tracing"
 	"github.com/OpenListTeam/OpenList/v4/pkg/singleflight"
 	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
 	"github.com/bmatcuk/doublestar/v4"
@@ -23,7 +25,9 @@ import (
 var listG singleflight.Group[[]model.Obj]
 
 // List files in storage, not contains virtual file
-func List(ctx context.Context, storage driver.Driver, path string, args model.ListArgs) ([]model.Obj, error) {
+func List(ctx context.Context, storage driver.Driver, path string, args model.ListArgs) (objs []model.Obj, err error) {
+	ctx, span := startSpan(ctx, "op.List", storage, path)
+	defer func() { tracing.End(span, err) }()
 	return list(ctx, storage, path, args, nil)
 }
 
@@ -35,7 +39,9 @@ func list(ctx context.Context, storage driver.Driver, path string, args model.Li
 	log.Debugf("op.List %s", path)
 	key := Key(storage, path)
 	if !args.Refresh {
-		if dirCache, exists := Cache.dirCache.Get(key); exists {
+		dirCache, exists := Cache.dirCache.Get(key)
+		cacheLookup(ctx, "dir", exists)
+		if exists {
 			log.Debugf("use cache when list %s", path)
 			objs := dirCache.GetSortedObjects(storage)
 			if resultValidator != nil {
@@ -57,7 +63,9 @@ func list(ctx context.Context, storage driver.Driver, path string, args model.Li
 		if !dir.IsDir() {
 			return nil, errors.WithStack(errs.NotFolder)
 		}
-		files, err := storage.List(ctx, dir, args)
+		dctx, done := observeDriverCall(ctx, storage, "list")
+		files, err := storage.List(dctx, dir, args)
+		done(err)
 		if err != nil {
 			return nil, errors.Wrapf(err, "failed to list objs")
 		}
@@ -80,33 +88,7 @@ func list(ctx context.Context, storage driver.Driver, path string, args model.Li
 			if len(files) > 0 {
 				log.Debugf("set cache: %s => %+v", key, files)
 
-				ttl := storage.GetStorage().CacheExpiration
-
-				customCachePolicies := storage.GetStorage().CustomCachePolicies
-				if len(customCachePolicies) > 0 {
-					configPolicies := strings.Split(customCachePolicies, "\n")
-					for _, configPolicy := range configPolicies {
-						pattern, ttlstr, ok := strings.Cut(strings.TrimSpace(configPolicy), ":")
-						if !ok {
-							log.Warnf("Malformed custom cache policy entry: %s in storage %s for path %s. Expected format: pattern:ttl", configPolicy, storage.GetStorage().MountPath, path)
-							continue
-						}
-						if match, err1 := doublestar.Match(pattern, path); err1 != nil {
-							log.Warnf("Invalid glob pattern in custom cache policy: %s, error: %v", pattern, err1)
-							continue
-						} else if !match {
-							continue
-						}
-
-						if configTtl, err1 := strconv.ParseInt(ttlstr, 10, 64); err1 == nil {
-							ttl = int(configTtl)
-							break
-						}
-					}
-				}
-
-				duration := time.Minute * time.Duration(ttl)
-				Cache.dirCache.SetWithTTL(key, newDirectoryCache(files), duration)
+				Cache.dirCache.SetWithTTL(key, newDirectoryCache(files), dirCacheTTL(storage, path))
 			} else {
 				log.Debugf("del cache: %s", key)
 				Cache.deleteDirectoryTree(key)
@@ -125,8 +107,185 @@ func list(ctx context.Context, storage driver.Driver, path string, args model.Li
 	return objs, nil
 }
 
+// dirCacheTTL returns the cache expiration of a directory,
+// the first matched custom cache policy takes precedence over the storage's one
+func dirCacheTTL(storage driver.Driver, path string) time.Duration {
+	ttl := storage.GetStorage().CacheExpiration
+
+	customCachePolicies := storage.GetStorage().CustomCachePolicies
+	if len(customCachePolicies) > 0 {
+		configPolicies := strings.Split(customCachePolicies, "\n")
+		for _, configPolicy := range configPolicies {
+			pattern, ttlstr, ok := strings.Cut(strings.TrimSpace(configPolicy), ":")
+			if !ok {
+				log.Warnf("Malformed custom cache policy entry: %s in storage %s for path %s. Expected format: pattern:ttl", configPolicy, storage.GetStorage().MountPath, path)
+				continue
+			}
+			if match, err1 := doublestar.Match(pattern, path); err1 != nil {
+				log.Warnf("Invalid glob pattern in custom cache policy: %s, error: %v", pattern, err1)
+				continue
+			} else if !match {
+				continue
+			}
+
+			if configTtl, err1 := strconv.ParseInt(ttlstr, 10, 64); err1 == nil {
+				ttl = int(configTtl)
+				break
+			}
+		}
+	}
+	return time.Minute * time.Duration(ttl)
+}
+
+const (
+	// DefaultPageSize is used when ListPage is called without a limit
+	DefaultPageSize = 1000
+
+	// cursors returned by ListPage are prefixed to know where they come from
+	offsetCursorPrefix = "o:" // offset in the full listing of the directory
+	driverCursorPrefix = "d:" // cursor of driver.PagedLister
+)
+
+var listPageG singleflight.Group[*model.ObjPage]
+
+// ListPage list one page of files in storage, not contains virtual file.
+// Storages implementing driver.PagedLister are listed page by page, the others
+// are listed entirely (and cached as usual) then sliced.
+// The returned cursor is opaque and only valid for the same path
+func ListPage(ctx context.Context, storage driver.Driver, path string, args model.ListPageArgs) (*model.ObjPage, error) {
+	if storage.Config().CheckStatus && storage.GetStorage().Status != WORK {
+		return nil, errors.WithMessagef(errs.StorageNotInit, "storage status: %s", storage.GetStorage().Status)
+	}
+	path = utils.FixAndCleanPath(path)
+	if args.Limit <= 0 {
+		args.Limit = DefaultPageSize
+	}
+	pager, ok := storage.(driver.PagedLister)
+	if ok && !strings.HasPrefix(args.Cursor, offsetCursorPrefix) {
+		_, cached := Cache.dirCache.Get(Key(storage, path))
+		if args.Cursor != "" || args.Refresh || !cached {
+			return listPage(ctx, storage, pager, path, args)
+		}
+	}
+
+	offset := 0
+	if args.Cursor != "" {
+		c, ok := strings.CutPrefix(args.Cursor, offsetCursorPrefix)
+		if !ok {
+			return nil, errors.WithStack(errs.InvalidCursor)
+		}
+		var err error
+		if offset, err = strconv.Atoi(c); err != nil || offset < 0 {
+			return nil, errors.WithStack(errs.InvalidCursor)
+		}
+	}
+	objs, err := List(ctx, storage, path, args.ListArgs)
+	if err != nil {
+		return nil, err
+	}
+	if offset >= len(objs) {
+		return &model.ObjPage{}, nil
+	}
+	end := min(offset+args.Limit, len(objs))
+	page := &model.ObjPage{Objs: objs[offset:end]}
+	if end < len(objs) {
+		page.Next = offsetCursorPrefix + strconv.Itoa(end)
+	}
+	return page, nil
+}
+
+func listPage(ctx context.Context, storage driver.Driver, pager driver.PagedLister, path string, args model.ListPageArgs) (*model.ObjPage, error) {
+	cursor, ok := strings.CutPrefix(args.Cursor, driverCursorPrefix)
+	if args.Cursor != "" && !ok {
+		return nil, errors.WithStack(errs.InvalidCursor)
+	}
+	log.Debugf("op.ListPage %s, cursor: %s", path, cursor)
+	key := Key(storage, path)
+	if args.Refresh && cursor == "" {
+		Cache.deleteDirectoryTree(key)
+	}
+	if !args.Refresh {
+		page, exists := Cache.getDirectoryPage(key, cursor)
+		cacheLookup(ctx, "dir_page", exists)
+		if exists {
+			log.Debugf("use cache when list page %s", path)
+			return wrapPageCursor(page), nil
+		}
+	}
+
+	page, err, _ := listPageG.Do(key+"\x00"+cursor, func() (*model.ObjPage, error) {
+		dir, err := GetUnwrap(ctx, storage, path)
+		if err != nil {
+			return nil, errors.WithMessage(err, "failed get dir")
+		}
+		if !dir.IsDir() {
+			return nil, errors.WithStack(errs.NotFolder)
+		}
+		dctx, done := observeDriverCall(ctx, storage, "list_page")
+		files, next, err := pager.ListPage(dctx, dir, model.ListPageArgs{
+			ListArgs: args.ListArgs,
+			Cursor:   cursor,
+			Limit:    args.Limit,
+		})
+		done(err)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to list page of objs")
+		}
+		wrapObjsName(storage, files)
+		// only sort inside the page, the full listing is sorted when it's complete
+		if storage.Config().LocalSort {
+			model.SortFiles(files, storage.GetStorage().OrderBy, storage.GetStorage().OrderDirection)
+		}
+		model.ExtractFolder(files, storage.GetStorage().ExtractFolder)
+		page := &model.ObjPage{Objs: files, Next: next}
+		if storage.Config().NoCache {
+			return page, nil
+		}
+
+		ttl := dirCacheTTL(storage, path)
+		all, complete := Cache.setDirectoryPage(key, cursor, page, ttl)
+		if !complete {
+			return page, nil
+		}
+		if len(all) == 0 {
+			Cache.deleteDirectoryTree(key)
+			return page, nil
+		}
+		if storage.Config().LocalSort {
+			model.SortFiles(all, storage.GetStorage().OrderBy, storage.GetStorage().OrderDirection)
+		}
+		model.ExtractFolder(all, storage.GetStorage().ExtractFolder)
+		Cache.dirCache.SetWithTTL(key, newDirectoryCache(all), ttl)
+		// the hooks expect the whole directory
+		if !args.SkipHook {
+			go func(reqPath string, files []model.Obj) {
+				HandleObjsUpdateHook(context.WithoutCancel(ctx), reqPath, files)
+			}(utils.GetFullPath(storage.GetStorage().MountPath, path), all)
+		}
+		return page, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return wrapPageCursor(page), nil
+}
+
+func wrapPageCursor(page *model.ObjPage) *model.ObjPage {
+	ret := &model.ObjPage{Objs: page.Objs}
+	if page.Next != "" {
+		ret.Next = driverCursorPrefix + page.Next
+	}
+	return ret
+}
+
 // Get object from list of files
-func Get(ctx context.Context, storage driver.Driver, path string, excludeTempObj ...bool) (model.Obj, error) {
+func Get(ctx context.Context, storage driver.Driver, path string, excludeTempObj ...bool) (obj model.Obj, err error) {
+	ctx, span := startSpan(ctx, "op.Get", storage, path)
+	defer func() { tracing.End(span, err) }()
+	return get(ctx, storage, path, excludeTempObj...)
+}
+
+func get(ctx context.Context, storage driver.Driver, path string, excludeTempObj ...bool) (model.Obj, error) {
 	if storage.Config().CheckStatus && storage.GetStorage().Status != WORK {
 		return nil, errors.WithMessagef(errs.StorageNotInit, "storage status: %s", storage.GetStorage().Status)
 	}
@@ -179,11 +338,17 @@ func Get(ctx context.Context, storage driver.Driver, path string, excludeTempObj
 				return f, nil
 			}
 		}
+	} else if f, ok := Cache.findInDirectoryPages(Key(storage, dir), name); ok {
+		if !excludeTemp || !model.ObjHasMask(f, model.Temp) {
+			return f, nil
+		}
 	}
 
 	// get the obj directly without list so that we can reduce the io
 	if g, ok := storage.(driver.Getter); ok {
-		obj, err := g.Get(ctx, path)
+		dctx, done := observeDriverCall(ctx, storage, "get")
+		obj, err := g.Get(dctx, path)
+		done(err)
 		if err == nil {
 			return obj, nil
 		}
@@ -225,7 +390,13 @@ func GetUnwrap(ctx context.Context, storage driver.Driver, path string) (model.O
 var linkG = singleflight.Group[*objWithLink]{}
 
 // Link get link, if is an url. should have an expiry time
-func Link(ctx context.Context, storage driver.Driver, path string, args model.LinkArgs) (*model.Link, model.Obj, error) {
+func Link(ctx context.Context, storage driver.Driver, path string, args model.LinkArgs) (l *model.Link, obj model.Obj, err error) {
+	ctx, span := startSpan(ctx, "op.Link", storage, path)
+	defer func() { tracing.End(span, err) }()
+	return getLink(ctx, storage, path, args)
+}
+
+func getLink(ctx context.Context, storage driver.Driver, path string, args model.LinkArgs) (*model.Link, model.Obj, error) {
 	if storage.Config().CheckStatus && storage.GetStorage().Status != WORK {
 		return nil, nil, errors.WithMessagef(errs.StorageNotInit, "storage status: %s", storage.GetStorage().Status)
 	}
@@ -242,7 +413,9 @@ func Link(ctx context.Context, storage driver.Driver, path string, args model.Li
 		typeKey += "/" + args.Header.Get("User-Agent")
 	}
 	key := Key(storage, path)
-	if ol, exists := Cache.linkCache.GetType(key, typeKey); exists {
+	ol, exists := Cache.linkCache.GetType(key, typeKey)
+	cacheLookup(ctx, "link", exists)
+	if exists {
 		if ol.link.Expiration != nil ||
 			ol.link.SyncClosers.AcquireReference() || !ol.link.RequireReference {
 			return ol.link, ol.obj, nil
@@ -258,7 +431,9 @@ func Link(ctx context.Context, storage driver.Driver, path string, args model.Li
 			return nil, errors.WithStack(errs.NotFile)
 		}
 
-		link, err := storage.Link(ctx, file, args)
+		dctx, done := observeDriverCall(ctx, storage, "link")
+		link, err := storage.Link(dctx, file, args)
+		done(err)
 		if err != nil {
 			return nil, errors.Wrapf(err, "failed get link")
 		}
@@ -294,11 +469,14 @@ func Other(ctx context.Context, storage driver.Driver, args model.FsOtherArgs) (
 	if err != nil {
 		return nil, errors.WithMessagef(err, "failed to get obj")
 	}
-	return o.Other(ctx, model.OtherArgs{
+	dctx, done := observeDriverCall(ctx, storage, "other")
+	res, err := o.Other(dctx, model.OtherArgs{
 		Obj:    obj,
 		Method: args.Method,
 		Data:   args.Data,
 	})
+	done(err)
+	return res, err
 }
 
 var mkdirG singleflight.Group[any]
@@ -308,6 +486,9 @@ func MakeDir(ctx context.Context, storage driver.Driver, path string) error {
 		return errors.WithMessagef(errs.StorageNotInit, "storage status: %s", storage.GetStorage().Status)
 	}
 	path = utils.FixAndCleanPath(path)
+	if err := checkTrashWrite(ctx, storage, path); err != nil {
+		return err
+	}
 	key := Key(storage, path)
 	_, err, _ := mkdirG.Do(key, func() (any, error) {
 		// check if dir exists
@@ -335,21 +516,24 @@ func MakeDir(ctx context.Context, storage driver.Driver, path string) error {
 		}
 
 		var newObj model.Obj
+		dctx, done := observeDriverCall(ctx, storage, "make_dir")
 		switch s := storage.(type) {
 		case driver.MkdirResult:
-			newObj, err = s.MakeDir(ctx, parentDir, dirName)
+			newObj, err = s.MakeDir(dctx, parentDir, dirName)
 		case driver.Mkdir:
-			err = s.MakeDir(ctx, parentDir, dirName)
+			err = s.MakeDir(dctx, parentDir, dirName)
 		default:
-			return nil, errs.NotImplement
+			err = errs.NotImplement
 		}
+		done(err)
 		if err != nil {
 			return nil, errors.WithStack(err)
 		}
+		publishObjEvent(ctx, event.ObjectCreated, storage, path, "", true)
 		if storage.Config().NoCache {
 			return nil, nil
 		}
-		if dirCache, exist := Cache.dirCache.Get(Key(storage, parentPath)); exist {
+		if dirCache, exist := Cache.getDirectory(Key(storage, parentPath)); exist {
 			if newObj == nil {
 				t := time.Now()
 				newObj = &model.Object{
@@ -367,7 +551,7 @@ func MakeDir(ctx context.Context, storage driver.Driver, path string) error {
 	return err
 }
 
-func Move(ctx context.Context, storage driver.Driver, srcPath, dstDirPath string) error {
+func Move(ctx context.Context, storage driver.Driver, srcPath, dstDirPath string) (err error) {
 	if storage.Config().CheckStatus && storage.GetStorage().Status != WORK {
 		return errors.WithMessagef(errs.StorageNotInit, "storage status: %s", storage.GetStorage().Status)
 	}
@@ -380,6 +564,9 @@ func Move(ctx context.Context, storage driver.Driver, srcPath, dstDirPath string
 	if dstDirPath == srcDirPath {
 		return errors.New("move in place")
 	}
+	if err := checkTrashWrite(ctx, storage, srcPath, dstDirPath); err != nil {
+		return err
+	}
 	srcRawObj, err := Get(ctx, storage, srcPath, true)
 	if err != nil {
 		return errors.WithMessage(err, "failed to get src object")
@@ -395,19 +582,48 @@ func Move(ctx context.Context, storage driver.Driver, srcPath, dstDirPath string
 	if model.ObjHasMask(dstDir, model.NoWrite) {
 		return errors.WithStack(errs.PermissionDenied)
 	}
+	skip, backup, err := applyConflictPolicy(ctx, storage, srcRawObj, dstDirPath)
+	if err != nil || skip {
+		return err
+	}
+	defer func() { backup.finish(err) }()
+	// a move between the paths of two users moves the usage too
+	srcOwner := quotaOwner(ctx, Key(storage, srcPath))
+	dstOwner := quotaOwner(ctx, Key(storage, stdpath.Join(dstDirPath, srcRawObj.GetName())))
+	var reservation *usageReservation
+	var bytes, files int64
+	if !sameUser(srcOwner, dstOwner) {
+		counter := dstOwner
+		if counter == nil || !counter.HasQuota() {
+			counter = srcOwner
+		}
+		if bytes, files, err = objUsage(ctx, storage, srcPath, srcRawObj, counter); err != nil {
+			return err
+		}
+		if reservation, err = reserveUsage(dstOwner, bytes, files); err != nil {
+			return err
+		}
+	}
 
 	var newObj model.Obj
+	dctx, done := observeDriverCall(ctx, storage, "move")
 	switch s := storage.(type) {
 	case driver.MoveResult:
-		newObj, err = s.Move(ctx, srcObj, dstDir)
+		newObj, err = s.Move(dctx, srcObj, dstDir)
 	case driver.Move:
-		err = s.Move(ctx, srcObj, dstDir)
+		err = s.Move(dctx, srcObj, dstDir)
 	default:
 		err = errs.NotImplement
 	}
+	done(err)
 	if err != nil {
+		reservation.release()
 		return errors.WithStack(err)
 	}
+	if !sameUser(srcOwner, dstOwner) {
+		addUsage(srcOwner, -bytes, -files)
+	}
+	publishMovedEvent(ctx, storage, srcPath, dstDirPath, srcRawObj.GetName(), srcRawObj.IsDir())
 
 	srcKey := Key(storage, srcDirPath)
 	dstKey := Key(storage, dstDirPath)
@@ -416,13 +632,13 @@ func Move(ctx context.Context, storage driver.Driver, srcPath, dstDirPath string
 		Cache.linkCache.DeleteKey(stdpath.Join(dstKey, srcRawObj.GetName()))
 	}
 	if !storage.Config().NoCache {
-		if cache, exist := Cache.dirCache.Get(srcKey); exist {
+		if cache, exist := Cache.getDirectory(srcKey); exist {
 			if srcRawObj.IsDir() {
 				Cache.deleteDirectoryTree(stdpath.Join(srcKey, srcRawObj.GetName()))
 			}
 			cache.RemoveObject(srcRawObj.GetName())
 		}
-		if cache, exist := Cache.dirCache.Get(dstKey); exist {
+		if cache, exist := Cache.getDirectory(dstKey); exist {
 			if newObj == nil {
 				newObj = &model.ObjWrapMask{Obj: srcRawObj, Mask: model.Temp}
 			} else {
@@ -451,6 +667,9 @@ func Rename(ctx context.Context, storage driver.Driver, srcPath, dstName string)
 	if utils.PathEqual(srcPath, "/") {
 		return errors.New("rename root folder is not allowed")
 	}
+	if err := checkTrashWrite(ctx, storage, srcPath, stdpath.Join(stdpath.Dir(srcPath), dstName)); err != nil {
+		return err
+	}
 	srcRawObj, err := Get(ctx, storage, srcPath, true)
 	if err != nil {
 		return errors.WithMessage(err, "failed to get src object")
@@ -461,17 +680,20 @@ func Rename(ctx context.Context, storage driver.Driver, srcPath, dstName string)
 	srcObj := model.UnwrapObjName(srcRawObj)
 
 	var newObj model.Obj
+	dctx, done := observeDriverCall(ctx, storage, "rename")
 	switch s := storage.(type) {
 	case driver.RenameResult:
-		newObj, err = s.Rename(ctx, srcObj, dstName)
+		newObj, err = s.Rename(dctx, srcObj, dstName)
 	case driver.Rename:
-		err = s.Rename(ctx, srcObj, dstName)
+		err = s.Rename(dctx, srcObj, dstName)
 	default:
-		return errs.NotImplement
+		err = errs.NotImplement
 	}
+	done(err)
 	if err != nil {
 		return errors.WithStack(err)
 	}
+	publishMovedEvent(ctx, storage, srcPath, stdpath.Dir(srcPath), dstName, srcRawObj.IsDir())
 
 	dirKey := Key(storage, stdpath.Dir(srcPath))
 	if !srcRawObj.IsDir() {
@@ -479,7 +701,7 @@ func Rename(ctx context.Context, storage driver.Driver, srcPath, dstName string)
 		Cache.linkCache.DeleteKey(stdpath.Join(dirKey, dstName))
 	}
 	if !storage.Config().NoCache {
-		if cache, exist := Cache.dirCache.Get(dirKey); exist {
+		if cache, exist := Cache.getDirectory(dirKey); exist {
 			if srcRawObj.IsDir() {
 				Cache.deleteDirectoryTree(stdpath.Join(dirKey, srcRawObj.GetName()))
 			}
@@ -504,7 +726,7 @@ func Rename(ctx context.Context, storage driver.Driver, srcPath, dstName string)
 }
 
 // Copy Just copy file[s] in a storage
-func Copy(ctx context.Context, storage driver.Driver, srcPath, dstDirPath string) error {
+func Copy(ctx context.Context, storage driver.Driver, srcPath, dstDirPath string) (err error) {
 	if storage.Config().CheckStatus && storage.GetStorage().Status != WORK {
 		return errors.WithMessagef(errs.StorageNotInit, "storage status: %s", storage.GetStorage().Status)
 	}
@@ -513,6 +735,9 @@ func Copy(ctx context.Context, storage driver.Driver, srcPath, dstDirPath string
 	if dstDirPath == stdpath.Dir(srcPath) {
 		return errors.New("copy in place")
 	}
+	if err := checkTrashWrite(ctx, storage, srcPath, dstDirPath); err != nil {
+		return err
+	}
 	srcRawObj, err := Get(ctx, storage, srcPath, true)
 	if err != nil {
 		return errors.WithMessage(err, "failed to get src object")
@@ -528,26 +753,44 @@ func Copy(ctx context.Context, storage driver.Driver, srcPath, dstDirPath string
 	if model.ObjHasMask(dstDir, model.NoWrite) {
 		return errors.WithStack(errs.PermissionDenied)
 	}
+	skip, backup, err := applyConflictPolicy(ctx, storage, srcRawObj, dstDirPath)
+	if err != nil || skip {
+		return err
+	}
+	defer func() { backup.finish(err) }()
+	owner := quotaOwner(ctx, Key(storage, stdpath.Join(dstDirPath, srcRawObj.GetName())))
+	bytes, files, err := objUsage(ctx, storage, srcPath, srcRawObj, owner)
+	if err != nil {
+		return err
+	}
+	reservation, err := reserveUsage(owner, bytes, files)
+	if err != nil {
+		return err
+	}
 
 	var newObj model.Obj
+	dctx, done := observeDriverCall(ctx, storage, "copy")
 	switch s := storage.(type) {
 	case driver.CopyResult:
-		newObj, err = s.Copy(ctx, srcObj, dstDir)
+		newObj, err = s.Copy(dctx, srcObj, dstDir)
 	case driver.Copy:
-		err = s.Copy(ctx, srcObj, dstDir)
+		err = s.Copy(dctx, srcObj, dstDir)
 	default:
 		err = errs.NotImplement
 	}
+	done(err)
 	if err != nil {
+		reservation.release()
 		return errors.WithStack(err)
 	}
+	publishObjEvent(ctx, event.ObjectCreated, storage, stdpath.Join(dstDirPath, srcRawObj.GetName()), "", srcRawObj.IsDir())
 
 	dstKey := Key(storage, dstDirPath)
 	if !srcRawObj.IsDir() {
 		Cache.linkCache.DeleteKey(stdpath.Join(dstKey, srcRawObj.GetName()))
 	}
 	if !storage.Config().NoCache {
-		if cache, exist := Cache.dirCache.Get(dstKey); exist {
+		if cache, exist := Cache.getDirectory(dstKey); exist {
 			if newObj == nil {
 				newObj = &model.ObjWrapMask{Obj: srcRawObj, Mask: model.Temp}
 			} else {
@@ -569,6 +812,11 @@ func Copy(ctx context.Context, storage driver.Driver, srcPath, dstDirPath string
 }
 
 func Remove(ctx context.Context, storage driver.Driver, path string) error {
+	return removeObj(ctx, storage, path, true)
+}
+
+// removeObj removes the object at path and frees its usage, to the trash if it's enabled and trash is true
+func removeObj(ctx context.Context, storage driver.Driver, path string, trash bool) error {
 	if storage.Config().CheckStatus && storage.GetStorage().Status != WORK {
 		return errors.WithMessagef(errs.StorageNotInit, "storage status: %s", storage.GetStorage().Status)
 	}
@@ -588,11 +836,32 @@ func Remove(ctx context.Context, storage driver.Driver, path string) error {
 	if model.ObjHasMask(rawObj, model.NoRemove) {
 		return errors.WithStack(errs.PermissionDenied)
 	}
-	dirPath := stdpath.Dir(path)
+	// the usage of a dir is counted before it's gone
+	owner := quotaOwner(ctx, Key(storage, path))
+	bytes, files, usageErr := objUsage(ctx, storage, path, rawObj, owner)
+	if usageErr != nil {
+		log.Errorf("failed count usage of [%s] before removing it: %+v", path, usageErr)
+	}
+	if trash && storage.GetStorage().EnableTrash && !IsTrashPath(path) {
+		err = moveToTrash(ctx, storage, path, rawObj)
+	} else {
+		err = remove(ctx, storage, path, rawObj)
+	}
+	if err == nil {
+		addUsage(owner, -bytes, -files)
+		publishObjEvent(ctx, event.ObjectDeleted, storage, path, "", rawObj.IsDir())
+	}
+	return err
+}
 
+func remove(ctx context.Context, storage driver.Driver, path string, rawObj model.Obj) error {
+	dirPath := stdpath.Dir(path)
+	var err error
 	switch s := storage.(type) {
 	case driver.Remove:
-		err = s.Remove(ctx, model.UnwrapObjName(rawObj))
+		dctx, done := observeDriverCall(ctx, storage, "remove")
+		err = s.Remove(dctx, model.UnwrapObjName(rawObj))
+		done(err)
 		if err == nil {
 			Cache.removeDirectoryObject(storage, dirPath, rawObj)
 		}
@@ -602,7 +871,13 @@ func Remove(ctx context.Context, storage driver.Driver, path string) error {
 	return errors.WithStack(err)
 }
 
-func Put(ctx context.Context, storage driver.Driver, dstDirPath string, file model.FileStreamer, up driver.UpdateProgress) error {
+func Put(ctx context.Context, storage driver.Driver, dstDirPath string, file model.FileStreamer, up driver.UpdateProgress) (err error) {
+	ctx, span := startSpan(ctx, "op.Put", storage, stdpath.Join(dstDirPath, file.GetName()))
+	defer func() { tracing.End(span, err) }()
+	return put(ctx, storage, dstDirPath, file, up)
+}
+
+func put(ctx context.Context, storage driver.Driver, dstDirPath string, file model.FileStreamer, up driver.UpdateProgress) error {
 	defer func() {
 		if err := file.Close(); err != nil {
 			log.Errorf("failed to close file streamer, %v", err)
@@ -620,18 +895,21 @@ func Put(ctx context.Context, storage driver.Driver, dstDirPath string, file mod
 	// if file exist and size = 0, delete it
 	dstDirPath = utils.FixAndCleanPath(dstDirPath)
 	dstPath := stdpath.Join(dstDirPath, file.GetName())
+	if err := checkTrashWrite(ctx, storage, dstPath); err != nil {
+		return err
+	}
 	tempName := file.GetName() + ".openlist_to_delete"
 	tempPath := stdpath.Join(dstDirPath, tempName)
 	fi, err := GetUnwrap(ctx, storage, dstPath)
 	if err == nil {
 		if fi.GetSize() == 0 {
-			err = Remove(ctx, storage, dstPath)
+			err = removeWithoutTrash(ctx, storage, dstPath)
 			if err != nil {
 				return errors.WithMessagef(err, "while uploading, failed remove existing file which size = 0")
 			}
 		} else if storage.Config().NoOverwriteUpload {
 			// try to rename old obj
-			err = Rename(ctx, storage, dstPath, tempName)
+			err = Rename(context.WithValue(ctx, conf.SkipEventKey, struct{}{}), storage, dstPath, tempName)
 			if err != nil {
 				return err
 			}
@@ -661,20 +939,44 @@ func Put(ctx context.Context, storage driver.Driver, dstDirPath string, file mod
 		log.Warnf("file size < 0, try to get full size from cache")
 		file.CacheFullAndWriter(nil, nil)
 	}
+	// the existing file is replaced
+	usageBytes, usageFiles := file.GetSize(), int64(1)
+	if fi != nil {
+		usageBytes -= fi.GetSize()
+		usageFiles = 0
+	}
+	reservation, err := reservePathUsage(ctx, Key(storage, dstPath), usageBytes, usageFiles)
+	if err != nil {
+		return err
+	}
 
 	var newObj model.Obj
-	switch s := storage.(type) {
-	case driver.PutResult:
-		newObj, err = s.Put(ctx, parentDir, file, up)
-	case driver.Put:
-		err = s.Put(ctx, parentDir, file, up)
-	default:
-		return errs.NotImplement
+	dctx, done := observeDriverCall(ctx, storage, "put")
+	session, _ := ctx.Value(conf.UploadSessionKey).(*model.UploadSession)
+	if s, ok := storage.(driver.ResumablePut); ok && session != nil && file.GetSize() > 0 {
+		newObj, err = putResumable(dctx, s, parentDir, dstPath, file, session, up)
+	} else {
+		switch s := storage.(type) {
+		case driver.PutResult:
+			newObj, err = s.Put(dctx, parentDir, file, up)
+		case driver.Put:
+			err = s.Put(dctx, parentDir, file, up)
+		default:
+			err = errs.NotImplement
+		}
 	}
-	if err == nil {
+	done(err)
+	if err != nil {
+		reservation.release()
+	} else {
+		if fi != nil {
+			publishObjEvent(ctx, event.ObjectUpdated, storage, dstPath, "", false)
+		} else {
+			publishObjEvent(ctx, event.ObjectCreated, storage, dstPath, "", false)
+		}
 		Cache.linkCache.DeleteKey(Key(storage, dstPath))
 		if !storage.Config().NoCache {
-			if cache, exist := Cache.dirCache.Get(Key(storage, dstDirPath)); exist {
+			if cache, exist := Cache.getDirectory(Key(storage, dstDirPath)); exist {
 				if newObj == nil {
 					newObj = &model.Object{
 						Name:     file.GetName(),
@@ -697,13 +999,13 @@ func Put(ctx context.Context, storage driver.Driver, dstDirPath string, file mod
 	if storage.Config().NoOverwriteUpload && fi != nil && fi.GetSize() > 0 {
 		if err != nil {
 			// upload failed, recover old obj
-			err := Rename(ctx, storage, tempPath, file.GetName())
+			err := Rename(context.WithValue(ctx, conf.SkipEventKey, struct{}{}), storage, tempPath, file.GetName())
 			if err != nil {
 				log.Errorf("failed recover old obj: %+v", err)
 			}
 		} else {
 			// upload success, remove old obj
-			err = Remove(ctx, storage, tempPath)
+			err = removeWithoutTrash(ctx, storage, tempPath)
 		}
 	}
 	return errors.WithStack(err)
@@ -715,6 +1017,9 @@ func PutURL(ctx context.Context, storage driver.Driver, dstDirPath, dstName, url
 	}
 	dstDirPath = utils.FixAndCleanPath(dstDirPath)
 	dstPath := stdpath.Join(dstDirPath, dstName)
+	if err := checkTrashWrite(ctx, storage, dstPath); err != nil {
+		return err
+	}
 
 	if _, err := Get(ctx, storage, dstPath); err == nil {
 		return errors.WithStack(errs.ObjectAlreadyExists)
@@ -730,19 +1035,32 @@ func PutURL(ctx context.Context, storage driver.Driver, dstDirPath, dstName, url
 	if model.ObjHasMask(dstDir, model.NoWrite) {
 		return errors.WithStack(errs.PermissionDenied)
 	}
+	reservation, err := reservePathUsage(ctx, Key(storage, dstPath), UnknownBytes, 1)
+	if err != nil {
+		return err
+	}
 	var newObj model.Obj
+	dctx, done := observeDriverCall(ctx, storage, "put_url")
 	switch s := storage.(type) {
 	case driver.PutURLResult:
-		newObj, err = s.PutURL(ctx, dstDir, dstName, url)
+		newObj, err = s.PutURL(dctx, dstDir, dstName, url)
 	case driver.PutURL:
-		err = s.PutURL(ctx, dstDir, dstName, url)
+		err = s.PutURL(dctx, dstDir, dstName, url)
 	default:
-		return errors.WithStack(errs.NotImplement)
+		err = errs.NotImplement
 	}
-	if err == nil {
+	done(err)
+	if err != nil {
+		reservation.release()
+	} else {
+		// the size is only known once the url is fetched
+		if reservation != nil && newObj != nil {
+			addUsage(reservation.user, max(newObj.GetSize(), 0), 0)
+		}
+		publishObjEvent(ctx, event.ObjectCreated, storage, dstPath, "", false)
 		Cache.linkCache.DeleteKey(Key(storage, dstPath))
 		if !storage.Config().NoCache {
-			if cache, exist := Cache.dirCache.Get(Key(storage, dstDirPath)); exist {
+			if cache, exist := Cache.getDirectory(Key(storage, dstDirPath)); exist {
 				if newObj == nil {
 					t := time.Now()
 					newObj = &model.Object{
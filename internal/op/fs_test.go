@@ -0,0 +1,109 @@
+package op_test
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/driver"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+)
+
+// pagedDriver serves a flat directory of n files, pageSize files per page
+type pagedDriver struct {
+	model.Storage
+	n, pageSize int
+	pageCalls   int
+}
+
+func (d *pagedDriver) Config() driver.Config          { return driver.Config{Name: "Paged"} }
+func (d *pagedDriver) GetAddition() driver.Additional { return nil }
+func (d *pagedDriver) Init(ctx context.Context) error { return nil }
+func (d *pagedDriver) Drop(ctx context.Context) error { return nil }
+
+func (d *pagedDriver) GetRoot(ctx context.Context) (model.Obj, error) {
+	return &model.Object{Name: "root", Path: "/", IsFolder: true}, nil
+}
+
+func (d *pagedDriver) List(ctx context.Context, dir model.Obj, args model.ListArgs) ([]model.Obj, error) {
+	objs, _, err := d.ListPage(ctx, dir, model.ListPageArgs{Limit: d.n})
+	return objs, err
+}
+
+func (d *pagedDriver) ListPage(ctx context.Context, dir model.Obj, args model.ListPageArgs) ([]model.Obj, string, error) {
+	d.pageCalls++
+	start := 0
+	if args.Cursor != "" {
+		start, _ = strconv.Atoi(args.Cursor)
+	}
+	end := min(start+d.pageSize, d.n)
+	objs := make([]model.Obj, 0, end-start)
+	for i := start; i < end; i++ {
+		objs = append(objs, &model.Object{Name: "file" + strconv.Itoa(i), Path: "/file" + strconv.Itoa(i)})
+	}
+	if end == d.n {
+		return objs, "", nil
+	}
+	return objs, strconv.Itoa(end), nil
+}
+
+func (d *pagedDriver) Link(ctx context.Context, file model.Obj, args model.LinkArgs) (*model.Link, error) {
+	return nil, nil
+}
+
+func TestListPage(t *testing.T) {
+	d := &pagedDriver{n: 25, pageSize: 10}
+	d.SetStorage(model.Storage{MountPath: "/paged", CacheExpiration: 10})
+	ctx := context.Background()
+	var names []string
+	cursor := ""
+	for {
+		page, err := op.ListPage(ctx, d, "/", model.ListPageArgs{Cursor: cursor, ListArgs: model.ListArgs{SkipHook: true}})
+		if err != nil {
+			t.Fatalf("failed list page: %+v", err)
+		}
+		for _, obj := range page.Objs {
+			names = append(names, obj.GetName())
+		}
+		if page.Next == "" {
+			break
+		}
+		cursor = page.Next
+	}
+	if len(names) != d.n || names[0] != "file0" || names[d.n-1] != "file24" {
+		t.Fatalf("unexpected listing: %v", names)
+	}
+	if d.pageCalls != 3 {
+		t.Errorf("expected 3 page calls, got %d", d.pageCalls)
+	}
+
+	// all pages have been listed, so the directory is cached as a whole
+	objs, err := op.List(ctx, d, "/", model.ListArgs{})
+	if err != nil {
+		t.Fatalf("failed list: %+v", err)
+	}
+	if len(objs) != d.n || d.pageCalls != 3 {
+		t.Errorf("expected the assembled listing from cache, got %d objs and %d page calls", len(objs), d.pageCalls)
+	}
+
+	// the first page is served from the full listing now
+	page, err := op.ListPage(ctx, d, "/", model.ListPageArgs{Limit: 20})
+	if err != nil {
+		t.Fatalf("failed list page: %+v", err)
+	}
+	if len(page.Objs) != 20 || page.Next == "" || d.pageCalls != 3 {
+		t.Errorf("unexpected page from cache: %d objs, next %q, %d page calls", len(page.Objs), page.Next, d.pageCalls)
+	}
+	page, err = op.ListPage(ctx, d, "/", model.ListPageArgs{Cursor: page.Next, Limit: 20})
+	if err != nil {
+		t.Fatalf("failed list page: %+v", err)
+	}
+	if len(page.Objs) != 5 || page.Next != "" {
+		t.Errorf("unexpected last page: %d objs, next %q", len(page.Objs), page.Next)
+	}
+
+	if _, err = op.ListPage(ctx, d, "/", model.ListPageArgs{Cursor: "bogus"}); err == nil {
+		t.Errorf("expected an error for an invalid cursor")
+	}
+}
@@ -0,0 +1,103 @@
+package op
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+func GetGroups(pageIndex, pageSize int) ([]model.Group, int64, error) {
+	return db.GetGroups(pageIndex, pageSize)
+}
+
+func GetGroupById(id uint) (*model.Group, error) {
+	return db.GetGroupById(id)
+}
+
+// GetGroupsByNames returns the groups of the comma separated names, unknown names are ignored
+func GetGroupsByNames(names string) ([]model.Group, error) {
+	var list []string
+	for _, name := range strings.Split(names, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			list = append(list, name)
+		}
+	}
+	return db.GetGroupsByNames(list)
+}
+
+func fixRootPaths(g *model.Group) error {
+	g.RootPaths = utils.MustSliceConvert(g.RootPaths, utils.FixAndCleanPath)
+	if slices.Contains(g.RootPaths, "/") {
+		return errors.New("root path can't be /, use the base path of users instead")
+	}
+	return nil
+}
+
+func CreateGroup(g *model.Group) error {
+	if err := fixRootPaths(g); err != nil {
+		return err
+	}
+	return db.CreateGroup(g)
+}
+
+func UpdateGroup(g *model.Group) error {
+	if _, err := db.GetGroupById(g.ID); err != nil {
+		return err
+	}
+	if err := fixRootPaths(g); err != nil {
+		return err
+	}
+	if err := db.UpdateGroup(g); err != nil {
+		return err
+	}
+	changed(clusterUsers, "")
+	return nil
+}
+
+// DeleteGroupById deletes the group and removes the users from it
+func DeleteGroupById(id uint) error {
+	users, err := db.GetAllUsers()
+	if err != nil {
+		return err
+	}
+	for _, u := range users {
+		if !slices.Contains(u.GroupIDs, id) {
+			continue
+		}
+		u.GroupIDs = slices.DeleteFunc(u.GroupIDs, func(gid uint) bool { return gid == id })
+		if err := db.UpdateUser(&u); err != nil {
+			return errors.WithMessagef(err, "failed remove user [%s] from group", u.Username)
+		}
+	}
+	if err := db.DeleteGroupById(id); err != nil {
+		return err
+	}
+	changed(clusterUsers, "")
+	return nil
+}
+
+// applyGroups grants the permissions and root paths of the user's groups to the user
+func applyGroups(u *model.User) {
+	if len(u.GroupIDs) == 0 {
+		u.SetGroups(nil)
+		return
+	}
+	groups, err := db.GetGroupsByIds(u.GroupIDs)
+	if err != nil {
+		log.Errorf("failed get groups of user [%s]: %+v", u.Username, err)
+	}
+	u.SetGroups(groups)
+}
+
+// clearUsers drops the loaded users so that changed groups apply to them
+func clearUsers() {
+	adminUser = nil
+	guestUser = nil
+	Cache.ClearUsers()
+	clearQuotaOwners()
+}
@@ -0,0 +1,63 @@
+package op_test
+
+import (
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+)
+
+func TestGroup(t *testing.T) {
+	group := &model.Group{Name: "team", Permission: 1 << 3, RootPaths: []string{"/shared/team/"}}
+	if err := op.CreateGroup(group); err != nil {
+		t.Fatalf("failed to create group: %+v", err)
+	}
+	other := &model.Group{Name: "other", RootPaths: []string{"/other/team", "/shared/team"}}
+	if err := op.CreateGroup(other); err != nil {
+		t.Fatalf("failed to create group: %+v", err)
+	}
+	user := &model.User{Username: "group_test", BasePath: "/home/a", Permission: 1, GroupIDs: []uint{group.ID, other.ID}}
+	if err := op.CreateUser(user); err != nil {
+		t.Fatalf("failed to create user: %+v", err)
+	}
+	user, err := op.GetUserByName("group_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !user.CanSeeHides() || !user.CanWrite() || user.CanRemove() {
+		t.Errorf("expected the union of permissions, got %b", user.EffectivePermission())
+	}
+	for reqPath, expected := range map[string]string{
+		"/":           "/home/a",
+		"/docs/a.txt": "/home/a/docs/a.txt",
+		// the roots don't shadow what's in the base path, and the same names are made unique
+		"/team":                         "/home/a/team",
+		"/Group folders/team":           "/shared/team",
+		"/Group folders/team/a.txt":     "/shared/team/a.txt",
+		"/Group folders/team (2)/b.txt": "/other/team/b.txt",
+	} {
+		p, err := user.JoinPath(reqPath)
+		if err != nil || p != expected {
+			t.Errorf("expected %s to join to %s, got %s: %v", reqPath, expected, p, err)
+		}
+		if rel := user.RelPath(p); rel != reqPath {
+			t.Errorf("expected %s to be %s of the user, got %s", p, reqPath, rel)
+		}
+	}
+	if user.CanAccessPath("/shared/other") {
+		t.Errorf("expected /shared/other to be inaccessible")
+	}
+
+	for _, id := range []uint{group.ID, other.ID} {
+		if err = op.DeleteGroupById(id); err != nil {
+			t.Fatalf("failed to delete group: %+v", err)
+		}
+	}
+	user, err = op.GetUserByName("group_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(user.GroupIDs) != 0 || user.CanWrite() || len(user.RootPaths()) != 0 {
+		t.Errorf("expected the user to be removed from the group, got %+v", user)
+	}
+}
@@ -0,0 +1,99 @@
+package op
+
+import (
+	"strings"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+func GetInternalShares(pageIndex, pageSize int) ([]model.InternalShare, int64, error) {
+	return db.GetInternalShares(pageIndex, pageSize)
+}
+
+func GetInternalSharesByCreatorId(creatorId uint, pageIndex, pageSize int) ([]model.InternalShare, int64, error) {
+	return db.GetInternalSharesByCreatorId(creatorId, pageIndex, pageSize)
+}
+
+func GetInternalShareById(id uint) (*model.InternalShare, error) {
+	return db.GetInternalShareById(id)
+}
+
+func validateInternalShare(s *model.InternalShare) error {
+	s.Path = utils.FixAndCleanPath(s.Path)
+	s.Name = strings.TrimSpace(s.Name)
+	if strings.Contains(s.Name, "/") || s.Name == "." || s.Name == ".." {
+		return errors.Errorf("invalid name [%s]", s.Name)
+	}
+	if len(s.UserIDs) == 0 && len(s.GroupIDs) == 0 {
+		return errors.New("must share with at least 1 user or group")
+	}
+	return nil
+}
+
+// CheckInternalShare checks that creator may share the path of s. Only the own paths of the creator
+// can be shared, not the ones shared with it, and only writable if the creator can write there
+func CheckInternalShare(creator *model.User, s *model.InternalShare) error {
+	if !creator.CanShare() {
+		return errors.WithStack(errs.PermissionDenied)
+	}
+	s.Path = utils.FixAndCleanPath(s.Path)
+	if !creator.CanAccessOwnPath(s.Path) {
+		return errors.WithMessagef(errs.PermissionDenied, "can't share path [%s]", s.Path)
+	}
+	if s.Writable && (!creator.CanWrite() || creator.InternalShareReadOnly(s.Path)) {
+		return errors.WithMessagef(errs.PermissionDenied, "can't share path [%s] as writable", s.Path)
+	}
+	if s.Writable {
+		if err := CheckAcl(creator, model.AclWrite, s.Path); err != nil {
+			return err
+		}
+	}
+	return CheckAcl(creator, model.AclShare, s.Path)
+}
+
+func CreateInternalShare(s *model.InternalShare) error {
+	if err := validateInternalShare(s); err != nil {
+		return err
+	}
+	s.ID = 0
+	defer changed(clusterUsers, "")
+	return db.CreateInternalShare(s)
+}
+
+func UpdateInternalShare(s *model.InternalShare) error {
+	old, err := db.GetInternalShareById(s.ID)
+	if err != nil {
+		return err
+	}
+	if err := validateInternalShare(s); err != nil {
+		return err
+	}
+	s.CreatorID, s.CreatedAt = old.CreatorID, old.CreatedAt
+	defer changed(clusterUsers, "")
+	return db.UpdateInternalShare(s)
+}
+
+func DeleteInternalShareById(id uint) error {
+	defer changed(clusterUsers, "")
+	return db.DeleteInternalShareById(id)
+}
+
+// applyInternalShares makes the paths shared with the user by others accessible to the user
+func applyInternalShares(u *model.User) {
+	shares, err := db.GetAllInternalShares()
+	if err != nil {
+		log.Errorf("failed get internal shares to user [%s]: %+v", u.Username, err)
+	}
+	var res []model.InternalShare
+	for _, s := range shares {
+		if !s.Expired() && s.SharedWith(u) {
+			res = append(res, s)
+		}
+	}
+	u.SetInternalShares(res)
+}
@@ -0,0 +1,103 @@
+package op_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/pkg/errors"
+)
+
+func TestInternalShare(t *testing.T) {
+	group := &model.Group{Name: "internal_share_team"}
+	if err := op.CreateGroup(group); err != nil {
+		t.Fatalf("failed to create group: %+v", err)
+	}
+	owner := &model.User{Username: "internal_share_owner", BasePath: "/home/owner", Permission: 0x7FFF}
+	recipient := &model.User{Username: "internal_share_recipient", BasePath: "/home/recipient", Permission: 0x7FFF, GroupIDs: []uint{group.ID}}
+	for _, u := range []*model.User{owner, recipient} {
+		if err := op.CreateUser(u); err != nil {
+			t.Fatalf("failed to create user: %+v", err)
+		}
+	}
+	expired := time.Now().Add(-time.Minute)
+	shares := []model.InternalShare{
+		{CreatorID: owner.ID, Path: "/home/owner/docs", UserIDs: []uint{recipient.ID}},
+		{CreatorID: owner.ID, Path: "/home/owner/drop/", Name: "inbox", GroupIDs: []uint{group.ID}, Writable: true},
+		{CreatorID: owner.ID, Path: "/home/owner/old", UserIDs: []uint{recipient.ID}, ExpiresAt: &expired},
+	}
+	for i := range shares {
+		if err := op.CreateInternalShare(&shares[i]); err != nil {
+			t.Fatalf("failed to create internal share: %+v", err)
+		}
+	}
+	defer func() {
+		for _, s := range shares {
+			_ = op.DeleteInternalShareById(s.ID)
+		}
+	}()
+
+	user, err := op.GetUserByName(recipient.Username)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(user.InternalShares()); n != 2 {
+		t.Fatalf("expected 2 shares to the recipient, got %d", n)
+	}
+	for reqPath, expected := range map[string]string{
+		"/docs":                    "/home/recipient/docs",
+		"/Shared with me":          "/home/recipient/Shared with me",
+		"/Shared with me/docs/a":   "/home/owner/docs/a",
+		"/Shared with me/inbox/b":  "/home/owner/drop/b",
+		"/Shared with me/old/file": "/home/recipient/Shared with me/old/file",
+	} {
+		if p, err := user.JoinPath(reqPath); err != nil || p != expected {
+			t.Errorf("expected %s to join to %s, got %s: %v", reqPath, expected, p, err)
+		}
+	}
+	if rel := user.RelPath("/home/owner/drop/b"); rel != "/Shared with me/inbox/b" {
+		t.Errorf("expected the path in the share, got %s", rel)
+	}
+	if !user.CanAccessPath("/home/owner/docs/a") || user.CanAccessPath("/home/owner/old") || user.CanAccessPath("/home/owner") {
+		t.Errorf("expected only the valid shares to be accessible")
+	}
+	if err = op.CheckAcl(user, model.AclRead, "/home/owner/docs/a"); err != nil {
+		t.Errorf("expected a read-only share to be readable, got %v", err)
+	}
+	if err = op.CheckAcl(user, model.AclWrite, "/home/owner/docs/a"); !errors.Is(err, errs.PermissionDenied) {
+		t.Errorf("expected a read-only share not to be writable, got %v", err)
+	}
+	if err = op.CheckAcl(user, model.AclWrite, "/home/owner/drop/a"); err != nil {
+		t.Errorf("expected a writable share to be writable, got %v", err)
+	}
+	if err = op.CheckAcl(owner, model.AclWrite, "/home/owner/docs/a"); err != nil {
+		t.Errorf("expected the owner not to be restricted, got %v", err)
+	}
+
+	for _, reshare := range []model.InternalShare{
+		{Path: "/home/owner/docs", UserIDs: []uint{owner.ID}, Writable: true},
+		{Path: "/home/owner/docs/a", UserIDs: []uint{owner.ID}},
+		{Path: "/home/owner/drop", UserIDs: []uint{owner.ID}},
+	} {
+		if err = op.CheckInternalShare(user, &reshare); !errors.Is(err, errs.PermissionDenied) {
+			t.Errorf("expected the recipient not to share %s again, got %v", reshare.Path, err)
+		}
+	}
+	if err = op.CheckInternalShare(user, &model.InternalShare{Path: "/home/recipient/a", Writable: true}); err != nil {
+		t.Errorf("expected the recipient to share its own path, got %v", err)
+	}
+	readOnly := *owner
+	readOnly.Permission &^= 1 << 3
+	if err = op.CheckInternalShare(&readOnly, &model.InternalShare{Path: "/home/owner/a", Writable: true}); !errors.Is(err, errs.PermissionDenied) {
+		t.Errorf("expected a user without write permission not to share as writable, got %v", err)
+	}
+
+	if err = op.DeleteInternalShareById(shares[0].ID); err != nil {
+		t.Fatalf("failed to delete internal share: %+v", err)
+	}
+	if user, err = op.GetUserByName(recipient.Username); err != nil || user.CanAccessPath("/home/owner/docs") {
+		t.Errorf("expected a deleted share not to be accessible: %v", err)
+	}
+}
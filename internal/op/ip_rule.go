@@ -0,0 +1,158 @@
+package op
+
+import (
+	"net/netip"
+	"slices"
+	"sync"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+type ipRule struct {
+	model.IPRule
+	prefixes []netip.Prefix
+}
+
+func (r *ipRule) contains(addr netip.Addr) bool {
+	for _, p := range r.prefixes {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// the ip rules are checked for every request, so they're kept in memory with the CIDRs parsed
+var (
+	ipRules   []ipRule
+	ipLoaded  bool
+	ipRulesMu sync.RWMutex
+)
+
+func getIPRules() ([]ipRule, error) {
+	ipRulesMu.RLock()
+	if ipLoaded {
+		defer ipRulesMu.RUnlock()
+		return ipRules, nil
+	}
+	ipRulesMu.RUnlock()
+	ipRulesMu.Lock()
+	defer ipRulesMu.Unlock()
+	if !ipLoaded {
+		rules, err := db.GetAllIPRules()
+		if err != nil {
+			return nil, err
+		}
+		ipRules = make([]ipRule, 0, len(rules))
+		for _, r := range rules {
+			prefixes, err := r.ParseCIDRs()
+			if err != nil {
+				log.Warnf("skip ip rule %d: %+v", r.ID, err)
+				continue
+			}
+			ipRules = append(ipRules, ipRule{IPRule: r, prefixes: prefixes})
+		}
+		ipLoaded = true
+	}
+	return ipRules, nil
+}
+
+func clearIPRules() {
+	ipRulesMu.Lock()
+	defer ipRulesMu.Unlock()
+	ipRules, ipLoaded = nil, false
+}
+
+func GetIPRules(pageIndex, pageSize int) ([]model.IPRule, int64, error) {
+	return db.GetIPRules(pageIndex, pageSize)
+}
+
+func GetIPRuleById(id uint) (*model.IPRule, error) {
+	return db.GetIPRuleById(id)
+}
+
+func validateIPRule(r *model.IPRule) error {
+	if r.Protocol != "" && !slices.Contains(model.IPRuleProtocols, r.Protocol) {
+		return errors.Errorf("unknown protocol [%s]", r.Protocol)
+	}
+	prefixes, err := r.ParseCIDRs()
+	if err != nil {
+		return err
+	}
+	if len(prefixes) == 0 {
+		return errors.New("cidrs are required")
+	}
+	if r.UserID != 0 {
+		if _, err := GetUserById(r.UserID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func CreateIPRule(r *model.IPRule) error {
+	if err := validateIPRule(r); err != nil {
+		return err
+	}
+	defer changed(clusterIPRules, "")
+	return db.CreateIPRule(r)
+}
+
+func UpdateIPRule(r *model.IPRule) error {
+	if _, err := db.GetIPRuleById(r.ID); err != nil {
+		return err
+	}
+	if err := validateIPRule(r); err != nil {
+		return err
+	}
+	defer changed(clusterIPRules, "")
+	return db.UpdateIPRule(r)
+}
+
+func DeleteIPRuleById(id uint) error {
+	defer changed(clusterIPRules, "")
+	return db.DeleteIPRuleById(id)
+}
+
+// CheckIP returns errs.PermissionDenied if the ip rules deny the client at ip to use the protocol as the user.
+// Only the global rules are checked without a user.
+func CheckIP(user *model.User, protocol, ip string) error {
+	rules, err := getIPRules()
+	if err != nil || len(rules) == 0 {
+		return err
+	}
+	addr, err := netip.ParseAddr(ip)
+	valid := err == nil
+	addr = addr.Unmap()
+	var userId uint
+	if user != nil {
+		userId = user.ID
+	}
+	// whether there are allow rules of all clients and of the user, and whether one of them matches
+	var globalAllow, globalAllowed, userAllow, userAllowed bool
+	for i := range rules {
+		r := &rules[i]
+		if r.Protocol != "" && r.Protocol != protocol || r.UserID != 0 && r.UserID != userId {
+			continue
+		}
+		match := valid && r.contains(addr)
+		switch {
+		case !r.Allow:
+			if match || !valid {
+				return errors.WithMessagef(errs.PermissionDenied, "ip [%s] is denied for %s", ip, protocol)
+			}
+		case r.UserID == 0:
+			globalAllow, globalAllowed = true, globalAllowed || match
+		default:
+			userAllow, userAllowed = true, userAllowed || match
+		}
+	}
+	if globalAllow && !globalAllowed || userAllow && !userAllowed {
+		return errors.WithMessagef(errs.PermissionDenied, "ip [%s] is not allowed for %s", ip, protocol)
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package op_test
+
+import (
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/pkg/errors"
+)
+
+func TestCheckIP(t *testing.T) {
+	admin := &model.User{Username: "ip_test", Role: model.ADMIN}
+	if err := op.CreateUser(admin); err != nil {
+		t.Fatalf("failed to create user: %+v", err)
+	}
+	user := &model.User{ID: admin.ID + 100}
+	rules := []model.IPRule{
+		{UserID: admin.ID, Allow: true, CIDRs: []string{"10.1.0.0/16", "fd00::/8"}},
+		{Protocol: model.TokenFtp, CIDRs: []string{"203.0.113.7"}},
+		{Protocol: model.IPRuleDownload, Allow: true, CIDRs: []string{"192.168.0.0/24"}},
+	}
+	for i := range rules {
+		if err := op.CreateIPRule(&rules[i]); err != nil {
+			t.Fatalf("failed to create ip rule: %+v", err)
+		}
+	}
+	defer func() {
+		for _, r := range rules {
+			_ = op.DeleteIPRuleById(r.ID)
+		}
+	}()
+	if err := op.CreateIPRule(&model.IPRule{CIDRs: []string{"10.0.0.0/33"}}); err == nil {
+		t.Errorf("expected an invalid cidr to be refused")
+	}
+	for _, c := range []struct {
+		user     *model.User
+		protocol string
+		ip       string
+		allowed  bool
+	}{
+		{admin, model.TokenApi, "10.1.2.3", true},
+		{admin, model.TokenWebdav, "::ffff:10.1.2.3", true},
+		{admin, model.TokenSftp, "fd12::1", true},
+		{admin, model.TokenApi, "8.8.8.8", false},
+		{admin, model.TokenApi, "", false},
+		{user, model.TokenApi, "8.8.8.8", true},
+		{user, model.TokenFtp, "203.0.113.7", false},
+		{user, model.TokenSftp, "203.0.113.7", true},
+		{nil, model.IPRuleDownload, "192.168.0.9", true},
+		{nil, model.IPRuleDownload, "192.168.1.9", false},
+		{nil, model.TokenApi, "192.168.1.9", true},
+	} {
+		err := op.CheckIP(c.user, c.protocol, c.ip)
+		if c.allowed && err != nil || !c.allowed && !errors.Is(err, errs.PermissionDenied) {
+			t.Errorf("expected %s from [%s] by %+v to be allowed: %v, got %v", c.protocol, c.ip, c.user, c.allowed, err)
+		}
+	}
+}
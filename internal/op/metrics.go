@@ -0,0 +1,65 @@
+package op
+
+import (
+	"context"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/driver"
+	"github.com/OpenListTeam/OpenList/v4/internal/metrics"
+	"github.com/OpenListTeam/OpenList/v4/internal/tracing"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// observeDriverCall starts timing a call of the method of the driver of storage in a child span of ctx,
+// the call should be made with the returned ctx and the returned func records it with its result
+func observeDriverCall(ctx context.Context, storage driver.Driver, method string) (context.Context, func(err error)) {
+	start := time.Now()
+	ctx, span := tracing.Start(ctx, "driver."+method, storageAttrs(storage)...)
+	return ctx, func(err error) {
+		metrics.ObserveDriverCall(storage.GetStorage().MountPath, storage.Config().Name, method, start, err)
+		tracing.End(span, err)
+	}
+}
+
+// cacheLookup records a lookup in the cache, also on the span of ctx
+func cacheLookup(ctx context.Context, cache string, hit bool) {
+	metrics.CacheLookup(cache, hit)
+	trace.SpanFromContext(ctx).SetAttributes(attribute.Bool("cache."+cache+".hit", hit))
+}
+
+// startSpan starts the span of the op call on the storage
+func startSpan(ctx context.Context, name string, storage driver.Driver, path string) (context.Context, trace.Span) {
+	return tracing.Start(ctx, name, append(storageAttrs(storage), attribute.String("path", path))...)
+}
+
+func storageAttrs(storage driver.Driver) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("storage", storage.GetStorage().MountPath),
+		attribute.String("driver", storage.Config().Name),
+	}
+}
+
+var storageUpDesc = prometheus.NewDesc("openlist_storage_up",
+	"Whether the storage works, by mount path and driver.", []string{"storage", "driver"}, nil)
+
+type storageCollector struct{}
+
+func (storageCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- storageUpDesc
+}
+
+func (storageCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, s := range GetAllStorages() {
+		up := 0.0
+		if s.GetStorage().Status == WORK {
+			up = 1
+		}
+		ch <- prometheus.MustNewConstMetric(storageUpDesc, prometheus.GaugeValue, up, s.GetStorage().MountPath, s.Config().Name)
+	}
+}
+
+func init() {
+	metrics.Registry.MustRegister(storageCollector{})
+}
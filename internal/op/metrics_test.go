@@ -0,0 +1,113 @@
+package op_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/driver"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/metrics"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/stream"
+	"github.com/pkg/errors"
+)
+
+// metricValue finds the value of the counter or gauge name with all the labels
+func metricValue(t *testing.T, name string, labels map[string]string) float64 {
+	families, err := metrics.Registry.Gather()
+	if err != nil {
+		t.Fatalf("failed to gather metrics: %+v", err)
+	}
+	for _, f := range families {
+		if f.GetName() != name {
+			continue
+		}
+	next:
+		for _, m := range f.GetMetric() {
+			got := map[string]string{}
+			for _, l := range m.GetLabel() {
+				got[l.GetName()] = l.GetValue()
+			}
+			for k, v := range labels {
+				if got[k] != v {
+					continue next
+				}
+			}
+			if m.GetCounter() != nil {
+				return m.GetCounter().GetValue()
+			}
+			return m.GetGauge().GetValue()
+		}
+	}
+	return 0
+}
+
+func TestDriverMetrics(t *testing.T) {
+	ctx := context.Background()
+	storage := createLocalStorage(t, "", model.Storage{MountPath: "/metrics_test"})
+	defer op.DeleteStorageById(ctx, storage.GetStorage().ID)
+
+	// the local driver is not cached, so every list is a miss calling the driver
+	misses := metricValue(t, "openlist_cache_lookups_total", map[string]string{"cache": "dir", "result": "miss"})
+	for i := 0; i < 2; i++ {
+		if _, err := op.List(ctx, storage, "/", model.ListArgs{}); err != nil {
+			t.Fatalf("failed to list: %+v", err)
+		}
+	}
+	labels := map[string]string{"storage": "/metrics_test", "driver": "Local", "method": "list"}
+	if n := metricValue(t, "openlist_driver_calls_total", labels); n != 2 {
+		t.Errorf("expected 2 list calls of the driver, got %v", n)
+	}
+	if n := metricValue(t, "openlist_driver_errors_total", labels); n != 0 {
+		t.Errorf("expected no failed list, got %v", n)
+	}
+	if n := metricValue(t, "openlist_cache_lookups_total", map[string]string{"cache": "dir", "result": "miss"}); n != misses+2 {
+		t.Errorf("expected 2 more cache misses, got %v after %v", n, misses)
+	}
+	if up := metricValue(t, "openlist_storage_up", map[string]string{"storage": "/metrics_test"}); up != 1 {
+		t.Errorf("expected the storage to be up, got %v", up)
+	}
+}
+
+// listOnlyDriver lists a file and implements nothing else
+type listOnlyDriver struct {
+	model.Storage
+}
+
+func (d *listOnlyDriver) Config() driver.Config          { return driver.Config{Name: "ListOnly"} }
+func (d *listOnlyDriver) GetAddition() driver.Additional { return nil }
+func (d *listOnlyDriver) Init(ctx context.Context) error { return nil }
+func (d *listOnlyDriver) Drop(ctx context.Context) error { return nil }
+
+func (d *listOnlyDriver) GetRoot(ctx context.Context) (model.Obj, error) {
+	return &model.Object{Name: "root", Path: "/", IsFolder: true}, nil
+}
+
+func (d *listOnlyDriver) List(ctx context.Context, dir model.Obj, args model.ListArgs) ([]model.Obj, error) {
+	return []model.Obj{&model.Object{Name: "a.txt", Path: "/a.txt", Size: 1}}, nil
+}
+
+func (d *listOnlyDriver) Link(ctx context.Context, file model.Obj, args model.LinkArgs) (*model.Link, error) {
+	return nil, errs.NotImplement
+}
+
+func TestDriverMetricsNotImplemented(t *testing.T) {
+	d := &listOnlyDriver{Storage: model.Storage{MountPath: "/metrics_not_implemented"}}
+	ctx := context.Background()
+	file := &stream.FileStream{Obj: &model.Object{Name: "b.txt", Size: 1}, Reader: strings.NewReader("b")}
+	for method, f := range map[string]func() error{
+		"make_dir": func() error { return op.MakeDir(ctx, d, "/dir") },
+		"rename":   func() error { return op.Rename(ctx, d, "/a.txt", "c.txt") },
+		"put":      func() error { return op.Put(ctx, d, "/", file, nil) },
+	} {
+		if err := f(); !errors.Is(err, errs.NotImplement) {
+			t.Errorf("expected %s not to be implemented, got %v", method, err)
+		}
+		labels := map[string]string{"storage": "/metrics_not_implemented", "driver": "ListOnly", "method": method}
+		if n := metricValue(t, "openlist_driver_errors_total", labels); n != 1 {
+			t.Errorf("expected the failed %s to be recorded, got %v", method, n)
+		}
+	}
+}
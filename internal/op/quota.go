@@ -0,0 +1,214 @@
+package op
+
+import (
+	"context"
+	"math"
+	stdpath "path"
+	"sync"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/driver"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// The usage of a user is counted from the objects put, copied, moved in and removed
+// under the paths the user owns, which are the ones in its base path and not in the
+// deeper base path of another user, whoever writes them. The dirs are walked to count
+// what they hold only if their owner has a quota, as it lists the whole tree.
+// A write reserves its usage in the same update that checks the quota, and releases
+// it if the write fails, so that concurrent writes can't exceed the quota together.
+
+// UnknownBytes is the size of a write that is unknown yet, which only requires some bytes left
+const UnknownBytes int64 = math.MinInt64
+
+var (
+	quotaOwners       []model.User
+	quotaOwnersLoaded bool
+	quotaOwnersMu     sync.Mutex
+)
+
+func GetUserUsage(userId uint) (*model.UserUsage, error) {
+	return db.GetUserUsage(userId)
+}
+
+func SetUserUsage(usage *model.UserUsage) error {
+	return db.SetUserUsage(usage)
+}
+
+// clearQuotaOwners drops the loaded base paths of the users after a user changed
+func clearQuotaOwners() {
+	quotaOwnersMu.Lock()
+	defer quotaOwnersMu.Unlock()
+	quotaOwners, quotaOwnersLoaded = nil, false
+}
+
+func getQuotaOwners() ([]model.User, error) {
+	quotaOwnersMu.Lock()
+	defer quotaOwnersMu.Unlock()
+	if !quotaOwnersLoaded {
+		users, err := db.GetAllUsers()
+		if err != nil {
+			return nil, err
+		}
+		quotaOwners, quotaOwnersLoaded = users, true
+	}
+	return quotaOwners, nil
+}
+
+// quotaOwner returns the user that owns the full path, preferring the user of ctx among
+// the users with the same base path. It's nil if the quota is skipped like when objects
+// are moved to or restored from trash, or no user acts, or the owner is the guest or isn't stored
+func quotaOwner(ctx context.Context, fullPath string) *model.User {
+	if ctx.Value(conf.SkipQuotaKey) != nil {
+		return nil
+	}
+	actor, _ := ctx.Value(conf.UserKey).(*model.User)
+	if actor == nil {
+		return nil
+	}
+	var owner *model.User
+	if utils.IsSubPath(actor.BasePath, fullPath) {
+		owner = actor
+	}
+	users, err := getQuotaOwners()
+	if err != nil {
+		log.Errorf("failed get users to find the owner of [%s]: %+v", fullPath, err)
+	}
+	for i := range users {
+		u := &users[i]
+		if u.ID == actor.ID || !utils.IsSubPath(u.BasePath, fullPath) {
+			continue
+		}
+		if owner == nil || len(utils.FixAndCleanPath(u.BasePath)) > len(utils.FixAndCleanPath(owner.BasePath)) {
+			owner = u
+		}
+	}
+	if owner == nil || owner.ID == 0 || owner.IsGuest() {
+		return nil
+	}
+	return owner
+}
+
+// CheckQuota returns errs.QuotaExceeded if the owner of the full path can't store
+// bytes more bytes and files more files, without reserving them. It's for checking
+// a write before it's received, the write itself reserves its usage
+func CheckQuota(ctx context.Context, fullPath string, bytes, files int64) error {
+	user := quotaOwner(ctx, fullPath)
+	if user == nil || !user.HasQuota() {
+		return nil
+	}
+	usage, err := db.GetUserUsage(user.ID)
+	if err != nil {
+		return err
+	}
+	if user.QuotaBytes > 0 && (usage.Bytes+bytes > user.QuotaBytes || bytes == UnknownBytes && usage.Bytes >= user.QuotaBytes) {
+		return errors.WithMessagef(errs.QuotaExceeded, "%d of %d bytes used", usage.Bytes, user.QuotaBytes)
+	}
+	if user.QuotaFiles > 0 && usage.Files+files > user.QuotaFiles {
+		return errors.WithMessagef(errs.QuotaExceeded, "%d of %d files used", usage.Files, user.QuotaFiles)
+	}
+	return nil
+}
+
+// usageReservation is the usage reserved to a user for a write
+type usageReservation struct {
+	user  *model.User
+	bytes int64
+	files int64
+}
+
+// reserveUsage reserves bytes and files to user, failing with errs.QuotaExceeded if it
+// exceeds the quota. A nil user reserves nothing
+func reserveUsage(user *model.User, bytes, files int64) (*usageReservation, error) {
+	if user == nil || (bytes == 0 && files == 0) {
+		return nil, nil
+	}
+	needBytes := bytes
+	if bytes == UnknownBytes {
+		bytes, needBytes = 0, 1
+	}
+	// freeing usage never exceeds the quota
+	if needBytes <= 0 && files <= 0 {
+		addUsage(user, bytes, files)
+		return &usageReservation{user: user, bytes: bytes, files: files}, nil
+	}
+	ok, err := db.ReserveUserUsage(user.ID, bytes, files, needBytes, user.QuotaBytes, user.QuotaFiles)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		usage, err := db.GetUserUsage(user.ID)
+		if err != nil {
+			return nil, errors.WithStack(errs.QuotaExceeded)
+		}
+		return nil, errors.WithMessagef(errs.QuotaExceeded, "%d of %d bytes and %d of %d files used",
+			usage.Bytes, user.QuotaBytes, usage.Files, user.QuotaFiles)
+	}
+	return &usageReservation{user: user, bytes: bytes, files: files}, nil
+}
+
+// reservePathUsage reserves bytes and files to the owner of the full path
+func reservePathUsage(ctx context.Context, fullPath string, bytes, files int64) (*usageReservation, error) {
+	return reserveUsage(quotaOwner(ctx, fullPath), bytes, files)
+}
+
+// release gives the reserved usage back after the write failed
+func (r *usageReservation) release() {
+	if r != nil {
+		addUsage(r.user, -r.bytes, -r.files)
+	}
+}
+
+// addUsage counts the written or removed objects to user
+func addUsage(user *model.User, bytes, files int64) {
+	if user == nil || (bytes == 0 && files == 0) {
+		return
+	}
+	if err := db.AddUserUsage(user.ID, bytes, files); err != nil {
+		log.Errorf("failed update usage of user [%s]: %+v", user.Username, err)
+	}
+}
+
+func sameUser(a, b *model.User) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.ID == b.ID
+}
+
+// objUsage returns the bytes and files that obj at path counts for. A dir is walked
+// only if user has a quota, otherwise it counts by its size
+func objUsage(ctx context.Context, storage driver.Driver, path string, obj model.Obj, user *model.User) (int64, int64, error) {
+	if !obj.IsDir() {
+		return max(obj.GetSize(), 0), 1, nil
+	}
+	if user == nil || !user.HasQuota() {
+		return max(obj.GetSize(), 0), 0, nil
+	}
+	return treeUsage(ctx, storage, path)
+}
+
+// treeUsage returns the bytes and files in the dir at path and its sub dirs
+func treeUsage(ctx context.Context, storage driver.Driver, path string) (bytes, files int64, err error) {
+	objs, err := List(ctx, storage, path, model.ListArgs{})
+	if err != nil {
+		return 0, 0, errors.WithMessagef(err, "failed list [%s] to count its usage", path)
+	}
+	for _, obj := range objs {
+		if obj.IsDir() {
+			b, f, err := treeUsage(ctx, storage, stdpath.Join(path, obj.GetName()))
+			if err != nil {
+				return 0, 0, err
+			}
+			bytes, files = bytes+b, files+f
+			continue
+		}
+		bytes, files = bytes+max(obj.GetSize(), 0), files+1
+	}
+	return bytes, files, nil
+}
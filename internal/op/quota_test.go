@@ -0,0 +1,157 @@
+package op_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/driver"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/stream"
+	"github.com/pkg/errors"
+)
+
+func putContent(ctx context.Context, storage driver.Driver, dirPath, name, content string) error {
+	file := &stream.FileStream{
+		Obj:    &model.Object{Name: name, Size: int64(len(content))},
+		Reader: strings.NewReader(content),
+	}
+	return op.Put(ctx, storage, dirPath, file, nil)
+}
+
+func userUsage(t *testing.T, userId uint) *model.UserUsage {
+	u, err := op.GetUserUsage(userId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u
+}
+
+func TestQuota(t *testing.T) {
+	storage := createLocalStorage(t, "", model.Storage{MountPath: "/quota_test"})
+	user := &model.User{ID: 100, Username: "quota", QuotaBytes: 8, QuotaFiles: 2}
+	ctx := context.WithValue(context.Background(), conf.UserKey, user)
+	put := func(name, content string) error {
+		return putContent(ctx, storage, "/", name, content)
+	}
+	usage := func() *model.UserUsage {
+		return userUsage(t, user.ID)
+	}
+	var err error
+
+	if err = put("a.txt", "hello"); err != nil {
+		t.Fatalf("failed to put: %+v", err)
+	}
+	if u := usage(); u.Bytes != 5 || u.Files != 1 {
+		t.Errorf("unexpected usage after put: %+v", u)
+	}
+	if err = put("b.txt", "hello"); !errors.Is(err, errs.QuotaExceeded) {
+		t.Errorf("expected the bytes quota to be exceeded, got %v", err)
+	}
+	// overwriting only counts the difference
+	if err = put("a.txt", "hello!!!"); err != nil {
+		t.Fatalf("failed to overwrite: %+v", err)
+	}
+	if u := usage(); u.Bytes != 8 || u.Files != 1 {
+		t.Errorf("unexpected usage after overwrite: %+v", u)
+	}
+	if err = op.Remove(ctx, storage, "/a.txt"); err != nil {
+		t.Fatalf("failed to remove: %+v", err)
+	}
+	if u := usage(); u.Bytes != 0 || u.Files != 0 {
+		t.Errorf("unexpected usage after remove: %+v", u)
+	}
+}
+
+func TestQuotaDirs(t *testing.T) {
+	storage := createLocalStorage(t, "", model.Storage{MountPath: "/quota_dirs_test"})
+	owner := &model.User{Username: "quota_dirs", Password: "x", BasePath: "/quota_dirs_test/home", QuotaBytes: 20, QuotaFiles: 4}
+	if err := op.CreateUser(owner); err != nil {
+		t.Fatalf("failed to create user: %+v", err)
+	}
+	defer op.DeleteUserById(owner.ID)
+	// another user writing in the home of the owner is charged to the owner
+	actor := &model.User{ID: 101, Username: "quota_dirs_admin", BasePath: "/"}
+	ctx := context.WithValue(context.Background(), conf.UserKey, actor)
+	expect := func(step string, bytes, files int64) {
+		t.Helper()
+		if u := userUsage(t, owner.ID); u.Bytes != bytes || u.Files != files {
+			t.Errorf("unexpected usage of the owner after %s: %+v", step, u)
+		}
+	}
+
+	for _, name := range []string{"x.txt", "y.txt"} {
+		if err := putContent(ctx, storage, "/home/d", name, "hello"); err != nil {
+			t.Fatalf("failed to put: %+v", err)
+		}
+	}
+	expect("put", 10, 2)
+	if u := userUsage(t, actor.ID); u.Bytes != 0 || u.Files != 0 {
+		t.Errorf("expected the actor not to be charged, got %+v", u)
+	}
+	if err := op.MakeDir(ctx, storage, "/home/e"); err != nil {
+		t.Fatalf("failed to make dir: %+v", err)
+	}
+	// the dir fits in the quota, the local driver leaves copying it to a task
+	if err := op.Copy(ctx, storage, "/home/d", "/home/e"); !errors.Is(err, errs.NotImplement) {
+		t.Fatalf("expected copying a dir to reach the driver, got %v", err)
+	}
+	expect("failed copy", 10, 2)
+	if err := putContent(ctx, storage, "/home/d", "z.txt", "hello"); err != nil {
+		t.Fatalf("failed to put: %+v", err)
+	}
+	if err := op.Copy(ctx, storage, "/home/d", "/home/e"); !errors.Is(err, errs.QuotaExceeded) {
+		t.Errorf("expected copying a dir to exceed the quota, got %v", err)
+	}
+	expect("exceeded copy", 15, 3)
+	// moving out of the home frees the usage of the owner
+	if err := op.Move(ctx, storage, "/home/d", "/"); err != nil {
+		t.Fatalf("failed to move dir: %+v", err)
+	}
+	expect("move out", 0, 0)
+	if err := op.Move(ctx, storage, "/d", "/home"); err != nil {
+		t.Fatalf("failed to move dir back: %+v", err)
+	}
+	expect("move in", 15, 3)
+	if err := op.Remove(ctx, storage, "/home/d"); err != nil {
+		t.Fatalf("failed to remove dir: %+v", err)
+	}
+	expect("remove", 0, 0)
+}
+
+func TestQuotaConcurrentPuts(t *testing.T) {
+	storage := createLocalStorage(t, "", model.Storage{MountPath: "/quota_concurrent_test"})
+	user := &model.User{ID: 102, Username: "quota_concurrent", QuotaFiles: 3}
+	ctx := context.WithValue(context.Background(), conf.UserKey, user)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	succeeded := 0
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			err := putContent(ctx, storage, "/", fmt.Sprintf("%d.txt", i), "hello")
+			if err != nil && !errors.Is(err, errs.QuotaExceeded) {
+				t.Errorf("failed to put: %+v", err)
+				return
+			}
+			if err == nil {
+				mu.Lock()
+				succeeded++
+				mu.Unlock()
+			}
+		}(i)
+	}
+	wg.Wait()
+	if succeeded != 3 {
+		t.Errorf("expected 3 puts to succeed, got %d", succeeded)
+	}
+	if u := userUsage(t, user.ID); u.Files != 3 || u.Bytes != 15 {
+		t.Errorf("unexpected usage after concurrent puts: %+v", u)
+	}
+}
@@ -0,0 +1,99 @@
+package op
+
+import (
+	"strings"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils/random"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+var s3KeysChangingCallbacks = make([]func(), 0)
+
+// RegisterS3KeysChangingCallback registers f to be called after an s3 key is created or deleted
+func RegisterS3KeysChangingCallback(f func()) {
+	s3KeysChangingCallbacks = append(s3KeysChangingCallbacks, f)
+}
+
+func s3KeysChanged() {
+	for _, cb := range s3KeysChangingCallbacks {
+		cb()
+	}
+}
+
+func GetS3KeysByUserId(userId uint) ([]model.S3Key, error) {
+	return db.GetS3KeysByUserId(userId)
+}
+
+// GetS3KeyPairs returns the secret access keys of all users by the access key ids
+func GetS3KeyPairs() (map[string]string, error) {
+	keys, err := db.GetS3Keys()
+	if err != nil {
+		return nil, err
+	}
+	pairs := make(map[string]string, len(keys))
+	for _, k := range keys {
+		pairs[k.AccessKeyID] = k.SecretAccessKey
+	}
+	return pairs, nil
+}
+
+// CreateS3Key creates k for the user and returns the secret access key, which isn't shown again
+func CreateS3Key(user *model.User, k *model.S3Key) (string, error) {
+	if user.IsGuest() {
+		return "", errors.New("guest can't have s3 keys")
+	}
+	k.ID = 0
+	k.UserID = user.ID
+	k.AccessKeyID = "OL" + strings.ToUpper(random.String(18))
+	k.SecretAccessKey = random.String(40)
+	k.LastUsedAt = nil
+	if err := db.CreateS3Key(k); err != nil {
+		return "", err
+	}
+	changed(clusterS3Keys, "")
+	return k.SecretAccessKey, nil
+}
+
+// DeleteS3Key deletes the key with id of the user, or of any user with userId 0
+func DeleteS3Key(id, userId uint) error {
+	k, err := db.GetS3KeyById(id)
+	if err != nil {
+		return err
+	}
+	if userId != 0 && k.UserID != userId {
+		return errors.WithStack(errs.ObjectNotFound)
+	}
+	if err = db.DeleteS3KeyById(id); err != nil {
+		return err
+	}
+	changed(clusterS3Keys, "")
+	return nil
+}
+
+// AuthS3Key returns the user of the access key id,
+// the signature of the request has to be checked by the caller
+func AuthS3Key(accessKeyId string) (*model.User, error) {
+	k, err := db.GetS3KeyByAccessKeyId(accessKeyId)
+	if err != nil {
+		return nil, err
+	}
+	user, err := GetUserById(k.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user.Disabled {
+		return nil, errors.New("user is disabled")
+	}
+	now := time.Now()
+	if k.LastUsedAt == nil || now.Sub(*k.LastUsedAt) > tokenLastUsedInterval {
+		if err := db.UpdateS3KeyLastUsed(k.ID, now); err != nil {
+			log.Warnf("failed update last used time of s3 key: %+v", err)
+		}
+	}
+	return user, nil
+}
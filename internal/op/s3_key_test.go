@@ -0,0 +1,55 @@
+package op_test
+
+import (
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+)
+
+func TestS3Key(t *testing.T) {
+	user := &model.User{Username: "s3_test", BasePath: "/dept", Permission: 0x7FFF}
+	if err := op.CreateUser(user); err != nil {
+		t.Fatalf("failed to create user: %+v", err)
+	}
+	changed := 0
+	op.RegisterS3KeysChangingCallback(func() { changed++ })
+	keys := make([]model.S3Key, 2)
+	for i := range keys {
+		keys[i].Name = "backup"
+		secret, err := op.CreateS3Key(user, &keys[i])
+		if err != nil {
+			t.Fatalf("failed to create s3 key: %+v", err)
+		}
+		if secret == "" || keys[i].AccessKeyID == "" {
+			t.Fatalf("expected a key pair, got %+v", keys[i])
+		}
+	}
+	pairs, err := op.GetS3KeyPairs()
+	if err != nil || pairs[keys[1].AccessKeyID] != keys[1].SecretAccessKey {
+		t.Errorf("expected the key pair of the user, got %+v: %v", pairs, err)
+	}
+	keyUser, err := op.AuthS3Key(keys[0].AccessKeyID)
+	if err != nil || keyUser.Username != "s3_test" {
+		t.Fatalf("expected the user of the key, got %+v: %v", keyUser, err)
+	}
+
+	if err = op.DeleteS3Key(keys[0].ID, user.ID+1); err == nil {
+		t.Errorf("expected the key of another user not to be deleted")
+	}
+	if err = op.DeleteS3Key(keys[0].ID, user.ID); err != nil {
+		t.Fatalf("failed to delete s3 key: %+v", err)
+	}
+	if _, err = op.AuthS3Key(keys[0].AccessKeyID); err == nil {
+		t.Errorf("expected a deleted key to be refused")
+	}
+	if err = op.DeleteUserById(user.ID); err != nil {
+		t.Fatalf("failed to delete user: %+v", err)
+	}
+	if _, err = op.AuthS3Key(keys[1].AccessKeyID); err == nil {
+		t.Errorf("expected the keys of a deleted user to be refused")
+	}
+	if changed != 4 {
+		t.Errorf("expected 4 changes of s3 keys, got %d", changed)
+	}
+}
@@ -0,0 +1,131 @@
+package op
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils/random"
+	"github.com/OpenListTeam/go-cache"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// sessionCacheTTL is how long a valid session is trusted without reading it again, every request
+// of a login token validates its session. A revoked session is dropped from the cache at once
+const sessionCacheTTL = 5 * time.Second
+
+var sessionCache = cache.NewMemCache(cache.WithShards[*model.Session](8))
+
+// clearSessionCache drops the session with id from the cache, or all of them if id is empty
+func clearSessionCache(id string) {
+	if id == "" {
+		sessionCache.Clear()
+		return
+	}
+	sessionCache.Del(id)
+}
+
+// sessionIdleTimeout returns how long a session can be unused, 0 for no limit
+func sessionIdleTimeout() time.Duration {
+	item, _ := GetSettingItemByKey(conf.SessionIdleTimeout)
+	if item == nil {
+		return 0
+	}
+	minutes, err := strconv.Atoi(item.Value)
+	if err != nil || minutes <= 0 {
+		return 0
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+func GetSessionsByUserId(userId uint) ([]model.Session, error) {
+	return db.GetSessionsByUserId(userId)
+}
+
+// CreateSession records a login of the user lasting for lifetime
+func CreateSession(user *model.User, userAgent, ip string, lifetime time.Duration) (*model.Session, error) {
+	now := time.Now()
+	s := &model.Session{
+		ID:         random.String(32),
+		UserID:     user.ID,
+		UserAgent:  userAgent,
+		IP:         ip,
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(lifetime),
+	}
+	if err := db.CreateSession(s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// ValidateSession returns errs.SessionInvalid if the session is expired, idle for too long or revoked,
+// and marks it as seen otherwise
+func ValidateSession(id string) (*model.Session, error) {
+	if id == "" {
+		return nil, errors.WithStack(errs.SessionInvalid)
+	}
+	if s, ok := sessionCache.Get(id); ok && time.Now().Before(s.ExpiresAt) {
+		return s, nil
+	}
+	s, err := db.GetSessionById(id)
+	if err != nil {
+		return nil, errors.WithStack(errs.SessionInvalid)
+	}
+	now := time.Now()
+	if now.After(s.ExpiresAt) {
+		return nil, errors.WithStack(errs.SessionInvalid)
+	}
+	if idle := sessionIdleTimeout(); idle > 0 && now.Sub(s.LastSeenAt) > idle {
+		return nil, errors.WithStack(errs.SessionInvalid)
+	}
+	if now.Sub(s.LastSeenAt) > tokenLastUsedInterval {
+		if err := db.UpdateSessionLastSeen(s.ID, now); err != nil {
+			log.Warnf("failed update last seen time of session: %+v", err)
+		}
+		s.LastSeenAt = now
+	}
+	sessionCache.Set(id, s, cache.WithEx[*model.Session](sessionCacheTTL))
+	return s, nil
+}
+
+// RevokeSession revokes the session with id of the user, or of any user with userId 0
+func RevokeSession(id string, userId uint) error {
+	s, err := db.GetSessionById(id)
+	if err != nil {
+		return err
+	}
+	if userId != 0 && s.UserID != userId {
+		return errors.WithStack(errs.ObjectNotFound)
+	}
+	if err = db.DeleteSessionById(id); err != nil {
+		return err
+	}
+	changed(clusterSessions, id)
+	return nil
+}
+
+// RevokeSessions logs the user out everywhere
+func RevokeSessions(userId uint) error {
+	if err := db.DeleteSessionsByUserId(userId); err != nil {
+		return err
+	}
+	changed(clusterSessions, "")
+	return nil
+}
+
+// PurgeSessions deletes the sessions that can't be used anymore
+func PurgeSessions() error {
+	now := time.Now()
+	var idleBefore *time.Time
+	if idle := sessionIdleTimeout(); idle > 0 {
+		t := now.Add(-idle)
+		idleBefore = &t
+	}
+	return db.DeleteStaleSessions(now, idleBefore)
+}
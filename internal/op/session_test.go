@@ -0,0 +1,73 @@
+package op_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/pkg/errors"
+)
+
+func TestSession(t *testing.T) {
+	user := &model.User{Username: "session_test"}
+	if err := op.CreateUser(user); err != nil {
+		t.Fatalf("failed to create user: %+v", err)
+	}
+	laptop, err := op.CreateSession(user, "laptop", "10.0.0.1", time.Hour)
+	if err != nil {
+		t.Fatalf("failed to create session: %+v", err)
+	}
+	phone, err := op.CreateSession(user, "phone", "10.0.0.2", time.Hour)
+	if err != nil {
+		t.Fatalf("failed to create session: %+v", err)
+	}
+	expired, err := op.CreateSession(user, "old", "10.0.0.3", -time.Minute)
+	if err != nil {
+		t.Fatalf("failed to create session: %+v", err)
+	}
+	if _, err = op.ValidateSession(laptop.ID); err != nil {
+		t.Errorf("expected the session to be valid, got %v", err)
+	}
+	if _, err = op.ValidateSession(expired.ID); !errors.Is(err, errs.SessionInvalid) {
+		t.Errorf("expected an expired session to be invalid, got %v", err)
+	}
+	if _, err = op.ValidateSession(""); !errors.Is(err, errs.SessionInvalid) {
+		t.Errorf("expected a token without session to be invalid, got %v", err)
+	}
+
+	if err = op.RevokeSession(laptop.ID, user.ID+1); err == nil {
+		t.Errorf("expected the session of another user not to be revoked")
+	}
+	if err = op.RevokeSession(laptop.ID, user.ID); err != nil {
+		t.Fatalf("failed to revoke session: %+v", err)
+	}
+	if _, err = op.ValidateSession(laptop.ID); !errors.Is(err, errs.SessionInvalid) {
+		t.Errorf("expected a revoked session to be invalid, got %v", err)
+	}
+	if err = op.PurgeSessions(); err != nil {
+		t.Fatalf("failed to purge sessions: %+v", err)
+	}
+	sessions, err := op.GetSessionsByUserId(user.ID)
+	if err != nil || len(sessions) != 1 || sessions[0].ID != phone.ID {
+		t.Errorf("expected only the phone session to be left, got %+v: %v", sessions, err)
+	}
+	// a valid session is cached for a while, but revoking drops it at once
+	if _, err = op.ValidateSession(phone.ID); err != nil {
+		t.Errorf("expected the session to be valid, got %v", err)
+	}
+	if err = db.DeleteSessionById(phone.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = op.ValidateSession(phone.ID); err != nil {
+		t.Errorf("expected the session to be cached, got %v", err)
+	}
+	if err = op.RevokeSessions(user.ID); err != nil {
+		t.Fatalf("failed to revoke sessions: %+v", err)
+	}
+	if _, err = op.ValidateSession(phone.ID); !errors.Is(err, errs.SessionInvalid) {
+		t.Errorf("expected all the sessions to be revoked, got %v", err)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/OpenListTeam/OpenList/v4/internal/cluster"
 	"github.com/OpenListTeam/OpenList/v4/internal/db"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/pkg/singleflight"
@@ -166,6 +167,11 @@ func SaveSettingItems(items []model.SettingItem) error {
 		return fmt.Errorf("failed save setting: %+v", err)
 	}
 	SettingCacheUpdate()
+	keys := make([]string, 0, len(items))
+	for _, item := range items {
+		keys = append(keys, item.Key)
+	}
+	cluster.Publish(clusterSettings, strings.Join(keys, ","))
 	return nil
 }
 
@@ -183,6 +189,7 @@ func SaveSettingItem(item *model.SettingItem) (err error) {
 		return fmt.Errorf("failed save setting on %s: %+v", item.Key, err)
 	}
 	SettingCacheUpdate()
+	cluster.Publish(clusterSettings, item.Key)
 	return nil
 }
 
@@ -195,7 +202,11 @@ func DeleteSettingItemByKey(key string) error {
 		return errors.Errorf("setting [%s] is not deprecated", key)
 	}
 	SettingCacheUpdate()
-	return db.DeleteSettingItemByKey(key)
+	if err = db.DeleteSettingItemByKey(key); err != nil {
+		return err
+	}
+	cluster.Publish(clusterSettings, "")
+	return nil
 }
 
 type MigrationValueItem struct {
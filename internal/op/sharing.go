@@ -4,6 +4,7 @@ import (
 	"fmt"
 	stdpath "path"
 	"strings"
+	"sync"
 
 	"github.com/OpenListTeam/OpenList/v4/internal/db"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
@@ -129,15 +130,61 @@ func UpdateSharing(sharing *model.Sharing, skipMarshal ...bool) (err error) {
 			return errors.WithStack(err)
 		}
 	}
-	sharingCache.Del(sharing.ID)
+	defer changed(clusterSharing, sharing.ID)
 	return db.UpdateSharing(sharing.SharingDB)
 }
 
 func DeleteSharing(sid string) error {
-	sharingCache.Del(sid)
+	defer changed(clusterSharing, sid)
 	return db.DeleteSharingById(sid)
 }
 
 func DeleteSharingsByCreatorId(creatorId uint) error {
 	return db.DeleteSharingsByCreatorId(creatorId)
 }
+
+// uploadMu serializes checking and counting the uploads of file requests
+var uploadMu sync.Mutex
+
+// ReserveSharingUpload counts an upload of size to the file request sid if it accepts the file,
+// and the upload has to be released with ReleaseSharingUpload if it fails
+func ReserveSharingUpload(sid, name string, size int64) error {
+	uploadMu.Lock()
+	defer uploadMu.Unlock()
+	sharing, err := GetSharingById(sid, true)
+	if err != nil {
+		return err
+	}
+	if err = sharing.CheckUpload(name, size); err != nil {
+		return err
+	}
+	defer changed(clusterSharing, sid)
+	return db.UpdateSharingUploads(sid, 1, max(size, 0))
+}
+
+func ReleaseSharingUpload(sid string, size int64) error {
+	uploadMu.Lock()
+	defer uploadMu.Unlock()
+	defer changed(clusterSharing, sid)
+	return db.UpdateSharingUploads(sid, -1, -max(size, 0))
+}
+
+// SettleSharingUpload counts the bytes actually received for an upload reserved with size,
+// which is 0 if it was unknown
+func SettleSharingUpload(sid string, size, received int64) error {
+	if received == max(size, 0) {
+		return nil
+	}
+	uploadMu.Lock()
+	defer uploadMu.Unlock()
+	defer changed(clusterSharing, sid)
+	return db.UpdateSharingUploads(sid, 0, received-max(size, 0))
+}
+
+func CreateSharingUpload(u *model.SharingUpload) error {
+	return db.CreateSharingUpload(u)
+}
+
+func GetSharingUploads(sid string, pageIndex, pageSize int) ([]model.SharingUpload, int64, error) {
+	return db.GetSharingUploads(sid, pageIndex, pageSize)
+}
@@ -0,0 +1,116 @@
+package op
+
+import (
+	"cmp"
+	"slices"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/cluster"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	log "github.com/sirupsen/logrus"
+)
+
+// statsTopN is how many files and referers the stats of a sharing keep
+const statsTopN = 20
+
+func CreateSharingAccessLog(l *model.SharingAccessLog) error {
+	return db.CreateSharingAccessLog(l)
+}
+
+// AddSharingTraffic counts the bytes served by the sharing, which is disabled when it reaches the limit.
+// A negative bytes gives back the traffic reserved but not served
+func AddSharingTraffic(sid string, bytes int64) error {
+	defer sharingCache.Del(sid)
+	disabled, err := db.AddSharingTraffic(sid, bytes)
+	if disabled {
+		log.Infof("sharing %s is disabled for reaching the traffic limit", sid)
+		// the traffic of every download isn't worth an event, the other instances only need to know it's disabled
+		cluster.Publish(clusterSharing, sid)
+	}
+	return err
+}
+
+// ReserveSharingTraffic counts bytes to the traffic of the sharing before they're served,
+// only if it stays within the limit, which is reported by ok
+func ReserveSharingTraffic(sid string, bytes int64) (ok bool, err error) {
+	return db.ReserveSharingTraffic(sid, bytes)
+}
+
+func ResetSharingTraffic(sid string) error {
+	defer changed(clusterSharing, sid)
+	return db.ResetSharingTraffic(sid)
+}
+
+func DeleteSharingAccessLogsBefore(t time.Time) error {
+	return db.DeleteSharingAccessLogsBefore(t)
+}
+
+// GetSharingStats sums up the accesses of the sharing in [start, end), with the timeline by the day in loc
+func GetSharingStats(sid string, start, end time.Time, loc *time.Location) (*model.SharingStats, error) {
+	stats := &model.SharingStats{
+		Files:    []model.SharingFileStats{},
+		Referers: []model.SharingRefererStats{},
+		Timeline: []model.SharingDayStats{},
+	}
+	ips := make(map[string]struct{})
+	files := make(map[string]*model.SharingFileStats)
+	referers := make(map[string]int64)
+	days := make(map[string]*model.SharingDayStats)
+	err := db.WalkSharingAccessLogs(sid, start, end, func(logs []model.SharingAccessLog) error {
+		for _, l := range logs {
+			ips[l.IP] = struct{}{}
+			date := l.Time.In(loc).Format(time.DateOnly)
+			day, ok := days[date]
+			if !ok {
+				day = &model.SharingDayStats{Date: date}
+				days[date] = day
+			}
+			if l.Referer != "" {
+				referers[l.Referer]++
+			}
+			if l.Type != model.SharingAccessDownload {
+				stats.Views++
+				day.Views++
+				continue
+			}
+			stats.Downloads++
+			stats.Bytes += l.Bytes
+			day.Downloads++
+			day.Bytes += l.Bytes
+			f, ok := files[l.Path]
+			if !ok {
+				f = &model.SharingFileStats{Path: l.Path}
+				files[l.Path] = f
+			}
+			f.Downloads++
+			f.Bytes += l.Bytes
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	stats.UniqueIPs = len(ips)
+	for _, f := range files {
+		stats.Files = append(stats.Files, *f)
+	}
+	slices.SortFunc(stats.Files, func(a, b model.SharingFileStats) int {
+		return cmp.Or(cmp.Compare(b.Downloads, a.Downloads), cmp.Compare(a.Path, b.Path))
+	})
+	stats.Files = stats.Files[:min(len(stats.Files), statsTopN)]
+	for r, n := range referers {
+		stats.Referers = append(stats.Referers, model.SharingRefererStats{Referer: r, Count: n})
+	}
+	slices.SortFunc(stats.Referers, func(a, b model.SharingRefererStats) int {
+		return cmp.Or(cmp.Compare(b.Count, a.Count), cmp.Compare(a.Referer, b.Referer))
+	})
+	stats.Referers = stats.Referers[:min(len(stats.Referers), statsTopN)]
+	for _, d := range days {
+		stats.Timeline = append(stats.Timeline, *d)
+	}
+	slices.SortFunc(stats.Timeline, func(a, b model.SharingDayStats) int {
+		return cmp.Compare(a.Date, b.Date)
+	})
+	return stats, nil
+}
@@ -0,0 +1,88 @@
+package op_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+)
+
+func TestSharingStats(t *testing.T) {
+	user := &model.User{Username: "share_stats_test", Permission: 0x7FFF}
+	if err := op.CreateUser(user); err != nil {
+		t.Fatalf("failed to create user: %+v", err)
+	}
+	s := &model.Sharing{
+		SharingDB: &model.SharingDB{MaxTraffic: 100},
+		Files:     []string{"/docs"},
+		Creator:   user,
+	}
+	sid, err := op.CreateSharing(s)
+	if err != nil {
+		t.Fatalf("failed to create sharing: %+v", err)
+	}
+	defer func() { _ = op.DeleteSharing(sid) }()
+
+	now := time.Now()
+	for _, l := range []model.SharingAccessLog{
+		{Type: model.SharingAccessView, Path: "/", IP: "1.1.1.1"},
+		{Type: model.SharingAccessDownload, Path: "/a.txt", IP: "1.1.1.1", Referer: "https://forum.example", Bytes: 60},
+		{Type: model.SharingAccessDownload, Path: "/a.txt", IP: "2.2.2.2", Bytes: 50},
+		{Type: model.SharingAccessDownload, Path: "/b.txt", IP: "2.2.2.2", Referer: "https://forum.example", Bytes: 1},
+	} {
+		l.SharingID, l.Time = sid, now
+		if err = op.CreateSharingAccessLog(&l); err != nil {
+			t.Fatalf("failed to create access log: %+v", err)
+		}
+	}
+	stats, err := op.GetSharingStats(sid, now.Add(-time.Hour), now.Add(time.Hour), time.Local)
+	if err != nil {
+		t.Fatalf("failed to get stats: %+v", err)
+	}
+	if stats.Views != 1 || stats.Downloads != 3 || stats.Bytes != 111 || stats.UniqueIPs != 2 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+	if len(stats.Files) != 2 || stats.Files[0].Path != "/a.txt" || stats.Files[0].Downloads != 2 {
+		t.Errorf("expected /a.txt to be the top file, got %+v", stats.Files)
+	}
+	if len(stats.Referers) != 1 || stats.Referers[0].Count != 2 {
+		t.Errorf("expected 1 referer with 2 accesses, got %+v", stats.Referers)
+	}
+	if len(stats.Timeline) != 1 || stats.Timeline[0].Bytes != 111 {
+		t.Errorf("expected 1 day in the timeline, got %+v", stats.Timeline)
+	}
+
+	if err = op.AddSharingTraffic(sid, 60); err != nil {
+		t.Fatalf("failed to add traffic: %+v", err)
+	}
+	if s, err = op.GetSharingById(sid); err != nil || s.Disabled || !s.Valid() {
+		t.Fatalf("expected the sharing to be valid under the limit, got %+v: %v", s, err)
+	}
+	if err = op.AddSharingTraffic(sid, 50); err != nil {
+		t.Fatalf("failed to add traffic: %+v", err)
+	}
+	if s, err = op.GetSharingById(sid); err != nil || !s.Disabled || s.Traffic != 110 {
+		t.Fatalf("expected the sharing to be disabled over the limit, got %+v: %v", s, err)
+	}
+	if err = op.ResetSharingTraffic(sid); err != nil {
+		t.Fatalf("failed to reset traffic: %+v", err)
+	}
+	if s, err = op.GetSharingById(sid); err != nil || s.Traffic != 0 {
+		t.Errorf("expected the traffic to be reset, got %+v: %v", s, err)
+	}
+
+	// a download reserves its traffic before serving it, and gives back what it didn't serve
+	if ok, err := op.ReserveSharingTraffic(sid, 80); err != nil || !ok {
+		t.Fatalf("expected the traffic to be reserved, got %v: %v", ok, err)
+	}
+	if ok, err := op.ReserveSharingTraffic(sid, 30); err != nil || ok {
+		t.Errorf("expected the traffic over the limit not to be reserved, got %v: %v", ok, err)
+	}
+	if err = op.AddSharingTraffic(sid, -10); err != nil {
+		t.Fatalf("failed to give back traffic: %+v", err)
+	}
+	if s, err = op.GetSharingById(sid); err != nil || s.Traffic != 70 {
+		t.Errorf("expected 70 bytes of traffic, got %+v: %v", s.SharingDB, err)
+	}
+}
@@ -0,0 +1,62 @@
+package op_test
+
+import (
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/pkg/errors"
+)
+
+func TestSharingUpload(t *testing.T) {
+	user := &model.User{Username: "file_request_test", Permission: 0x7FFF}
+	if err := op.CreateUser(user); err != nil {
+		t.Fatalf("failed to create user: %+v", err)
+	}
+	s := &model.Sharing{
+		SharingDB: &model.SharingDB{
+			Type:        model.SharingTypeUpload,
+			AllowedExts: "pdf, .ZIP",
+			MaxFileSize: 10,
+			MaxUploads:  1,
+		},
+		Files:   []string{"/inbox"},
+		Creator: user,
+	}
+	sid, err := op.CreateSharing(s)
+	if err != nil {
+		t.Fatalf("failed to create sharing: %+v", err)
+	}
+	defer func() { _ = op.DeleteSharing(sid) }()
+	for _, c := range []struct {
+		name string
+		size int64
+	}{{"a.txt", 1}, {"a.pdf", 20}, {"a.pdf", -1}} {
+		if err = op.ReserveSharingUpload(sid, c.name, c.size); !errors.Is(err, errs.FileRequestRefused) {
+			t.Errorf("expected %s of %d bytes to be refused, got %v", c.name, c.size, err)
+		}
+	}
+	stale, err := op.GetSharingById(sid)
+	if err != nil {
+		t.Fatalf("failed to get sharing: %+v", err)
+	}
+	if err = op.ReserveSharingUpload(sid, "a.ZIP", 5); err != nil {
+		t.Fatalf("failed to reserve upload: %+v", err)
+	}
+	// saving the sharing doesn't change the upload counters
+	stale.Remark = "vendors"
+	if err = op.UpdateSharing(stale); err != nil {
+		t.Fatalf("failed to update sharing: %+v", err)
+	}
+	if err = op.ReserveSharingUpload(sid, "b.pdf", 5); !errors.Is(err, errs.FileRequestRefused) {
+		t.Errorf("expected the second upload to be refused, got %v", err)
+	}
+	if err = op.ReleaseSharingUpload(sid, 5); err != nil {
+		t.Fatalf("failed to release upload: %+v", err)
+	}
+	got, err := op.GetSharingById(sid, true)
+	if err != nil || got.Uploads != 0 || got.UploadedSize != 0 || got.Remark != "vendors" {
+		t.Errorf("expected the upload to be released, got %+v: %v", got.SharingDB, err)
+	}
+}
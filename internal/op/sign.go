@@ -0,0 +1,77 @@
+package op
+
+import (
+	"sync"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+)
+
+// the revocations are checked for every scoped sign, so their ids are kept in memory
+var (
+	signRevocations map[string]struct{}
+	signRevokedMu   sync.RWMutex
+)
+
+func getSignRevocations() (map[string]struct{}, error) {
+	signRevokedMu.RLock()
+	if signRevocations != nil {
+		defer signRevokedMu.RUnlock()
+		return signRevocations, nil
+	}
+	signRevokedMu.RUnlock()
+	signRevokedMu.Lock()
+	defer signRevokedMu.Unlock()
+	if signRevocations == nil {
+		rs, err := db.GetAllSignRevocations()
+		if err != nil {
+			return nil, err
+		}
+		m := make(map[string]struct{}, len(rs))
+		for _, r := range rs {
+			m[r.ID] = struct{}{}
+		}
+		signRevocations = m
+	}
+	return signRevocations, nil
+}
+
+func clearSignRevocations() {
+	signRevokedMu.Lock()
+	defer signRevokedMu.Unlock()
+	signRevocations = nil
+}
+
+// SignRevoked reports whether any of the ids, which are key ids or batches of scoped signs, is revoked
+func SignRevoked(ids ...string) (bool, error) {
+	m, err := getSignRevocations()
+	if err != nil {
+		return false, err
+	}
+	for _, id := range ids {
+		if _, ok := m[id]; ok && id != "" {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func RevokeSigns(rs []model.SignRevocation) error {
+	if len(rs) == 0 {
+		return nil
+	}
+	defer changed(clusterSigns, "")
+	return db.CreateSignRevocations(rs)
+}
+
+// UseSign counts a download by the scoped sign with the key id, and reports false if it allows no more
+func UseSign(keyID string, max int, expiresAt *time.Time) (bool, error) {
+	return db.UseSign(keyID, max, expiresAt)
+}
+
+// PurgeSigns drops the revocations and usages of the expired signs
+func PurgeSigns() error {
+	defer changed(clusterSigns, "")
+	return db.DeleteExpiredSigns(time.Now())
+}
@@ -0,0 +1,36 @@
+package op_test
+
+import (
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+)
+
+func TestSignRevocationAndUsage(t *testing.T) {
+	if revoked, err := op.SignRevoked("key1", "batch1"); err != nil || revoked {
+		t.Fatalf("expected nothing to be revoked, got %v: %v", revoked, err)
+	}
+	if err := op.RevokeSigns([]model.SignRevocation{{ID: "batch1"}}); err != nil {
+		t.Fatalf("failed to revoke: %+v", err)
+	}
+	// revoking again is fine
+	if err := op.RevokeSigns([]model.SignRevocation{{ID: "batch1"}}); err != nil {
+		t.Fatalf("failed to revoke again: %+v", err)
+	}
+	if revoked, err := op.SignRevoked("key1", "batch1"); err != nil || !revoked {
+		t.Errorf("expected the batch to be revoked, got %v: %v", revoked, err)
+	}
+	if revoked, _ := op.SignRevoked("key2", ""); revoked {
+		t.Errorf("expected another key not to be revoked")
+	}
+
+	for i := 0; i < 2; i++ {
+		if ok, err := op.UseSign("key3", 2, nil); err != nil || !ok {
+			t.Fatalf("expected use %d to be allowed, got %v: %v", i+1, ok, err)
+		}
+	}
+	if ok, err := op.UseSign("key3", 2, nil); err != nil || ok {
+		t.Errorf("expected the third use to be refused, got %v: %v", ok, err)
+	}
+}
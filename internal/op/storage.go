@@ -61,6 +61,7 @@ func CreateStorage(ctx context.Context, storage model.Storage) (uint, error) {
 	if err != nil {
 		return storage.ID, errors.WithMessage(err, "failed create storage in database")
 	}
+	defer publishStorageChange(storage.ID)
 	// already has an id
 	err = initStorage(ctx, storage, storageDriver)
 	go callStorageHooks("add", storageDriver)
@@ -181,6 +182,7 @@ func EnableStorage(ctx context.Context, id uint) error {
 	if err != nil {
 		return errors.WithMessage(err, "failed update storage in db")
 	}
+	defer publishStorageChange(id)
 	err = LoadStorage(ctx, *storage)
 	if err != nil {
 		return errors.WithMessage(err, "failed load storage")
@@ -211,6 +213,8 @@ func DisableStorage(ctx context.Context, id uint) error {
 	if err != nil {
 		return errors.WithMessage(err, "failed update storage in db")
 	}
+	publishStorageChange(id)
+	publishStorageStatus(storage)
 	storagesMap.Delete(storage.MountPath)
 	go callStorageHooks("del", storageDriver)
 	return nil
@@ -233,6 +237,7 @@ func UpdateStorage(ctx context.Context, storage model.Storage) error {
 	if err != nil {
 		return errors.WithMessage(err, "failed update storage in database")
 	}
+	defer publishStorageChange(storage.ID)
 	if storage.Disabled {
 		return nil
 	}
@@ -282,6 +287,8 @@ func DeleteStorageById(ctx context.Context, id uint) error {
 	if err := db.DeleteStorageById(id); err != nil {
 		return errors.WithMessage(err, "failed delete storage in database")
 	}
+	publishStorageChange(id)
+	storageStatuses.Delete(id)
 	return dropErr
 }
 
@@ -301,6 +308,7 @@ func saveDriverStorage(driver driver.Driver) error {
 		return errors.Wrap(err, "error while marshal addition")
 	}
 	storage.Addition = str
+	publishStorageStatus(storage)
 	err = db.UpdateStorage(storage)
 	if err != nil {
 		return errors.WithMessage(err, "failed update storage in database")
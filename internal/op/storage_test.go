@@ -2,10 +2,12 @@ package op_test
 
 import (
 	"context"
+	"encoding/json"
 	"testing"
 
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
 	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/driver"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/internal/op"
 	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
@@ -23,6 +25,24 @@ func init() {
 	db.Init(dB)
 }
 
+// createLocalStorage creates a Local storage of the root folder, a temp dir if it's empty,
+// with the mount path and other settings of the storage
+func createLocalStorage(t *testing.T, root string, storage model.Storage) driver.Driver {
+	if root == "" {
+		root = t.TempDir()
+	}
+	addition, _ := json.Marshal(map[string]string{"root_folder_path": root})
+	storage.Driver, storage.Addition = "Local", string(addition)
+	if _, err := op.CreateStorage(context.Background(), storage); err != nil {
+		t.Fatalf("failed to create storage: %+v", err)
+	}
+	d, err := op.GetStorageByMountPath(storage.MountPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
 func TestCreateStorage(t *testing.T) {
 	var storages = []struct {
 		storage model.Storage
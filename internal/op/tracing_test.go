@@ -0,0 +1,53 @@
+package op_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tracing.SetProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	ctx := context.Background()
+	storage := createLocalStorage(t, "", model.Storage{MountPath: "/tracing_test"})
+	defer op.DeleteStorageById(ctx, storage.GetStorage().ID)
+
+	ctx, root := tracing.Start(ctx, "test")
+	if _, err := op.List(ctx, storage, "/", model.ListArgs{}); err != nil {
+		t.Fatalf("failed to list: %+v", err)
+	}
+	root.End()
+
+	spans := map[string]sdktrace.ReadOnlySpan{}
+	for _, s := range recorder.Ended() {
+		spans[s.Name()] = s
+	}
+	list, driverList := spans["op.List"], spans["driver.list"]
+	if list == nil || driverList == nil {
+		t.Fatalf("expected op.List and driver.list spans, got %v", spans)
+	}
+	if list.Parent().SpanID() != root.SpanContext().SpanID() {
+		t.Errorf("expected op.List to be a child of the span of the caller")
+	}
+	if driverList.Parent().SpanID() != list.SpanContext().SpanID() {
+		t.Errorf("expected driver.list to be a child of op.List")
+	}
+	attrs := map[attribute.Key]attribute.Value{}
+	for _, a := range list.Attributes() {
+		attrs[a.Key] = a.Value
+	}
+	if attrs["storage"].AsString() != "/tracing_test" || attrs["path"].AsString() != "/" {
+		t.Errorf("unexpected attributes of op.List: %v", attrs)
+	}
+	if hit, ok := attrs["cache.dir.hit"]; !ok || hit.AsBool() {
+		t.Errorf("expected a dir cache miss on op.List, got %v", attrs)
+	}
+}
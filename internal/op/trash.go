@@ -0,0 +1,251 @@
+package op
+
+import (
+	"context"
+	stdpath "path"
+	"strings"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/driver"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/event"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/stream"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils/random"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// TrashDirName is the folder in the root of a storage that keeps removed objects,
+// each of them in its own sub folder so that names never collide
+const TrashDirName = ".openlist_trash"
+
+// IsTrashPath reports whether the actual path is the trash folder or inside it
+func IsTrashPath(path string) bool {
+	path = utils.FixAndCleanPath(path)
+	return path == "/"+TrashDirName || strings.HasPrefix(path, "/"+TrashDirName+"/")
+}
+
+// trashKey marks the ctx of the trash itself, which is the only one writing in the trash folder
+type trashKey struct{}
+
+// checkTrashWrite refuses writing any of the actual paths if it's in the trash folder of the storage,
+// so that the removed objects are only restored or purged through the trash
+func checkTrashWrite(ctx context.Context, storage driver.Driver, paths ...string) error {
+	if !storage.GetStorage().EnableTrash || ctx.Value(trashKey{}) != nil {
+		return nil
+	}
+	for _, path := range paths {
+		if IsTrashPath(path) {
+			return errors.WithMessagef(errs.PermissionDenied, "[%s] is in the trash", path)
+		}
+	}
+	return nil
+}
+
+// removeWithoutTrash is used to remove the temporary objects of op itself,
+// which must not end up in the trash
+func removeWithoutTrash(ctx context.Context, storage driver.Driver, path string) error {
+	rawObj, err := Get(ctx, storage, path, true)
+	if err != nil {
+		if errs.IsObjectNotFound(err) {
+			return nil
+		}
+		return errors.WithMessage(err, "failed to get object")
+	}
+	return remove(ctx, storage, path, rawObj)
+}
+
+func moveToTrash(ctx context.Context, storage driver.Driver, path string, rawObj model.Obj) error {
+	entryDir := stdpath.Join("/", TrashDirName, random.String(16))
+	trashCtx := context.WithValue(context.WithValue(ctx, conf.SkipHookKey, struct{}{}), conf.SkipEventKey, struct{}{})
+	trashCtx = context.WithValue(trashCtx, trashKey{}, struct{}{})
+	if err := MakeDir(trashCtx, storage, entryDir); err != nil {
+		return errors.WithMessage(err, "failed make trash dir")
+	}
+	if err := relocate(trashCtx, storage, path, entryDir); err != nil {
+		if obj, e := Get(trashCtx, storage, entryDir); e == nil {
+			_ = remove(trashCtx, storage, entryDir, obj)
+		}
+		return errors.WithMessage(err, "failed move to trash")
+	}
+	name := stdpath.Base(path)
+	item := &model.TrashItem{
+		StorageID:    storage.GetStorage().ID,
+		MountPath:    storage.GetStorage().MountPath,
+		OriginalPath: utils.GetFullPath(storage.GetStorage().MountPath, path),
+		TrashPath:    stdpath.Join(entryDir, name),
+		Name:         name,
+		IsDir:        rawObj.IsDir(),
+		Size:         rawObj.GetSize(),
+		DeletedAt:    time.Now(),
+	}
+	if user, ok := ctx.Value(conf.UserKey).(*model.User); ok {
+		item.DeleterId, item.Deleter = user.ID, user.Username
+	}
+	return errors.WithMessagef(db.CreateTrashItem(item), "[%s] is in trash [%s] but not recorded", path, item.TrashPath)
+}
+
+// relocate moves an object inside the storage. Drivers that can't move are
+// copied then removed, and those that can't copy either are copied through streams.
+func relocate(ctx context.Context, storage driver.Driver, srcPath, dstDirPath string) error {
+	ctx = context.WithValue(context.WithValue(ctx, conf.SkipQuotaKey, struct{}{}), trashKey{}, struct{}{})
+	switch storage.(type) {
+	case driver.Move, driver.MoveResult:
+		return Move(ctx, storage, srcPath, dstDirPath)
+	case driver.Copy, driver.CopyResult:
+		if err := Copy(ctx, storage, srcPath, dstDirPath); err != nil {
+			return err
+		}
+	default:
+		if err := copyByStream(ctx, storage, srcPath, dstDirPath); err != nil {
+			return err
+		}
+	}
+	obj, err := Get(ctx, storage, srcPath, true)
+	if err != nil {
+		return errors.WithMessage(err, "failed get copied src object")
+	}
+	return remove(ctx, storage, srcPath, obj)
+}
+
+func copyByStream(ctx context.Context, storage driver.Driver, srcPath, dstDirPath string) error {
+	obj, err := GetUnwrap(ctx, storage, srcPath)
+	if err != nil {
+		return errors.WithMessagef(err, "failed get [%s]", srcPath)
+	}
+	if obj.IsDir() {
+		dstPath := stdpath.Join(dstDirPath, obj.GetName())
+		if err = MakeDir(ctx, storage, dstPath); err != nil {
+			return err
+		}
+		objs, err := List(ctx, storage, srcPath, model.ListArgs{SkipHook: true})
+		if err != nil {
+			return err
+		}
+		for _, o := range objs {
+			if err = copyByStream(ctx, storage, stdpath.Join(srcPath, o.GetName()), dstPath); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	link, file, err := Link(ctx, storage, srcPath, model.LinkArgs{})
+	if err != nil {
+		return errors.WithMessagef(err, "failed get [%s] link", srcPath)
+	}
+	ss, err := stream.NewSeekableStream(&stream.FileStream{Obj: file, Ctx: ctx}, link)
+	if err != nil {
+		_ = link.Close()
+		return errors.WithMessagef(err, "failed get [%s] stream", srcPath)
+	}
+	return Put(ctx, storage, dstDirPath, ss, nil)
+}
+
+func getTrashItemStorage(item *model.TrashItem) (driver.Driver, error) {
+	storage, err := GetStorageByMountPath(item.MountPath)
+	if err != nil || storage.GetStorage().ID != item.StorageID {
+		return nil, errors.WithStack(errs.StorageNotFound)
+	}
+	return storage, nil
+}
+
+// RestoreTrashItem moves the object back to its original path, it fails if
+// another object took that path in the meantime, or the acl rules deny the user of ctx
+// to write there
+func RestoreTrashItem(ctx context.Context, item *model.TrashItem) error {
+	// the acl rules may have changed since the object was removed
+	user, _ := ctx.Value(conf.UserKey).(*model.User)
+	if err := CheckAcl(user, model.AclWrite, item.OriginalPath); err != nil {
+		return err
+	}
+	storage, err := getTrashItemStorage(item)
+	if err != nil {
+		return errors.WithMessage(err, "failed get storage of trash item")
+	}
+	actualPath := utils.FixAndCleanPath(strings.TrimPrefix(item.OriginalPath, utils.GetActualMountPath(storage.GetStorage().MountPath)))
+	if _, err = Get(ctx, storage, actualPath); err == nil {
+		return errors.WithStack(errs.ObjectAlreadyExists)
+	} else if !errs.IsObjectNotFound(err) {
+		return err
+	}
+	owner := quotaOwner(ctx, item.OriginalPath)
+	bytes, files := max(item.Size, 0), int64(1)
+	if item.IsDir {
+		files = 0
+		if owner != nil && owner.HasQuota() {
+			if bytes, files, err = treeUsage(ctx, storage, item.TrashPath); err != nil {
+				return err
+			}
+		}
+	}
+	reservation, err := reserveUsage(owner, bytes, files)
+	if err != nil {
+		return err
+	}
+	dstDirPath := stdpath.Dir(actualPath)
+	if err = MakeDir(ctx, storage, dstDirPath); err != nil {
+		reservation.release()
+		return errors.WithMessage(err, "failed make original dir")
+	}
+	if err = relocate(context.WithValue(ctx, conf.SkipEventKey, struct{}{}), storage, item.TrashPath, dstDirPath); err != nil {
+		reservation.release()
+		return errors.WithMessage(err, "failed restore from trash")
+	}
+	publishObjEvent(ctx, event.ObjectCreated, storage, actualPath, "", item.IsDir)
+	trashCtx := context.WithValue(context.WithValue(ctx, conf.SkipHookKey, struct{}{}), conf.SkipEventKey, struct{}{})
+	if obj, err := Get(trashCtx, storage, stdpath.Dir(item.TrashPath)); err == nil {
+		_ = remove(trashCtx, storage, stdpath.Dir(item.TrashPath), obj)
+	}
+	return db.DeleteTrashItemById(item.ID)
+}
+
+// PurgeTrashItem removes the object from the trash for good. If the storage
+// doesn't exist anymore, only the record is deleted
+func PurgeTrashItem(ctx context.Context, item *model.TrashItem) error {
+	storage, err := getTrashItemStorage(item)
+	if err == nil {
+		entryDir := stdpath.Dir(item.TrashPath)
+		obj, err := Get(ctx, storage, entryDir)
+		if err == nil {
+			err = remove(context.WithValue(ctx, conf.SkipHookKey, struct{}{}), storage, entryDir, obj)
+		}
+		if err != nil && !errs.IsObjectNotFound(err) {
+			return errors.WithMessage(err, "failed purge trash item")
+		}
+	}
+	return db.DeleteTrashItemById(item.ID)
+}
+
+// PurgeExpiredTrash purges the trash items removed before t.
+// Items of storages that are not loaded are kept, they may come back
+func PurgeExpiredTrash(ctx context.Context, before time.Time) {
+	items, err := db.GetTrashItemsDeletedBefore(before)
+	if err != nil {
+		log.Errorf("failed get expired trash items: %+v", err)
+		return
+	}
+	for i := range items {
+		if _, err = getTrashItemStorage(&items[i]); err != nil {
+			continue
+		}
+		if err = PurgeTrashItem(ctx, &items[i]); err != nil {
+			log.Errorf("failed purge trash item [%s]: %+v", items[i].OriginalPath, err)
+		}
+	}
+}
+
+func GetTrashItemById(id uint) (*model.TrashItem, error) {
+	return db.GetTrashItemById(id)
+}
+
+func GetTrashItems(pageIndex, pageSize int) ([]model.TrashItem, int64, error) {
+	return db.GetTrashItems(pageIndex, pageSize)
+}
+
+func GetTrashItemsByDeleterId(deleterId uint, pageIndex, pageSize int) ([]model.TrashItem, int64, error) {
+	return db.GetTrashItemsByDeleterId(deleterId, pageIndex, pageSize)
+}
@@ -0,0 +1,131 @@
+package op_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/stream"
+	"github.com/pkg/errors"
+)
+
+func TestTrash(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "a.txt"), []byte("hello"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	storage := createLocalStorage(t, root, model.Storage{MountPath: "/trash_test", Trash: model.Trash{EnableTrash: true}})
+
+	if err := op.Remove(ctx, storage, "/a.txt"); err != nil {
+		t.Fatalf("failed to remove: %+v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "a.txt")); !os.IsNotExist(err) {
+		t.Fatalf("expected a.txt to be moved away, got %v", err)
+	}
+	items, total, err := op.GetTrashItems(1, 10)
+	if err != nil || total != 1 {
+		t.Fatalf("expected 1 trash item, got %d: %+v", total, err)
+	}
+	item := items[0]
+	if item.OriginalPath != "/trash_test/a.txt" || item.Size != 5 {
+		t.Errorf("unexpected trash item: %+v", item)
+	}
+	if _, err = os.Stat(filepath.Join(root, filepath.FromSlash(item.TrashPath))); err != nil {
+		t.Errorf("expected the object in the trash: %v", err)
+	}
+
+	// the write acl of the user restoring the object applies to the original path
+	rule := &model.AclRule{Path: "/trash_test", SubjectType: model.AclSubjectUser, SubjectID: 300, Operations: []string{model.AclWrite}}
+	if err = op.CreateAclRule(rule); err != nil {
+		t.Fatalf("failed to create acl rule: %+v", err)
+	}
+	userCtx := context.WithValue(ctx, conf.UserKey, &model.User{ID: 300, BasePath: "/"})
+	if err = op.RestoreTrashItem(userCtx, &item); !errors.Is(err, errs.PermissionDenied) {
+		t.Errorf("expected the restore to be denied by the acl, got %v", err)
+	}
+	if err = op.DeleteAclRuleById(rule.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err = op.RestoreTrashItem(userCtx, &item); err != nil {
+		t.Fatalf("failed to restore: %+v", err)
+	}
+	if data, err := os.ReadFile(filepath.Join(root, "a.txt")); err != nil || string(data) != "hello" {
+		t.Errorf("expected a.txt to be restored, got %q, %v", data, err)
+	}
+
+	if err = op.Remove(ctx, storage, "/a.txt"); err != nil {
+		t.Fatalf("failed to remove: %+v", err)
+	}
+	items, _, _ = op.GetTrashItems(1, 10)
+	if err = op.PurgeTrashItem(ctx, &items[0]); err != nil {
+		t.Fatalf("failed to purge: %+v", err)
+	}
+	if _, total, _ = op.GetTrashItems(1, 10); total != 0 {
+		t.Errorf("expected no trash item after purge, got %d", total)
+	}
+	if _, err = os.Stat(filepath.Join(root, filepath.FromSlash(items[0].TrashPath))); !os.IsNotExist(err) {
+		t.Errorf("expected the object to be purged, got %v", err)
+	}
+}
+
+func TestTrashWrites(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "a.txt"), []byte("hello"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(root, "b"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	storage := createLocalStorage(t, root, model.Storage{MountPath: "/trash_write_test", Trash: model.Trash{EnableTrash: true}})
+	if err := op.Remove(ctx, storage, "/a.txt"); err != nil {
+		t.Fatalf("failed to remove: %+v", err)
+	}
+	trashPath := ""
+	items, _, _ := op.GetTrashItems(1, 100)
+	for _, item := range items {
+		if item.OriginalPath == "/trash_write_test/a.txt" {
+			trashPath = item.TrashPath
+		}
+	}
+	if trashPath == "" {
+		t.Fatalf("expected a trash item of a.txt, got %+v", items)
+	}
+	put := func(dirPath, name string) error {
+		return op.Put(ctx, storage, dirPath, &stream.FileStream{
+			Obj:    &model.Object{Name: name, Size: 1},
+			Reader: strings.NewReader("x"),
+		}, nil)
+	}
+
+	for _, c := range []struct {
+		name string
+		do   func() error
+	}{
+		{"make dir in trash", func() error { return op.MakeDir(ctx, storage, "/"+op.TrashDirName+"/x") }},
+		{"put in trash", func() error { return put("/"+op.TrashDirName, "x.txt") }},
+		{"put as trash", func() error { return put("/", op.TrashDirName) }},
+		{"put url in trash", func() error { return op.PutURL(ctx, storage, "/"+op.TrashDirName, "x.txt", "http://127.0.0.1/x") }},
+		{"rename trash away", func() error { return op.Rename(ctx, storage, "/"+op.TrashDirName, "gone") }},
+		{"rename as trash", func() error { return op.Rename(ctx, storage, "/b", op.TrashDirName) }},
+		{"move trash away", func() error { return op.Move(ctx, storage, "/"+op.TrashDirName, "/b") }},
+		{"move out of trash", func() error { return op.Move(ctx, storage, trashPath, "/") }},
+		{"move in trash", func() error { return op.Move(ctx, storage, "/b", "/"+op.TrashDirName) }},
+		{"copy out of trash", func() error { return op.Copy(ctx, storage, trashPath, "/b") }},
+		{"copy in trash", func() error { return op.Copy(ctx, storage, "/b", "/"+op.TrashDirName) }},
+	} {
+		if err := c.do(); !errors.Is(err, errs.PermissionDenied) {
+			t.Errorf("%s: expected permission denied, got %v", c.name, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(root, filepath.FromSlash(trashPath))); err != nil {
+		t.Errorf("expected the object to stay in the trash: %v", err)
+	}
+}
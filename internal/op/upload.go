@@ -0,0 +1,75 @@
+package op
+
+import (
+	"context"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/driver"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	log "github.com/sirupsen/logrus"
+)
+
+// putResumable uploads the file through the upload session of the task,
+// the session is continued from the uploaded offset if it's of the same file
+func putResumable(ctx context.Context, s driver.ResumablePut, dstDir model.Obj, dstPath string, file model.FileStreamer, session *model.UploadSession, up driver.UpdateProgress) (model.Obj, error) {
+	save := func(state string) {
+		session.State = state
+		if session.Save != nil {
+			session.Save()
+		}
+	}
+	var offset int64
+	if session.State != "" && (session.Path != dstPath || session.Size != file.GetSize()) {
+		abortUpload(ctx, s, session.State)
+		save("")
+	}
+	if session.State != "" {
+		var err error
+		offset, err = s.UploadedOffset(ctx, dstDir, file, session.State)
+		if err != nil {
+			log.Warnf("failed resume the upload session of [%s], begin a new one: %+v", dstPath, err)
+			// the session may have expired, or it's still there and only holds space
+			abortUpload(ctx, s, session.State)
+			offset = 0
+			save("")
+		} else {
+			log.Infof("resume the upload of [%s] from %d bytes", dstPath, offset)
+		}
+	}
+	if session.State == "" {
+		state, err := s.BeginUpload(ctx, dstDir, file, up)
+		if err != nil || state == "" {
+			return nil, err
+		}
+		session.Path, session.Size = dstPath, file.GetSize()
+		save(state)
+	}
+	obj, err := s.ResumeUpload(ctx, dstDir, file, session.State, offset, save, up)
+	if err == nil {
+		save("")
+	}
+	return obj, err
+}
+
+// AbortUploadSession discards the upload session which won't be continued, like the one of a failed task
+func AbortUploadSession(ctx context.Context, storage driver.Driver, session *model.UploadSession) {
+	if session.State == "" {
+		return
+	}
+	abortUpload(ctx, storage, session.State)
+	session.State = ""
+	if session.Save != nil {
+		session.Save()
+	}
+}
+
+// abortUpload discards the upload session of the state in the driver if it keeps the uploaded data,
+// errors are only logged as the session expires in the end
+func abortUpload(ctx context.Context, s any, state string) {
+	a, ok := s.(driver.UploadAborter)
+	if !ok {
+		return
+	}
+	if err := a.AbortUpload(ctx, state); err != nil {
+		log.Warnf("failed abort upload session: %+v", err)
+	}
+}
@@ -0,0 +1,165 @@
+package op_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/driver"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/stream"
+)
+
+// resumableDriver keeps the uploaded bytes of its sessions, an upload stops after failAt bytes if set
+type resumableDriver struct {
+	model.Storage
+	sessions map[string][]byte
+	begins   int
+	puts     int
+	aborted  []string
+	failAt   int64
+}
+
+func (d *resumableDriver) Config() driver.Config          { return driver.Config{Name: "Resumable"} }
+func (d *resumableDriver) GetAddition() driver.Additional { return nil }
+func (d *resumableDriver) Init(ctx context.Context) error { return nil }
+func (d *resumableDriver) Drop(ctx context.Context) error { return nil }
+
+func (d *resumableDriver) GetRoot(ctx context.Context) (model.Obj, error) {
+	return &model.Object{Name: "root", Path: "/", IsFolder: true}, nil
+}
+
+func (d *resumableDriver) List(ctx context.Context, dir model.Obj, args model.ListArgs) ([]model.Obj, error) {
+	return nil, nil
+}
+
+func (d *resumableDriver) Link(ctx context.Context, file model.Obj, args model.LinkArgs) (*model.Link, error) {
+	return nil, nil
+}
+
+func (d *resumableDriver) Put(ctx context.Context, dstDir model.Obj, file model.FileStreamer, up driver.UpdateProgress) error {
+	d.puts++
+	_, err := io.Copy(io.Discard, file)
+	return err
+}
+
+func (d *resumableDriver) AbortUpload(ctx context.Context, state string) error {
+	d.aborted = append(d.aborted, state)
+	delete(d.sessions, state)
+	return nil
+}
+
+func (d *resumableDriver) BeginUpload(ctx context.Context, dstDir model.Obj, file model.FileStreamer, up driver.UpdateProgress) (string, error) {
+	d.begins++
+	state := "session" + strconv.Itoa(d.begins)
+	d.sessions[state] = nil
+	return state, nil
+}
+
+func (d *resumableDriver) UploadedOffset(ctx context.Context, dstDir model.Obj, file model.FileStreamer, state string) (int64, error) {
+	data, ok := d.sessions[state]
+	if !ok {
+		return 0, errors.New("session not found")
+	}
+	return int64(len(data)), nil
+}
+
+func (d *resumableDriver) ResumeUpload(ctx context.Context, dstDir model.Obj, file model.FileStreamer, state string, offset int64, save func(string), up driver.UpdateProgress) (model.Obj, error) {
+	if _, err := io.CopyN(io.Discard, file, offset); err != nil {
+		return nil, err
+	}
+	rest, err := io.ReadAll(file)
+	if err != nil {
+		return nil, err
+	}
+	if d.failAt > 0 {
+		d.sessions[state] = append(d.sessions[state], rest[:d.failAt-offset]...)
+		return nil, errors.New("connection lost")
+	}
+	d.sessions[state] = append(d.sessions[state], rest...)
+	return nil, nil
+}
+
+func TestPutResumable(t *testing.T) {
+	d := &resumableDriver{sessions: map[string][]byte{}, failAt: 4}
+	d.SetStorage(model.Storage{MountPath: "/resumable"})
+	saves := 0
+	session := &model.UploadSession{Save: func() { saves++ }}
+	ctx := context.WithValue(context.Background(), conf.UploadSessionKey, session)
+	const content = "0123456789"
+	put := func() error {
+		file := &stream.FileStream{
+			Obj:    &model.Object{Name: "a.txt", Size: int64(len(content))},
+			Reader: strings.NewReader(content),
+		}
+		return op.Put(ctx, d, "/", file, nil)
+	}
+
+	if err := put(); err == nil {
+		t.Fatalf("expected the first upload to fail")
+	}
+	if session.State != "session1" || session.Path != "/a.txt" || saves == 0 {
+		t.Fatalf("expected the session to be kept, got %+v after %d saves", session, saves)
+	}
+
+	d.failAt = 0
+	if err := put(); err != nil {
+		t.Fatalf("failed resume: %+v", err)
+	}
+	if d.begins != 1 || string(d.sessions["session1"]) != content {
+		t.Errorf("expected the upload to be resumed, got %d sessions and %q", d.begins, d.sessions["session1"])
+	}
+	if session.State != "" {
+		t.Errorf("expected the session to be cleared, got %+v", session)
+	}
+
+	// a session that can't be continued is replaced by a new one
+	session.Path, session.Size, session.State = "/a.txt", int64(len(content)), "expired"
+	if err := put(); err != nil {
+		t.Fatalf("failed upload: %+v", err)
+	}
+	if d.begins != 2 || string(d.sessions["session2"]) != content {
+		t.Errorf("expected a new session, got %d sessions", d.begins)
+	}
+	if len(d.aborted) != 1 || d.aborted[0] != "expired" {
+		t.Errorf("expected the old session to be aborted, got %v", d.aborted)
+	}
+
+	// a session of another file is aborted before a new one begins
+	d.failAt = 4
+	if err := put(); err == nil {
+		t.Fatalf("expected the upload to fail")
+	}
+	session.Path = "/b.txt"
+	d.failAt = 0
+	if err := put(); err != nil {
+		t.Fatalf("failed upload: %+v", err)
+	}
+	if d.begins != 4 || len(d.aborted) != 2 || d.aborted[1] != "session3" {
+		t.Errorf("expected the session of another file to be aborted, got %d sessions and %v aborted", d.begins, d.aborted)
+	}
+
+	// a session that won't be continued is aborted
+	d.failAt = 4
+	if err := put(); err == nil {
+		t.Fatalf("expected the upload to fail")
+	}
+	op.AbortUploadSession(ctx, d, session)
+	if session.State != "" || len(d.aborted) != 3 || d.aborted[2] != "session5" {
+		t.Errorf("expected the session to be aborted, got %+v and %v aborted", session, d.aborted)
+	}
+
+	// without a session asked for, the file is put as usual
+	file := &stream.FileStream{
+		Obj:    &model.Object{Name: "c.txt", Size: int64(len(content))},
+		Reader: strings.NewReader(content),
+	}
+	if err := op.Put(context.Background(), d, "/", file, nil); err != nil || d.puts != 1 || d.begins != 5 {
+		t.Errorf("expected a plain put, got %d puts and %d sessions: %v", d.puts, d.begins, err)
+	}
+}
@@ -19,6 +19,8 @@ func GetAdmin() (*model.User, error) {
 		if err != nil {
 			return nil, err
 		}
+		applyGroups(user)
+		applyInternalShares(user)
 		adminUser = user
 	}
 	return adminUser, nil
@@ -30,6 +32,8 @@ func GetGuest() (*model.User, error) {
 		if err != nil {
 			return nil, err
 		}
+		applyGroups(user)
+		applyInternalShares(user)
 		guestUser = user
 	}
 	return guestUser, nil
@@ -51,6 +55,8 @@ func GetUserByName(username string) (*model.User, error) {
 		if err != nil {
 			return nil, err
 		}
+		applyGroups(_user)
+		applyInternalShares(_user)
 		Cache.SetUser(username, _user)
 		return _user, nil
 	})
@@ -58,7 +64,13 @@ func GetUserByName(username string) (*model.User, error) {
 }
 
 func GetUserById(id uint) (*model.User, error) {
-	return db.GetUserById(id)
+	user, err := db.GetUserById(id)
+	if err != nil {
+		return nil, err
+	}
+	applyGroups(user)
+	applyInternalShares(user)
+	return user, nil
 }
 
 func GetUsers(pageIndex, pageSize int) (users []model.User, count int64, err error) {
@@ -67,7 +79,11 @@ func GetUsers(pageIndex, pageSize int) (users []model.User, count int64, err err
 
 func CreateUser(u *model.User) error {
 	u.BasePath = utils.FixAndCleanPath(u.BasePath)
-	return db.CreateUser(u)
+	if err := db.CreateUser(u); err != nil {
+		return err
+	}
+	changed(clusterUser, u.Username)
+	return nil
 }
 
 func DeleteUserById(id uint) error {
@@ -78,27 +94,32 @@ func DeleteUserById(id uint) error {
 	if old.IsAdmin() || old.IsGuest() {
 		return errs.DeleteAdminOrGuest
 	}
-	Cache.DeleteUser(old.Username)
-	if err := DeleteSharingsByCreatorId(id); err != nil {
-		return errors.WithMessage(err, "failed to delete user's sharings")
+	if err := db.DeleteUserById(id); err != nil {
+		return err
 	}
-	return db.DeleteUserById(id)
+	changed(clusterS3Keys, "")
+	changed(clusterIPRules, "")
+	changed(clusterSessions, "")
+	changed(clusterACL, "")
+	changed(clusterUsers, "")
+	return nil
 }
 
 func UpdateUser(u *model.User) error {
+	// the user of an access token is restricted by it, and mustn't be saved like that
+	if u.AccessToken() != nil {
+		return errors.WithStack(errs.ByAccessToken)
+	}
 	old, err := db.GetUserById(u.ID)
 	if err != nil {
 		return err
 	}
-	if u.IsAdmin() {
-		adminUser = nil
-	}
-	if u.IsGuest() {
-		guestUser = nil
-	}
-	Cache.DeleteUser(old.Username)
 	u.BasePath = utils.FixAndCleanPath(u.BasePath)
-	return db.UpdateUser(u)
+	if err := db.UpdateUser(u); err != nil {
+		return err
+	}
+	changed(clusterUser, old.Username)
+	return nil
 }
 
 func Cancel2FAByUser(u *model.User) error {
@@ -119,12 +140,6 @@ func DelUserCache(username string) error {
 	if err != nil {
 		return err
 	}
-	if user.IsAdmin() {
-		adminUser = nil
-	}
-	if user.IsGuest() {
-		guestUser = nil
-	}
-	Cache.DeleteUser(username)
+	changed(clusterUser, user.Username)
 	return nil
 }
@@ -0,0 +1,61 @@
+package op_test
+
+import (
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+)
+
+func TestDeleteUserById(t *testing.T) {
+	owner := &model.User{Username: "delete_owner", BasePath: "/home/owner", Permission: 0x7FFF}
+	leaving := &model.User{Username: "delete_leaving", BasePath: "/home/leaving", Permission: 0x7FFF}
+	staying := &model.User{Username: "delete_staying", BasePath: "/home/staying", Permission: 0x7FFF}
+	for _, u := range []*model.User{owner, leaving, staying} {
+		if err := op.CreateUser(u); err != nil {
+			t.Fatalf("failed to create user: %+v", err)
+		}
+	}
+	shares := []model.InternalShare{
+		{CreatorID: owner.ID, Path: "/home/owner/only", UserIDs: []uint{leaving.ID}},
+		{CreatorID: owner.ID, Path: "/home/owner/both", UserIDs: []uint{leaving.ID, staying.ID}},
+		{CreatorID: leaving.ID, Path: "/home/leaving/own", UserIDs: []uint{staying.ID}},
+	}
+	for i := range shares {
+		if err := op.CreateInternalShare(&shares[i]); err != nil {
+			t.Fatalf("failed to create internal share: %+v", err)
+		}
+	}
+	defer func() {
+		for _, s := range shares {
+			_ = op.DeleteInternalShareById(s.ID)
+		}
+	}()
+	rule := &model.AclRule{Path: "/home/owner", SubjectType: model.AclSubjectUser, SubjectID: leaving.ID, Operations: []string{model.AclRead}}
+	if err := op.CreateAclRule(rule); err != nil {
+		t.Fatalf("failed to create acl rule: %+v", err)
+	}
+	if _, err := op.CreateAccessToken(leaving, &model.AccessToken{Name: "laptop", Protocol: model.TokenWebdav}); err != nil {
+		t.Fatalf("failed to create access token: %+v", err)
+	}
+
+	if err := op.DeleteUserById(leaving.ID); err != nil {
+		t.Fatalf("failed to delete user: %+v", err)
+	}
+	if _, err := op.GetAclRuleById(rule.ID); err == nil {
+		t.Errorf("expected the acl rules of the user to be deleted")
+	}
+	if tokens, err := op.GetAccessTokensByUserId(leaving.ID); err != nil || len(tokens) != 0 {
+		t.Errorf("expected the access tokens of the user to be deleted, got %+v: %v", tokens, err)
+	}
+	if _, err := op.GetInternalShareById(shares[0].ID); err == nil {
+		t.Errorf("expected a share to the user only to be deleted")
+	}
+	if _, err := op.GetInternalShareById(shares[2].ID); err == nil {
+		t.Errorf("expected the shares of the user to be deleted")
+	}
+	s, err := op.GetInternalShareById(shares[1].ID)
+	if err != nil || len(s.UserIDs) != 1 || s.UserIDs[0] != staying.ID {
+		t.Errorf("expected the user to be removed from a share to others, got %+v: %v", s, err)
+	}
+}
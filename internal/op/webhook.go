@@ -0,0 +1,173 @@
+package op
+
+import (
+	"net/url"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/event"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils/random"
+	"github.com/pkg/errors"
+)
+
+// the webhooks are matched against every event, so they're kept in memory
+var (
+	webhooks       []model.Webhook
+	webhooksLoaded bool
+	webhooksMu     sync.RWMutex
+)
+
+func getWebhooks() ([]model.Webhook, error) {
+	webhooksMu.RLock()
+	if webhooksLoaded {
+		defer webhooksMu.RUnlock()
+		return webhooks, nil
+	}
+	webhooksMu.RUnlock()
+	webhooksMu.Lock()
+	defer webhooksMu.Unlock()
+	if !webhooksLoaded {
+		hooks, err := db.GetAllWebhooks()
+		if err != nil {
+			return nil, err
+		}
+		webhooks, webhooksLoaded = hooks, true
+	}
+	return webhooks, nil
+}
+
+func clearWebhooks() {
+	webhooksMu.Lock()
+	defer webhooksMu.Unlock()
+	webhooks, webhooksLoaded = nil, false
+}
+
+func GetWebhooks(pageIndex, pageSize int) ([]model.Webhook, int64, error) {
+	return db.GetWebhooks(pageIndex, pageSize)
+}
+
+// GetWebhookById gets the webhook from memory
+func GetWebhookById(id uint) (*model.Webhook, error) {
+	hooks, err := getWebhooks()
+	if err != nil {
+		return nil, err
+	}
+	for i := range hooks {
+		if hooks[i].ID == id {
+			w := hooks[i]
+			return &w, nil
+		}
+	}
+	return nil, errors.Errorf("webhook [%d] not found", id)
+}
+
+func validateWebhook(w *model.Webhook) error {
+	u, err := url.Parse(w.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.Errorf("invalid url [%s]", w.URL)
+	}
+	for _, e := range w.Events {
+		if !slices.Contains(event.Types, event.Type(e)) {
+			return errors.Errorf("unknown event [%s]", e)
+		}
+	}
+	for i, p := range w.Paths {
+		w.Paths[i] = utils.FixAndCleanPath(p)
+	}
+	if w.Secret == "" {
+		w.Secret = random.String(32)
+	}
+	return nil
+}
+
+func CreateWebhook(w *model.Webhook) error {
+	if err := validateWebhook(w); err != nil {
+		return err
+	}
+	defer changed(clusterWebhooks, "")
+	return db.CreateWebhook(w)
+}
+
+func UpdateWebhook(w *model.Webhook) error {
+	old, err := db.GetWebhookById(w.ID)
+	if err != nil {
+		return err
+	}
+	w.CreatedAt = old.CreatedAt
+	if w.Secret == "" {
+		w.Secret = old.Secret
+	}
+	if err = validateWebhook(w); err != nil {
+		return err
+	}
+	defer changed(clusterWebhooks, "")
+	return db.UpdateWebhook(w)
+}
+
+func DeleteWebhookById(id uint) error {
+	defer changed(clusterWebhooks, "")
+	return db.DeleteWebhookById(id)
+}
+
+// EnqueueWebhookEvent adds a delivery of e to the outbox for each webhook it matches,
+// and returns the number of them
+func EnqueueWebhookEvent(e *event.Event) (int, error) {
+	hooks, err := getWebhooks()
+	if err != nil {
+		return 0, err
+	}
+	var deliveries []model.WebhookDelivery
+	var payload string
+	for _, w := range hooks {
+		if !w.Matches(string(e.Type), e.Path, e.DstPath) {
+			continue
+		}
+		if payload == "" {
+			if payload, err = utils.Json.MarshalToString(e); err != nil {
+				return 0, errors.Wrap(err, "failed marshal event")
+			}
+		}
+		deliveries = append(deliveries, model.WebhookDelivery{
+			WebhookID:     w.ID,
+			EventID:       e.ID,
+			EventType:     string(e.Type),
+			Payload:       payload,
+			Status:        model.WebhookDeliveryPending,
+			NextAttemptAt: time.Now(),
+		})
+	}
+	return len(deliveries), db.CreateWebhookDeliveries(deliveries)
+}
+
+func GetWebhookDeliveries(webhookID uint, pageIndex, pageSize int) ([]model.WebhookDelivery, int64, error) {
+	return db.GetWebhookDeliveries(webhookID, pageIndex, pageSize)
+}
+
+// GetDueWebhookDeliveries gets at most limit deliveries to attempt now
+func GetDueWebhookDeliveries(limit int) ([]model.WebhookDelivery, error) {
+	return db.GetDueWebhookDeliveries(time.Now(), limit)
+}
+
+func UpdateWebhookDelivery(d *model.WebhookDelivery) error {
+	return db.UpdateWebhookDelivery(d)
+}
+
+// RedeliverWebhookDelivery puts the delivery back to the outbox to be attempted again from scratch
+func RedeliverWebhookDelivery(id uint) error {
+	d, err := db.GetWebhookDeliveryById(id)
+	if err != nil {
+		return err
+	}
+	d.Status, d.Attempts, d.NextAttemptAt = model.WebhookDeliveryPending, 0, time.Now()
+	d.LastError, d.ResponseCode, d.DeliveredAt = "", 0, nil
+	return db.UpdateWebhookDelivery(d)
+}
+
+// DeleteWebhookDeliveriesBefore deletes the delivered and failed deliveries created before t
+func DeleteWebhookDeliveriesBefore(t time.Time) (int64, error) {
+	return db.DeleteWebhookDeliveriesBefore(t)
+}
@@ -0,0 +1,70 @@
+package op_test
+
+import (
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/event"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+)
+
+func TestWebhookOutbox(t *testing.T) {
+	all := &model.Webhook{Name: "all", URL: "http://127.0.0.1/all"}
+	docs := &model.Webhook{Name: "docs", URL: "http://127.0.0.1/docs", Paths: []string{"/docs/"}, Events: []string{string(event.ObjectCreated), string(event.ObjectMoved)}}
+	for _, w := range []*model.Webhook{all, docs} {
+		if err := op.CreateWebhook(w); err != nil {
+			t.Fatalf("failed to create webhook: %+v", err)
+		}
+		if w.Secret == "" {
+			t.Errorf("expected a secret to be generated for %s", w.Name)
+		}
+	}
+	defer op.DeleteWebhookById(all.ID)
+	defer op.DeleteWebhookById(docs.ID)
+	if err := op.CreateWebhook(&model.Webhook{URL: "ftp://127.0.0.1"}); err == nil {
+		t.Errorf("expected a non http url to be refused")
+	}
+	if err := op.CreateWebhook(&model.Webhook{URL: "http://127.0.0.1", Events: []string{"unknown"}}); err == nil {
+		t.Errorf("expected an unknown event to be refused")
+	}
+
+	cases := []struct {
+		e    event.Event
+		want int
+	}{
+		{event.Event{ID: "1", Type: event.ObjectCreated, Path: "/docs/a.txt"}, 2},
+		{event.Event{ID: "2", Type: event.ObjectCreated, Path: "/docsx/a.txt"}, 1},
+		{event.Event{ID: "3", Type: event.ObjectDeleted, Path: "/docs/a.txt"}, 1},
+		{event.Event{ID: "4", Type: event.ObjectMoved, Path: "/tmp/a.txt", DstPath: "/docs/a.txt"}, 2},
+		{event.Event{ID: "5", Type: event.UserLogin}, 1},
+	}
+	for _, c := range cases {
+		if n, err := op.EnqueueWebhookEvent(&c.e); err != nil || n != c.want {
+			t.Errorf("event %s: expected %d deliveries, got %d: %v", c.e.ID, c.want, n, err)
+		}
+	}
+
+	docs.Disabled = true
+	docs.Secret = ""
+	if err := op.UpdateWebhook(docs); err != nil {
+		t.Fatalf("failed to update webhook: %+v", err)
+	}
+	if w, _ := op.GetWebhookById(docs.ID); w == nil || w.Secret == "" {
+		t.Errorf("expected the secret to be kept on update")
+	}
+	if n, _ := op.EnqueueWebhookEvent(&event.Event{ID: "6", Type: event.ObjectCreated, Path: "/docs/b.txt"}); n != 1 {
+		t.Errorf("expected a disabled webhook to be skipped, got %d deliveries", n)
+	}
+
+	due, err := op.GetDueWebhookDeliveries(100)
+	if err != nil || len(due) != 8 {
+		t.Fatalf("expected 8 due deliveries, got %d: %v", len(due), err)
+	}
+	if err = op.RedeliverWebhookDelivery(due[0].ID); err != nil {
+		t.Errorf("failed to redeliver: %+v", err)
+	}
+	deliveries, total, err := op.GetWebhookDeliveries(docs.ID, 1, 10)
+	if err != nil || total != 2 || len(deliveries) != 2 {
+		t.Errorf("expected 2 deliveries of the docs webhook, got %d: %v", total, err)
+	}
+}
@@ -0,0 +1,54 @@
+package scheduler
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/cluster"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// the kinds of the cluster events about the jobs, key: id of the job.
+// Every instance schedules the jobs, but only the leader runs them on schedule
+const (
+	clusterJob       = "scheduled_job"
+	clusterJobCancel = "scheduled_job_cancel"
+)
+
+func init() {
+	cluster.Handle(clusterJob, func(_ context.Context, key string) error {
+		id, err := parseJobID(key)
+		if err != nil {
+			return err
+		}
+		job, err := db.GetScheduledJobById(id)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			unschedule(id)
+			cancelRunning(id)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return schedule(job)
+	})
+	cluster.Handle(clusterJobCancel, func(_ context.Context, key string) error {
+		id, err := parseJobID(key)
+		if err != nil {
+			return err
+		}
+		cancelRunning(id)
+		return nil
+	})
+}
+
+func parseJobID(key string) (uint, error) {
+	id, err := strconv.ParseUint(key, 10, 64)
+	return uint(id), errors.WithStack(err)
+}
+
+func publishJob(kind string, id uint) {
+	cluster.Publish(kind, strconv.FormatUint(uint64(id), 10))
+}
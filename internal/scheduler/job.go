@@ -0,0 +1,84 @@
+package scheduler
+
+import (
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/cron"
+	"github.com/pkg/errors"
+)
+
+// Validate checks the spec and the params of the job
+func Validate(job *model.ScheduledJob) error {
+	if _, err := cron.ParseSchedule(job.Spec); err != nil {
+		return err
+	}
+	_, err := parseParams(job)
+	return err
+}
+
+func GetJobById(id uint) (*model.ScheduledJob, error) {
+	return db.GetScheduledJobById(id)
+}
+
+func GetJobs(pageIndex, pageSize int) ([]model.ScheduledJob, int64, error) {
+	return db.GetScheduledJobs(pageIndex, pageSize)
+}
+
+func GetJobRuns(id uint, pageIndex, pageSize int) ([]model.ScheduledJobRun, int64, error) {
+	return db.GetScheduledJobRuns(id, pageIndex, pageSize)
+}
+
+func CreateJob(job *model.ScheduledJob) error {
+	if err := Validate(job); err != nil {
+		return err
+	}
+	if err := db.CreateScheduledJob(job); err != nil {
+		return err
+	}
+	publishJob(clusterJob, job.ID)
+	return schedule(job)
+}
+
+func UpdateJob(job *model.ScheduledJob) error {
+	if _, err := db.GetScheduledJobById(job.ID); err != nil {
+		return errors.WithMessage(err, "failed get old job")
+	}
+	if err := Validate(job); err != nil {
+		return err
+	}
+	if err := db.UpdateScheduledJob(job); err != nil {
+		return err
+	}
+	publishJob(clusterJob, job.ID)
+	return schedule(job)
+}
+
+// DeleteJob deletes the job with its runs, and cancels it if it's running
+func DeleteJob(id uint) error {
+	unschedule(id)
+	cancelRunning(id)
+	if err := db.DeleteScheduledJobById(id); err != nil {
+		return err
+	}
+	publishJob(clusterJob, id)
+	return nil
+}
+
+// RunJob runs the job now in background
+func RunJob(id uint) error {
+	job, err := db.GetScheduledJobById(id)
+	if err != nil {
+		return err
+	}
+	if err = Validate(job); err != nil {
+		return err
+	}
+	go run(*job, true)
+	return nil
+}
+
+// CancelJob cancels the current run of the job, on whichever instance of the cluster it runs
+func CancelJob(id uint) {
+	cancelRunning(id)
+	publishJob(clusterJobCancel, id)
+}
@@ -0,0 +1,186 @@
+package scheduler
+
+import (
+	"context"
+	stderrors "errors"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/fs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/offline_download/tool"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/search"
+	"github.com/OpenListTeam/OpenList/v4/internal/setting"
+	"github.com/OpenListTeam/OpenList/v4/internal/task"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+// jobParams are the arguments of a job type, decoded from ScheduledJob.Params
+type jobParams interface {
+	validate() error
+	run(ctx context.Context) error
+}
+
+var jobTypes = map[string]func() jobParams{
+	"copy":             func() jobParams { return &transferParams{transfer: fs.Copy} },
+	"move":             func() jobParams { return &transferParams{transfer: fs.Move} },
+	"merge":            func() jobParams { return &transferParams{transfer: fs.Merge} },
+	"sync":             func() jobParams { return &transferParams{sync: true} },
+	"build_index":      func() jobParams { return &buildIndexParams{} },
+	"update_index":     func() jobParams { return &updateIndexParams{} },
+	"scan":             func() jobParams { return &scanParams{} },
+	"offline_download": func() jobParams { return &offlineDownloadParams{} },
+}
+
+func parseParams(job *model.ScheduledJob) (jobParams, error) {
+	newParams, ok := jobTypes[job.Type]
+	if !ok {
+		return nil, errors.Errorf("unknown job type: %s", job.Type)
+	}
+	p := newParams()
+	if job.Params != "" {
+		if err := utils.Json.UnmarshalFromString(job.Params, p); err != nil {
+			return nil, errors.WithMessage(err, "invalid job params")
+		}
+	}
+	return p, p.validate()
+}
+
+type transferParams struct {
+	SrcPath      string `json:"src_path"`
+	DstDir       string `json:"dst_dir"`
+	DeleteExtras bool   `json:"delete_extras"`
+
+	ConflictPolicy model.ConflictPolicy `json:"conflict_policy"`
+
+	transfer func(ctx context.Context, srcPath, dstDirPath string, skipHook ...bool) (task.TaskExtensionInfo, error)
+	sync     bool
+}
+
+func (p *transferParams) validate() error {
+	if p.SrcPath == "" || p.DstDir == "" {
+		return errors.New("src_path and dst_dir are required")
+	}
+	if !p.ConflictPolicy.Valid() {
+		return errors.Errorf("invalid conflict policy: %s", p.ConflictPolicy)
+	}
+	p.SrcPath, p.DstDir = utils.FixAndCleanPath(p.SrcPath), utils.FixAndCleanPath(p.DstDir)
+	return nil
+}
+
+func (p *transferParams) run(ctx context.Context) error {
+	// run the transfer in place instead of adding tasks, so that the run lasts as long as the transfer
+	ctx = context.WithValue(ctx, conf.NoTaskKey, struct{}{})
+	ctx = context.WithValue(ctx, conf.ConflictPolicyKey, p.ConflictPolicy)
+	var err error
+	if p.sync {
+		_, err = fs.Sync(ctx, p.SrcPath, p.DstDir, p.DeleteExtras)
+	} else {
+		_, err = p.transfer(ctx, p.SrcPath, p.DstDir)
+	}
+	return err
+}
+
+type buildIndexParams struct{}
+
+func (p *buildIndexParams) validate() error {
+	return nil
+}
+
+func (p *buildIndexParams) run(ctx context.Context) error {
+	if search.Running() {
+		return errors.New("index is running")
+	}
+	if err := search.Clear(ctx); err != nil {
+		return errors.WithMessage(err, "failed clear index")
+	}
+	return search.BuildIndex(ctx, []string{"/"}, conf.SlicesMap[conf.IgnorePaths], setting.GetInt(conf.MaxIndexDepth, 20), true)
+}
+
+type updateIndexParams struct {
+	Paths    []string `json:"paths"`
+	MaxDepth int      `json:"max_depth"`
+}
+
+func (p *updateIndexParams) validate() error {
+	if len(p.Paths) == 0 {
+		return errors.New("paths are required")
+	}
+	p.Paths = utils.MustSliceConvert(p.Paths, utils.FixAndCleanPath)
+	return nil
+}
+
+func (p *updateIndexParams) run(ctx context.Context) error {
+	if search.Running() {
+		return errors.New("index is running")
+	}
+	if !search.Config(ctx).AutoUpdate {
+		return errors.New("update is not supported for current index")
+	}
+	for _, path := range p.Paths {
+		if err := search.Del(ctx, path); err != nil {
+			return errors.WithMessagef(err, "failed delete index on %s", path)
+		}
+	}
+	return search.BuildIndex(ctx, p.Paths, conf.SlicesMap[conf.IgnorePaths], p.MaxDepth, false)
+}
+
+type scanParams struct {
+	Path  string  `json:"path"`
+	Limit float64 `json:"limit"`
+}
+
+func (p *scanParams) validate() error {
+	p.Path = utils.FixAndCleanPath(p.Path)
+	return nil
+}
+
+func (p *scanParams) run(ctx context.Context) error {
+	if err := op.BeginManualScan(p.Path, p.Limit); err != nil {
+		return err
+	}
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for op.ManualScanRunning() {
+		select {
+		case <-ctx.Done():
+			op.StopManualScan()
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
+
+type offlineDownloadParams struct {
+	Urls         []string `json:"urls"`
+	DstDir       string   `json:"dst_dir"`
+	Tool         string   `json:"tool"`
+	DeletePolicy string   `json:"delete_policy"`
+}
+
+func (p *offlineDownloadParams) validate() error {
+	if len(p.Urls) == 0 || p.DstDir == "" || p.Tool == "" {
+		return errors.New("urls, dst_dir and tool are required")
+	}
+	p.DstDir = utils.FixAndCleanPath(p.DstDir)
+	return nil
+}
+
+func (p *offlineDownloadParams) run(ctx context.Context) error {
+	var errs []error
+	for _, url := range p.Urls {
+		_, err := tool.AddURL(ctx, &tool.AddURLArgs{
+			URL:          url,
+			DstDirPath:   p.DstDir,
+			Tool:         p.Tool,
+			DeletePolicy: tool.DeletePolicy(p.DeletePolicy),
+		})
+		if err != nil {
+			errs = append(errs, errors.WithMessagef(err, "failed add %s", url))
+		}
+	}
+	return stderrors.Join(errs...)
+}
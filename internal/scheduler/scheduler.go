@@ -0,0 +1,184 @@
+package scheduler
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/cluster"
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/pkg/cron"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// keepRuns is the number of runs kept in the history of each job
+const keepRuns = 100
+
+var (
+	mu sync.Mutex
+	// stops of the scheduled jobs
+	scheduled = map[uint]chan struct{}{}
+	// cancels of the running jobs, a job never runs twice at the same time
+	running = map[uint]context.CancelFunc{}
+)
+
+// Init schedules the enabled jobs
+func Init() {
+	if err := db.FailRunningScheduledJobRuns(cluster.NodeID(), "interrupted by restart"); err != nil {
+		log.Errorf("failed update interrupted job runs: %+v", err)
+	}
+	jobs, err := db.GetEnabledScheduledJobs()
+	if err != nil {
+		log.Errorf("failed get scheduled jobs: %+v", err)
+		return
+	}
+	for i := range jobs {
+		if err = schedule(&jobs[i]); err != nil {
+			log.Errorf("failed schedule job [%s]: %+v", jobs[i].Name, err)
+		}
+	}
+}
+
+// schedule replaces the schedule of the job, under mu so that a concurrent change of the job
+// can't leave the replaced schedule running
+func schedule(job *model.ScheduledJob) error {
+	mu.Lock()
+	defer mu.Unlock()
+	unscheduleLocked(job.ID)
+	if job.Disabled {
+		return nil
+	}
+	s, err := cron.ParseSchedule(job.Spec)
+	if err != nil {
+		return err
+	}
+	stop := make(chan struct{})
+	scheduled[job.ID] = stop
+	go func() {
+		for {
+			next := s.Next(time.Now())
+			if next.IsZero() {
+				return
+			}
+			timer := time.NewTimer(time.Until(next))
+			select {
+			case <-timer.C:
+				// the leader may have changed since the job was scheduled
+				if cluster.IsLeader() {
+					go run(*job, false)
+				}
+			case <-stop:
+				timer.Stop()
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+func unschedule(id uint) {
+	mu.Lock()
+	defer mu.Unlock()
+	unscheduleLocked(id)
+}
+
+func unscheduleLocked(id uint) {
+	if stop, ok := scheduled[id]; ok {
+		close(stop)
+		delete(scheduled, id)
+	}
+}
+
+func run(job model.ScheduledJob, manual bool) {
+	r := &model.ScheduledJobRun{
+		JobID:     job.ID,
+		Node:      cluster.NodeID(),
+		Manual:    manual,
+		Status:    model.JobRunRunning,
+		StartTime: time.Now(),
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	skip := func(reason string) {
+		r.Status = model.JobRunSkipped
+		r.Error = reason
+		r.EndTime = &r.StartTime
+		if err := db.CreateScheduledJobRun(r); err != nil {
+			log.Errorf("failed save run of job [%s]: %+v", job.Name, err)
+		}
+	}
+	mu.Lock()
+	if _, ok := running[job.ID]; ok {
+		mu.Unlock()
+		skip("the previous run is still running")
+		return
+	}
+	running[job.ID] = cancel
+	mu.Unlock()
+	defer func() {
+		mu.Lock()
+		delete(running, job.ID)
+		mu.Unlock()
+	}()
+	// another instance may still run it, like the leader before a handover
+	unlock, ok, err := cluster.Lock("scheduled_job_" + strconv.FormatUint(uint64(job.ID), 10))
+	if err != nil || !ok {
+		if err != nil {
+			log.Errorf("failed lock job [%s]: %+v", job.Name, err)
+		}
+		skip("the previous run is still running on another instance")
+		return
+	}
+	defer unlock()
+	if err = db.CreateScheduledJobRun(r); err != nil {
+		log.Errorf("failed save run of job [%s]: %+v", job.Name, err)
+	}
+
+	err = execute(ctx, &job)
+	now := time.Now()
+	r.EndTime = &now
+	if err != nil {
+		r.Status = model.JobRunFailed
+		r.Error = err.Error()
+		log.Errorf("failed run job [%s]: %+v", job.Name, err)
+	} else {
+		r.Status = model.JobRunSucceeded
+	}
+	if err = db.UpdateScheduledJobRun(r); err != nil {
+		log.Errorf("failed save run of job [%s]: %+v", job.Name, err)
+	}
+	if err = db.PruneScheduledJobRuns(job.ID, keepRuns); err != nil {
+		log.Errorf("failed prune runs of job [%s]: %+v", job.Name, err)
+	}
+}
+
+func execute(ctx context.Context, job *model.ScheduledJob) (err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			err = errors.Errorf("panic: %v", e)
+		}
+	}()
+	p, err := parseParams(job)
+	if err != nil {
+		return err
+	}
+	admin, err := op.GetAdmin()
+	if err != nil {
+		return errors.WithMessage(err, "failed get admin user")
+	}
+	ctx = context.WithValue(ctx, conf.UserKey, admin)
+	return p.run(ctx)
+}
+
+func cancelRunning(id uint) {
+	mu.Lock()
+	defer mu.Unlock()
+	if cancel, ok := running[id]; ok {
+		cancel()
+	}
+}
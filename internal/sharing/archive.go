@@ -21,12 +21,18 @@ func archiveMeta(ctx context.Context, sid, path string, args model.SharingArchiv
 	if !sharing.Verify(args.Pwd) {
 		return sharing, nil, errors.WithStack(errs.WrongShareCode)
 	}
+	if sharing.IsUpload() {
+		return sharing, nil, errors.WithStack(errs.FileRequestReadOnly)
+	}
 	path = utils.FixAndCleanPath(path)
 	if len(sharing.Files) == 1 || path != "/" {
 		unwrapPath, err := op.GetSharingUnwrapPath(sharing, path)
 		if err != nil {
 			return nil, nil, errors.WithMessage(err, "failed get sharing unwrap path")
 		}
+		if err = checkAcl(sharing, model.AclArchive, unwrapPath); err != nil {
+			return nil, nil, err
+		}
 		storage, actualPath, err := op.GetStorageAndActualPath(unwrapPath)
 		if err != nil {
 			return nil, nil, errors.WithMessage(err, "failed get sharing file")
@@ -48,12 +54,18 @@ func archiveList(ctx context.Context, sid, path string, args model.SharingArchiv
 	if !sharing.Verify(args.Pwd) {
 		return sharing, nil, errors.WithStack(errs.WrongShareCode)
 	}
+	if sharing.IsUpload() {
+		return sharing, nil, errors.WithStack(errs.FileRequestReadOnly)
+	}
 	path = utils.FixAndCleanPath(path)
 	if len(sharing.Files) == 1 || path != "/" {
 		unwrapPath, err := op.GetSharingUnwrapPath(sharing, path)
 		if err != nil {
 			return nil, nil, errors.WithMessage(err, "failed get sharing unwrap path")
 		}
+		if err = checkAcl(sharing, model.AclArchive, unwrapPath); err != nil {
+			return nil, nil, err
+		}
 		storage, actualPath, err := op.GetStorageAndActualPath(unwrapPath)
 		if err != nil {
 			return nil, nil, errors.WithMessage(err, "failed get sharing file")
@@ -24,11 +24,17 @@ func get(ctx context.Context, sid, path string, args model.SharingListArgs) (*mo
 		return sharing, nil, errors.WithStack(errs.WrongShareCode)
 	}
 	path = utils.FixAndCleanPath(path)
+	if sharing.IsUpload() && !sharing.UploadListable && path != "/" {
+		return sharing, nil, errors.WithStack(errs.FileRequestReadOnly)
+	}
 	if len(sharing.Files) == 1 || path != "/" {
 		unwrapPath, err := op.GetSharingUnwrapPath(sharing, path)
 		if err != nil {
 			return nil, nil, errors.WithMessage(err, "failed get sharing unwrap path")
 		}
+		if err = checkAcl(sharing, model.AclList, unwrapPath); err != nil {
+			return nil, nil, err
+		}
 		if unwrapPath != "/" {
 			virtualFiles := op.GetStorageVirtualFilesByPath(stdpath.Dir(unwrapPath))
 			for _, f := range virtualFiles {
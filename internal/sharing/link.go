@@ -23,12 +23,18 @@ func link(ctx context.Context, sid, path string, args *LinkArgs) (*model.Sharing
 	if !sharing.Verify(args.Pwd) {
 		return sharing, nil, nil, errors.WithStack(errs.WrongShareCode)
 	}
+	if sharing.IsUpload() {
+		return sharing, nil, nil, errors.WithStack(errs.FileRequestReadOnly)
+	}
 	path = utils.FixAndCleanPath(path)
 	if len(sharing.Files) == 1 || path != "/" {
 		unwrapPath, err := op.GetSharingUnwrapPath(sharing, path)
 		if err != nil {
 			return nil, nil, nil, errors.WithMessage(err, "failed get sharing unwrap path")
 		}
+		if err = checkAcl(sharing, model.AclRead, unwrapPath); err != nil {
+			return nil, nil, nil, err
+		}
 		storage, actualPath, err := op.GetStorageAndActualPath(unwrapPath)
 		if err != nil {
 			return nil, nil, nil, errors.WithMessage(err, "failed get sharing link")
@@ -22,12 +22,18 @@ func list(ctx context.Context, sid, path string, args model.SharingListArgs) (*m
 	if !sharing.Verify(args.Pwd) {
 		return sharing, nil, errors.WithStack(errs.WrongShareCode)
 	}
+	if sharing.IsUpload() && !sharing.UploadListable {
+		return sharing, []model.Obj{}, nil
+	}
 	path = utils.FixAndCleanPath(path)
 	if len(sharing.Files) == 1 || path != "/" {
 		unwrapPath, err := op.GetSharingUnwrapPath(sharing, path)
 		if err != nil {
 			return nil, nil, errors.WithMessage(err, "failed get sharing unwrap path")
 		}
+		if err = checkAcl(sharing, model.AclList, unwrapPath); err != nil {
+			return nil, nil, err
+		}
 		virtualFiles := op.GetStorageVirtualFilesByPath(unwrapPath)
 		storage, actualPath, err := op.GetStorageAndActualPath(unwrapPath)
 		if err != nil && len(virtualFiles) == 0 {
@@ -44,13 +50,16 @@ func list(ctx context.Context, sid, path string, args model.SharingListArgs) (*m
 			}
 		}
 		om := model.NewObjMerge()
-		objs = om.Merge(objs, virtualFiles...)
+		objs = op.FilterAcl(sharing.Creator, model.AclList, unwrapPath, om.Merge(objs, virtualFiles...))
 		model.SortFiles(objs, sharing.OrderBy, sharing.OrderDirection)
 		model.ExtractFolder(objs, sharing.ExtractFolder)
 		return sharing, objs, nil
 	}
 	objs := make([]model.Obj, 0, len(sharing.Files))
 	for _, f := range sharing.Files {
+		if checkAcl(sharing, model.AclList, f) != nil {
+			continue
+		}
 		if f != "/" {
 			isVf := false
 			virtualFiles := op.GetStorageVirtualFilesByPath(stdpath.Dir(f))
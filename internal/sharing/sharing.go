@@ -2,13 +2,26 @@ package sharing
 
 import (
 	"context"
+	stdpath "path"
 
+	"github.com/OpenListTeam/OpenList/v4/internal/audit"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
 	log "github.com/sirupsen/logrus"
 )
 
+// checkAcl returns errs.PermissionDenied if the creator of the sharing
+// isn't allowed to share the path or do the operation on it anymore
+func checkAcl(sharing *model.Sharing, operation, path string) error {
+	if err := op.CheckAcl(sharing.Creator, model.AclShare, path); err != nil {
+		return err
+	}
+	return op.CheckAcl(sharing.Creator, operation, path)
+}
+
 func List(ctx context.Context, sid, path string, args model.SharingListArgs) (*model.Sharing, []model.Obj, error) {
 	sharing, res, err := list(ctx, sid, path, args)
+	audit.LogDetail(ctx, model.AuditShareAccess, sid, err, path)
 	if err != nil {
 		log.Errorf("failed list sharing %s/%s: %+v", sid, path, err)
 		return nil, nil, err
@@ -18,6 +31,7 @@ func List(ctx context.Context, sid, path string, args model.SharingListArgs) (*m
 
 func Get(ctx context.Context, sid, path string, args model.SharingListArgs) (*model.Sharing, model.Obj, error) {
 	sharing, res, err := get(ctx, sid, path, args)
+	audit.LogDetail(ctx, model.AuditShareAccess, sid, err, path)
 	if err != nil {
 		log.Warnf("failed get sharing %s/%s: %s", sid, path, err)
 		return nil, nil, err
@@ -27,6 +41,7 @@ func Get(ctx context.Context, sid, path string, args model.SharingListArgs) (*mo
 
 func ArchiveMeta(ctx context.Context, sid, path string, args model.SharingArchiveMetaArgs) (*model.Sharing, *model.ArchiveMetaProvider, error) {
 	sharing, res, err := archiveMeta(ctx, sid, path, args)
+	audit.LogDetail(ctx, model.AuditShareAccess, sid, err, path)
 	if err != nil {
 		log.Warnf("failed get sharing archive meta %s/%s: %s", sid, path, err)
 		return nil, nil, err
@@ -36,6 +51,7 @@ func ArchiveMeta(ctx context.Context, sid, path string, args model.SharingArchiv
 
 func ArchiveList(ctx context.Context, sid, path string, args model.SharingArchiveListArgs) (*model.Sharing, []model.Obj, error) {
 	sharing, res, err := archiveList(ctx, sid, path, args)
+	audit.LogDetail(ctx, model.AuditShareAccess, sid, err, path)
 	if err != nil {
 		log.Warnf("failed list sharing archive %s/%s: %s", sid, path, err)
 		return nil, nil, err
@@ -43,6 +59,33 @@ func ArchiveList(ctx context.Context, sid, path string, args model.SharingArchiv
 	return sharing, res, nil
 }
 
+// Upload puts the file to path of the file request sid
+func Upload(ctx context.Context, sid, path string, file model.FileStreamer, args model.SharingListArgs) (*model.Sharing, error) {
+	sharing, err := upload(ctx, sid, path, file, args)
+	audit.LogDetail(ctx, model.AuditShareUpload, sid, err, path)
+	if err != nil {
+		log.Warnf("failed upload to sharing %s/%s: %s", sid, path, err)
+		return nil, err
+	}
+	return sharing, nil
+}
+
+// CheckUpload checks the file request sid accepts a file at path before the body of the upload
+// is read, and returns the most bytes the file may have, which is -1 if it's unlimited
+func CheckUpload(ctx context.Context, sid, path string, args model.SharingListArgs) (int64, error) {
+	sharing, _, err := checkUpload(ctx, sid, path, -1, args)
+	if err == nil {
+		// the size is unknown yet, so only the extension and the number of uploads are checked
+		err = sharing.CheckUpload(stdpath.Base(path), 0)
+	}
+	if err != nil {
+		audit.LogDetail(ctx, model.AuditShareUpload, sid, err, path)
+		log.Warnf("failed upload to sharing %s/%s: %s", sid, path, err)
+		return 0, err
+	}
+	return sharing.UploadLimit(), nil
+}
+
 type LinkArgs struct {
 	model.SharingListArgs
 	model.LinkArgs
@@ -50,6 +93,7 @@ type LinkArgs struct {
 
 func Link(ctx context.Context, sid, path string, args *LinkArgs) (*model.Sharing, *model.Link, model.Obj, error) {
 	sharing, res, file, err := link(ctx, sid, path, args)
+	audit.LogDetail(ctx, model.AuditShareAccess, sid, err, path)
 	if err != nil {
 		log.Errorf("failed get sharing link %s/%s: %+v", sid, path, err)
 		return nil, nil, nil, err
@@ -0,0 +1,130 @@
+package sharing
+
+import (
+	"context"
+	"io"
+	stdpath "path"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/fs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/stream"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+func upload(ctx context.Context, sid, path string, file model.FileStreamer, args model.SharingListArgs) (*model.Sharing, error) {
+	sharing, unwrapPath, err := checkUpload(ctx, sid, path, file.GetSize(), args)
+	if err != nil {
+		_ = file.Close()
+		return sharing, err
+	}
+	// the file is uploaded by the creator, so the permissions and quota of the creator apply,
+	// and the upload is audited once by Upload
+	ctx = context.WithValue(ctx, conf.UserKey, sharing.Creator)
+	ctx = context.WithValue(ctx, conf.SkipAuditKey, struct{}{})
+	size := file.GetSize()
+	if err = op.ReserveSharingUpload(sid, file.GetName(), size); err != nil {
+		_ = file.Close()
+		return sharing, err
+	}
+	// the size is declared by the client, so the body mustn't be more or less than that
+	body := &uploadReader{size: size}
+	if s, ok := file.(*stream.FileStream); ok {
+		body.Reader, s.Reader = s.Reader, body
+	}
+	if err = fs.PutDirectly(ctx, stdpath.Dir(unwrapPath), file); err != nil {
+		if e := op.ReleaseSharingUpload(sid, size); e != nil {
+			log.Errorf("failed release the upload to sharing %s: %+v", sid, e)
+		}
+		return sharing, err
+	}
+	if body.Reader != nil && size < 0 {
+		size = body.read
+		if err := op.SettleSharingUpload(sid, -1, size); err != nil {
+			log.Errorf("failed count the upload to sharing %s: %+v", sid, err)
+		}
+	}
+	ip, _ := ctx.Value(conf.ClientIPKey).(string)
+	if err := op.CreateSharingUpload(&model.SharingUpload{
+		SharingID: sid,
+		Path:      unwrapPath,
+		Size:      size,
+		IP:        ip,
+		CreatedAt: time.Now(),
+	}); err != nil {
+		log.Errorf("failed record the upload to sharing %s: %+v", sid, err)
+	}
+	return sharing, nil
+}
+
+// uploadReader counts the bytes read from the body of an upload, and fails if they
+// don't match the size reserved to the file request, a negative size is unknown
+type uploadReader struct {
+	io.Reader
+	size int64
+	read int64
+}
+
+func (r *uploadReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	r.read += int64(n)
+	if r.size >= 0 && r.read > r.size {
+		return n, errors.WithMessagef(errs.FileRequestRefused, "the file is larger than the %d bytes declared", r.size)
+	}
+	if r.size >= 0 && err == io.EOF && r.read < r.size {
+		return n, errors.WithMessagef(io.ErrUnexpectedEOF, "the file is smaller than the %d bytes declared", r.size)
+	}
+	return n, err
+}
+
+// checkUpload returns the file request and the path to put the file of size if it's accepted,
+// size is -1 if it's unknown
+func checkUpload(ctx context.Context, sid, path string, size int64, args model.SharingListArgs) (*model.Sharing, string, error) {
+	sharing, err := op.GetSharingById(sid, args.Refresh)
+	if err != nil {
+		return nil, "", errors.WithStack(errs.SharingNotFound)
+	}
+	if !sharing.IsUpload() {
+		return sharing, "", errors.WithStack(errs.NotAFileRequest)
+	}
+	if !sharing.Valid() {
+		return sharing, "", errors.WithStack(errs.InvalidSharing)
+	}
+	if !sharing.Verify(args.Pwd) {
+		return sharing, "", errors.WithStack(errs.WrongShareCode)
+	}
+	path = utils.FixAndCleanPath(path)
+	if path == "/" {
+		return sharing, "", errors.New("the name of the file is required")
+	}
+	unwrapPath, err := op.GetSharingUnwrapPath(sharing, path)
+	if err != nil {
+		return nil, "", errors.WithMessage(err, "failed get sharing unwrap path")
+	}
+	if !sharing.Creator.CanAccessPath(unwrapPath) {
+		return sharing, "", errors.WithStack(errs.PermissionDenied)
+	}
+	// the creator may have lost the permission to write since the file request was created
+	dir := stdpath.Dir(unwrapPath)
+	if meta, _ := op.GetNearestMeta(dir); !sharing.Creator.CanWrite() && !common.CanWrite(meta, dir) {
+		return sharing, "", errors.WithStack(errs.PermissionDenied)
+	}
+	if err = checkAcl(sharing, model.AclWrite, unwrapPath); err != nil {
+		return sharing, "", err
+	}
+	ctx = context.WithValue(ctx, conf.UserKey, sharing.Creator)
+	// anyone with the link uploads, so nothing is overwritten
+	if obj, _ := fs.Get(ctx, unwrapPath, &fs.GetArgs{NoLog: true}); obj != nil {
+		return sharing, "", errors.WithMessagef(errs.FileRequestRefused, "[%s] exists", path)
+	}
+	if err = fs.CheckQuota(ctx, unwrapPath, size); err != nil {
+		return sharing, "", err
+	}
+	return sharing, unwrapPath, nil
+}
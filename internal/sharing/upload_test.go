@@ -0,0 +1,52 @@
+package sharing
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+)
+
+func TestUploadReader(t *testing.T) {
+	for _, c := range []struct {
+		name string
+		body string
+		size int64
+		err  error
+	}{
+		{"declared size", "hello", 5, nil},
+		{"larger than declared", "hello!", 5, errs.FileRequestRefused},
+		{"smaller than declared", "hell", 5, io.ErrUnexpectedEOF},
+		{"unknown size", "hello", -1, nil},
+	} {
+		r := &uploadReader{Reader: strings.NewReader(c.body), size: c.size}
+		_, err := io.Copy(io.Discard, r)
+		if c.err == nil && err != nil || c.err != nil && !errors.Is(err, c.err) {
+			t.Errorf("%s: expected %v, got %v", c.name, c.err, err)
+		}
+		if c.err == nil && r.read != int64(len(c.body)) {
+			t.Errorf("%s: expected %d bytes read, got %d", c.name, len(c.body), r.read)
+		}
+	}
+}
+
+func TestUploadLimit(t *testing.T) {
+	for _, c := range []struct {
+		name     string
+		sharing  model.SharingDB
+		expected int64
+	}{
+		{"unlimited", model.SharingDB{}, -1},
+		{"max file size", model.SharingDB{MaxFileSize: 10}, 10},
+		{"total size left", model.SharingDB{MaxTotalSize: 10, UploadedSize: 4}, 6},
+		{"smaller of both", model.SharingDB{MaxFileSize: 5, MaxTotalSize: 10, UploadedSize: 4}, 5},
+		{"total size reached", model.SharingDB{MaxFileSize: 5, MaxTotalSize: 10, UploadedSize: 12}, 0},
+	} {
+		if limit := (&model.Sharing{SharingDB: &c.sharing}).UploadLimit(); limit != c.expected {
+			t.Errorf("%s: expected a limit of %d, got %d", c.name, c.expected, limit)
+		}
+	}
+}
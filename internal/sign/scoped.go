@@ -0,0 +1,158 @@
+package sign
+
+import (
+	"net/netip"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/setting"
+	"github.com/OpenListTeam/OpenList/v4/pkg/sign"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils/random"
+)
+
+// Scope restricts a scoped sign besides the path, the zero value restricts nothing else
+type Scope struct {
+	// ExpiresAt is when the sign expires, link_expiration applies if nil
+	ExpiresAt *time.Time
+	CIDRs     []string
+	UserAgent string
+	// MaxDownloads caps the GET requests of the sign, each range request counts as one
+	MaxDownloads int
+	// Batch is shared by the signs minted together to revoke them at once
+	Batch  string
+	UserID uint
+}
+
+// Request is the client a scoped sign is checked against
+type Request struct {
+	IP        string
+	UserAgent string
+	// Download is whether the request counts as one of Scope.MaxDownloads
+	Download bool
+}
+
+func hashUserAgent(ua string) string {
+	return utils.HashData(utils.SHA256, []byte(ua))[:32]
+}
+
+func (s Scope) claims() (sign.Claims, error) {
+	if _, err := utils.ParseCIDRs(s.CIDRs); err != nil {
+		return sign.Claims{}, err
+	}
+	c := sign.Claims{
+		KeyID:   random.String(16),
+		Batch:   s.Batch,
+		CIDRs:   s.CIDRs,
+		MaxUses: s.MaxDownloads,
+		UserID:  s.UserID,
+	}
+	if s.UserAgent != "" {
+		c.UserAgent = hashUserAgent(s.UserAgent)
+	}
+	if s.ExpiresAt != nil {
+		c.Expire = s.ExpiresAt.Unix()
+	} else if expire := setting.GetInt(conf.LinkExpiration, 0); expire != 0 {
+		c.Expire = time.Now().Add(time.Duration(expire) * time.Hour).Unix()
+	}
+	return c, nil
+}
+
+// SignScoped signs data restricted by the scope, and returns the sign with the key id of it
+func SignScoped(data string, scope Scope) (string, string, error) {
+	once.Do(Instance)
+	c, err := scope.claims()
+	if err != nil {
+		return "", "", err
+	}
+	return instance.SignClaims(data, c), c.KeyID, nil
+}
+
+// SignArchiveScoped signs the data of an archive restricted by the scope, like SignScoped
+func SignArchiveScoped(data string, scope Scope) (string, string, error) {
+	onceArchive.Do(InstanceArchive)
+	c, err := scope.claims()
+	if err != nil {
+		return "", "", err
+	}
+	return instanceArchive.SignClaims(data, c), c.KeyID, nil
+}
+
+// ParseScoped returns the claims of a valid scoped sign of data, by the key of either downloads or archives
+func ParseScoped(data, s string) (*sign.Claims, error) {
+	once.Do(Instance)
+	onceArchive.Do(InstanceArchive)
+	c, err := instance.VerifyClaims(data, s)
+	if err != nil {
+		c, err = instanceArchive.VerifyClaims(data, s)
+	}
+	return c, err
+}
+
+// VerifyRequest verifies a sign of Sign or SignScoped for the client of r
+func VerifyRequest(data, s string, r Request) error {
+	once.Do(Instance)
+	return verifyRequest(instance, data, s, r)
+}
+
+func VerifyArchiveRequest(data, s string, r Request) error {
+	onceArchive.Do(InstanceArchive)
+	return verifyRequest(instanceArchive, data, s, r)
+}
+
+func verifyRequest(s sign.Sign, data, sg string, r Request) error {
+	if !sign.IsClaims(sg) {
+		return s.Verify(data, sg)
+	}
+	c, err := s.VerifyClaims(data, sg)
+	if err != nil {
+		return err
+	}
+	revoked, err := op.SignRevoked(c.KeyID, c.Batch)
+	if err != nil {
+		return err
+	}
+	if revoked {
+		return sign.ErrSignRevoked
+	}
+	if len(c.CIDRs) > 0 && !inCIDRs(c.CIDRs, r.IP) {
+		return sign.ErrSignOutOfScope
+	}
+	if c.UserAgent != "" && hashUserAgent(r.UserAgent) != c.UserAgent {
+		return sign.ErrSignOutOfScope
+	}
+	if c.MaxUses > 0 && r.Download {
+		var expiresAt *time.Time
+		if c.Expire != 0 {
+			t := time.Unix(c.Expire, 0)
+			expiresAt = &t
+		}
+		ok, err := op.UseSign(c.KeyID, c.MaxUses, expiresAt)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return sign.ErrSignUsedUp
+		}
+	}
+	return nil
+}
+
+func inCIDRs(cidrs []string, ip string) bool {
+	prefixes, err := utils.ParseCIDRs(cidrs)
+	if err != nil {
+		return false
+	}
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, p := range prefixes {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,43 @@
+package sign
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/pkg/sign"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func init() {
+	dB, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
+	if err != nil {
+		panic("failed to connect database")
+	}
+	conf.Conf = conf.DefaultConfig("data")
+	db.Init(dB)
+}
+
+func TestSignArchiveScoped(t *testing.T) {
+	s, _, err := SignArchiveScoped("/a.zip", Scope{MaxDownloads: 1})
+	if err != nil {
+		t.Fatalf("failed to sign: %+v", err)
+	}
+	if err = VerifyRequest("/a.zip", s, Request{}); !errors.Is(err, sign.ErrSignInvalid) {
+		t.Errorf("expected an archive sign to be refused for downloads, got %v", err)
+	}
+	if err = VerifyArchiveRequest("/b.zip", s, Request{}); !errors.Is(err, sign.ErrSignInvalid) {
+		t.Errorf("expected the sign to be refused for another archive, got %v", err)
+	}
+	if err = VerifyArchiveRequest("/a.zip", s, Request{Download: true}); err != nil {
+		t.Errorf("expected the first download to be allowed, got %v", err)
+	}
+	if err = VerifyArchiveRequest("/a.zip", s, Request{Download: true}); !errors.Is(err, sign.ErrSignUsedUp) {
+		t.Errorf("expected the second download to be refused, got %v", err)
+	}
+	if c, err := ParseScoped("/a.zip", s); err != nil || c.MaxUses != 1 {
+		t.Errorf("expected the claims of the archive sign, got %+v: %v", c, err)
+	}
+}
@@ -34,6 +34,45 @@ var (
 	ServerUploadLimit   Limiter
 )
 
+type blockBurstLimiter struct {
+	*rate.Limiter
+}
+
+func (l blockBurstLimiter) WaitN(ctx context.Context, total int) error {
+	for total > 0 {
+		n := l.Burst()
+		if l.Limiter.Limit() == rate.Inf || n > total {
+			n = total
+		}
+		err := l.Limiter.WaitN(ctx, n)
+		if err != nil {
+			return err
+		}
+		total -= n
+	}
+	return nil
+}
+
+// NewLimiter returns a Limiter whose WaitN waits for more than the burst in turns instead of failing
+func NewLimiter(limit rate.Limit, burst int) Limiter {
+	return blockBurstLimiter{Limiter: rate.NewLimiter(limit, burst)}
+}
+
+type countedLimiter struct {
+	Limiter
+	count func(n int)
+}
+
+func (l countedLimiter) WaitN(ctx context.Context, n int) error {
+	l.count(n)
+	return l.Limiter.WaitN(ctx, n)
+}
+
+// WithCounter returns a Limiter calling count with the bytes passing through l, for metrics
+func WithCounter(l Limiter, count func(n int)) Limiter {
+	return countedLimiter{Limiter: l, count: count}
+}
+
 type RateLimitReader struct {
 	io.Reader
 	Limiter Limiter
@@ -249,6 +249,26 @@ func NewStreamSectionReader(file model.FileStreamer, maxBufferSize int, up *mode
 	return ss, nil
 }
 
+// discard skips length bytes of the file from off. A seekable stream which hasn't been read
+// is range read from the end of them instead, so that they are not downloaded, e.g. when
+// a resumed upload skips the uploaded bytes
+func discard(file model.FileStreamer, off int64, length int64) (int64, error) {
+	if ss, ok := file.(*SeekableStream); ok && off == 0 && ss.Reader == nil && ss.rangeReader != nil {
+		if length >= ss.GetSize() {
+			ss.Reader = bytes.NewReader(nil)
+			return length, nil
+		}
+		rc, err := ss.rangeReader.RangeRead(ss.Ctx, http_range.Range{Start: length, Length: -1})
+		if err != nil {
+			return 0, err
+		}
+		ss.Add(rc)
+		ss.Reader = rc
+		return length, nil
+	}
+	return utils.CopyWithBufferN(io.Discard, file, length)
+}
+
 type cachedSectionReader struct {
 	cache io.ReaderAt
 }
@@ -279,7 +299,7 @@ func (ss *fileSectionReader) DiscardSection(off int64, length int64) error {
 	if off != ss.fileOffset {
 		return fmt.Errorf("stream not cached: request offset %d != current offset %d", off, ss.fileOffset)
 	}
-	n, err := utils.CopyWithBufferN(io.Discard, ss.file, length)
+	n, err := discard(ss.file, off, length)
 	ss.fileOffset += n
 	if err != nil {
 		return fmt.Errorf("failed to skip data: (expect =%d, actual =%d) %w", length, n, err)
@@ -326,7 +346,7 @@ func (ss *directSectionReader) DiscardSection(off int64, length int64) error {
 	if off != ss.fileOffset {
 		return fmt.Errorf("stream not cached: request offset %d != current offset %d", off, ss.fileOffset)
 	}
-	n, err := utils.CopyWithBufferN(io.Discard, ss.file, length)
+	n, err := discard(ss.file, off, length)
 	ss.fileOffset += n
 	if err != nil {
 		return fmt.Errorf("failed to skip data: (expect =%d, actual =%d) %w", length, n, err)
@@ -4,7 +4,9 @@ import (
 	"context"
 	"time"
 
+	"github.com/OpenListTeam/OpenList/v4/internal/cluster"
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/event"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/tache"
 )
@@ -16,9 +18,13 @@ type TaskExtension struct {
 	endTime    *time.Time
 	TotalBytes int64
 	ApiUrl     string
+	Owner      string // node id of the instance of the cluster running the task
 }
 
 func (t *TaskExtension) SetCtx(ctx context.Context) {
+	if t.Owner == "" {
+		t.Owner = cluster.NodeID()
+	}
 	if t.Creator != nil {
 		ctx = context.WithValue(ctx, conf.UserKey, t.Creator)
 	}
@@ -28,6 +34,32 @@ func (t *TaskExtension) SetCtx(ctx context.Context) {
 	t.Base.SetCtx(ctx)
 }
 
+// SetState publishes the events of the task when it succeeds or fails for good
+func (t *TaskExtension) SetState(state tache.State) {
+	old := t.GetState()
+	t.Base.SetState(state)
+	if old == state {
+		return
+	}
+	var typ event.Type
+	switch state {
+	case tache.StateSucceeded:
+		typ = event.TaskSucceeded
+	case tache.StateFailed:
+		typ = event.TaskFailed
+	default:
+		return
+	}
+	e := &event.Event{Type: typ, Data: map[string]any{"id": t.GetID()}}
+	if t.Creator != nil {
+		e.Username = t.Creator.Username
+	}
+	if err := t.GetErr(); err != nil && state == tache.StateFailed {
+		e.Data["error"] = err.Error()
+	}
+	event.Publish(context.Background(), e)
+}
+
 func (t *TaskExtension) SetCreator(creator *model.User) {
 	t.Creator = creator
 	t.Persist()
@@ -57,6 +89,10 @@ func (t *TaskExtension) ClearEndTime() {
 	t.endTime = nil
 }
 
+func (t *TaskExtension) GetOwner() string {
+	return t.Owner
+}
+
 func (t *TaskExtension) SetTotalBytes(totalBytes int64) {
 	t.TotalBytes = totalBytes
 }
@@ -85,4 +121,5 @@ type TaskExtensionInfo interface {
 	GetStartTime() *time.Time
 	GetEndTime() *time.Time
 	GetTotalBytes() int64
+	GetOwner() string
 }
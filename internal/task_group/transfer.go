@@ -18,6 +18,15 @@ import (
 
 type SrcPathToRemove string
 
+// SrcPathToKeep is a src path skipped by the conflict policy, it's not removed after a move
+type SrcPathToKeep string
+
+// SrcPathRenamed is a src path written to another name by the conflict policy
+type SrcPathRenamed struct {
+	SrcPath string
+	DstName string
+}
+
 // ActualPath
 type DstPathToHook string
 
@@ -52,6 +61,15 @@ func HookAndRemove(ctx context.Context, dstPath string, payloads ...any) {
 	if dstNeedHandleHook {
 		handleHook(dstActualPath)
 	}
+	v := &verifier{kept: make(map[string]struct{}), renamed: make(map[string]string)}
+	for _, payload := range payloads {
+		switch p := payload.(type) {
+		case SrcPathToKeep:
+			v.kept[string(p)] = struct{}{}
+		case SrcPathRenamed:
+			v.renamed[p.SrcPath] = p.DstName
+		}
+	}
 	for _, payload := range payloads {
 		switch p := payload.(type) {
 		case DstPathToHook:
@@ -64,7 +82,7 @@ func HookAndRemove(ctx context.Context, dstPath string, payloads ...any) {
 				log.Error(errors.WithMessage(err, "failed get src storage"))
 				continue
 			}
-			err = verifyAndRemove(ctx, srcStorage, dstStorage, srcActualPath, dstActualPath)
+			_, err = v.verifyAndRemove(ctx, srcStorage, dstStorage, srcActualPath, dstActualPath)
 			if err != nil {
 				log.Error(err)
 			}
@@ -72,49 +90,69 @@ func HookAndRemove(ctx context.Context, dstPath string, payloads ...any) {
 	}
 }
 
-func verifyAndRemove(ctx context.Context, srcStorage, dstStorage driver.Driver, srcPath, dstPath string) error {
+// verifier removes the moved src objects that are found in dst
+type verifier struct {
+	// full src paths
+	kept    map[string]struct{}
+	renamed map[string]string
+}
+
+// verifyAndRemove returns whether some objects are kept in srcPath
+func (v *verifier) verifyAndRemove(ctx context.Context, srcStorage, dstStorage driver.Driver, srcPath, dstPath string) (bool, error) {
+	srcFullPath := path.Join(srcStorage.GetStorage().MountPath, srcPath)
+	if _, ok := v.kept[srcFullPath]; ok {
+		return true, nil
+	}
 	srcObj, err := op.GetUnwrap(ctx, srcStorage, srcPath)
 	if err != nil {
-		return errors.WithMessagef(err, "failed get src [%s] file", path.Join(srcStorage.GetStorage().MountPath, srcPath))
+		return false, errors.WithMessagef(err, "failed get src [%s] file", srcFullPath)
 	}
 
-	dstObjPath := path.Join(dstPath, srcObj.GetName())
+	dstName := srcObj.GetName()
+	if name, ok := v.renamed[srcFullPath]; ok {
+		dstName = name
+	}
+	dstObjPath := path.Join(dstPath, dstName)
 	dstObj, err := op.GetUnwrap(ctx, dstStorage, dstObjPath)
 	if err != nil {
-		return errors.WithMessagef(err, "failed get dst [%s] file", path.Join(dstStorage.GetStorage().MountPath, dstObjPath))
+		return false, errors.WithMessagef(err, "failed get dst [%s] file", path.Join(dstStorage.GetStorage().MountPath, dstObjPath))
 	}
 
 	if !dstObj.IsDir() {
 		err = op.Remove(ctx, srcStorage, srcPath)
 		if err != nil {
-			return fmt.Errorf("failed remove %s: %+v", path.Join(srcStorage.GetStorage().MountPath, srcPath), err)
+			return false, fmt.Errorf("failed remove %s: %+v", srcFullPath, err)
 		}
-		return nil
+		return false, nil
 	}
 
 	// Verify directory
 	srcObjs, err := op.List(ctx, srcStorage, srcPath, model.ListArgs{})
 	if err != nil {
-		return errors.WithMessagef(err, "failed list src [%s] objs", path.Join(srcStorage.GetStorage().MountPath, srcPath))
+		return false, errors.WithMessagef(err, "failed list src [%s] objs", srcFullPath)
 	}
 
-	hasErr := false
+	hasErr, hasKept := false, false
 	for _, obj := range srcObjs {
 		srcSubPath := path.Join(srcPath, obj.GetName())
-		err := verifyAndRemove(ctx, srcStorage, dstStorage, srcSubPath, dstObjPath)
+		kept, err := v.verifyAndRemove(ctx, srcStorage, dstStorage, srcSubPath, dstObjPath)
 		if err != nil {
 			log.Error(err)
 			hasErr = true
 		}
+		hasKept = hasKept || kept
 	}
 	if hasErr {
-		return errors.Errorf("some subitems of [%s] failed to verify and remove", path.Join(srcStorage.GetStorage().MountPath, srcPath))
+		return hasKept, errors.Errorf("some subitems of [%s] failed to verify and remove", srcFullPath)
+	}
+	if hasKept {
+		return true, nil
 	}
 	err = op.Remove(ctx, srcStorage, srcPath)
 	if err != nil {
-		return fmt.Errorf("failed remove %s: %+v", path.Join(srcStorage.GetStorage().MountPath, srcPath), err)
+		return false, fmt.Errorf("failed remove %s: %+v", srcFullPath, err)
 	}
-	return nil
+	return false, nil
 }
 
 var TransferCoordinator *TaskGroupCoordinator = NewTaskGroupCoordinator("HookAndRemove", HookAndRemove)
@@ -0,0 +1,108 @@
+// Package tracing exports OpenTelemetry traces of the requests, from the http, ftp and sftp
+// servers through op to the requests of the drivers. It does nothing until Init is called
+// with an endpoint
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/OpenListTeam/OpenList/v4"
+
+var enabled atomic.Bool
+
+// Init sets up the exporter of cfg, the returned func flushes and stops it.
+// Without an endpoint tracing stays disabled and the func does nothing
+func Init(cfg conf.Tracing) (func(context.Context) error, error) {
+	if cfg.Endpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+	exporter, err := otlptracehttp.New(context.Background(),
+		otlptracehttp.WithEndpointURL(cfg.Endpoint),
+		otlptracehttp.WithHeaders(cfg.Headers),
+	)
+	if err != nil {
+		return nil, err
+	}
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL,
+		semconv.ServiceName(cfg.ServiceName),
+		semconv.ServiceVersion(conf.Version),
+	))
+	if err != nil {
+		return nil, err
+	}
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	SetProvider(tp)
+	return tp.Shutdown, nil
+}
+
+// SetProvider enables tracing with tp, Init calls it and tests use it with an in-process exporter
+func SetProvider(tp trace.TracerProvider) {
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	enabled.Store(true)
+}
+
+// Enabled reports whether the traces are exported
+func Enabled() bool {
+	return enabled.Load()
+}
+
+// Start starts a span as a child of the one in ctx
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if !Enabled() {
+		return ctx, trace.SpanFromContext(ctx)
+	}
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// StartServer starts the span of a request received by a server, continuing the trace
+// of the client if header carries one
+func StartServer(ctx context.Context, name string, header http.Header, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if !Enabled() {
+		return ctx, trace.SpanFromContext(ctx)
+	}
+	if header != nil {
+		ctx = otel.GetTextMapPropagator().Extract(ctx, propagation.HeaderCarrier(header))
+	}
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithSpanKind(trace.SpanKindServer), trace.WithAttributes(attrs...))
+}
+
+// End ends the span, recording err if it's not nil
+func End(span trace.Span, err error) {
+	if !Enabled() {
+		return
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Transport makes rt emit a client span for each request, and send the trace context
+// to the server. It returns rt as is when tracing is disabled
+func Transport(rt http.RoundTripper) http.RoundTripper {
+	if !Enabled() {
+		return rt
+	}
+	return otelhttp.NewTransport(rt)
+}
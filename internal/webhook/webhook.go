@@ -0,0 +1,208 @@
+// Package webhook posts the events of the event bus to the webhooks configured by admins.
+// Matching events are put in an outbox in the database first, and a worker posts them
+// with retries, so they are not lost when the receiver or OpenList itself is down
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/cluster"
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/event"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/net"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// MaxAttempts is the number of attempts of a delivery before it fails for good
+	MaxAttempts = 10
+	batchSize   = 50
+	timeout     = 10 * time.Second
+	// passTime bounds the time spent on the deliveries of a webhook in a pass over the outbox,
+	// the rest of them are left for the next pass
+	passTime   = 30 * time.Second
+	minBackoff = 30 * time.Second
+	maxBackoff = 6 * time.Hour
+)
+
+var (
+	client   *http.Client
+	wake     = make(chan struct{}, 1)
+	initOnce sync.Once
+)
+
+// Init subscribes the webhooks to the event bus and starts the worker of the outbox
+func Init() {
+	initOnce.Do(func() {
+		client = net.NewHttpClient()
+		client.Timeout = timeout
+		event.Subscribe(enqueue)
+		go work()
+	})
+}
+
+func enqueue(e *event.Event) {
+	n, err := op.EnqueueWebhookEvent(e)
+	if err != nil {
+		log.Errorf("failed enqueue webhook deliveries of event %s: %+v", e.Type, err)
+		return
+	}
+	if n > 0 {
+		Wake()
+	}
+}
+
+// Wake makes the worker check the outbox now
+func Wake() {
+	select {
+	case wake <- struct{}{}:
+	default:
+	}
+}
+
+func work() {
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-wake:
+		}
+		// the outbox is shared by the instances of the cluster, so only the leader posts it
+		if !cluster.IsLeader() {
+			continue
+		}
+		for {
+			deliveries, err := op.GetDueWebhookDeliveries(batchSize)
+			if err != nil {
+				log.Errorf("failed get due webhook deliveries: %+v", err)
+				break
+			}
+			deliverAll(deliveries)
+			if len(deliveries) < batchSize {
+				break
+			}
+		}
+	}
+}
+
+// deliverAll posts the deliveries of each webhook concurrently, so that a slow or unreachable
+// receiver doesn't hold up the others. The ones of a webhook are posted in order, and once
+// one of them fails the rest are postponed with it rather than waiting for the same timeout
+func deliverAll(deliveries []model.WebhookDelivery) {
+	byWebhook := make(map[uint][]*model.WebhookDelivery)
+	for i := range deliveries {
+		d := &deliveries[i]
+		byWebhook[d.WebhookID] = append(byWebhook[d.WebhookID], d)
+	}
+	var wg sync.WaitGroup
+	for _, ds := range byWebhook {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			deadline := time.Now().Add(passTime)
+			for i, d := range ds {
+				if time.Now().After(deadline) {
+					return
+				}
+				if !attempt(d) {
+					postpone(ds[i+1:], d.NextAttemptAt)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// postpone moves the next attempt of the deliveries to t, without counting an attempt
+func postpone(deliveries []*model.WebhookDelivery, t time.Time) {
+	for _, d := range deliveries {
+		d.NextAttemptAt = t
+		if err := op.UpdateWebhookDelivery(d); err != nil {
+			log.Errorf("failed update webhook delivery %d: %+v", d.ID, err)
+		}
+	}
+}
+
+// attempt posts the delivery once and records the result, scheduling the next attempt on failure.
+// It returns false if the delivery is to be retried
+func attempt(d *model.WebhookDelivery) bool {
+	d.Attempts++
+	w, err := op.GetWebhookById(d.WebhookID)
+	if err == nil && w.Disabled {
+		err = fmt.Errorf("webhook is disabled")
+	}
+	if err != nil {
+		d.Status, d.LastError = model.WebhookDeliveryFailed, err.Error()
+	} else {
+		d.ResponseCode, err = post(w, d)
+		if err == nil {
+			now := time.Now()
+			d.Status, d.LastError, d.DeliveredAt = model.WebhookDeliveryDelivered, "", &now
+		} else if d.LastError = err.Error(); d.Attempts >= MaxAttempts {
+			d.Status = model.WebhookDeliveryFailed
+		} else {
+			d.NextAttemptAt = time.Now().Add(Backoff(d.Attempts))
+		}
+	}
+	if err := op.UpdateWebhookDelivery(d); err != nil {
+		log.Errorf("failed update webhook delivery %d: %+v", d.ID, err)
+	}
+	return d.Status != model.WebhookDeliveryPending
+}
+
+// Backoff is the delay before the next attempt after the given number of failed ones
+func Backoff(attempts int) time.Duration {
+	if attempts > 20 {
+		return maxBackoff
+	}
+	return min(minBackoff<<(attempts-1), maxBackoff)
+}
+
+// Sign signs the body sent at the unix time ts with the secret of a webhook,
+// receivers compute the same to verify the X-OpenList-Signature header
+func Sign(secret string, ts int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(ts, 10) + "."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func post(w *model.Webhook, d *model.WebhookDelivery) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	body := []byte(d.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	ts := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "OpenList/"+conf.Version)
+	req.Header.Set("X-OpenList-Event", d.EventType)
+	req.Header.Set("X-OpenList-Delivery", strconv.FormatUint(uint64(d.ID), 10))
+	req.Header.Set("X-OpenList-Signature", fmt.Sprintf("t=%d,v1=%s", ts, Sign(w.Secret, ts, body)))
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,77 @@
+package webhook
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/event"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/net"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func init() {
+	dB, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
+	if err != nil {
+		panic("failed to connect database")
+	}
+	conf.Conf = conf.DefaultConfig("data")
+	db.Init(dB)
+	client = net.NewHttpClient()
+	client.Timeout = timeout
+}
+
+func TestDeliverAll(t *testing.T) {
+	var posted atomic.Int32
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		posted.Add(1)
+	}))
+	defer up.Close()
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+
+	good := &model.Webhook{Name: "good", URL: up.URL}
+	bad := &model.Webhook{Name: "bad", URL: down.URL}
+	for _, w := range []*model.Webhook{good, bad} {
+		if err := op.CreateWebhook(w); err != nil {
+			t.Fatalf("failed to create webhook: %+v", err)
+		}
+		defer op.DeleteWebhookById(w.ID)
+	}
+	for _, id := range []string{"1", "2", "3"} {
+		if _, err := op.EnqueueWebhookEvent(&event.Event{ID: id, Type: event.UserLogin}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	deliveries, err := op.GetDueWebhookDeliveries(batchSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	deliverAll(deliveries)
+
+	if n := posted.Load(); n != 3 {
+		t.Errorf("expected the 3 deliveries of the good webhook to be posted, got %d", n)
+	}
+	// the first delivery to the unreachable webhook fails, and the rest wait with it
+	ds, _, err := op.GetWebhookDeliveries(bad.ID, 1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	attempts := 0
+	for _, d := range ds {
+		if d.Status != model.WebhookDeliveryPending || !d.NextAttemptAt.After(time.Now()) {
+			t.Errorf("expected delivery %d to be postponed, got %s at %s", d.ID, d.Status, d.NextAttemptAt)
+		}
+		attempts += d.Attempts
+	}
+	if len(ds) != 3 || attempts != 1 {
+		t.Errorf("expected 3 deliveries attempted once in total, got %d attempted %d times", len(ds), attempts)
+	}
+}
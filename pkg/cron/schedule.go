@@ -0,0 +1,190 @@
+package cron
+
+import (
+	"fmt"
+	"math/bits"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is a parsed cron expression of the five standard fields
+// "minute hour day-of-month month day-of-week", the descriptors like @daily
+// and "@every <duration>" are supported too
+type Schedule struct {
+	minute, hour, dom, month, dow uint64
+	// when both day fields are restricted, a day matching either of them is matched
+	domStar, dowStar bool
+	every            time.Duration
+}
+
+type field struct {
+	min, max int
+	names    map[string]int
+}
+
+var (
+	minuteField = field{min: 0, max: 59}
+	hourField   = field{min: 0, max: 23}
+	domField    = field{min: 1, max: 31}
+	monthField  = field{min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	// 7 is sunday as well
+	dowField = field{min: 0, max: 7, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+var descriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+func ParseSchedule(spec string) (*Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if d, ok := strings.CutPrefix(spec, "@every "); ok {
+		every, err := time.ParseDuration(strings.TrimSpace(d))
+		if err != nil {
+			return nil, fmt.Errorf("invalid duration of %s: %w", spec, err)
+		}
+		if every < time.Minute {
+			return nil, fmt.Errorf("invalid duration of %s: must be at least 1m", spec)
+		}
+		return &Schedule{every: every}, nil
+	}
+	if s, ok := descriptors[strings.ToLower(spec)]; ok {
+		spec = s
+	}
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid cron expression %s: expected 5 fields, got %d", spec, len(fields))
+	}
+	var (
+		s   Schedule
+		err error
+	)
+	if s.minute, err = minuteField.parse(fields[0]); err != nil {
+		return nil, err
+	}
+	if s.hour, err = hourField.parse(fields[1]); err != nil {
+		return nil, err
+	}
+	if s.dom, err = domField.parse(fields[2]); err != nil {
+		return nil, err
+	}
+	if s.month, err = monthField.parse(fields[3]); err != nil {
+		return nil, err
+	}
+	if s.dow, err = dowField.parse(fields[4]); err != nil {
+		return nil, err
+	}
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+	s.domStar = strings.HasPrefix(fields[2], "*")
+	s.dowStar = strings.HasPrefix(fields[4], "*")
+	return &s, nil
+}
+
+func (f field) value(s string) (int, error) {
+	if v, ok := f.names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid cron value %s", s)
+	}
+	if v < f.min || v > f.max {
+		return 0, fmt.Errorf("cron value %d out of range [%d, %d]", v, f.min, f.max)
+	}
+	return v, nil
+}
+
+// parse returns the bit set of the values matched by a field like "1-10/2,15,*/20"
+func (f field) parse(s string) (uint64, error) {
+	var set uint64
+	for _, item := range strings.Split(s, ",") {
+		r, stepStr, hasStep := strings.Cut(item, "/")
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepStr); err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid cron step %s", item)
+			}
+		}
+		start, end := f.min, f.max
+		if r != "*" {
+			lo, hi, isRange := strings.Cut(r, "-")
+			var err error
+			if start, err = f.value(lo); err != nil {
+				return 0, err
+			}
+			end = start
+			if isRange {
+				if end, err = f.value(hi); err != nil {
+					return 0, err
+				}
+			} else if hasStep {
+				end = f.max
+			}
+			if start > end {
+				return 0, fmt.Errorf("invalid cron range %s", item)
+			}
+		}
+		for i := start; i <= end; i += step {
+			set |= 1 << i
+		}
+	}
+	return set, nil
+}
+
+func (s *Schedule) dayMatches(t time.Time) bool {
+	domMatch := s.dom&(1<<t.Day()) != 0
+	dowMatch := s.dow&(1<<t.Weekday()) != 0
+	if s.domStar || s.dowStar {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+// Next returns the first time after t that matches the schedule,
+// or the zero time if there is none in the next five years
+func (s *Schedule) Next(t time.Time) time.Time {
+	if s.every > 0 {
+		return t.Add(s.every)
+	}
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		if s.month&(1<<t.Month()) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.hour&(1<<t.Hour()) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.minute&(1<<t.Minute()) == 0 {
+			// jump to the next matching minute of the hour, if any
+			if next := s.minute >> (t.Minute() + 1) << (t.Minute() + 1); next != 0 {
+				t = t.Add(time.Duration(bits.TrailingZeros64(next)-t.Minute()) * time.Minute)
+			} else {
+				t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			}
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
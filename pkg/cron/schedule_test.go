@@ -0,0 +1,42 @@
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSchedule(t *testing.T) {
+	from := time.Date(2024, 1, 31, 10, 30, 15, 0, time.UTC)
+	tests := []struct {
+		spec string
+		next time.Time
+	}{
+		{"* * * * *", time.Date(2024, 1, 31, 10, 31, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2024, 1, 31, 10, 45, 0, 0, time.UTC)},
+		{"0 3 * * *", time.Date(2024, 2, 1, 3, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 feb *", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"30 9 * * mon-fri", time.Date(2024, 2, 1, 9, 30, 0, 0, time.UTC)},
+		{"0 12 * * 7", time.Date(2024, 2, 4, 12, 0, 0, 0, time.UTC)},
+		// day of month or day of week
+		{"0 0 15 * sat", time.Date(2024, 2, 3, 0, 0, 0, 0, time.UTC)},
+		{"5,40 10-11 * * *", time.Date(2024, 1, 31, 10, 40, 0, 0, time.UTC)},
+		{"@every 2h", from.Add(2 * time.Hour)},
+	}
+	for _, test := range tests {
+		s, err := ParseSchedule(test.spec)
+		if err != nil {
+			t.Errorf("failed parse %s: %v", test.spec, err)
+			continue
+		}
+		if next := s.Next(from); !next.Equal(test.next) {
+			t.Errorf("next of %s: expected %s, got %s", test.spec, test.next, next)
+		}
+	}
+
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* * 0 * *", "5-1 * * * *", "*/0 * * * *", "@every 1s", "@every x"} {
+		if _, err := ParseSchedule(spec); err == nil {
+			t.Errorf("expected an error for %q", spec)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/base64"
+	"encoding/json"
 	"io"
 	"strconv"
 	"strings"
@@ -50,3 +51,45 @@ func (s HMACSign) Verify(data, sign string) error {
 func NewHMACSign(secret []byte) Sign {
 	return HMACSign{SecretKey: secret}
 }
+
+// SignClaims signs data with the claims in the form of payload.signature,
+// which has no colon in it to tell it from the form of Sign
+func (s HMACSign) SignClaims(data string, claims Claims) string {
+	b, err := json.Marshal(claims)
+	if err != nil {
+		return ""
+	}
+	payload := base64.RawURLEncoding.EncodeToString(b)
+	return payload + "." + s.claimsSignature(data, payload)
+}
+
+func (s HMACSign) claimsSignature(data, payload string) string {
+	h := hmac.New(sha256.New, s.SecretKey)
+	_, _ = io.WriteString(h, data+"\n"+payload)
+	return base64.RawURLEncoding.EncodeToString(h.Sum(nil))
+}
+
+// VerifyClaims verifies a sign of SignClaims and returns the claims of it, only the expiry of them is checked
+func (s HMACSign) VerifyClaims(data, sign string) (*Claims, error) {
+	payload, signature, ok := strings.Cut(sign, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(s.claimsSignature(data, payload))) {
+		return nil, ErrSignInvalid
+	}
+	b, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, ErrSignInvalid
+	}
+	var claims Claims
+	if err = json.Unmarshal(b, &claims); err != nil {
+		return nil, ErrSignInvalid
+	}
+	if claims.Expire != 0 && claims.Expire < time.Now().Unix() {
+		return nil, ErrSignExpired
+	}
+	return &claims, nil
+}
+
+// IsClaims reports whether the sign is in the form of SignClaims
+func IsClaims(sign string) bool {
+	return sign != "" && !strings.Contains(sign, ":")
+}
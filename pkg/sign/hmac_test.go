@@ -0,0 +1,34 @@
+package sign
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestHMACSignClaims(t *testing.T) {
+	s := NewHMACSign([]byte("secret"))
+	old := s.Sign("/a.txt", 0)
+	if IsClaims(old) || s.Verify("/a.txt", old) != nil {
+		t.Errorf("expected the old sign to be verified, got %s", old)
+	}
+	claims := Claims{KeyID: "k1", Batch: "b1", CIDRs: []string{"10.0.0.0/8"}, MaxUses: 3}
+	sg := s.SignClaims("/a.txt", claims)
+	if !IsClaims(sg) {
+		t.Fatalf("expected a scoped sign, got %s", sg)
+	}
+	got, err := s.VerifyClaims("/a.txt", sg)
+	if err != nil || got.KeyID != "k1" || got.Batch != "b1" || got.MaxUses != 3 || len(got.CIDRs) != 1 {
+		t.Errorf("expected the claims back, got %+v: %v", got, err)
+	}
+	if _, err = s.VerifyClaims("/b.txt", sg); !errors.Is(err, ErrSignInvalid) {
+		t.Errorf("expected the sign to be invalid for another path, got %v", err)
+	}
+	if _, err = NewHMACSign([]byte("other")).VerifyClaims("/a.txt", sg); !errors.Is(err, ErrSignInvalid) {
+		t.Errorf("expected the sign to be invalid with another key, got %v", err)
+	}
+	claims.Expire = time.Now().Add(-time.Minute).Unix()
+	if _, err = s.VerifyClaims("/a.txt", s.SignClaims("/a.txt", claims)); !errors.Is(err, ErrSignExpired) {
+		t.Errorf("expected the sign to be expired, got %v", err)
+	}
+}
@@ -5,11 +5,32 @@ import "errors"
 type Sign interface {
 	Sign(data string, expire int64) string
 	Verify(data, sign string) error
+	SignClaims(data string, claims Claims) string
+	VerifyClaims(data, sign string) (*Claims, error)
+}
+
+// Claims are what a scoped sign is bound to besides the data
+type Claims struct {
+	// KeyID tells the sign from others to revoke it, Batch is shared by the signs minted together
+	KeyID  string `json:"k"`
+	Batch  string `json:"b,omitempty"`
+	Expire int64  `json:"e,omitempty"`
+	// CIDRs the client has to be in, any client if empty
+	CIDRs []string `json:"ip,omitempty"`
+	// UserAgent is the hash of the user agent the client has to send, any if empty
+	UserAgent string `json:"ua,omitempty"`
+	// MaxUses is how many downloads the sign allows, 0 for no limit
+	MaxUses int `json:"n,omitempty"`
+	// UserID is the user who minted the sign
+	UserID uint `json:"u,omitempty"`
 }
 
 var (
-	ErrSignExpired   = errors.New("sign expired")
-	ErrSignInvalid   = errors.New("sign invalid")
-	ErrExpireInvalid = errors.New("expire invalid")
-	ErrExpireMissing = errors.New("expire missing")
+	ErrSignExpired    = errors.New("sign expired")
+	ErrSignInvalid    = errors.New("sign invalid")
+	ErrExpireInvalid  = errors.New("expire invalid")
+	ErrExpireMissing  = errors.New("expire missing")
+	ErrSignRevoked    = errors.New("sign revoked")
+	ErrSignOutOfScope = errors.New("sign not allowed for this client")
+	ErrSignUsedUp     = errors.New("sign used up")
 )
@@ -3,7 +3,10 @@ package utils
 import (
 	"net"
 	"net/http"
+	"net/netip"
 	"strings"
+
+	"github.com/pkg/errors"
 )
 
 func ClientIP(r *http.Request) string {
@@ -25,6 +28,14 @@ func ClientIP(r *http.Request) string {
 	return ""
 }
 
+// HostIP returns the ip of a remote address like 1.2.3.4:5678
+func HostIP(addr string) string {
+	if ip, _, err := net.SplitHostPort(addr); err == nil {
+		return ip
+	}
+	return addr
+}
+
 func IsLocalIPAddr(ip string) bool {
 	return IsLocalIP(net.ParseIP(ip))
 }
@@ -47,3 +58,29 @@ func IsLocalIP(ip net.IP) bool {
 		(ip4[0] == 169 && ip4[1] == 254) || // 169.254.0.0/16
 		(ip4[0] == 192 && ip4[1] == 168) // 192.168.0.0/16
 }
+
+// ParseCIDRs parses the CIDRs, where a single ip is taken as a CIDR of it only, and empty ones are skipped
+func ParseCIDRs(cidrs []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(cidrs))
+	for _, s := range cidrs {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		if !strings.Contains(s, "/") {
+			addr, err := netip.ParseAddr(s)
+			if err != nil {
+				return nil, errors.Errorf("invalid ip [%s]", s)
+			}
+			addr = addr.Unmap()
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+		p, err := netip.ParsePrefix(s)
+		if err != nil {
+			return nil, errors.Errorf("invalid cidr [%s]", s)
+		}
+		prefixes = append(prefixes, p.Masked())
+	}
+	return prefixes, nil
+}
@@ -4,8 +4,11 @@ import (
 	"time"
 
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/event"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
-	"github.com/OpenListTeam/go-cache"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/setting"
+	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/pkg/errors"
 )
@@ -13,28 +16,40 @@ import (
 var SecretKey []byte
 
 type UserClaims struct {
-	Username string `json:"username"`
-	PwdTS    int64  `json:"pwd_ts"`
+	Username  string `json:"username"`
+	PwdTS     int64  `json:"pwd_ts"`
+	SessionID string `json:"sid"`
 	jwt.RegisteredClaims
 }
 
-var validTokenCache = cache.NewMemCache[bool]()
-
-func GenerateToken(user *model.User) (tokenString string, err error) {
+// GenerateToken logs the user in with a new session from the client of c
+func GenerateToken(c *gin.Context, user *model.User) (tokenString string, err error) {
+	lifetime := time.Duration(setting.GetInt(conf.SessionLifetime, 0)) * time.Hour
+	if lifetime <= 0 {
+		lifetime = time.Duration(conf.Conf.TokenExpiresIn) * time.Hour
+	}
+	session, err := op.CreateSession(user, c.Request.UserAgent(), c.ClientIP(), lifetime)
+	if err != nil {
+		return "", err
+	}
 	claim := UserClaims{
-		Username: user.Username,
-		PwdTS:    user.PwdTS,
+		Username:  user.Username,
+		PwdTS:     user.PwdTS,
+		SessionID: session.ID,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Duration(conf.Conf.TokenExpiresIn) * time.Hour)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			NotBefore: jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(session.ExpiresAt),
+			IssuedAt:  jwt.NewNumericDate(session.CreatedAt),
+			NotBefore: jwt.NewNumericDate(session.CreatedAt),
 		}}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claim)
 	tokenString, err = token.SignedString(SecretKey)
-	if err != nil {
-		return "", err
+	if err == nil {
+		event.Publish(c.Request.Context(), &event.Event{
+			Type:     event.UserLogin,
+			Username: user.Username,
+			Data:     map[string]any{"ip": c.ClientIP(), "user_agent": c.Request.UserAgent()},
+		})
 	}
-	validTokenCache.Set(tokenString, true)
 	return tokenString, err
 }
 
@@ -42,9 +57,6 @@ func ParseToken(tokenString string) (*UserClaims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &UserClaims{}, func(token *jwt.Token) (interface{}, error) {
 		return SecretKey, nil
 	})
-	if IsTokenInvalidated(tokenString) {
-		return nil, errors.New("token is invalidated")
-	}
 	if err != nil {
 		if ve, ok := err.(*jwt.ValidationError); ok {
 			if ve.Errors&jwt.ValidationErrorMalformed != 0 {
@@ -59,20 +71,28 @@ func ParseToken(tokenString string) (*UserClaims, error) {
 		}
 	}
 	if claims, ok := token.Claims.(*UserClaims); ok && token.Valid {
+		// a token is only valid with its session. The tokens issued before the sessions have none,
+		// they are refused rather than trusted until they expire since they can't be revoked,
+		// which logs no one out who wasn't already: they were only valid in the process issuing them
+		if claims.SessionID == "" {
+			return nil, errors.New("the token has no session, login please")
+		}
+		if _, err := op.ValidateSession(claims.SessionID); err != nil {
+			return nil, err
+		}
 		return claims, nil
 	}
 	return nil, errors.New("couldn't handle this token")
 }
 
+// InvalidateToken revokes the session of the token
 func InvalidateToken(tokenString string) error {
 	if tokenString == "" {
 		return nil // don't invalidate empty guest token
 	}
-	validTokenCache.Del(tokenString)
-	return nil
-}
-
-func IsTokenInvalidated(tokenString string) bool {
-	_, ok := validTokenCache.Get(tokenString)
-	return !ok
+	claims, err := ParseToken(tokenString)
+	if err != nil {
+		return nil // the token can't be used already
+	}
+	return op.RevokeSession(claims.SessionID, 0)
 }
@@ -0,0 +1,24 @@
+package common
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+func TestParseTokenWithoutSession(t *testing.T) {
+	SecretKey = []byte("test")
+	claims := UserClaims{
+		Username: "legacy",
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		}}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(SecretKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = ParseToken(token); err == nil {
+		t.Errorf("expected a token without a session to be refused")
+	}
+}
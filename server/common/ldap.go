@@ -82,6 +82,7 @@ func LdapRegister(username string) (*model.User, error) {
 		Role:       0,
 		Disabled:   false,
 		AllowLdap:  true,
+		GroupIDs:   utils.MustSliceConvert(ldapDefaultGroups(), func(g model.Group) uint { return g.ID }),
 	}
 	user.SetPassword(random.String(16))
 	if err := op.CreateUser(user); err != nil {
@@ -90,6 +91,24 @@ func LdapRegister(username string) (*model.User, error) {
 	return user, nil
 }
 
+// ldapDefaultGroups returns the groups that new ldap users are assigned to
+func ldapDefaultGroups() []model.Group {
+	groups, err := op.GetGroupsByNames(setting.GetStr(conf.LdapDefaultGroups))
+	if err != nil {
+		log.Errorf("failed get ldap default groups: %+v", err)
+	}
+	return groups
+}
+
+// LdapDefaultPermission returns the permission of new ldap users, including their groups'
+func LdapDefaultPermission() int32 {
+	permission := int32(setting.GetInt(conf.LdapDefaultPermission, 0))
+	for _, g := range ldapDefaultGroups() {
+		permission |= g.Permission
+	}
+	return permission
+}
+
 func dial(ldapServer string, skipTlsVerify ...bool) (*ldap.Conn, error) {
 	tlsEnabled := false
 	if strings.HasPrefix(ldapServer, "ldaps://") {
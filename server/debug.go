@@ -17,7 +17,7 @@ func _pprof(g *gin.RouterGroup) {
 }
 
 func debug(g *gin.RouterGroup) {
-	g.GET("/path/*path", middlewares.Down(sign.Verify), func(c *gin.Context) {
+	g.GET("/path/*path", middlewares.Down(sign.VerifyRequest), func(c *gin.Context) {
 		rawPath := c.Request.Context().Value(conf.PathKey).(string)
 		c.JSON(200, gin.H{
 			"path": rawPath,
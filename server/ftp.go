@@ -14,6 +14,7 @@ import (
 	"sync"
 
 	"github.com/OpenListTeam/OpenList/v4/drivers/base"
+	"github.com/OpenListTeam/OpenList/v4/internal/audit"
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/internal/op"
@@ -113,6 +114,18 @@ func (d *FtpMainDriver) ClientDisconnected(cc ftpserver.ClientContext) {
 }
 
 func (d *FtpMainDriver) AuthUser(cc ftpserver.ClientContext, user, pass string) (ftpserver.ClientDriver, error) {
+	driver, err := d.authUser(cc, user, pass)
+	ctx := context.WithValue(context.Background(), conf.ClientIPKey, cc.RemoteAddr().String())
+	ctx = context.WithValue(ctx, conf.ProtocolKey, model.TokenFtp)
+	method := "password"
+	if op.IsAccessToken(pass) {
+		method = "access_token"
+	}
+	audit.Login(ctx, user, method, err)
+	return driver, err
+}
+
+func (d *FtpMainDriver) authUser(cc ftpserver.ClientContext, user, pass string) (ftpserver.ClientDriver, error) {
 	ip := cc.RemoteAddr().String()
 	count, ok := model.LoginCache.Get(ip)
 	if ok && count >= model.DefaultMaxAuthRetries {
@@ -126,6 +139,12 @@ func (d *FtpMainDriver) AuthUser(cc ftpserver.ClientContext, user, pass string)
 		if err != nil {
 			return nil, err
 		}
+	} else if op.IsAccessToken(pass) {
+		userObj, err = op.AuthAccessToken(user, pass, model.TokenFtp)
+		if err != nil {
+			model.LoginCache.Set(ip, count+1)
+			return nil, err
+		}
 	} else {
 		userObj, err = op.GetUserByName(user)
 		if err == nil {
@@ -133,7 +152,7 @@ func (d *FtpMainDriver) AuthUser(cc ftpserver.ClientContext, user, pass string)
 			if err != nil && setting.GetBool(conf.LdapLoginEnabled) && userObj.AllowLdap {
 				err = common.HandleLdapLogin(user, pass)
 			}
-		} else if setting.GetBool(conf.LdapLoginEnabled) && model.CanFTPAccess(int32(setting.GetInt(conf.LdapDefaultPermission, 0))) {
+		} else if setting.GetBool(conf.LdapLoginEnabled) && model.CanFTPAccess(common.LdapDefaultPermission()) {
 			userObj, err = tryLdapLoginAndRegister(user, pass)
 		}
 		if err != nil {
@@ -145,6 +164,9 @@ func (d *FtpMainDriver) AuthUser(cc ftpserver.ClientContext, user, pass string)
 		model.LoginCache.Set(ip, count+1)
 		return nil, errors.New("user is not allowed to access via FTP")
 	}
+	if err = op.CheckIP(userObj, model.TokenFtp, utils.HostIP(ip)); err != nil {
+		return nil, err
+	}
 	model.LoginCache.Del(ip)
 
 	ctx := context.Background()
@@ -155,6 +177,7 @@ func (d *FtpMainDriver) AuthUser(cc ftpserver.ClientContext, user, pass string)
 		ctx = context.WithValue(ctx, conf.MetaPassKey, "")
 	}
 	ctx = context.WithValue(ctx, conf.ClientIPKey, ip)
+	ctx = context.WithValue(ctx, conf.ProtocolKey, model.TokenFtp)
 	ctx = context.WithValue(ctx, conf.ProxyHeaderKey, d.proxyHeader)
 	return ftp.NewAferoAdapter(ctx), nil
 }
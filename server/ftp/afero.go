@@ -12,9 +12,12 @@ import (
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
 	"github.com/OpenListTeam/OpenList/v4/internal/errs"
 	"github.com/OpenListTeam/OpenList/v4/internal/fs"
+	"github.com/OpenListTeam/OpenList/v4/internal/metrics"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/tracing"
 	ftpserver "github.com/fclairamb/ftpserverlib"
 	"github.com/spf13/afero"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type AferoAdapter struct {
@@ -31,8 +34,30 @@ func (a *AferoAdapter) Create(_ string) (afero.File, error) {
 	return nil, errs.NotImplement
 }
 
+// observe starts timing an operation of the client on name in a span, the operation should be done
+// with the returned ctx and the returned func records it with its result
+func (a *AferoAdapter) observe(op, name string) (context.Context, func(err error)) {
+	start := time.Now()
+	protocol, _ := a.ctx.Value(conf.ProtocolKey).(string)
+	ctx, span := tracing.StartServer(a.ctx, protocol+" "+op, nil,
+		attribute.String("protocol", protocol),
+		attribute.String("path", name),
+	)
+	return ctx, func(err error) {
+		status := "ok"
+		if err != nil {
+			status = "error"
+		}
+		metrics.ObserveRequest(protocol, op, "", status, start)
+		tracing.End(span, err)
+	}
+}
+
 func (a *AferoAdapter) Mkdir(name string, _ os.FileMode) error {
-	return Mkdir(a.ctx, name)
+	ctx, done := a.observe("mkdir", name)
+	err := Mkdir(ctx, name)
+	done(err)
+	return err
 }
 
 func (a *AferoAdapter) MkdirAll(path string, perm os.FileMode) error {
@@ -50,7 +75,10 @@ func (a *AferoAdapter) OpenFile(_ string, _ int, _ os.FileMode) (afero.File, err
 }
 
 func (a *AferoAdapter) Remove(name string) error {
-	return Remove(a.ctx, name)
+	ctx, done := a.observe("remove", name)
+	err := Remove(ctx, name)
+	done(err)
+	return err
 }
 
 func (a *AferoAdapter) RemoveAll(path string) error {
@@ -58,11 +86,17 @@ func (a *AferoAdapter) RemoveAll(path string) error {
 }
 
 func (a *AferoAdapter) Rename(oldName, newName string) error {
-	return Rename(a.ctx, oldName, newName)
+	ctx, done := a.observe("rename", oldName)
+	err := Rename(ctx, oldName, newName)
+	done(err)
+	return err
 }
 
 func (a *AferoAdapter) Stat(name string) (os.FileInfo, error) {
-	return Stat(a.ctx, name)
+	ctx, done := a.observe("stat", name)
+	info, err := Stat(ctx, name)
+	done(err)
+	return info, err
 }
 
 func (a *AferoAdapter) Name() string {
@@ -82,10 +116,20 @@ func (a *AferoAdapter) Chtimes(_ string, _ time.Time, _ time.Time) error {
 }
 
 func (a *AferoAdapter) ReadDir(name string) ([]os.FileInfo, error) {
-	return List(a.ctx, name)
+	ctx, done := a.observe("list", name)
+	infos, err := List(ctx, name)
+	done(err)
+	return infos, err
 }
 
 func (a *AferoAdapter) GetHandle(name string, flags int, offset int64) (ftpserver.FileTransfer, error) {
+	ctx, done := a.observe("open", name)
+	f, err := a.getHandle(ctx, name, flags, offset)
+	done(err)
+	return f, err
+}
+
+func (a *AferoAdapter) getHandle(ctx context.Context, name string, flags int, offset int64) (ftpserver.FileTransfer, error) {
 	fileSize := a.nextFileSize
 	a.nextFileSize = 0
 	if (flags & os.O_SYNC) != 0 {
@@ -94,12 +138,12 @@ func (a *AferoAdapter) GetHandle(name string, flags int, offset int64) (ftpserve
 	if (flags & os.O_APPEND) != 0 {
 		return nil, errs.NotSupport
 	}
-	user := a.ctx.Value(conf.UserKey).(*model.User)
+	user := ctx.Value(conf.UserKey).(*model.User)
 	path, err := user.JoinPath(name)
 	if err != nil {
 		return nil, err
 	}
-	if f, err := Borrow(a.ctx, path); !errors.Is(err, errs.ObjectNotFound) {
+	if f, err := Borrow(ctx, path); !errors.Is(err, errs.ObjectNotFound) {
 		if err != nil {
 			return nil, err
 		}
@@ -116,7 +160,7 @@ func (a *AferoAdapter) GetHandle(name string, flags int, offset int64) (ftpserve
 		}
 		return f, nil
 	}
-	_, err = fs.Get(a.ctx, path, &fs.GetArgs{})
+	_, err = fs.Get(ctx, path, &fs.GetArgs{})
 	exists := err == nil
 	if (flags&os.O_CREATE) == 0 && !exists {
 		return nil, errs.ObjectNotFound
@@ -130,12 +174,12 @@ func (a *AferoAdapter) GetHandle(name string, flags int, offset int64) (ftpserve
 		}
 		trunc := (flags & os.O_TRUNC) != 0
 		if fileSize > 0 {
-			return OpenUploadWithLength(a.ctx, path, trunc, fileSize)
+			return OpenUploadWithLength(ctx, path, trunc, fileSize)
 		} else {
-			return OpenUpload(a.ctx, path, trunc)
+			return OpenUpload(ctx, path, trunc)
 		}
 	}
-	return OpenDownload(a.ctx, path, offset)
+	return OpenDownload(ctx, path, offset)
 }
 
 func (a *AferoAdapter) Site(param string) *ftpserver.AnswerCommand {
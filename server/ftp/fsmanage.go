@@ -9,6 +9,7 @@ import (
 	"github.com/OpenListTeam/OpenList/v4/internal/fs"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/setting"
 	"github.com/OpenListTeam/OpenList/v4/server/common"
 	"github.com/pkg/errors"
 )
@@ -81,7 +82,8 @@ func Rename(ctx context.Context, oldPath, newPath string) error {
 				return err
 			}
 		}
-		_, err = fs.Move(ctx, stdpath.Join(srcDir, dstBase), dstDir)
+		policy := model.ConflictPolicy(setting.GetStr(conf.FTPConflictPolicy, string(model.ConflictOverwrite)))
+		_, err = fs.Move(context.WithValue(ctx, conf.ConflictPolicyKey, policy), stdpath.Join(srcDir, dstBase), dstDir)
 		return err
 	}
 }
@@ -46,6 +46,14 @@ func uploadAuth(ctx context.Context, path string) error {
 	return nil
 }
 
+// quotaErr makes a quota error replied with 552
+func quotaErr(err error) error {
+	if errors.Is(err, errs.QuotaExceeded) {
+		return fmt.Errorf("%w: %w", ftpserver.ErrStorageExceeded, err)
+	}
+	return err
+}
+
 func OpenUpload(ctx context.Context, path string, trunc bool) (*FileUploadProxy, error) {
 	err := uploadAuth(ctx, path)
 	if err != nil {
@@ -56,6 +64,9 @@ func OpenUpload(ctx context.Context, path string, trunc bool) (*FileUploadProxy,
 	if setting.GetBool(conf.IgnoreSystemFiles) && utils.IsSystemFile(name) {
 		return nil, errs.IgnoredSystemFile
 	}
+	if err = fs.CheckQuota(ctx, path, -1); err != nil {
+		return nil, quotaErr(err)
+	}
 	tmpFile, err := os.CreateTemp(conf.Conf.TempDir, "file-*")
 	if err != nil {
 		return nil, err
@@ -97,6 +108,11 @@ func (f *FileUploadProxy) Close() error {
 	if _, err := f.buffer.Seek(0, io.SeekStart); err != nil {
 		return err
 	}
+	if err = fs.CheckQuota(f.ctx, f.path, size); err != nil {
+		_ = f.buffer.Close()
+		_ = os.Remove(f.buffer.Name())
+		return quotaErr(err)
+	}
 	user := f.ctx.Value(conf.UserKey).(*model.User)
 	sf, borrow, err := MakeStage(f.ctx, f.buffer, size, f.path, func(target string) {
 		ctx := context.WithValue(context.Background(), conf.UserKey, user)
@@ -133,7 +149,7 @@ func (f *FileUploadProxy) Close() error {
 	task, err := fs.PutAsTask(f.ctx, dir, s)
 	if err != nil {
 		_ = s.Close()
-		return err
+		return quotaErr(err)
 	}
 	sf.SetRemoveCallback(func() {
 		fs.UploadTaskManager.Cancel(task.GetID())
@@ -162,6 +178,9 @@ func OpenUploadWithLength(ctx context.Context, path string, trunc bool, length i
 	if setting.GetBool(conf.IgnoreSystemFiles) && utils.IsSystemFile(name) {
 		return nil, errs.IgnoredSystemFile
 	}
+	if err = fs.CheckQuota(ctx, path, length); err != nil {
+		return nil, quotaErr(err)
+	}
 	if trunc {
 		_ = fs.Remove(ctx, path)
 	}
@@ -176,7 +195,7 @@ func (f *FileUploadWithLengthProxy) write(p []byte) (n int, err error) {
 	if f.pipeWriter != nil {
 		select {
 		case e := <-f.errChan:
-			return 0, e
+			return 0, quotaErr(e)
 		default:
 			return f.pipeWriter.Write(p)
 		}
@@ -239,7 +258,7 @@ func (f *FileUploadWithLengthProxy) Close() error {
 			return err
 		}
 		err = <-f.errChan
-		return err
+		return quotaErr(err)
 	} else {
 		data := f.first512Bytes[:f.pFirst]
 		contentType := http.DetectContentType(data)
@@ -254,6 +273,6 @@ func (f *FileUploadWithLengthProxy) Close() error {
 			WebPutAsTask: false,
 			Reader:       bytes.NewReader(data),
 		}
-		return fs.PutDirectly(f.ctx, dir, s)
+		return quotaErr(fs.PutDirectly(f.ctx, dir, s))
 	}
 }
@@ -0,0 +1,106 @@
+package handles
+
+import (
+	"strconv"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+// tokenUser returns the current user, who has to log in to manage the access tokens
+func tokenUser(c *gin.Context) (*model.User, bool) {
+	user, ok := c.Request.Context().Value(conf.UserKey).(*model.User)
+	if !ok || user.IsGuest() {
+		common.ErrorStrResp(c, "user invalid", 401)
+		return nil, false
+	}
+	if user.AccessToken() != nil {
+		common.ErrorResp(c, errs.ByAccessToken, 403)
+		return nil, false
+	}
+	return user, true
+}
+
+func ListMyAccessTokens(c *gin.Context) {
+	user, ok := tokenUser(c)
+	if !ok {
+		return
+	}
+	tokens, err := op.GetAccessTokensByUserId(user.ID)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, tokens)
+}
+
+type CreateAccessTokenResp struct {
+	model.AccessToken
+	Token string `json:"token"`
+}
+
+func CreateMyAccessToken(c *gin.Context) {
+	user, ok := tokenUser(c)
+	if !ok {
+		return
+	}
+	var req model.AccessToken
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	token, err := op.CreateAccessToken(user, &req)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	common.SuccessResp(c, CreateAccessTokenResp{AccessToken: req, Token: token})
+}
+
+func RevokeMyAccessToken(c *gin.Context) {
+	user, ok := tokenUser(c)
+	if !ok {
+		return
+	}
+	id, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		common.ErrorStrResp(c, "id format invalid", 400)
+		return
+	}
+	if err = op.RevokeAccessToken(uint(id), user.ID); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+func ListAccessTokens(c *gin.Context) {
+	userId, err := strconv.Atoi(c.Query("uid"))
+	if err != nil {
+		common.ErrorStrResp(c, "user id format invalid", 400)
+		return
+	}
+	tokens, err := op.GetAccessTokensByUserId(uint(userId))
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, tokens)
+}
+
+func RevokeAccessToken(c *gin.Context) {
+	id, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		common.ErrorStrResp(c, "id format invalid", 400)
+		return
+	}
+	if err = op.RevokeAccessToken(uint(id), 0); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c)
+}
@@ -127,7 +127,7 @@ func FsArchiveMeta(c *gin.Context, req *ArchiveMetaReq, user *model.User) {
 		if errors.Is(err, errs.WrongArchivePassword) {
 			common.ErrorResp(c, err, 202)
 		} else {
-			common.ErrorResp(c, err, 500)
+			common.ErrorResp(c, err, fsErrorCode(err, 500))
 		}
 		return
 	}
@@ -214,7 +214,7 @@ func FsArchiveList(c *gin.Context, req *ArchiveListReq, user *model.User) {
 		if errors.Is(err, errs.WrongArchivePassword) {
 			common.ErrorResp(c, err, 202)
 		} else {
-			common.ErrorResp(c, err, 500)
+			common.ErrorResp(c, err, fsErrorCode(err, 500))
 		}
 		return
 	}
@@ -285,7 +285,7 @@ func FsArchiveDecompress(c *gin.Context) {
 			if errors.Is(e, errs.WrongArchivePassword) {
 				common.ErrorResp(c, e, 202)
 			} else {
-				common.ErrorResp(c, e, 500)
+				common.ErrorResp(c, e, fsErrorCode(e, 500))
 			}
 			return
 		}
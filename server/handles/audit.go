@@ -0,0 +1,99 @@
+package handles
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/audit"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+type ListAuditLogsReq struct {
+	model.PageReq
+	model.AuditLogFilter
+}
+
+func ListAuditLogs(c *gin.Context) {
+	var req ListAuditLogsReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	logs, total, err := audit.GetLogs(req.AuditLogFilter, req.Page, req.PerPage)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: logs,
+		Total:   total,
+	})
+}
+
+var auditLogCSVHeader = []string{"id", "time", "user_id", "username", "ip", "protocol", "action", "path", "dst_path", "detail", "success", "error"}
+
+// ExportAuditLogs writes the logs matching the filter as a csv or json file, the earliest first
+func ExportAuditLogs(c *gin.Context) {
+	var req model.AuditLogFilter
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	format := c.DefaultQuery("format", "csv")
+	if format != "csv" && format != "json" {
+		common.ErrorStrResp(c, "format must be csv or json", 400)
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="audit_logs_%s.%s"`, time.Now().Format("20060102150405"), format))
+	var err error
+	if format == "csv" {
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		w := csv.NewWriter(c.Writer)
+		_ = w.Write(auditLogCSVHeader)
+		err = audit.WalkLogs(req, func(logs []model.AuditLog) error {
+			for _, l := range logs {
+				if err := w.Write([]string{
+					strconv.FormatUint(uint64(l.ID), 10), l.Time.Format(time.RFC3339), strconv.FormatUint(uint64(l.UserID), 10),
+					l.Username, l.IP, l.Protocol, l.Action, l.Path, l.DstPath, l.Detail, strconv.FormatBool(l.Success), l.Error,
+				}); err != nil {
+					return err
+				}
+			}
+			w.Flush()
+			return w.Error()
+		})
+		w.Flush()
+	} else {
+		c.Header("Content-Type", "application/json; charset=utf-8")
+		first := true
+		_, _ = c.Writer.WriteString("[")
+		err = audit.WalkLogs(req, func(logs []model.AuditLog) error {
+			for _, l := range logs {
+				b, err := json.Marshal(l)
+				if err != nil {
+					return err
+				}
+				if !first {
+					_, _ = c.Writer.WriteString(",")
+				}
+				first = false
+				if _, err = c.Writer.Write(b); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		_, _ = c.Writer.WriteString("]")
+	}
+	if err != nil {
+		// the status is sent already, so the export can only be cut off
+		log.Errorf("failed export audit logs: %+v", err)
+	}
+}
@@ -5,11 +5,13 @@ import (
 	"encoding/base64"
 	"image/png"
 
+	"github.com/OpenListTeam/OpenList/v4/internal/audit"
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/internal/op"
 	"github.com/OpenListTeam/OpenList/v4/server/common"
 	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
 	"github.com/pquerna/otp/totp"
 )
 
@@ -47,6 +49,7 @@ func loginHash(c *gin.Context, req *LoginReq) {
 	if ok && count >= model.DefaultMaxAuthRetries {
 		common.ErrorStrResp(c, "Too many unsuccessful sign-in attempts have been made using an incorrect username or password, Try again later.", 429)
 		model.LoginCache.Expire(ip, model.DefaultLockDuration)
+		audit.Login(c.Request.Context(), req.Username, "password", errors.New("too many attempts"))
 		return
 	}
 	// check username
@@ -54,12 +57,14 @@ func loginHash(c *gin.Context, req *LoginReq) {
 	if err != nil {
 		common.ErrorResp(c, err, 400)
 		model.LoginCache.Set(ip, count+1)
+		audit.Login(c.Request.Context(), req.Username, "password", err)
 		return
 	}
 	// validate password hash
 	if err := user.ValidatePwdStaticHash(req.Password); err != nil {
 		common.ErrorResp(c, err, 400)
 		model.LoginCache.Set(ip, count+1)
+		audit.Login(c.Request.Context(), req.Username, "password", err)
 		return
 	}
 	// check 2FA
@@ -67,22 +72,30 @@ func loginHash(c *gin.Context, req *LoginReq) {
 		if !totp.Validate(req.OtpCode, user.OtpSecret) {
 			common.ErrorStrResp(c, "Invalid 2FA code", 402)
 			model.LoginCache.Set(ip, count+1)
+			audit.Login(c.Request.Context(), req.Username, "password", errors.New("invalid 2FA code"))
 			return
 		}
 	}
+	if err := op.CheckIP(user, model.TokenApi, ip); err != nil {
+		common.ErrorResp(c, err, 403)
+		audit.Login(c.Request.Context(), req.Username, "password", err)
+		return
+	}
 	// generate token
-	token, err := common.GenerateToken(user)
+	token, err := common.GenerateToken(c, user)
 	if err != nil {
 		common.ErrorResp(c, err, 400, true)
 		return
 	}
 	common.SuccessResp(c, gin.H{"token": token})
 	model.LoginCache.Del(ip)
+	audit.Login(c.Request.Context(), req.Username, "password", nil)
 }
 
 type UserResp struct {
 	model.User
-	Otp bool `json:"otp"`
+	Otp       bool     `json:"otp"`
+	RootPaths []string `json:"root_paths"`
 }
 
 // CurrentUser get current user by token
@@ -90,8 +103,11 @@ type UserResp struct {
 func CurrentUser(c *gin.Context) {
 	user := c.Request.Context().Value(conf.UserKey).(*model.User)
 	userResp := UserResp{
-		User: *user,
+		User:      *user,
+		RootPaths: user.RootPaths(),
 	}
+	// the frontend shows what the user can do, including what the groups grant
+	userResp.Permission = user.EffectivePermission()
 	userResp.Password = ""
 	if userResp.OtpSecret != "" {
 		userResp.Otp = true
@@ -123,9 +139,9 @@ func UpdateCurrent(c *gin.Context) {
 }
 
 func Generate2FA(c *gin.Context) {
-	user := c.Request.Context().Value(conf.UserKey).(*model.User)
-	if user.IsGuest() {
-		common.ErrorStrResp(c, "Guest user can not generate 2FA code", 403)
+	// an access token mustn't be able to set up the 2FA of the account
+	user, ok := tokenUser(c)
+	if !ok {
 		return
 	}
 	key, err := totp.Generate(totp.GenerateOpts{
@@ -162,9 +178,9 @@ func Verify2FA(c *gin.Context) {
 		common.ErrorResp(c, err, 400)
 		return
 	}
-	user := c.Request.Context().Value(conf.UserKey).(*model.User)
-	if user.IsGuest() {
-		common.ErrorStrResp(c, "Guest user can not generate 2FA code", 403)
+	// an access token mustn't be able to set up the 2FA of the account
+	user, ok := tokenUser(c)
+	if !ok {
 		return
 	}
 	if !totp.Validate(req.Code, req.Secret) {
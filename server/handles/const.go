@@ -1,7 +1,6 @@
 package handles
 
 const (
-	CANCEL    = "cancel"
-	OVERWRITE = "overwrite"
-	SKIP      = "skip"
+	// CANCEL is the former conflict policy of recursive move, it's the same as fail now
+	CANCEL = "cancel"
 )
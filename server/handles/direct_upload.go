@@ -47,7 +47,7 @@ func FsGetDirectUploadInfo(c *gin.Context) {
 	}
 	directUploadInfo, err := fs.GetDirectUploadInfo(c, req.Tool, path, req.FileName, req.FileSize)
 	if err != nil {
-		common.ErrorResp(c, err, 500)
+		common.ErrorResp(c, err, fsErrorCode(err, 500))
 		return
 	}
 	common.SuccessResp(c, directUploadInfo)
@@ -17,9 +17,9 @@ import (
 )
 
 type RecursiveMoveReq struct {
-	SrcDir         string `json:"src_dir"`
-	DstDir         string `json:"dst_dir"`
-	ConflictPolicy string `json:"conflict_policy"`
+	SrcDir         string               `json:"src_dir"`
+	DstDir         string               `json:"dst_dir"`
+	ConflictPolicy model.ConflictPolicy `json:"conflict_policy"`
 }
 
 func FsRecursiveMove(c *gin.Context) {
@@ -28,6 +28,14 @@ func FsRecursiveMove(c *gin.Context) {
 		common.ErrorResp(c, err, 400)
 		return
 	}
+	// cancel is the former name of fail
+	if req.ConflictPolicy == CANCEL {
+		req.ConflictPolicy = model.ConflictFail
+	}
+	ctx, ok := withConflictPolicy(c, req.ConflictPolicy)
+	if !ok {
+		return
+	}
 
 	user := c.Request.Context().Value(conf.UserKey).(*model.User)
 	if !user.CanMove() {
@@ -56,15 +64,17 @@ func FsRecursiveMove(c *gin.Context) {
 
 	rootFiles, err := fs.List(c.Request.Context(), srcDir, &fs.ListArgs{})
 	if err != nil {
-		common.ErrorResp(c, err, 500)
+		common.ErrorResp(c, err, fsErrorCode(err, 500))
 		return
 	}
 
 	var existingFileNames []string
-	if req.ConflictPolicy != OVERWRITE {
+	// the names are checked here for skip and fail, as the files of all the sub dirs are moved to the same dst dir
+	checkNames := req.ConflictPolicy == model.ConflictSkip || req.ConflictPolicy == model.ConflictFail
+	if checkNames {
 		dstFiles, err := fs.List(c.Request.Context(), dstDir, &fs.ListArgs{})
 		if err != nil {
-			common.ErrorResp(c, err, 500)
+			common.ErrorResp(c, err, fsErrorCode(err, 500))
 			return
 		}
 		existingFileNames = make([]string, 0, len(dstFiles))
@@ -92,7 +102,7 @@ func FsRecursiveMove(c *gin.Context) {
 			subFilePath := movingFileName
 			subFiles, err := fs.List(c.Request.Context(), movingFileName, &fs.ListArgs{Refresh: true})
 			if err != nil {
-				common.ErrorResp(c, err, 500)
+				common.ErrorResp(c, err, fsErrorCode(err, 500))
 				return
 			}
 			for _, subFile := range subFiles {
@@ -106,13 +116,13 @@ func FsRecursiveMove(c *gin.Context) {
 			}
 
 			if slices.Contains(existingFileNames, movingFile.GetName()) {
-				if req.ConflictPolicy == CANCEL {
+				if req.ConflictPolicy == model.ConflictFail {
 					common.ErrorStrResp(c, fmt.Sprintf("file [%s] exists", movingFile.GetName()), 403)
 					return
-				} else if req.ConflictPolicy == SKIP {
+				} else if req.ConflictPolicy == model.ConflictSkip {
 					continue
 				}
-			} else if req.ConflictPolicy != OVERWRITE {
+			} else if checkNames {
 				existingFileNames = append(existingFileNames, movingFile.GetName())
 			}
 			movingFileNames = append(movingFileNames, movingFileName)
@@ -124,9 +134,9 @@ func FsRecursiveMove(c *gin.Context) {
 	var count = 0
 	for i, fileName := range movingFileNames {
 		// move
-		_, err := fs.Move(c.Request.Context(), fileName, dstDir, len(movingFileNames) > i+1)
+		_, err := fs.Move(ctx, fileName, dstDir, len(movingFileNames) > i+1)
 		if err != nil {
-			common.ErrorResp(c, err, 500)
+			transferErrResp(c, err)
 			return
 		}
 		count++
@@ -180,7 +190,7 @@ func FsBatchRename(c *gin.Context) {
 		}
 		filePath := fmt.Sprintf("%s/%s", reqPath, renameObject.SrcName)
 		if err := fs.Rename(c.Request.Context(), filePath, renameObject.NewName); err != nil {
-			common.ErrorResp(c, err, 500)
+			common.ErrorResp(c, err, fsErrorCode(err, 500))
 			return
 		}
 	}
@@ -228,7 +238,7 @@ func FsRegexRename(c *gin.Context) {
 
 	files, err := fs.List(c.Request.Context(), reqPath, &fs.ListArgs{})
 	if err != nil {
-		common.ErrorResp(c, err, 500)
+		common.ErrorResp(c, err, fsErrorCode(err, 500))
 		return
 	}
 
@@ -242,7 +252,7 @@ func FsRegexRename(c *gin.Context) {
 			}
 			filePath := fmt.Sprintf("%s/%s", reqPath, file.GetName())
 			if err := fs.Rename(c.Request.Context(), filePath, newFileName); err != nil {
-				common.ErrorResp(c, err, 500)
+				common.ErrorResp(c, err, fsErrorCode(err, 500))
 				return
 			}
 		}
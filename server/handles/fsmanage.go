@@ -1,6 +1,7 @@
 package handles
 
 import (
+	"context"
 	"fmt"
 	stdpath "path"
 	"strings"
@@ -50,7 +51,7 @@ func FsMkdir(c *gin.Context) {
 		}
 	}
 	if err := fs.MakeDir(c.Request.Context(), reqPath); err != nil {
-		common.ErrorResp(c, err, 500)
+		common.ErrorResp(c, err, fsErrorCode(err, 500))
 		return
 	}
 	common.SuccessResp(c)
@@ -63,6 +64,40 @@ type MoveCopyReq struct {
 	Overwrite    bool     `json:"overwrite"`
 	SkipExisting bool     `json:"skip_existing"`
 	Merge        bool     `json:"merge"`
+	// ConflictPolicy takes precedence over Overwrite and SkipExisting when it's set
+	ConflictPolicy model.ConflictPolicy `json:"conflict_policy"`
+	// Resumable lets the uploads of the tasks continue after a restart, if the dst driver supports it
+	Resumable bool `json:"resumable"`
+}
+
+// withConflictPolicy puts the conflict policy in the ctx of the copy or move
+func withConflictPolicy(c *gin.Context, policy model.ConflictPolicy) (context.Context, bool) {
+	if !policy.Valid() {
+		common.ErrorStrResp(c, fmt.Sprintf("invalid conflict policy: %s", policy), 400)
+		return nil, false
+	}
+	return context.WithValue(c.Request.Context(), conf.ConflictPolicyKey, policy), true
+}
+
+// transferErrResp responds the error of a copy or move, the conflicts are refused like the precheck does
+// fsErrorCode returns the code of an error of a fs operation, which is of a write over the
+// quota or of an operation denied by the acl rules, and code for any other error
+func fsErrorCode(err error, code int) int {
+	if errors.Is(err, errs.QuotaExceeded) {
+		return 507
+	}
+	if errors.Is(err, errs.PermissionDenied) {
+		return 403
+	}
+	return code
+}
+
+func transferErrResp(c *gin.Context, err error) {
+	if errors.Is(err, errs.ObjectAlreadyExists) {
+		common.ErrorResp(c, err, 403)
+		return
+	}
+	common.ErrorResp(c, err, fsErrorCode(err, 500))
 }
 
 func FsMove(c *gin.Context) {
@@ -91,8 +126,15 @@ func FsMove(c *gin.Context) {
 		return
 	}
 
+	ctx, ok := withConflictPolicy(c, req.ConflictPolicy)
+	if !ok {
+		return
+	}
+	if req.Resumable {
+		ctx = context.WithValue(ctx, conf.ResumableUploadKey, struct{}{})
+	}
 	var validNames []string
-	if !req.Overwrite {
+	if !req.Overwrite && req.ConflictPolicy == model.ConflictDefault {
 		for _, name := range req.Names {
 			if res, _ := fs.Get(c.Request.Context(), stdpath.Join(dstDir, name), &fs.GetArgs{NoLog: true}); res != nil && !req.SkipExisting {
 				common.ErrorStrResp(c, fmt.Sprintf("file [%s] exists", name), 403)
@@ -109,12 +151,12 @@ func FsMove(c *gin.Context) {
 	// All validation will be done asynchronously in the background
 	var addedTasks []task.TaskExtensionInfo
 	for i, name := range validNames {
-		t, err := fs.Move(c.Request.Context(), stdpath.Join(srcDir, name), dstDir, len(validNames) > i+1)
+		t, err := fs.Move(ctx, stdpath.Join(srcDir, name), dstDir, len(validNames) > i+1)
 		if t != nil {
 			addedTasks = append(addedTasks, t)
 		}
 		if err != nil {
-			common.ErrorResp(c, err, 500)
+			transferErrResp(c, err)
 			return
 		}
 	}
@@ -158,8 +200,15 @@ func FsCopy(c *gin.Context) {
 		return
 	}
 
+	ctx, ok := withConflictPolicy(c, req.ConflictPolicy)
+	if !ok {
+		return
+	}
+	if req.Resumable {
+		ctx = context.WithValue(ctx, conf.ResumableUploadKey, struct{}{})
+	}
 	var validNames []string
-	if !req.Overwrite {
+	if !req.Overwrite && req.ConflictPolicy == model.ConflictDefault {
 		for _, name := range req.Names {
 			if res, _ := fs.Get(c.Request.Context(), stdpath.Join(dstDir, name), &fs.GetArgs{NoLog: true}); res != nil {
 				if !req.SkipExisting && !req.Merge {
@@ -182,15 +231,15 @@ func FsCopy(c *gin.Context) {
 	for i, name := range validNames {
 		var t task.TaskExtensionInfo
 		if req.Merge {
-			t, err = fs.Merge(c.Request.Context(), stdpath.Join(srcDir, name), dstDir, len(validNames) > i+1)
+			t, err = fs.Merge(ctx, stdpath.Join(srcDir, name), dstDir, len(validNames) > i+1)
 		} else {
-			t, err = fs.Copy(c.Request.Context(), stdpath.Join(srcDir, name), dstDir, len(validNames) > i+1)
+			t, err = fs.Copy(ctx, stdpath.Join(srcDir, name), dstDir, len(validNames) > i+1)
 		}
 		if t != nil {
 			addedTasks = append(addedTasks, t)
 		}
 		if err != nil {
-			common.ErrorResp(c, err, 500)
+			transferErrResp(c, err)
 			return
 		}
 	}
@@ -208,6 +257,85 @@ func FsCopy(c *gin.Context) {
 	}
 }
 
+type SyncReq struct {
+	SrcDir       string   `json:"src_dir"`
+	DstDir       string   `json:"dst_dir"`
+	Names        []string `json:"names"`
+	DeleteExtras bool     `json:"delete_extras"`
+	DryRun       bool     `json:"dry_run"`
+}
+
+func FsSync(c *gin.Context) {
+	var req SyncReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if len(req.Names) == 0 {
+		common.ErrorStrResp(c, "Empty file names", 400)
+		return
+	}
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	if !user.CanCopy() || (req.DeleteExtras && !user.CanRemove()) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
+	srcDir, err := user.JoinPath(req.SrcDir)
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return
+	}
+	dstDir, err := user.JoinPath(req.DstDir)
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return
+	}
+	for _, name := range req.Names {
+		if err = checkRelativePath(name); err != nil {
+			common.ErrorResp(c, err, 403)
+			return
+		}
+	}
+
+	if req.DryRun {
+		actions := make([]fs.SyncAction, 0)
+		for _, name := range req.Names {
+			res, err := fs.PlanSync(c.Request.Context(), stdpath.Join(srcDir, name), dstDir, req.DeleteExtras)
+			if err != nil {
+				transferErrResp(c, err)
+				return
+			}
+			actions = append(actions, res...)
+		}
+		common.SuccessResp(c, gin.H{
+			"actions": actions,
+		})
+		return
+	}
+
+	var addedTasks []task.TaskExtensionInfo
+	for i, name := range req.Names {
+		t, err := fs.Sync(c.Request.Context(), stdpath.Join(srcDir, name), dstDir, req.DeleteExtras, len(req.Names) > i+1)
+		if t != nil {
+			addedTasks = append(addedTasks, t)
+		}
+		if err != nil {
+			transferErrResp(c, err)
+			return
+		}
+	}
+	if len(addedTasks) > 0 {
+		common.SuccessResp(c, gin.H{
+			"message": fmt.Sprintf("Successfully created %d sync task(s)", len(addedTasks)),
+			"tasks":   getTaskInfos(addedTasks),
+		})
+	} else {
+		common.SuccessResp(c, gin.H{
+			"message": "Sync operations completed immediately",
+		})
+	}
+}
+
 type RenameReq struct {
 	Path      string `json:"path"`
 	Name      string `json:"name"`
@@ -243,7 +371,7 @@ func FsRename(c *gin.Context) {
 		}
 	}
 	if err := fs.Rename(c.Request.Context(), reqPath, req.Name); err != nil {
-		common.ErrorResp(c, err, 500)
+		common.ErrorResp(c, err, fsErrorCode(err, 500))
 		return
 	}
 	common.SuccessResp(c)
@@ -284,7 +412,7 @@ func FsRemove(c *gin.Context) {
 	for _, name := range req.Names {
 		err := fs.Remove(c.Request.Context(), stdpath.Join(reqDir, name))
 		if err != nil {
-			common.ErrorResp(c, err, 500)
+			common.ErrorResp(c, err, fsErrorCode(err, 500))
 			return
 		}
 	}
@@ -325,7 +453,7 @@ func FsRemoveEmptyDirectory(c *gin.Context) {
 
 	rootFiles, err := fs.List(c.Request.Context(), srcDir, &fs.ListArgs{})
 	if err != nil {
-		common.ErrorResp(c, err, 500)
+		common.ErrorResp(c, err, fsErrorCode(err, 500))
 		return
 	}
 
@@ -356,7 +484,7 @@ func FsRemoveEmptyDirectory(c *gin.Context) {
 
 		subFiles, err := fs.List(c.Request.Context(), removingFilePath, &fs.ListArgs{Refresh: true})
 		if err != nil {
-			common.ErrorResp(c, err, 500)
+			common.ErrorResp(c, err, fsErrorCode(err, 500))
 			return
 		}
 
@@ -365,7 +493,7 @@ func FsRemoveEmptyDirectory(c *gin.Context) {
 			err = fs.Remove(c.Request.Context(), removingFilePath)
 			removedFiles[removingFilePath] = true
 			if err != nil {
-				common.ErrorResp(c, err, 500)
+				common.ErrorResp(c, err, fsErrorCode(err, 500))
 				return
 			}
 			// recheck parent folder
@@ -418,7 +546,7 @@ func Link(c *gin.Context) {
 	}
 	link, _, err := fs.Link(c.Request.Context(), rawPath, model.LinkArgs{IP: c.ClientIP(), Header: c.Request.Header, Redirect: true})
 	if err != nil {
-		common.ErrorResp(c, err, 500)
+		common.ErrorResp(c, err, fsErrorCode(err, 500))
 		return
 	}
 	defer link.Close()
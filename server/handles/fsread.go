@@ -24,6 +24,9 @@ type ListReq struct {
 	Path     string `json:"path" form:"path"`
 	Password string `json:"password" form:"password"`
 	Refresh  bool   `json:"refresh"`
+	// Cursor switches to cursor based paging, set it to empty for the first page
+	// and to the returned next for the following ones. Page is ignored then
+	Cursor *string `json:"cursor" form:"cursor"`
 }
 
 type DirReq struct {
@@ -48,7 +51,8 @@ type ObjResp struct {
 
 type FsListResp struct {
 	Content           []ObjResp `json:"content"`
-	Total             int64     `json:"total"`
+	Total             int64     `json:"total"` // -1 when listing with cursor, the total is unknown then
+	Next              string    `json:"next,omitempty"`
 	Readme            string    `json:"readme"`
 	Header            string    `json:"header"`
 	Write             bool      `json:"write"`
@@ -98,15 +102,40 @@ func FsList(c *gin.Context, req *ListReq, user *model.User) {
 		common.ErrorStrResp(c, "Refresh without permission", 403)
 		return
 	}
-	objs, err := fs.List(c.Request.Context(), reqPath, &fs.ListArgs{
+	listArgs := fs.ListArgs{
 		Refresh:            req.Refresh,
 		WithStorageDetails: !user.IsGuest() && !setting.GetBool(conf.HideStorageDetails),
-	})
-	if err != nil {
-		common.ErrorResp(c, err, 500)
-		return
 	}
-	total, objs := pagination(objs, &req.PageReq)
+	var objs []model.Obj
+	var total int
+	var next string
+	if req.Cursor != nil {
+		limit := req.PerPage
+		if limit == model.MaxInt {
+			limit = 0
+		}
+		page, err := fs.ListPage(c.Request.Context(), reqPath, &fs.ListPageArgs{
+			ListArgs: listArgs,
+			Cursor:   *req.Cursor,
+			Limit:    limit,
+		})
+		if err != nil {
+			if errors.Is(err, errs.InvalidCursor) {
+				common.ErrorResp(c, err, 400)
+			} else {
+				common.ErrorResp(c, err, fsErrorCode(err, 500))
+			}
+			return
+		}
+		objs, total, next = page.Objs, -1, page.Next
+	} else {
+		all, err := fs.List(c.Request.Context(), reqPath, &listArgs)
+		if err != nil {
+			common.ErrorResp(c, err, fsErrorCode(err, 500))
+			return
+		}
+		total, objs = pagination(all, &req.PageReq)
+	}
 	provider := "unknown"
 	var directUploadTools []string
 	if user.CanWrite() {
@@ -115,8 +144,9 @@ func FsList(c *gin.Context, req *ListReq, user *model.User) {
 		}
 	}
 	common.SuccessResp(c, FsListResp{
-		Content:           toObjsResp(objs, reqPath, isEncrypt(meta, reqPath)),
+		Content:           toObjsResp(c, objs, reqPath, isEncrypt(meta, reqPath)),
 		Total:             int64(total),
+		Next:              next,
 		Readme:            getReadme(meta, reqPath),
 		Header:            getHeader(meta, reqPath),
 		Write:             user.CanWrite() || common.CanWrite(meta, reqPath),
@@ -160,7 +190,7 @@ func FsDirs(c *gin.Context) {
 	}
 	objs, err := fs.List(c.Request.Context(), reqPath, &fs.ListArgs{})
 	if err != nil {
-		common.ErrorResp(c, err, 500)
+		common.ErrorResp(c, err, fsErrorCode(err, 500))
 		return
 	}
 	dirs := filterDirs(objs)
@@ -226,7 +256,16 @@ func pagination(objs []model.Obj, req *model.PageReq) (int, []model.Obj) {
 	return total, objs[start:end]
 }
 
-func toObjsResp(objs []model.Obj, parent string, encrypt bool) []ObjResp {
+// readableSign returns the sign of obj in parent if the acl rules let the user read it,
+// since the sign alone is enough to download it from /d and /p
+func readableSign(c *gin.Context, obj model.Obj, parent string, encrypt bool) string {
+	if obj.IsDir() || fs.CheckAcl(c.Request.Context(), model.AclRead, stdpath.Join(parent, obj.GetName())) != nil {
+		return ""
+	}
+	return common.Sign(obj, parent, encrypt)
+}
+
+func toObjsResp(c *gin.Context, objs []model.Obj, parent string, encrypt bool) []ObjResp {
 	var resp []ObjResp
 	for _, obj := range objs {
 		thumb, _ := model.GetThumb(obj)
@@ -239,7 +278,7 @@ func toObjsResp(objs []model.Obj, parent string, encrypt bool) []ObjResp {
 			Created:      obj.CreateTime(),
 			HashInfoStr:  obj.GetHash().String(),
 			HashInfo:     obj.GetHash().Export(),
-			Sign:         common.Sign(obj, parent, encrypt),
+			Sign:         readableSign(c, obj, parent, encrypt),
 			Thumb:        thumb,
 			Type:         utils.GetObjType(obj.GetName(), obj.IsDir()),
 			MountDetails: mountDetails,
@@ -303,17 +342,19 @@ func FsGet(c *gin.Context, req *FsGetReq, user *model.User) {
 		WithStorageDetails: !user.IsGuest() && !setting.GetBool(conf.HideStorageDetails),
 	})
 	if err != nil {
-		common.ErrorResp(c, err, 500)
+		common.ErrorResp(c, err, fsErrorCode(err, 500))
 		return
 	}
 	var rawURL string
+	// /d and /p check nothing but the sign, so no url is given for a file the user can't read
+	canRead := fs.CheckAcl(c.Request.Context(), model.AclRead, reqPath) == nil
 
 	storage, err := fs.GetStorage(reqPath, &fs.GetStoragesArgs{})
 	provider, ok := model.GetProvider(obj)
 	if !ok && err == nil {
 		provider = storage.Config().Name
 	}
-	if !obj.IsDir() {
+	if !obj.IsDir() && canRead {
 		if err != nil {
 			common.ErrorResp(c, err, 500)
 			return
@@ -342,7 +383,7 @@ func FsGet(c *gin.Context, req *FsGetReq, user *model.User) {
 					Redirect: true,
 				})
 				if err != nil {
-					common.ErrorResp(c, err, 500)
+					common.ErrorResp(c, err, fsErrorCode(err, 500))
 					return
 				}
 				defer link.Close()
@@ -368,7 +409,7 @@ func FsGet(c *gin.Context, req *FsGetReq, user *model.User) {
 			Created:      obj.CreateTime(),
 			HashInfoStr:  obj.GetHash().String(),
 			HashInfo:     obj.GetHash().Export(),
-			Sign:         common.Sign(obj, parentPath, isEncrypt(meta, reqPath)),
+			Sign:         readableSign(c, obj, parentPath, isEncrypt(meta, reqPath)),
 			Type:         utils.GetFileType(obj.GetName()),
 			Thumb:        thumb,
 			MountDetails: mountDetails,
@@ -377,7 +418,7 @@ func FsGet(c *gin.Context, req *FsGetReq, user *model.User) {
 		Readme:   getReadme(meta, reqPath),
 		Header:   getHeader(meta, reqPath),
 		Provider: provider,
-		Related:  toObjsResp(related, parentPath, isEncrypt(parentMeta, parentPath)),
+		Related:  toObjsResp(c, related, parentPath, isEncrypt(parentMeta, parentPath)),
 	})
 }
 
@@ -427,7 +468,7 @@ func FsOther(c *gin.Context) {
 	}
 	res, err := fs.Other(c.Request.Context(), req.FsOtherArgs)
 	if err != nil {
-		common.ErrorResp(c, err, 500)
+		common.ErrorResp(c, err, fsErrorCode(err, 500))
 		return
 	}
 	common.SuccessResp(c, res)
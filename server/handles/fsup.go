@@ -83,6 +83,10 @@ func FsStream(c *gin.Context) {
 			}
 		}
 	}
+	if err = fs.CheckQuota(c.Request.Context(), path, size); err != nil {
+		common.ErrorResp(c, err, 507)
+		return
+	}
 	h := make(map[*utils.HashType]string)
 	if md5 := c.GetHeader("X-File-Md5"); md5 != "" {
 		h[utils.MD5] = md5
@@ -115,7 +119,7 @@ func FsStream(c *gin.Context) {
 		err = fs.PutDirectly(c.Request.Context(), dir, s)
 	}
 	if err != nil {
-		common.ErrorResp(c, err, 500)
+		common.ErrorResp(c, err, fsErrorCode(err, 500))
 		return
 	}
 	if t == nil {
@@ -180,6 +184,10 @@ func FsForm(c *gin.Context) {
 		common.ErrorStrResp(c, errs.IgnoredSystemFile.Error(), 403)
 		return
 	}
+	if err = fs.CheckQuota(c.Request.Context(), path, file.Size); err != nil {
+		common.ErrorResp(c, err, 507)
+		return
+	}
 	h := make(map[*utils.HashType]string)
 	if md5 := c.GetHeader("X-File-Md5"); md5 != "" {
 		h[utils.MD5] = md5
@@ -215,7 +223,7 @@ func FsForm(c *gin.Context) {
 		err = fs.PutDirectly(c.Request.Context(), dir, s)
 	}
 	if err != nil {
-		common.ErrorResp(c, err, 500)
+		common.ErrorResp(c, err, fsErrorCode(err, 500))
 		return
 	}
 	if t == nil {
@@ -0,0 +1,163 @@
+package handles
+
+import (
+	stdpath "path"
+	"strconv"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/audit"
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+// ListInternalShares lists the internal shares created by the current user, or all of them for the admin
+func ListInternalShares(c *gin.Context) {
+	var req model.PageReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	var shares []model.InternalShare
+	var total int64
+	var err error
+	if user.IsAdmin() {
+		shares, total, err = op.GetInternalShares(req.Page, req.PerPage)
+	} else {
+		shares, total, err = op.GetInternalSharesByCreatorId(user.ID, req.Page, req.PerPage)
+	}
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: shares,
+		Total:   total,
+	})
+}
+
+// checkInternalShare responds the error if the creator can't share the path of s
+func checkInternalShare(c *gin.Context, creator *model.User, s *model.InternalShare) bool {
+	if err := op.CheckInternalShare(creator, s); err != nil {
+		common.ErrorResp(c, err, 403)
+		return false
+	}
+	return true
+}
+
+func CreateInternalShare(c *gin.Context) {
+	var req model.InternalShare
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	if !checkInternalShare(c, user, &req) {
+		return
+	}
+	req.CreatorID = user.ID
+	err := op.CreateInternalShare(&req)
+	audit.LogDetail(c.Request.Context(), model.AuditInternalShareCreate, strconv.Itoa(int(req.ID)), err, req.Path)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, req)
+}
+
+// getOwnInternalShare responds 404 unless the internal share exists and the user can manage it
+func getOwnInternalShare(c *gin.Context, user *model.User, id uint) (*model.InternalShare, bool) {
+	s, err := op.GetInternalShareById(id)
+	if err != nil || (!user.IsAdmin() && s.CreatorID != user.ID) {
+		common.ErrorStrResp(c, "internal share not found", 404)
+		return nil, false
+	}
+	return s, true
+}
+
+func UpdateInternalShare(c *gin.Context) {
+	var req model.InternalShare
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	s, ok := getOwnInternalShare(c, user, req.ID)
+	if !ok {
+		return
+	}
+	// the path is checked against the creator, also when the admin updates it
+	creator := user
+	if s.CreatorID != user.ID {
+		var err error
+		if creator, err = op.GetUserById(s.CreatorID); err != nil {
+			common.ErrorResp(c, err, 500, true)
+			return
+		}
+	}
+	if !checkInternalShare(c, creator, &req) {
+		return
+	}
+	err := op.UpdateInternalShare(&req)
+	audit.LogDetail(c.Request.Context(), model.AuditInternalShareUpdate, strconv.Itoa(int(req.ID)), err, req.Path)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, req)
+}
+
+func DeleteInternalShare(c *gin.Context) {
+	id, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	s, ok := getOwnInternalShare(c, user, uint(id))
+	if !ok {
+		return
+	}
+	err = op.DeleteInternalShareById(s.ID)
+	audit.LogDetail(c.Request.Context(), model.AuditInternalShareDelete, strconv.Itoa(id), err, s.Path)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+type ReceivedShareResp struct {
+	ID uint `json:"id"`
+	// Path is where the share is in the paths of the current user
+	Path      string     `json:"path"`
+	Name      string     `json:"name"`
+	Writable  bool       `json:"writable"`
+	ExpiresAt *time.Time `json:"expires_at"`
+	Creator   string     `json:"creator"`
+}
+
+// ListReceivedShares lists the internal shares to the current user, which are in model.SharedWithMeDir
+func ListReceivedShares(c *gin.Context) {
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	shares := user.InternalShares()
+	res := make([]ReceivedShareResp, 0, len(shares))
+	for _, s := range shares {
+		r := ReceivedShareResp{
+			ID:        s.ID,
+			Path:      stdpath.Join("/", model.SharedWithMeDir, s.Name),
+			Name:      s.Name,
+			Writable:  s.Writable,
+			ExpiresAt: s.ExpiresAt,
+		}
+		if creator, err := op.GetUserById(s.CreatorID); err == nil {
+			r.Creator = creator.Username
+		}
+		res = append(res, r)
+	}
+	common.SuccessResp(c, res)
+}
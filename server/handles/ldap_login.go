@@ -1,6 +1,7 @@
 package handles
 
 import (
+	"github.com/OpenListTeam/OpenList/v4/internal/audit"
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/internal/op"
@@ -33,6 +34,7 @@ func LoginLdap(c *gin.Context) {
 	if ok && count >= model.DefaultMaxAuthRetries {
 		common.ErrorStrResp(c, "Too many unsuccessful sign-in attempts have been made using an incorrect username or password, Try again later.", 429)
 		model.LoginCache.Expire(ip, model.DefaultLockDuration)
+		audit.Login(c.Request.Context(), req.Username, "ldap", errors.New("too many attempts"))
 		return
 	}
 
@@ -44,6 +46,7 @@ func LoginLdap(c *gin.Context) {
 		} else {
 			common.ErrorResp(c, err, 500)
 		}
+		audit.Login(c.Request.Context(), req.Username, "ldap", err)
 		return
 	}
 
@@ -56,12 +59,18 @@ func LoginLdap(c *gin.Context) {
 		}
 	}
 
+	if err := op.CheckIP(user, model.TokenApi, ip); err != nil {
+		common.ErrorResp(c, err, 403)
+		audit.Login(c.Request.Context(), req.Username, "ldap", err)
+		return
+	}
 	// generate token
-	token, err := common.GenerateToken(user)
+	token, err := common.GenerateToken(c, user)
 	if err != nil {
 		common.ErrorResp(c, err, 400, true)
 		return
 	}
 	common.SuccessResp(c, gin.H{"token": token})
 	model.LoginCache.Del(ip)
+	audit.Login(c.Request.Context(), req.Username, "ldap", nil)
 }
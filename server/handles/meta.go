@@ -5,6 +5,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/OpenListTeam/OpenList/v4/internal/audit"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/internal/op"
 	"github.com/OpenListTeam/OpenList/v4/server/common"
@@ -43,7 +44,9 @@ func CreateMeta(c *gin.Context) {
 		common.ErrorStrResp(c, fmt.Sprintf("%s is illegal: %s", r, err.Error()), 400)
 		return
 	}
-	if err := op.CreateMeta(&req); err != nil {
+	err = op.CreateMeta(&req)
+	audit.Log(c.Request.Context(), model.AuditMetaCreate, err, req.Path)
+	if err != nil {
 		common.ErrorResp(c, err, 500, true)
 	} else {
 		common.SuccessResp(c)
@@ -61,7 +64,9 @@ func UpdateMeta(c *gin.Context) {
 		common.ErrorStrResp(c, fmt.Sprintf("%s is illegal: %s", r, err.Error()), 400)
 		return
 	}
-	if err := op.UpdateMeta(&req); err != nil {
+	err = op.UpdateMeta(&req)
+	audit.Log(c.Request.Context(), model.AuditMetaUpdate, err, req.Path)
+	if err != nil {
 		common.ErrorResp(c, err, 500, true)
 	} else {
 		common.SuccessResp(c)
@@ -86,7 +91,13 @@ func DeleteMeta(c *gin.Context) {
 		common.ErrorResp(c, err, 400)
 		return
 	}
-	if err := op.DeleteMetaById(uint(id)); err != nil {
+	var metaPath string
+	if meta, err := op.GetMetaById(uint(id)); err == nil {
+		metaPath = meta.Path
+	}
+	err = op.DeleteMetaById(uint(id))
+	audit.Log(c.Request.Context(), model.AuditMetaDelete, err, metaPath)
+	if err != nil {
 		common.ErrorResp(c, err, 500, true)
 		return
 	}
@@ -0,0 +1,29 @@
+package handles
+
+import (
+	"crypto/subtle"
+	"strings"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/metrics"
+	"github.com/OpenListTeam/OpenList/v4/internal/setting"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+// Metrics serves the prometheus metrics to the scrapers with the metrics token,
+// it's not found while the token is empty
+func Metrics(c *gin.Context) {
+	token := setting.GetStr(conf.MetricsToken)
+	if token == "" {
+		common.ErrorStrResp(c, "metrics are disabled", 404)
+		return
+	}
+	got, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+		c.Header("WWW-Authenticate", `Bearer realm="metrics"`)
+		common.ErrorStrResp(c, "invalid metrics token", 401)
+		return
+	}
+	metrics.Handler().ServeHTTP(c.Writer, c.Request)
+}
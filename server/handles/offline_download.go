@@ -513,7 +513,7 @@ func AddOfflineDownload(c *gin.Context) {
 			DeletePolicy: tool.DeletePolicy(req.DeletePolicy),
 		})
 		if err != nil {
-			common.ErrorResp(c, err, 500)
+			common.ErrorResp(c, err, fsErrorCode(err, 500))
 			return
 		}
 		if t != nil {
@@ -0,0 +1,68 @@
+package handles
+
+import (
+	"strconv"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+type UsageResp struct {
+	model.UserUsage
+	QuotaBytes int64 `json:"quota_bytes"`
+	QuotaFiles int64 `json:"quota_files"`
+}
+
+func usageResp(c *gin.Context, user *model.User) {
+	usage, err := op.GetUserUsage(user.ID)
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	common.SuccessResp(c, UsageResp{
+		UserUsage:  *usage,
+		QuotaBytes: user.QuotaBytes,
+		QuotaFiles: user.QuotaFiles,
+	})
+}
+
+func CurrentUsage(c *gin.Context) {
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	usageResp(c, user)
+}
+
+func GetUserUsage(c *gin.Context) {
+	id, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user, err := op.GetUserById(uint(id))
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	usageResp(c, user)
+}
+
+// SetUserUsage corrects the counted usage of a user, e.g. after objects
+// were changed outside of OpenList
+func SetUserUsage(c *gin.Context) {
+	var req model.UserUsage
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if _, err := op.GetUserById(req.UserID); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	if err := op.SetUserUsage(&req); err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	common.SuccessResp(c)
+}
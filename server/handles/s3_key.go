@@ -0,0 +1,90 @@
+package handles
+
+import (
+	"strconv"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+func ListMyS3Keys(c *gin.Context) {
+	user, ok := tokenUser(c)
+	if !ok {
+		return
+	}
+	keys, err := op.GetS3KeysByUserId(user.ID)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, keys)
+}
+
+type CreateS3KeyResp struct {
+	model.S3Key
+	SecretAccessKey string `json:"secret_access_key"`
+}
+
+func CreateMyS3Key(c *gin.Context) {
+	user, ok := tokenUser(c)
+	if !ok {
+		return
+	}
+	var req model.S3Key
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	secret, err := op.CreateS3Key(user, &req)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	common.SuccessResp(c, CreateS3KeyResp{S3Key: req, SecretAccessKey: secret})
+}
+
+func DeleteMyS3Key(c *gin.Context) {
+	user, ok := tokenUser(c)
+	if !ok {
+		return
+	}
+	id, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		common.ErrorStrResp(c, "id format invalid", 400)
+		return
+	}
+	if err = op.DeleteS3Key(uint(id), user.ID); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+func ListS3Keys(c *gin.Context) {
+	userId, err := strconv.Atoi(c.Query("uid"))
+	if err != nil {
+		common.ErrorStrResp(c, "user id format invalid", 400)
+		return
+	}
+	keys, err := op.GetS3KeysByUserId(uint(userId))
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, keys)
+}
+
+func DeleteS3Key(c *gin.Context) {
+	id, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		common.ErrorStrResp(c, "id format invalid", 400)
+		return
+	}
+	if err = op.DeleteS3Key(uint(id), 0); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c)
+}
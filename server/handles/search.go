@@ -2,7 +2,6 @@ package handles
 
 import (
 	"path"
-	"strings"
 
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
 	"github.com/OpenListTeam/OpenList/v4/internal/errs"
@@ -51,7 +50,7 @@ func Search(c *gin.Context) {
 	}
 	var filteredNodes []model.SearchNode
 	for _, node := range nodes {
-		if !strings.HasPrefix(node.Parent, user.BasePath) {
+		if !user.CanAccessPath(node.Parent) {
 			continue
 		}
 		meta, err := op.GetNearestMeta(node.Parent)
@@ -61,6 +60,12 @@ func Search(c *gin.Context) {
 		if !common.CanAccess(user, meta, path.Join(node.Parent, node.Name), req.Password) {
 			continue
 		}
+		// a name is only seen by listing its parent, so both must be listable
+		if op.CheckAcl(user, model.AclList, node.Parent) != nil ||
+			op.CheckAcl(user, model.AclList, path.Join(node.Parent, node.Name)) != nil {
+			continue
+		}
+		node.Parent = user.RelPath(node.Parent)
 		filteredNodes = append(filteredNodes, node)
 	}
 	common.SuccessResp(c, common.PageResp{
@@ -0,0 +1,94 @@
+package handles
+
+import (
+	"strconv"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+type SessionResp struct {
+	model.Session
+	Current bool `json:"current"`
+}
+
+func ListMySessions(c *gin.Context) {
+	user, ok := tokenUser(c)
+	if !ok {
+		return
+	}
+	sessions, err := op.GetSessionsByUserId(user.ID)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	current, _ := c.Request.Context().Value(conf.SessionIDKey).(string)
+	resp := make([]SessionResp, len(sessions))
+	for i, s := range sessions {
+		resp[i] = SessionResp{Session: s, Current: s.ID == current}
+	}
+	common.SuccessResp(c, resp)
+}
+
+func RevokeMySession(c *gin.Context) {
+	user, ok := tokenUser(c)
+	if !ok {
+		return
+	}
+	if err := op.RevokeSession(c.Query("id"), user.ID); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+// RevokeMySessions logs the current user out everywhere, including the current session
+func RevokeMySessions(c *gin.Context) {
+	user, ok := tokenUser(c)
+	if !ok {
+		return
+	}
+	if err := op.RevokeSessions(user.ID); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+func ListSessions(c *gin.Context) {
+	userId, err := strconv.Atoi(c.Query("uid"))
+	if err != nil {
+		common.ErrorStrResp(c, "user id format invalid", 400)
+		return
+	}
+	sessions, err := op.GetSessionsByUserId(uint(userId))
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, sessions)
+}
+
+func RevokeSession(c *gin.Context) {
+	if err := op.RevokeSession(c.Query("id"), 0); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+func RevokeSessions(c *gin.Context) {
+	userId, err := strconv.Atoi(c.Query("uid"))
+	if err != nil {
+		common.ErrorStrResp(c, "user id format invalid", 400)
+		return
+	}
+	if err = op.RevokeSessions(uint(userId)); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c)
+}
@@ -6,9 +6,11 @@ import (
 	"strings"
 	"time"
 
+	"github.com/OpenListTeam/OpenList/v4/internal/audit"
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
 	"github.com/OpenListTeam/OpenList/v4/internal/driver"
 	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/fs"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/internal/op"
 	"github.com/OpenListTeam/OpenList/v4/internal/setting"
@@ -34,6 +36,7 @@ func SharingGet(c *gin.Context, req *FsGetReq) {
 		return
 	}
 	_ = countAccess(c.ClientIP(), s)
+	recordSharingAccess(c, s, model.SharingAccessView, utils.FixAndCleanPath(path), 0)
 	url := ""
 	if !obj.IsDir() {
 		fakePath := fmt.Sprintf("/%s/%s", sid, path)
@@ -78,6 +81,7 @@ func SharingList(c *gin.Context, req *ListReq) {
 		return
 	}
 	_ = countAccess(c.ClientIP(), s)
+	recordSharingAccess(c, s, model.SharingAccessView, utils.FixAndCleanPath(path), 0)
 	total, objs := pagination(objs, &req.PageReq)
 	common.SuccessResp(c, FsListResp{
 		Content: utils.MustSliceConvert(objs, func(obj model.Obj) ObjResp {
@@ -98,7 +102,7 @@ func SharingList(c *gin.Context, req *ListReq) {
 		Total:    int64(total),
 		Readme:   s.Readme,
 		Header:   s.Header,
-		Write:    false,
+		Write:    s.IsUpload(),
 		Provider: "unknown",
 	})
 }
@@ -198,6 +202,8 @@ func SharingDown(c *gin.Context) {
 			err = errs.InvalidSharing
 		} else if !s.Verify(pwd) {
 			err = errs.WrongShareCode
+		} else if s.IsUpload() {
+			err = errs.FileRequestReadOnly
 		} else if len(s.Files) != 1 && path == "/" {
 			err = errors.New("cannot get sharing root link")
 		}
@@ -214,11 +220,12 @@ func SharingDown(c *gin.Context) {
 	if dealErrorPage(c, err) {
 		return
 	}
-	if setting.GetBool(conf.ShareForceProxy) || common.ShouldProxy(storage, stdpath.Base(actualPath)) {
-		if _, ok := c.GetQuery("d"); !ok {
+	if setting.GetBool(conf.ShareForceProxy) || sharingLimited(s) || common.ShouldProxy(storage, stdpath.Base(actualPath)) {
+		if _, ok := c.GetQuery("d"); !ok && !sharingLimited(s) {
 			if url := common.GenerateDownProxyURL(storage.GetStorage(), unwrapPath); url != "" {
 				c.Redirect(302, url)
 				_ = countAccess(c.ClientIP(), s)
+				recordSharingAccess(c, s, model.SharingAccessDownload, path, 0)
 				return
 			}
 		}
@@ -231,9 +238,11 @@ func SharingDown(c *gin.Context) {
 			return
 		}
 		_ = countAccess(c.ClientIP(), s)
+		w := wrapSharingWriter(c, s)
 		proxy(c, link, obj, storage.GetStorage().ProxyRange)
+		recordSharingAccess(c, s, model.SharingAccessDownload, path, w.written)
 	} else {
-		link, _, err := op.Link(c.Request.Context(), storage, actualPath, model.LinkArgs{
+		link, obj, err := op.Link(c.Request.Context(), storage, actualPath, model.LinkArgs{
 			IP:       c.ClientIP(),
 			Header:   c.Request.Header,
 			Type:     c.Query("type"),
@@ -245,6 +254,7 @@ func SharingDown(c *gin.Context) {
 		}
 		_ = countAccess(c.ClientIP(), s)
 		redirect(c, link)
+		recordSharingAccess(c, s, model.SharingAccessDownload, path, obj.GetSize())
 	}
 }
 
@@ -265,6 +275,8 @@ func SharingArchiveExtract(c *gin.Context) {
 			err = errs.InvalidSharing
 		} else if !s.Verify(pwd) {
 			err = errs.WrongShareCode
+		} else if s.IsUpload() {
+			err = errs.FileRequestReadOnly
 		} else if len(s.Files) != 1 && path == "/" {
 			err = errors.New("cannot extract sharing root")
 		}
@@ -292,12 +304,14 @@ func SharingArchiveExtract(c *gin.Context) {
 		InnerPath: innerPath,
 	}
 	if _, ok := storage.(driver.ArchiveReader); ok {
-		if setting.GetBool(conf.ShareForceProxy) || common.ShouldProxy(storage, stdpath.Base(actualPath)) {
+		if setting.GetBool(conf.ShareForceProxy) || sharingLimited(s) || common.ShouldProxy(storage, stdpath.Base(actualPath)) {
 			link, obj, err := op.DriverExtract(c.Request.Context(), storage, actualPath, args)
 			if dealErrorPage(c, err) {
 				return
 			}
+			w := wrapSharingWriter(c, s)
 			proxy(c, link, obj, storage.GetStorage().ProxyRange)
+			recordSharingAccess(c, s, model.SharingAccessDownload, path, w.written)
 		} else {
 			args.Redirect = true
 			link, _, err := op.DriverExtract(c.Request.Context(), storage, actualPath, args)
@@ -305,6 +319,7 @@ func SharingArchiveExtract(c *gin.Context) {
 				return
 			}
 			redirect(c, link)
+			recordSharingAccess(c, s, model.SharingAccessDownload, path, 0)
 		}
 	} else {
 		rc, size, err := op.InternalExtract(c.Request.Context(), storage, actualPath, args)
@@ -312,7 +327,9 @@ func SharingArchiveExtract(c *gin.Context) {
 			return
 		}
 		fileName := stdpath.Base(innerPath)
+		w := wrapSharingWriter(c, s)
 		proxyInternalExtract(c, rc, size, fileName)
+		recordSharingAccess(c, s, model.SharingAccessDownload, path, w.written)
 	}
 }
 
@@ -323,12 +340,12 @@ func dealError(c *gin.Context, err error) bool {
 		common.ErrorStrResp(c, "the share does not exist", 500)
 	} else if errors.Is(err, errs.InvalidSharing) {
 		common.ErrorStrResp(c, "the share has expired or is no longer valid", 500)
-	} else if errors.Is(err, errs.WrongShareCode) {
+	} else if errors.Is(err, errs.WrongShareCode) || errors.Is(err, errs.FileRequestReadOnly) || errors.Is(err, errs.FileRequestRefused) {
 		common.ErrorResp(c, err, 403)
 	} else if errors.Is(err, errs.WrongArchivePassword) {
 		common.ErrorResp(c, err, 202)
 	} else {
-		common.ErrorResp(c, err, 500)
+		common.ErrorResp(c, err, fsErrorCode(err, 500))
 	}
 	return true
 }
@@ -340,7 +357,7 @@ func dealErrorPage(c *gin.Context, err error) bool {
 		common.ErrorPage(c, errors.New("the share does not exist"), 500)
 	} else if errors.Is(err, errs.InvalidSharing) {
 		common.ErrorPage(c, errors.New("the share has expired or is no longer valid"), 500)
-	} else if errors.Is(err, errs.WrongShareCode) {
+	} else if errors.Is(err, errs.WrongShareCode) || errors.Is(err, errs.FileRequestReadOnly) {
 		common.ErrorPage(c, err, 403)
 	} else if errors.Is(err, errs.WrongArchivePassword) {
 		common.ErrorPage(c, err, 202)
@@ -413,9 +430,52 @@ type UpdateSharingReq struct {
 	Readme      string     `json:"readme"`
 	Header      string     `json:"header"`
 	model.Sort
-	CreatorName string `json:"creator"`
-	Accessed    int    `json:"accessed"`
-	ID          string `json:"id"`
+	CreatorName    string `json:"creator"`
+	Accessed       int    `json:"accessed"`
+	ID             string `json:"id"`
+	Type           int    `json:"type"`
+	UploadListable bool   `json:"upload_listable"`
+	AllowedExts    string `json:"allowed_exts"`
+	MaxFileSize    int64  `json:"max_file_size"`
+	MaxTotalSize   int64  `json:"max_total_size"`
+	MaxUploads     int    `json:"max_uploads"`
+	MaxTraffic     int64  `json:"max_traffic"`
+	MaxSpeed       int    `json:"max_speed"`
+	ResetTraffic   bool   `json:"reset_traffic"`
+}
+
+// checkFileRequest responds the error if req isn't a valid sharing of its type
+func checkFileRequest(c *gin.Context, user *model.User, req *UpdateSharingReq) bool {
+	switch req.Type {
+	case model.SharingTypeFiles:
+		return true
+	case model.SharingTypeUpload:
+	default:
+		common.ErrorStrResp(c, "unknown sharing type", 400)
+		return false
+	}
+	if len(req.Files) != 1 {
+		common.ErrorStrResp(c, "a file request must have exactly 1 folder", 400)
+		return false
+	}
+	if !user.CanWrite() {
+		common.ErrorStrResp(c, "permission denied to upload", 403)
+		return false
+	}
+	if err := op.CheckAcl(user, model.AclWrite, req.Files[0]); err != nil {
+		common.ErrorResp(c, err, 403)
+		return false
+	}
+	obj, err := fs.Get(c.Request.Context(), req.Files[0], &fs.GetArgs{NoLog: true})
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return false
+	}
+	if !obj.IsDir() {
+		common.ErrorStrResp(c, "a file request must be a folder", 400)
+		return false
+	}
+	return true
 }
 
 func UpdateSharing(c *gin.Context) {
@@ -447,10 +507,17 @@ func UpdateSharing(c *gin.Context) {
 	for i, s := range req.Files {
 		s = utils.FixAndCleanPath(s)
 		req.Files[i] = s
-		if !reqUser.IsAdmin() && !strings.HasPrefix(s, user.BasePath) {
+		if !reqUser.IsAdmin() && !user.CanAccessPath(s) {
 			common.ErrorStrResp(c, fmt.Sprintf("permission denied to share path [%s]", s), 500)
 			return
 		}
+		if err := op.CheckAcl(user, model.AclShare, s); err != nil {
+			common.ErrorResp(c, err, 403)
+			return
+		}
+	}
+	if !checkFileRequest(c, user, &req) {
+		return
 	}
 	s, err := op.GetSharingById(req.ID)
 	if err != nil || (!reqUser.IsAdmin() && s.CreatorId != user.ID) {
@@ -470,8 +537,25 @@ func UpdateSharing(c *gin.Context) {
 	s.Header = req.Header
 	s.Readme = req.Readme
 	s.Remark = req.Remark
+	s.Type = req.Type
+	s.UploadListable = req.UploadListable
+	s.AllowedExts = req.AllowedExts
+	s.MaxFileSize = req.MaxFileSize
+	s.MaxTotalSize = req.MaxTotalSize
+	s.MaxUploads = req.MaxUploads
+	s.MaxTraffic = req.MaxTraffic
+	s.MaxSpeed = req.MaxSpeed
 	s.Creator = user
-	if err = op.UpdateSharing(s); err != nil {
+	if req.ResetTraffic {
+		if err = op.ResetSharingTraffic(s.ID); err != nil {
+			common.ErrorResp(c, err, 500)
+			return
+		}
+		s.Traffic = 0
+	}
+	err = op.UpdateSharing(s)
+	audit.LogDetail(c.Request.Context(), model.AuditShareUpdate, s.ID, err, strings.Join(s.Files, ","))
+	if err != nil {
 		common.ErrorResp(c, err, 500)
 	} else {
 		common.SuccessResp(c, SharingResp{
@@ -511,29 +595,45 @@ func CreateSharing(c *gin.Context) {
 	for i, s := range req.Files {
 		s = utils.FixAndCleanPath(s)
 		req.Files[i] = s
-		if !reqUser.IsAdmin() && !strings.HasPrefix(s, user.BasePath) {
+		if !reqUser.IsAdmin() && !user.CanAccessPath(s) {
 			common.ErrorStrResp(c, fmt.Sprintf("permission denied to share path [%s]", s), 500)
 			return
 		}
+		if err := op.CheckAcl(user, model.AclShare, s); err != nil {
+			common.ErrorResp(c, err, 403)
+			return
+		}
+	}
+	if !checkFileRequest(c, user, &req) {
+		return
 	}
 	s := &model.Sharing{
 		SharingDB: &model.SharingDB{
-			ID:          req.ID,
-			Expires:     req.Expires,
-			Pwd:         req.Pwd,
-			Accessed:    req.Accessed,
-			MaxAccessed: req.MaxAccessed,
-			Disabled:    req.Disabled,
-			Sort:        req.Sort,
-			Remark:      req.Remark,
-			Readme:      req.Readme,
-			Header:      req.Header,
+			ID:             req.ID,
+			Expires:        req.Expires,
+			Pwd:            req.Pwd,
+			Accessed:       req.Accessed,
+			MaxAccessed:    req.MaxAccessed,
+			Disabled:       req.Disabled,
+			Sort:           req.Sort,
+			Remark:         req.Remark,
+			Readme:         req.Readme,
+			Header:         req.Header,
+			Type:           req.Type,
+			UploadListable: req.UploadListable,
+			AllowedExts:    req.AllowedExts,
+			MaxFileSize:    req.MaxFileSize,
+			MaxTotalSize:   req.MaxTotalSize,
+			MaxUploads:     req.MaxUploads,
+			MaxTraffic:     req.MaxTraffic,
+			MaxSpeed:       req.MaxSpeed,
 		},
 		Files:   req.Files,
 		Creator: user,
 	}
-	var id string
-	if id, err = op.CreateSharing(s); err != nil {
+	id, err := op.CreateSharing(s)
+	audit.LogDetail(c.Request.Context(), model.AuditShareCreate, id, err, strings.Join(s.Files, ","))
+	if err != nil {
 		common.ErrorResp(c, err, 500)
 	} else {
 		s.ID = id
@@ -0,0 +1,202 @@
+package handles
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/event"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/stream"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/time/rate"
+)
+
+type sharingLimiter struct {
+	speed int
+	used  time.Time
+	stream.Limiter
+}
+
+// the downloads of a sharing share one limiter, which is kept while the speed limit is the same,
+// and dropped once it's idle for sharingLimiterTTL so that deleted sharings don't keep theirs
+var (
+	sharingLimiters     = make(map[string]*sharingLimiter)
+	sharingLimitersMu   sync.Mutex
+	sharingLimiterSwept time.Time
+)
+
+const sharingLimiterTTL = 10 * time.Minute
+
+func getSharingLimiter(s *model.Sharing) stream.Limiter {
+	sharingLimitersMu.Lock()
+	defer sharingLimitersMu.Unlock()
+	now := time.Now()
+	if now.Sub(sharingLimiterSwept) > sharingLimiterTTL {
+		for id, l := range sharingLimiters {
+			if now.Sub(l.used) > sharingLimiterTTL {
+				delete(sharingLimiters, id)
+			}
+		}
+		sharingLimiterSwept = now
+	}
+	if s.MaxSpeed <= 0 {
+		delete(sharingLimiters, s.ID)
+		return nil
+	}
+	l, ok := sharingLimiters[s.ID]
+	if !ok || l.speed != s.MaxSpeed {
+		l = &sharingLimiter{speed: s.MaxSpeed, Limiter: stream.NewLimiter(rate.Limit(s.MaxSpeed)*1024, s.MaxSpeed*1024)}
+		sharingLimiters[s.ID] = l
+	}
+	l.used = now
+	return l.Limiter
+}
+
+// sharingLimited reports whether the downloads of the sharing are limited, which can only
+// be enforced when they are proxied, not redirected
+func sharingLimited(s *model.Sharing) bool {
+	return s.MaxSpeed > 0 || s.MaxTraffic > 0
+}
+
+// sharingTrafficChunk is how much traffic a download reserves at once when the traffic is limited
+const sharingTrafficChunk = 4 * 1024 * 1024
+
+// sharingWriter counts the bytes served for a sharing and limits the speed of them. If the traffic
+// is limited, it's reserved before the bytes are written, and the download is cut at the limit
+type sharingWriter struct {
+	gin.ResponseWriter
+	ctx      context.Context
+	sharing  *model.Sharing
+	limiter  stream.Limiter
+	written  int64
+	reserved int64
+}
+
+func (w *sharingWriter) Write(p []byte) (int, error) {
+	exceeded := false
+	if w.sharing.MaxTraffic > 0 {
+		p, exceeded = w.reserve(p)
+	}
+	n, err := w.ResponseWriter.Write(p)
+	w.written += int64(n)
+	if err == nil && w.limiter != nil {
+		err = w.limiter.WaitN(w.ctx, n)
+	}
+	if err == nil && exceeded {
+		err = errors.WithMessage(errs.InvalidSharing, "the traffic limit is reached")
+	}
+	return n, err
+}
+
+// reserve reserves the traffic for p, and cuts p to the traffic left if it can't
+func (w *sharingWriter) reserve(p []byte) ([]byte, bool) {
+	need := w.written + int64(len(p)) - w.reserved
+	if need <= 0 {
+		return p, false
+	}
+	for _, n := range []int64{max(need, sharingTrafficChunk), need} {
+		ok, err := op.ReserveSharingTraffic(w.sharing.ID, n)
+		if err != nil {
+			log.Errorf("failed reserve traffic of sharing %s: %+v", w.sharing.ID, err)
+			break
+		}
+		if ok {
+			w.reserved += n
+			return p, false
+		}
+	}
+	return p[:w.reserved-w.written], true
+}
+
+func (w *sharingWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func wrapSharingWriter(c *gin.Context, s *model.Sharing) *sharingWriter {
+	w := &sharingWriter{ResponseWriter: c.Writer, ctx: c, sharing: s, limiter: getSharingLimiter(s)}
+	c.Writer = w
+	return w
+}
+
+// recordSharingAccess saves a view or a download of bytes of path in the sharing, errors are only logged
+func recordSharingAccess(c *gin.Context, s *model.Sharing, typ, path string, bytes int64) {
+	if c.Request.Method == http.MethodHead {
+		return
+	}
+	err := op.CreateSharingAccessLog(&model.SharingAccessLog{
+		SharingID: s.ID,
+		Time:      time.Now(),
+		Type:      typ,
+		Path:      path,
+		IP:        c.ClientIP(),
+		Referer:   c.Request.Referer(),
+		Bytes:     bytes,
+	})
+	if err != nil {
+		log.Errorf("failed record access of sharing %s: %+v", s.ID, err)
+	}
+	e := &event.Event{
+		Type: event.ShareAccessed,
+		Data: map[string]any{"id": s.ID, "type": typ, "share_path": path, "ip": c.ClientIP(), "bytes": bytes},
+	}
+	if s.Creator != nil {
+		e.Data["creator"] = s.Creator.Username
+	}
+	if unwrapPath, err := op.GetSharingUnwrapPath(s, path); err == nil {
+		e.Path = unwrapPath
+	}
+	event.Publish(c.Request.Context(), e)
+	if typ != model.SharingAccessDownload {
+		return
+	}
+	// the traffic reserved by the writer is already counted
+	if w, ok := c.Writer.(*sharingWriter); ok {
+		bytes -= w.reserved
+	}
+	if err = op.AddSharingTraffic(s.ID, bytes); err != nil {
+		log.Errorf("failed count traffic of sharing %s: %+v", s.ID, err)
+	}
+}
+
+type SharingStatsReq struct {
+	ID    string     `json:"id" form:"id" binding:"required"`
+	Start *time.Time `json:"start" form:"start"`
+	End   *time.Time `json:"end" form:"end"`
+}
+
+// GetSharingStats sums up the accesses of a sharing of the current user, in the last 30 days by default
+func GetSharingStats(c *gin.Context) {
+	var req SharingStatsReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	s, err := op.GetSharingById(req.ID)
+	if err != nil || (!user.IsAdmin() && s.CreatorId != user.ID) {
+		common.ErrorStrResp(c, "sharing not found", 404)
+		return
+	}
+	end := time.Now()
+	if req.End != nil {
+		end = *req.End
+	}
+	start := end.AddDate(0, 0, -30)
+	if req.Start != nil {
+		start = *req.Start
+	}
+	stats, err := op.GetSharingStats(s.ID, start, end, time.Local)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, stats)
+}
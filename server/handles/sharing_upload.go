@@ -0,0 +1,189 @@
+package handles
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	stdpath "path"
+	"strconv"
+	"strings"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/sharing"
+	"github.com/OpenListTeam/OpenList/v4/internal/stream"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+// sharingUploadPath returns the file request and the path in it of File-Path, like /@s/{sid}/{path}
+func sharingUploadPath(c *gin.Context) (string, string, bool) {
+	path, err := url.PathUnescape(c.GetHeader("File-Path"))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return "", "", false
+	}
+	sid, path, _ := strings.Cut(strings.TrimPrefix(strings.TrimPrefix(path, "/@s"), "/"), "/")
+	if sid == "" {
+		common.ErrorStrResp(c, "invalid share id", 400)
+		return "", "", false
+	}
+	path = utils.FixAndCleanPath(path)
+	if shouldIgnoreSystemFile(stdpath.Base(path)) {
+		common.ErrorStrResp(c, errs.IgnoredSystemFile.Error(), 403)
+		return "", "", false
+	}
+	return sid, path, true
+}
+
+func sharingUploadHashes(c *gin.Context) utils.HashInfo {
+	h := make(map[*utils.HashType]string)
+	if md5 := c.GetHeader("X-File-Md5"); md5 != "" {
+		h[utils.MD5] = md5
+	}
+	if sha1 := c.GetHeader("X-File-Sha1"); sha1 != "" {
+		h[utils.SHA1] = sha1
+	}
+	if sha256 := c.GetHeader("X-File-Sha256"); sha256 != "" {
+		h[utils.SHA256] = sha256
+	}
+	return utils.NewHashInfoByMap(h)
+}
+
+// SharingPut uploads the request body to a file request
+func SharingPut(c *gin.Context) {
+	defer func() {
+		if n, _ := io.ReadFull(c.Request.Body, []byte{0}); n == 1 {
+			_, _ = utils.CopyWithBuffer(io.Discard, c.Request.Body)
+		}
+		_ = c.Request.Body.Close()
+	}()
+	sid, path, ok := sharingUploadPath(c)
+	if !ok {
+		return
+	}
+	size := c.Request.ContentLength
+	if size < 0 {
+		if sizeStr := c.GetHeader("X-File-Size"); sizeStr != "" {
+			var err error
+			size, err = strconv.ParseInt(sizeStr, 10, 64)
+			if err != nil {
+				common.ErrorResp(c, err, 400)
+				return
+			}
+		}
+	}
+	name := stdpath.Base(path)
+	mimetype := c.GetHeader("Content-Type")
+	if len(mimetype) == 0 {
+		mimetype = utils.GetMimeType(name)
+	}
+	s := &stream.FileStream{
+		Obj: &model.Object{
+			Name:     name,
+			Size:     size,
+			Modified: getLastModified(c),
+			HashInfo: sharingUploadHashes(c),
+		},
+		Reader:   c.Request.Body,
+		Mimetype: mimetype,
+	}
+	_, err := sharing.Upload(c.Request.Context(), sid, path, s, model.SharingListArgs{Pwd: c.GetHeader("Password")})
+	if dealError(c, err) {
+		return
+	}
+	common.SuccessResp(c)
+}
+
+// sharingFormOverhead is the room for the headers and boundaries of a multipart form
+// beyond the size of the file in it
+const sharingFormOverhead = 64 << 10
+
+// SharingForm uploads the file of a multipart form to a file request
+func SharingForm(c *gin.Context) {
+	defer func() {
+		if n, _ := io.ReadFull(c.Request.Body, []byte{0}); n == 1 {
+			_, _ = utils.CopyWithBuffer(io.Discard, c.Request.Body)
+		}
+		_ = c.Request.Body.Close()
+	}()
+	sid, path, ok := sharingUploadPath(c)
+	if !ok {
+		return
+	}
+	args := model.SharingListArgs{Pwd: c.GetHeader("Password")}
+	// the form is read to memory or a temp file, so nothing is read before the file request
+	// accepts the upload, and no more than the file may have
+	limit, err := sharing.CheckUpload(c.Request.Context(), sid, path, args)
+	if dealError(c, err) {
+		return
+	}
+	if limit >= 0 {
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit+sharingFormOverhead)
+	}
+	file, err := c.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			common.ErrorResp(c, errors.WithMessagef(errs.FileRequestRefused, "the file is larger than %d bytes", limit), 413)
+			return
+		}
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	f, err := file.Open()
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	defer f.Close()
+	name := stdpath.Base(path)
+	mimetype := file.Header.Get("Content-Type")
+	if len(mimetype) == 0 {
+		mimetype = utils.GetMimeType(name)
+	}
+	s := &stream.FileStream{
+		Obj: &model.Object{
+			Name:     name,
+			Size:     file.Size,
+			Modified: getLastModified(c),
+			HashInfo: sharingUploadHashes(c),
+		},
+		Reader:   f,
+		Mimetype: mimetype,
+	}
+	_, err = sharing.Upload(c.Request.Context(), sid, path, s, args)
+	if dealError(c, err) {
+		return
+	}
+	common.SuccessResp(c)
+}
+
+// ListSharingUploads lists the files uploaded through a file request of the current user
+func ListSharingUploads(c *gin.Context) {
+	var req model.PageReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	s, err := op.GetSharingById(c.Query("id"))
+	if err != nil || (!user.IsAdmin() && s.CreatorId != user.ID) {
+		common.ErrorStrResp(c, "sharing not found", 404)
+		return
+	}
+	uploads, total, err := op.GetSharingUploads(s.ID, req.Page, req.PerPage)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: uploads,
+		Total:   total,
+	})
+}
@@ -0,0 +1,208 @@
+package handles
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/fs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/sign"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils/random"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+// maxSignPaths is how many signed urls can be minted by one request
+const maxSignPaths = 1000
+
+type FsSignReq struct {
+	Paths []string `json:"paths" binding:"required"`
+	// Password is the one of the metas of the paths
+	Password     string     `json:"password"`
+	ExpiresAt    *time.Time `json:"expires_at"`
+	CIDRs        []string   `json:"cidrs"`
+	UserAgent    string     `json:"user_agent"`
+	MaxDownloads int        `json:"max_downloads"`
+	// Archive signs the paths as archives to download or extract files in them from /ad or /ae
+	Archive     bool   `json:"archive"`
+	ArchivePass string `json:"archive_pass"`
+}
+
+type SignedURL struct {
+	Path  string `json:"path"`
+	URL   string `json:"url"`
+	Sign  string `json:"sign"`
+	KeyID string `json:"key_id"`
+}
+
+type FsSignResp struct {
+	// Batch revokes all of the urls at once
+	Batch   string      `json:"batch"`
+	Content []SignedURL `json:"content"`
+}
+
+// FsSign mints scoped signed download urls of files, or of archives, for the current user
+func FsSign(c *gin.Context) {
+	var req FsSignReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if len(req.Paths) == 0 || len(req.Paths) > maxSignPaths {
+		common.ErrorStrResp(c, fmt.Sprintf("must sign 1 to %d paths", maxSignPaths), 400)
+		return
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		common.ErrorStrResp(c, "expires_at must be in the future", 400)
+		return
+	}
+	if req.MaxDownloads < 0 {
+		common.ErrorStrResp(c, "max_downloads can't be negative", 400)
+		return
+	}
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	if req.Archive && !user.CanReadArchives() {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
+	scope := sign.Scope{
+		ExpiresAt:    req.ExpiresAt,
+		CIDRs:        req.CIDRs,
+		UserAgent:    req.UserAgent,
+		MaxDownloads: req.MaxDownloads,
+		Batch:        random.String(16),
+		UserID:       user.ID,
+	}
+	resp := FsSignResp{Batch: scope.Batch, Content: make([]SignedURL, 0, len(req.Paths))}
+	for _, p := range req.Paths {
+		reqPath, err := user.JoinPath(p)
+		if err != nil {
+			common.ErrorResp(c, err, 403)
+			return
+		}
+		meta, err := op.GetNearestMeta(reqPath)
+		if err != nil && !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+			common.ErrorResp(c, err, 500, true)
+			return
+		}
+		if !common.CanAccess(user, meta, reqPath, req.Password) {
+			common.ErrorStrResp(c, fmt.Sprintf("password is incorrect or you have no permission to [%s]", p), 403)
+			return
+		}
+		if err = fs.CheckAcl(c.Request.Context(), model.AclRead, reqPath); err != nil {
+			common.ErrorResp(c, err, 403)
+			return
+		}
+		obj, err := fs.Get(c.Request.Context(), reqPath, &fs.GetArgs{NoLog: true})
+		if err != nil {
+			common.ErrorResp(c, err, 400)
+			return
+		}
+		if obj.IsDir() {
+			common.ErrorStrResp(c, fmt.Sprintf("[%s] is a folder", p), 400)
+			return
+		}
+		api, signScoped := "/d", sign.SignScoped
+		if req.Archive {
+			ret, err := fs.ArchiveMeta(c.Request.Context(), reqPath, model.ArchiveMetaArgs{
+				ArchiveArgs: model.ArchiveArgs{Password: req.ArchivePass},
+			})
+			if err != nil {
+				if errors.Is(err, errs.WrongArchivePassword) {
+					common.ErrorResp(c, err, 202)
+				} else {
+					common.ErrorResp(c, err, 400)
+				}
+				return
+			}
+			api, signScoped = "/ae", sign.SignArchiveScoped
+			if ret.DriverProviding {
+				api = "/ad"
+			}
+		}
+		s, keyID, err := signScoped(reqPath, scope)
+		if err != nil {
+			common.ErrorResp(c, err, 400)
+			return
+		}
+		resp.Content = append(resp.Content, SignedURL{
+			Path:  p,
+			URL:   fmt.Sprintf("%s%s%s?sign=%s", common.GetApiUrl(c), api, utils.EncodePath(reqPath, true), s),
+			Sign:  s,
+			KeyID: keyID,
+		})
+	}
+	common.SuccessResp(c, resp)
+}
+
+type FsSignRevokeReq struct {
+	// Path and Sign are of a scoped sign minted by the current user, to revoke it or its batch
+	Path  string `json:"path"`
+	Sign  string `json:"sign"`
+	Batch bool   `json:"batch"`
+	// IDs are key ids or batches to revoke, only for the admin
+	IDs []string `json:"ids"`
+}
+
+// FsSignRevoke revokes scoped signs, a user proves a sign is minted by itself by the sign
+func FsSignRevoke(c *gin.Context) {
+	var req FsSignRevokeReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	var rs []model.SignRevocation
+	if len(req.IDs) > 0 {
+		if !user.IsAdmin() {
+			common.ErrorStrResp(c, "You are not an admin", 403)
+			return
+		}
+		for _, id := range req.IDs {
+			rs = append(rs, model.SignRevocation{ID: id})
+		}
+	}
+	if req.Sign != "" {
+		reqPath, err := user.JoinPath(req.Path)
+		if err != nil {
+			common.ErrorResp(c, err, 403)
+			return
+		}
+		claims, err := sign.ParseScoped(reqPath, req.Sign)
+		if err != nil {
+			common.ErrorResp(c, err, 400)
+			return
+		}
+		if !user.IsAdmin() && claims.UserID != user.ID {
+			common.ErrorStrResp(c, "the sign is not minted by you", 403)
+			return
+		}
+		r := model.SignRevocation{ID: claims.KeyID}
+		if req.Batch {
+			if claims.Batch == "" {
+				common.ErrorStrResp(c, "the sign has no batch", 400)
+				return
+			}
+			r.ID = claims.Batch
+		}
+		if claims.Expire != 0 {
+			t := time.Unix(claims.Expire, 0)
+			r.ExpiresAt = &t
+		}
+		rs = append(rs, r)
+	}
+	if len(rs) == 0 {
+		common.ErrorStrResp(c, "nothing to revoke", 400)
+		return
+	}
+	if err := op.RevokeSigns(rs); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c)
+}
@@ -17,9 +17,8 @@ type SSHKeyAddReq struct {
 }
 
 func AddMyPublicKey(c *gin.Context) {
-	userObj, ok := c.Request.Context().Value(conf.UserKey).(*model.User)
-	if !ok || userObj.IsGuest() {
-		common.ErrorStrResp(c, "user invalid", 401)
+	userObj, ok := tokenUser(c)
+	if !ok {
 		return
 	}
 	var req SSHKeyAddReq
@@ -57,9 +56,8 @@ func ListMyPublicKey(c *gin.Context) {
 }
 
 func DeleteMyPublicKey(c *gin.Context) {
-	userObj, ok := c.Request.Context().Value(conf.UserKey).(*model.User)
-	if !ok || userObj.IsGuest() {
-		common.ErrorStrResp(c, "user invalid", 401)
+	userObj, ok := tokenUser(c)
+	if !ok {
 		return
 	}
 	keyId, err := strconv.Atoi(c.Query("id"))
@@ -12,9 +12,11 @@ import (
 
 	"github.com/OpenListTeam/go-cache"
 
+	"github.com/OpenListTeam/OpenList/v4/internal/audit"
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
 	"github.com/OpenListTeam/OpenList/v4/internal/db"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
 	"github.com/OpenListTeam/OpenList/v4/internal/setting"
 	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
 	"github.com/OpenListTeam/OpenList/v4/pkg/utils/random"
@@ -22,6 +24,7 @@ import (
 	"github.com/coreos/go-oidc"
 	"github.com/gin-gonic/gin"
 	"github.com/go-resty/resty/v2"
+	log "github.com/sirupsen/logrus"
 	"golang.org/x/oauth2"
 	"gorm.io/gorm"
 )
@@ -158,6 +161,13 @@ func autoRegister(username, userID string, err error) (*model.User, error) {
 		Disabled:   false,
 		SsoID:      userID,
 	}
+	groups, err := op.GetGroupsByNames(setting.GetStr(conf.SSODefaultGroups))
+	if err != nil {
+		log.Errorf("failed get sso default groups: %+v", err)
+	}
+	for _, g := range groups {
+		user.GroupIDs = append(user.GroupIDs, g.ID)
+	}
 	if err = db.CreateUser(user); err != nil {
 		if strings.HasPrefix(err.Error(), "UNIQUE constraint failed") && strings.HasSuffix(err.Error(), "username") {
 			user.Username = user.Username + "_" + userID
@@ -222,6 +232,7 @@ func OIDCLoginCallback(c *gin.Context) {
 	_, err = verifier.Verify(c, rawIDToken)
 	if err != nil {
 		common.ErrorResp(c, err, 400)
+		audit.Login(c.Request.Context(), "", "oidc", err)
 		return
 	}
 	payload, err := parseJWT(rawIDToken)
@@ -256,12 +267,21 @@ func OIDCLoginCallback(c *gin.Context) {
 			user, err = autoRegister(userID, userID, err)
 			if err != nil {
 				common.ErrorResp(c, err, 400)
+				audit.Login(c.Request.Context(), userID, "oidc", err)
+				return
 			}
 		}
-		token, err := common.GenerateToken(user)
+		if err := op.CheckIP(user, model.TokenApi, c.ClientIP()); err != nil {
+			common.ErrorResp(c, err, 403)
+			audit.Login(c.Request.Context(), user.Username, "oidc", err)
+			return
+		}
+		token, err := common.GenerateToken(c, user)
 		if err != nil {
 			common.ErrorResp(c, err, 400)
+			return
 		}
+		audit.Login(c.Request.Context(), user.Username, "oidc", nil)
 		if useCompatibility {
 			c.Redirect(302, common.GetApiUrl(c)+"/@login?token="+token)
 			return
@@ -421,13 +441,21 @@ func SSOLoginCallback(c *gin.Context) {
 		user, err = autoRegister(username, userID, err)
 		if err != nil {
 			common.ErrorResp(c, err, 400)
+			audit.Login(c.Request.Context(), username, "sso", err)
 			return
 		}
 	}
-	token, err := common.GenerateToken(user)
+	if err := op.CheckIP(user, model.TokenApi, c.ClientIP()); err != nil {
+		common.ErrorResp(c, err, 403)
+		audit.Login(c.Request.Context(), user.Username, "sso", err)
+		return
+	}
+	token, err := common.GenerateToken(c, user)
 	if err != nil {
 		common.ErrorResp(c, err, 400)
+		return
 	}
+	audit.Login(c.Request.Context(), user.Username, "sso", nil)
 	if usecompatibility {
 		c.Redirect(302, common.GetApiUrl(c)+"/@login?token="+token)
 		return
@@ -6,6 +6,7 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/OpenListTeam/OpenList/v4/internal/audit"
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
 	"github.com/OpenListTeam/OpenList/v4/internal/db"
 	"github.com/OpenListTeam/OpenList/v4/internal/driver"
@@ -96,7 +97,9 @@ func CreateStorage(c *gin.Context) {
 		common.ErrorResp(c, err, 400)
 		return
 	}
-	if id, err := op.CreateStorage(c.Request.Context(), req); err != nil {
+	id, err := op.CreateStorage(c.Request.Context(), req)
+	audit.Log(c.Request.Context(), model.AuditStorageCreate, err, req.MountPath)
+	if err != nil {
 		common.ErrorWithDataResp(c, err, 500, gin.H{
 			"id": id,
 		}, true)
@@ -113,7 +116,9 @@ func UpdateStorage(c *gin.Context) {
 		common.ErrorResp(c, err, 400)
 		return
 	}
-	if err := op.UpdateStorage(c.Request.Context(), req); err != nil {
+	err := op.UpdateStorage(c.Request.Context(), req)
+	audit.Log(c.Request.Context(), model.AuditStorageUpdate, err, req.MountPath)
+	if err != nil {
 		common.ErrorResp(c, err, 500, true)
 	} else {
 		common.SuccessResp(c)
@@ -127,7 +132,10 @@ func DeleteStorage(c *gin.Context) {
 		common.ErrorResp(c, err, 400)
 		return
 	}
-	if err := op.DeleteStorageById(c.Request.Context(), uint(id)); err != nil {
+	mountPath := storageMountPath(uint(id))
+	err = op.DeleteStorageById(c.Request.Context(), uint(id))
+	audit.Log(c.Request.Context(), model.AuditStorageDelete, err, mountPath)
+	if err != nil {
 		common.ErrorResp(c, err, 500, true)
 		return
 	}
@@ -141,7 +149,9 @@ func DisableStorage(c *gin.Context) {
 		common.ErrorResp(c, err, 400)
 		return
 	}
-	if err := op.DisableStorage(c.Request.Context(), uint(id)); err != nil {
+	err = op.DisableStorage(c.Request.Context(), uint(id))
+	audit.Log(c.Request.Context(), model.AuditStorageDisable, err, storageMountPath(uint(id)))
+	if err != nil {
 		common.ErrorResp(c, err, 500, true)
 		return
 	}
@@ -155,13 +165,24 @@ func EnableStorage(c *gin.Context) {
 		common.ErrorResp(c, err, 400)
 		return
 	}
-	if err := op.EnableStorage(c.Request.Context(), uint(id)); err != nil {
+	err = op.EnableStorage(c.Request.Context(), uint(id))
+	audit.Log(c.Request.Context(), model.AuditStorageEnable, err, storageMountPath(uint(id)))
+	if err != nil {
 		common.ErrorResp(c, err, 500, true)
 		return
 	}
 	common.SuccessResp(c)
 }
 
+// storageMountPath returns the mount path of the storage for audit logs
+func storageMountPath(id uint) string {
+	storage, err := db.GetStorageById(id)
+	if err != nil {
+		return ""
+	}
+	return storage.MountPath
+}
+
 func GetStorage(c *gin.Context) {
 	idStr := c.Query("id")
 	id, err := strconv.Atoi(idStr)
@@ -28,6 +28,9 @@ type TaskInfo struct {
 	EndTime     *time.Time  `json:"end_time"`
 	TotalBytes  int64       `json:"total_bytes"`
 	Error       string      `json:"error"`
+	Owner       string      `json:"owner"`
+	// Verification is the result of verifying the uploaded file of a transfer task
+	Verification string `json:"verification,omitempty"`
 }
 
 func getTaskInfo[T task.TaskExtensionInfo](task T) TaskInfo {
@@ -46,7 +49,7 @@ func getTaskInfo[T task.TaskExtensionInfo](task T) TaskInfo {
 		creatorName = task.GetCreator().Username
 		creatorRole = task.GetCreator().Role
 	}
-	return TaskInfo{
+	info := TaskInfo{
 		ID:          task.GetID(),
 		Name:        task.GetName(),
 		Creator:     creatorName,
@@ -58,7 +61,12 @@ func getTaskInfo[T task.TaskExtensionInfo](task T) TaskInfo {
 		EndTime:     task.GetEndTime(),
 		TotalBytes:  task.GetTotalBytes(),
 		Error:       errMsg,
+		Owner:       task.GetOwner(),
 	}
+	if t, ok := any(task).(*fs.FileTransferTask); ok {
+		info.Verification = t.Verification
+	}
+	return info
 }
 
 func getTaskInfos[T task.TaskExtensionInfo](tasks []T) []TaskInfo {
@@ -221,6 +229,7 @@ func SetupTaskRoute(g *gin.RouterGroup) {
 	taskRoute(g.Group("/upload"), fs.UploadTaskManager)
 	taskRoute(g.Group("/copy"), fs.CopyTaskManager)
 	taskRoute(g.Group("/move"), fs.MoveTaskManager)
+	taskRoute(g.Group("/sync"), fs.SyncTaskManager)
 	taskRoute(g.Group("/offline_download"), tool.DownloadTaskManager)
 	taskRoute(g.Group("/offline_download_transfer"), tool.TransferTaskManager)
 	taskRoute(g.Group("/decompress"), fs.ArchiveDownloadTaskManager)
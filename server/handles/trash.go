@@ -0,0 +1,147 @@
+package handles
+
+import (
+	"strconv"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+func ListMyTrash(c *gin.Context) {
+	var req model.PageReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	items, total, err := op.GetTrashItemsByDeleterId(user.ID, req.Page, req.PerPage)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: items,
+		Total:   total,
+	})
+}
+
+// getMyTrashItem returns the trash item of the id query, only if it was removed by the user
+func getMyTrashItem(c *gin.Context) (*model.TrashItem, bool) {
+	item, ok := getTrashItem(c)
+	if !ok {
+		return nil, false
+	}
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	if item.DeleterId != user.ID {
+		common.ErrorStrResp(c, "trash item not found", 404)
+		return nil, false
+	}
+	return item, true
+}
+
+func getTrashItem(c *gin.Context) (*model.TrashItem, bool) {
+	id, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		common.ErrorStrResp(c, "id format invalid", 400)
+		return nil, false
+	}
+	item, err := op.GetTrashItemById(uint(id))
+	if err != nil {
+		common.ErrorStrResp(c, "trash item not found", 404)
+		return nil, false
+	}
+	return item, true
+}
+
+func RestoreMyTrash(c *gin.Context) {
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	if !user.CanWrite() {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
+	item, ok := getMyTrashItem(c)
+	if !ok {
+		return
+	}
+	// the base path of the user may have changed since the object was removed
+	if !user.CanAccessPath(item.OriginalPath) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
+	restoreTrash(c, item)
+}
+
+func PurgeMyTrash(c *gin.Context) {
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	if !user.CanRemove() {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
+	item, ok := getMyTrashItem(c)
+	if !ok {
+		return
+	}
+	purgeTrash(c, item)
+}
+
+func ListTrash(c *gin.Context) {
+	var req model.PageReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	items, total, err := op.GetTrashItems(req.Page, req.PerPage)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: items,
+		Total:   total,
+	})
+}
+
+func RestoreTrash(c *gin.Context) {
+	item, ok := getTrashItem(c)
+	if !ok {
+		return
+	}
+	restoreTrash(c, item)
+}
+
+func PurgeTrash(c *gin.Context) {
+	item, ok := getTrashItem(c)
+	if !ok {
+		return
+	}
+	purgeTrash(c, item)
+}
+
+func restoreTrash(c *gin.Context, item *model.TrashItem) {
+	if err := op.RestoreTrashItem(c.Request.Context(), item); err != nil {
+		if errors.Is(err, errs.ObjectAlreadyExists) {
+			common.ErrorStrResp(c, "an object already exists at the original path", 409)
+		} else if errors.Is(err, errs.PermissionDenied) {
+			common.ErrorResp(c, err, 403)
+		} else {
+			common.ErrorResp(c, err, 500)
+		}
+		return
+	}
+	common.SuccessResp(c)
+}
+
+func purgeTrash(c *gin.Context, item *model.TrashItem) {
+	if err := op.PurgeTrashItem(c.Request.Context(), item); err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	common.SuccessResp(c)
+}
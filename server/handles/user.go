@@ -3,6 +3,7 @@ package handles
 import (
 	"strconv"
 
+	"github.com/OpenListTeam/OpenList/v4/internal/audit"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/internal/op"
 	"github.com/OpenListTeam/OpenList/v4/server/common"
@@ -42,7 +43,9 @@ func CreateUser(c *gin.Context) {
 	req.SetPassword(req.Password)
 	req.Password = ""
 	req.Authn = "[]"
-	if err := op.CreateUser(&req); err != nil {
+	err := op.CreateUser(&req)
+	audit.Log(c.Request.Context(), model.AuditUserCreate, err, req.Username)
+	if err != nil {
 		common.ErrorResp(c, err, 500, true)
 	} else {
 		common.SuccessResp(c)
@@ -78,7 +81,9 @@ func UpdateUser(c *gin.Context) {
 		common.ErrorStrResp(c, "admin user can not be disabled", 400)
 		return
 	}
-	if err := op.UpdateUser(&req); err != nil {
+	err = op.UpdateUser(&req)
+	audit.Log(c.Request.Context(), model.AuditUserUpdate, err, req.Username)
+	if err != nil {
 		common.ErrorResp(c, err, 500)
 	} else {
 		common.SuccessResp(c)
@@ -92,7 +97,13 @@ func DeleteUser(c *gin.Context) {
 		common.ErrorResp(c, err, 400)
 		return
 	}
-	if err := op.DeleteUserById(uint(id)); err != nil {
+	var username string
+	if user, err := op.GetUserById(uint(id)); err == nil {
+		username = user.Username
+	}
+	err = op.DeleteUserById(uint(id))
+	audit.Log(c.Request.Context(), model.AuditUserDelete, err, username)
+	if err != nil {
 		common.ErrorResp(c, err, 500)
 		return
 	}
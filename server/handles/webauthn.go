@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 
+	"github.com/OpenListTeam/OpenList/v4/internal/audit"
 	"github.com/OpenListTeam/OpenList/v4/internal/authn"
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
 	"github.com/OpenListTeam/OpenList/v4/internal/db"
@@ -108,15 +109,26 @@ func FinishAuthnLogin(c *gin.Context) {
 	}
 	if err != nil {
 		common.ErrorResp(c, err, 400)
+		username := c.Query("username")
+		if user != nil {
+			username = user.Username
+		}
+		audit.Login(c.Request.Context(), username, "webauthn", err)
+		return
+	}
+	if err := op.CheckIP(user, model.TokenApi, c.ClientIP()); err != nil {
+		common.ErrorResp(c, err, 403)
+		audit.Login(c.Request.Context(), user.Username, "webauthn", err)
 		return
 	}
 
-	token, err := common.GenerateToken(user)
+	token, err := common.GenerateToken(c, user)
 	if err != nil {
 		common.ErrorResp(c, err, 400, true)
 		return
 	}
 	common.SuccessResp(c, gin.H{"token": token})
+	audit.Login(c.Request.Context(), user.Username, "webauthn", nil)
 }
 
 func BeginAuthnRegistration(c *gin.Context) {
@@ -2,6 +2,7 @@ package middlewares
 
 import (
 	"crypto/subtle"
+	"strings"
 
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
@@ -24,11 +25,29 @@ func Auth(allowDisabledGuest bool) func(c *gin.Context) {
 				c.Abort()
 				return
 			}
+			if !checkIP(c, admin, model.TokenApi) {
+				return
+			}
 			common.GinWithValue(c, conf.UserKey, admin)
 			log.Debugf("use admin token: %+v", admin)
 			c.Next()
 			return
 		}
+		if t := strings.TrimPrefix(token, "Bearer "); op.IsAccessToken(t) {
+			user, err := op.AuthAccessToken("", t, model.TokenApi)
+			if err != nil {
+				common.ErrorResp(c, err, 401)
+				c.Abort()
+				return
+			}
+			if !checkIP(c, user, model.TokenApi) {
+				return
+			}
+			common.GinWithValue(c, conf.UserKey, user)
+			log.Debugf("use access token: %+v", user)
+			c.Next()
+			return
+		}
 		if token == "" {
 			guest, err := op.GetGuest()
 			if err != nil {
@@ -41,6 +60,9 @@ func Auth(allowDisabledGuest bool) func(c *gin.Context) {
 				c.Abort()
 				return
 			}
+			if !checkIP(c, guest, model.TokenApi) {
+				return
+			}
 			common.GinWithValue(c, conf.UserKey, guest)
 			log.Debugf("use empty token: %+v", guest)
 			c.Next()
@@ -69,7 +91,10 @@ func Auth(allowDisabledGuest bool) func(c *gin.Context) {
 			c.Abort()
 			return
 		}
-		common.GinWithValue(c, conf.UserKey, user)
+		if !checkIP(c, user, model.TokenApi) {
+			return
+		}
+		common.GinWithValue(c, conf.UserKey, user, conf.SessionIDKey, userClaims.SessionID)
 		log.Debugf("use login token: %+v", user)
 		c.Next()
 	}
@@ -84,6 +109,9 @@ func Authn(c *gin.Context) {
 			c.Abort()
 			return
 		}
+		if !checkIP(c, admin, model.TokenApi) {
+			return
+		}
 		common.GinWithValue(c, conf.UserKey, admin)
 		log.Debugf("use admin token: %+v", admin)
 		c.Next()
@@ -96,6 +124,9 @@ func Authn(c *gin.Context) {
 			c.Abort()
 			return
 		}
+		if !checkIP(c, guest, model.TokenApi) {
+			return
+		}
 		common.GinWithValue(c, conf.UserKey, guest)
 		log.Debugf("use empty token: %+v", guest)
 		c.Next()
@@ -124,11 +155,24 @@ func Authn(c *gin.Context) {
 		c.Abort()
 		return
 	}
-	common.GinWithValue(c, conf.UserKey, user)
+	if !checkIP(c, user, model.TokenApi) {
+		return
+	}
+	common.GinWithValue(c, conf.UserKey, user, conf.SessionIDKey, userClaims.SessionID)
 	log.Debugf("use login token: %+v", user)
 	c.Next()
 }
 
+// checkIP aborts with 403 if the ip rules deny the client to use the protocol as the user
+func checkIP(c *gin.Context, user *model.User, protocol string) bool {
+	if err := op.CheckIP(user, protocol, c.ClientIP()); err != nil {
+		common.ErrorResp(c, err, 403)
+		c.Abort()
+		return false
+	}
+	return true
+}
+
 func AuthNotGuest(c *gin.Context) {
 	user := c.Request.Context().Value(conf.UserKey).(*model.User)
 	if user.IsGuest() {
@@ -144,6 +188,9 @@ func AuthAdmin(c *gin.Context) {
 	if !user.IsAdmin() {
 		common.ErrorStrResp(c, "You are not an admin", 403)
 		c.Abort()
+	} else if t := user.AccessToken(); t != nil && (t.ReadOnly || t.SubPath != "") {
+		common.ErrorStrResp(c, "The access token is restricted", 403)
+		c.Abort()
 	} else {
 		c.Next()
 	}
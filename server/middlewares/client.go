@@ -0,0 +1,32 @@
+package middlewares
+
+import (
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/cache"
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+// untrustedForwarders are the peers warned about sending X-Forwarded-For untrusted lately,
+// so that a peer is warned about once an hour
+var untrustedForwarders = cache.NewKeyedCache[struct{}](time.Hour)
+
+// ClientInfo puts the ip of the client and the protocol of the request into the context
+func ClientInfo(protocol string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		clientIP := c.ClientIP()
+		if remoteIP := c.RemoteIP(); clientIP == remoteIP && c.GetHeader("X-Forwarded-For") != "" {
+			// c.ClientIP only ignores the forwarded ip of a peer which isn't a trusted proxy
+			if _, warned := untrustedForwarders.Get(remoteIP); !warned {
+				untrustedForwarders.Set(remoteIP, struct{}{})
+				log.Warnf("ignored X-Forwarded-For from %s, which isn't in trusted_proxies, "+
+					"add it there if it's your reverse proxy so the ip rules see the ips of its clients", remoteIP)
+			}
+		}
+		common.GinWithValue(c, conf.ClientIPKey, clientIP, conf.ProtocolKey, protocol)
+		c.Next()
+	}
+}
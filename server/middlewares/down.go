@@ -1,10 +1,12 @@
 package middlewares
 
 import (
+	"net/http"
 	"strings"
 
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
 	"github.com/OpenListTeam/OpenList/v4/internal/setting"
+	"github.com/OpenListTeam/OpenList/v4/internal/sign"
 
 	"github.com/OpenListTeam/OpenList/v4/internal/errs"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
@@ -21,8 +23,13 @@ func PathParse(c *gin.Context) {
 	c.Next()
 }
 
-func Down(verifyFunc func(string, string) error) func(c *gin.Context) {
+func Down(verifyFunc func(data, sign string, r sign.Request) error) func(c *gin.Context) {
 	return func(c *gin.Context) {
+		// downloads have no user, so only the global ip rules apply
+		if err := op.CheckIP(nil, model.IPRuleDownload, c.ClientIP()); err != nil {
+			common.ErrorPage(c, err, 403)
+			return
+		}
 		rawPath := c.Request.Context().Value(conf.PathKey).(string)
 		meta, err := op.GetNearestMeta(rawPath)
 		if err != nil {
@@ -35,7 +42,11 @@ func Down(verifyFunc func(string, string) error) func(c *gin.Context) {
 		// verify sign
 		if needSign(meta, rawPath) {
 			s := c.Query("sign")
-			err = verifyFunc(rawPath, strings.TrimSuffix(s, "/"))
+			err = verifyFunc(rawPath, strings.TrimSuffix(s, "/"), sign.Request{
+				IP:        c.ClientIP(),
+				UserAgent: c.Request.UserAgent(),
+				Download:  isDownload(c.Request),
+			})
 			if err != nil {
 				common.ErrorPage(c, err, 401)
 				c.Abort()
@@ -46,6 +57,12 @@ func Down(verifyFunc func(string, string) error) func(c *gin.Context) {
 	}
 }
 
+// isDownload reports whether the request counts as a download of a sign with a download cap.
+// Every GET counts, ranges too, as any range may fetch all but a few bytes of the file
+func isDownload(r *http.Request) bool {
+	return r.Method == http.MethodGet
+}
+
 // TODO: implement
 // path maybe contains # ? etc.
 func parsePath(path string) string {
@@ -0,0 +1,33 @@
+package middlewares
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestIsDownload(t *testing.T) {
+	for _, c := range []struct {
+		method, rg string
+		expected   bool
+	}{
+		{http.MethodGet, "", true},
+		{http.MethodHead, "", false},
+		{http.MethodGet, "bytes=0-", true},
+		{http.MethodGet, "bytes=0-0", true},
+		{http.MethodGet, "bytes=-1048576", true},
+		{http.MethodGet, "bytes=1-", true},
+		{http.MethodGet, "bytes=100-200, 300-", true},
+		{http.MethodGet, "bytes=100-200, 0-99", true},
+		{http.MethodGet, "bytes=100-200, -50", true},
+		{http.MethodGet, "items=1-", true},
+		{http.MethodGet, "bytes=x-", true},
+	} {
+		r, _ := http.NewRequest(c.method, "/d/a.txt", nil)
+		if c.rg != "" {
+			r.Header.Set("Range", c.rg)
+		}
+		if got := isDownload(r); got != c.expected {
+			t.Errorf("%s %q: expected %v, got %v", c.method, c.rg, c.expected, got)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package middlewares
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/metrics"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/gin-gonic/gin"
+)
+
+// Metrics records the count and latency of the requests by route, with the protocol
+// put into the context by ClientInfo, or api if there isn't one
+func Metrics(c *gin.Context) {
+	start := time.Now()
+	c.Next()
+	protocol, _ := c.Request.Context().Value(conf.ProtocolKey).(string)
+	if protocol == "" {
+		protocol = model.TokenApi
+	}
+	route := c.FullPath()
+	if route == "" {
+		route = "unmatched"
+	}
+	metrics.ObserveRequest(protocol, route, c.Request.Method, strconv.Itoa(c.Writer.Status()), start)
+}
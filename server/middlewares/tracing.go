@@ -0,0 +1,41 @@
+package middlewares
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/tracing"
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Tracing starts the span of the request, the spans of the op calls and of the requests
+// of the drivers made by the handler are its children
+func Tracing(c *gin.Context) {
+	if !tracing.Enabled() {
+		c.Next()
+		return
+	}
+	route := c.FullPath()
+	if route == "" {
+		route = "unmatched"
+	}
+	ctx, span := tracing.StartServer(c.Request.Context(), c.Request.Method+" "+route, c.Request.Header,
+		attribute.String("http.request.method", c.Request.Method),
+		attribute.String("http.route", route),
+		attribute.String("url.path", c.Request.URL.Path),
+	)
+	c.Request = c.Request.WithContext(ctx)
+	c.Next()
+	status := c.Writer.Status()
+	span.SetAttributes(attribute.Int("http.response.status_code", status))
+	if protocol, ok := c.Request.Context().Value(conf.ProtocolKey).(string); ok {
+		span.SetAttributes(attribute.String("protocol", protocol))
+	}
+	var err error
+	if status >= http.StatusInternalServerError {
+		err = fmt.Errorf("%d %s", status, http.StatusText(status))
+	}
+	tracing.End(span, err)
+}
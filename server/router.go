@@ -4,6 +4,7 @@ import (
 	"github.com/OpenListTeam/OpenList/v4/cmd/flags"
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
 	"github.com/OpenListTeam/OpenList/v4/internal/message"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/internal/sign"
 	"github.com/OpenListTeam/OpenList/v4/internal/stream"
 	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
@@ -17,6 +18,7 @@ import (
 
 func Init(e *gin.Engine) {
 	e.ContextWithFallback = true
+	e.Use(middlewares.Metrics, middlewares.Tracing)
 	if !utils.SliceContains([]string{"", "/"}, conf.URL.Path) {
 		e.GET("/", func(c *gin.Context) {
 			c.Redirect(302, conf.URL.Path)
@@ -30,12 +32,13 @@ func Init(e *gin.Engine) {
 	g.Any("/ping", func(c *gin.Context) {
 		c.String(200, "pong")
 	})
+	g.GET("/metrics", handles.Metrics)
 	g.GET("/favicon.ico", handles.Favicon)
 	g.GET("/robots.txt", handles.Robots)
 	g.GET("/manifest.json", static.ManifestJSON)
 	g.GET("/i/:link_name", handles.Plist)
 	common.SecretKey = []byte(conf.Conf.JwtSecret)
-	g.Use(middlewares.StoragesLoaded)
+	g.Use(middlewares.StoragesLoaded, middlewares.ClientInfo(model.TokenApi))
 	if conf.Conf.MaxConnections > 0 {
 		g.Use(middlewares.MaxAllowed(conf.Conf.MaxConnections))
 	}
@@ -43,12 +46,12 @@ func Init(e *gin.Engine) {
 	S3(g.Group("/s3"))
 
 	downloadLimiter := middlewares.DownloadRateLimiter(stream.ClientDownloadLimit)
-	signCheck := middlewares.Down(sign.Verify)
+	signCheck := middlewares.Down(sign.VerifyRequest)
 	g.GET("/d/*path", middlewares.PathParse, signCheck, downloadLimiter, handles.Down)
 	g.GET("/p/*path", middlewares.PathParse, signCheck, downloadLimiter, handles.Proxy)
 	g.HEAD("/d/*path", middlewares.PathParse, signCheck, handles.Down)
 	g.HEAD("/p/*path", middlewares.PathParse, signCheck, handles.Proxy)
-	archiveSignCheck := middlewares.Down(sign.VerifyArchive)
+	archiveSignCheck := middlewares.Down(sign.VerifyArchiveRequest)
 	g.GET("/ad/*path", middlewares.PathParse, archiveSignCheck, downloadLimiter, handles.ArchiveDown)
 	g.GET("/ap/*path", middlewares.PathParse, archiveSignCheck, downloadLimiter, handles.ArchiveProxy)
 	g.GET("/ae/*path", middlewares.PathParse, archiveSignCheck, downloadLimiter, handles.ArchiveInternalExtract)
@@ -74,6 +77,16 @@ func Init(e *gin.Engine) {
 	api.POST("/auth/login/ldap", handles.LoginLdap)
 	auth.GET("/me", handles.CurrentUser)
 	auth.POST("/me/update", handles.UpdateCurrent)
+	auth.GET("/me/usage", handles.CurrentUsage)
+	auth.GET("/me/token/list", handles.ListMyAccessTokens)
+	auth.POST("/me/token/create", handles.CreateMyAccessToken)
+	auth.POST("/me/token/revoke", handles.RevokeMyAccessToken)
+	auth.GET("/me/session/list", handles.ListMySessions)
+	auth.POST("/me/session/revoke", handles.RevokeMySession)
+	auth.POST("/me/session/revoke_all", handles.RevokeMySessions)
+	auth.GET("/me/s3key/list", handles.ListMyS3Keys)
+	auth.POST("/me/s3key/create", handles.CreateMyS3Key)
+	auth.POST("/me/s3key/delete", handles.DeleteMyS3Key)
 	auth.GET("/me/sshkey/list", handles.ListMyPublicKey)
 	auth.POST("/me/sshkey/add", handles.AddMyPublicKey)
 	auth.POST("/me/sshkey/delete", handles.DeleteMyPublicKey)
@@ -130,9 +143,53 @@ func admin(g *gin.RouterGroup) {
 	user.POST("/cancel_2fa", handles.Cancel2FAById)
 	user.POST("/delete", handles.DeleteUser)
 	user.POST("/del_cache", handles.DelUserCache)
+	user.GET("/usage", handles.GetUserUsage)
+	user.POST("/usage/set", handles.SetUserUsage)
+	user.GET("/token/list", handles.ListAccessTokens)
+	user.POST("/token/revoke", handles.RevokeAccessToken)
+	user.GET("/session/list", handles.ListSessions)
+	user.POST("/session/revoke", handles.RevokeSession)
+	user.POST("/session/revoke_all", handles.RevokeSessions)
+	user.GET("/s3key/list", handles.ListS3Keys)
+	user.POST("/s3key/delete", handles.DeleteS3Key)
 	user.GET("/sshkey/list", handles.ListPublicKeys)
 	user.POST("/sshkey/delete", handles.DeletePublicKey)
 
+	group := g.Group("/group")
+	group.GET("/list", handles.ListGroups)
+	group.GET("/get", handles.GetGroup)
+	group.POST("/create", handles.CreateGroup)
+	group.POST("/update", handles.UpdateGroup)
+	group.POST("/delete", handles.DeleteGroup)
+
+	acl := g.Group("/acl")
+	acl.GET("/list", handles.ListAclRules)
+	acl.GET("/get", handles.GetAclRule)
+	acl.POST("/create", handles.CreateAclRule)
+	acl.POST("/update", handles.UpdateAclRule)
+	acl.POST("/delete", handles.DeleteAclRule)
+
+	ipRule := g.Group("/ip_rule")
+	ipRule.GET("/list", handles.ListIPRules)
+	ipRule.GET("/get", handles.GetIPRule)
+	ipRule.POST("/create", handles.CreateIPRule)
+	ipRule.POST("/update", handles.UpdateIPRule)
+	ipRule.POST("/delete", handles.DeleteIPRule)
+
+	webhook := g.Group("/webhook")
+	webhook.GET("/list", handles.ListWebhooks)
+	webhook.GET("/get", handles.GetWebhook)
+	webhook.GET("/events", handles.ListWebhookEvents)
+	webhook.POST("/create", handles.CreateWebhook)
+	webhook.POST("/update", handles.UpdateWebhook)
+	webhook.POST("/delete", handles.DeleteWebhook)
+	webhook.GET("/deliveries", handles.ListWebhookDeliveries)
+	webhook.POST("/redeliver", handles.RedeliverWebhook)
+
+	auditLog := g.Group("/audit")
+	auditLog.GET("/list", handles.ListAuditLogs)
+	auditLog.GET("/export", handles.ExportAuditLogs)
+
 	storage := g.Group("/storage")
 	storage.GET("/list", handles.ListStorages)
 	storage.GET("/get", handles.GetStorage)
@@ -185,6 +242,21 @@ func admin(g *gin.RouterGroup) {
 	scan.POST("/start", handles.StartManualScan)
 	scan.POST("/stop", handles.StopManualScan)
 	scan.GET("/progress", handles.GetManualScanProgress)
+
+	trash := g.Group("/trash")
+	trash.GET("/list", handles.ListTrash)
+	trash.POST("/restore", handles.RestoreTrash)
+	trash.POST("/purge", handles.PurgeTrash)
+
+	job := g.Group("/scheduled_job")
+	job.GET("/list", handles.ListScheduledJobs)
+	job.GET("/get", handles.GetScheduledJob)
+	job.GET("/runs", handles.ListScheduledJobRuns)
+	job.POST("/create", handles.CreateScheduledJob)
+	job.POST("/update", handles.UpdateScheduledJob)
+	job.POST("/delete", handles.DeleteScheduledJob)
+	job.POST("/run", handles.RunScheduledJob)
+	job.POST("/cancel", handles.CancelScheduledJob)
 }
 
 func fsAndShare(g *gin.RouterGroup) {
@@ -193,6 +265,9 @@ func fsAndShare(g *gin.RouterGroup) {
 	a := g.Group("/archive")
 	a.Any("/meta", handles.FsArchiveMetaSplit)
 	a.Any("/list", handles.FsArchiveListSplit)
+	uploadLimiter := middlewares.UploadRateLimiter(stream.ClientUploadLimit)
+	g.PUT("/share/put", uploadLimiter, handles.SharingPut)
+	g.PUT("/share/form", uploadLimiter, handles.SharingForm)
 }
 
 func _fs(g *gin.RouterGroup) {
@@ -206,12 +281,15 @@ func _fs(g *gin.RouterGroup) {
 	g.POST("/move", handles.FsMove)
 	g.POST("/recursive_move", handles.FsRecursiveMove)
 	g.POST("/copy", handles.FsCopy)
+	g.POST("/sync", handles.FsSync)
 	g.POST("/remove", handles.FsRemove)
 	g.POST("/remove_empty_directory", handles.FsRemoveEmptyDirectory)
 	uploadLimiter := middlewares.UploadRateLimiter(stream.ClientUploadLimit)
 	g.PUT("/put", middlewares.FsUp, uploadLimiter, handles.FsStream)
 	g.PUT("/form", middlewares.FsUp, uploadLimiter, handles.FsForm)
 	g.POST("/link", middlewares.AuthAdmin, handles.Link)
+	g.POST("/sign", middlewares.AuthNotGuest, handles.FsSign)
+	g.POST("/sign/revoke", middlewares.AuthNotGuest, handles.FsSignRevoke)
 	// g.POST("/add_aria2", handles.AddOfflineDownload)
 	// g.POST("/add_qbit", handles.AddQbittorrent)
 	// g.POST("/add_transmission", handles.SetTransmission)
@@ -219,6 +297,11 @@ func _fs(g *gin.RouterGroup) {
 	g.POST("/archive/decompress", handles.FsArchiveDecompress)
 	// Direct upload (client-side upload to storage)
 	g.POST("/get_direct_upload_info", middlewares.FsUp, handles.FsGetDirectUploadInfo)
+
+	trash := g.Group("/trash", middlewares.AuthNotGuest)
+	trash.GET("/list", handles.ListMyTrash)
+	trash.POST("/restore", handles.RestoreMyTrash)
+	trash.POST("/purge", handles.PurgeMyTrash)
 }
 
 func _task(g *gin.RouterGroup) {
@@ -231,8 +314,15 @@ func _sharing(g *gin.RouterGroup) {
 	g.POST("/create", handles.CreateSharing)
 	g.POST("/update", handles.UpdateSharing)
 	g.POST("/delete", handles.DeleteSharing)
+	g.GET("/uploads", handles.ListSharingUploads)
+	g.GET("/stats", handles.GetSharingStats)
 	g.POST("/enable", handles.SetEnableSharing(false))
 	g.POST("/disable", handles.SetEnableSharing(true))
+	g.Any("/internal/list", handles.ListInternalShares)
+	g.POST("/internal/create", handles.CreateInternalShare)
+	g.POST("/internal/update", handles.UpdateInternalShare)
+	g.POST("/internal/delete", handles.DeleteInternalShare)
+	g.GET("/internal/received", handles.ListReceivedShares)
 }
 
 func Cors(r *gin.Engine) {
@@ -245,6 +335,7 @@ func Cors(r *gin.Engine) {
 }
 
 func InitS3(e *gin.Engine) {
+	e.Use(middlewares.Metrics, middlewares.Tracing)
 	Cors(e)
 	S3Server(e.Group("/"))
 }
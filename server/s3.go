@@ -6,7 +6,9 @@ import (
 	"strings"
 
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/OpenListTeam/OpenList/v4/server/middlewares"
 	"github.com/OpenListTeam/OpenList/v4/server/s3"
 	"github.com/gin-gonic/gin"
 )
@@ -26,6 +28,7 @@ func S3(g *gin.RouterGroup) {
 	}
 	h, _ := s3.NewServer(context.Background())
 
+	g.Use(middlewares.ClientInfo(model.TokenS3))
 	g.Any("/*path", func(c *gin.Context) {
 		adjustedPath := strings.TrimPrefix(c.Request.URL.Path, path.Join(conf.URL.Path, "/s3"))
 		c.Request.URL.Path = adjustedPath
@@ -35,5 +38,5 @@ func S3(g *gin.RouterGroup) {
 
 func S3Server(g *gin.RouterGroup) {
 	h, _ := s3.NewServer(context.Background())
-	g.Any("/*path", gin.WrapH(h))
+	g.Any("/*path", middlewares.ClientInfo(model.TokenS3), gin.WrapH(h))
 }
@@ -0,0 +1,104 @@
+package s3
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/setting"
+	"github.com/itsHenry35/gofakes3"
+	log "github.com/sirupsen/logrus"
+)
+
+// authKeys keeps the keys of the server the same as the key in settings and the s3 keys of users
+type authKeys struct {
+	mu    sync.Mutex
+	faker *gofakes3.GoFakeS3
+	keys  map[string]string
+}
+
+func (a *authKeys) reload() {
+	keys, err := op.GetS3KeyPairs()
+	if err != nil {
+		log.Errorf("failed load s3 keys: %+v", err)
+		return
+	}
+	if id, secret := settingKey(); id != "" || secret != "" {
+		keys[id] = secret
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var removed []string
+	for id := range a.keys {
+		if _, ok := keys[id]; !ok {
+			removed = append(removed, id)
+		}
+	}
+	if len(removed) > 0 {
+		a.faker.DelAuthKeys(removed)
+	}
+	a.faker.AddAuthKeys(keys)
+	a.keys = keys
+}
+
+func settingKey() (id, secret string) {
+	return setting.GetStr(conf.S3AccessKeyId), setting.GetStr(conf.S3SecretAccessKey)
+}
+
+// accessKeyId returns the access key id in the signature v4 or v2 of the request,
+// both are accepted by gofakes3
+func accessKeyId(r *http.Request) string {
+	query := r.URL.Query()
+	if auth := r.Header.Get("Authorization"); auth != "" {
+		// v2: AWS id:signature
+		if c, ok := strings.CutPrefix(auth, "AWS "); ok {
+			id, _, _ := strings.Cut(strings.TrimSpace(c), ":")
+			return id
+		}
+		_, c, ok := strings.Cut(auth, "Credential=")
+		if !ok {
+			return ""
+		}
+		cred, _, _ := strings.Cut(c, ",")
+		id, _, _ := strings.Cut(strings.TrimSpace(cred), "/")
+		return id
+	}
+	if id := query.Get("AWSAccessKeyId"); id != "" {
+		return id
+	}
+	id, _, _ := strings.Cut(strings.TrimSpace(query.Get("X-Amz-Credential")), "/")
+	return id
+}
+
+// withUser runs the request as the user of its access key, the key in settings is of the admin,
+// and a request without a key is of the guest. The signature is checked by gofakes3 later,
+// a request of an unknown key runs without a user and is refused by the backend.
+func withUser(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var user *model.User
+		var err error
+		if id := accessKeyId(r); id == "" {
+			user, err = op.GetGuest()
+		} else if settingId, _ := settingKey(); id == settingId {
+			user, err = op.GetAdmin()
+		} else {
+			user, err = op.AuthS3Key(id)
+		}
+		if err != nil {
+			log.Debugf("s3 request without user: %v", err)
+			user = nil
+		} else {
+			r = r.WithContext(context.WithValue(r.Context(), conf.UserKey, user))
+		}
+		ip, _ := r.Context().Value(conf.ClientIPKey).(string)
+		if err = op.CheckIP(user, model.TokenS3, ip); err != nil {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,29 @@
+package s3
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAccessKeyId(t *testing.T) {
+	for _, c := range []struct {
+		name     string
+		target   string
+		auth     string
+		expected string
+	}{
+		{"v4 header", "/bucket/a.txt", "AWS4-HMAC-SHA256 Credential=key4/20260101/us-east-1/s3/aws4_request, SignedHeaders=host, Signature=abc", "key4"},
+		{"v4 query", "/bucket/a.txt?X-Amz-Algorithm=AWS4-HMAC-SHA256&X-Amz-Credential=key4%2F20260101%2Fus-east-1%2Fs3%2Faws4_request", "", "key4"},
+		{"v2 header", "/bucket/a.txt", "AWS key2:c2lnbmF0dXJl", "key2"},
+		{"v2 query", "/bucket/a.txt?AWSAccessKeyId=key2&Expires=1&Signature=c2lnbmF0dXJl", "", "key2"},
+		{"anonymous", "/bucket/a.txt", "", ""},
+	} {
+		r := httptest.NewRequest("GET", c.target, nil)
+		if c.auth != "" {
+			r.Header.Set("Authorization", c.auth)
+		}
+		if id := accessKeyId(r); id != c.expected {
+			t.Errorf("%s: expected access key id %q, got %q", c.name, c.expected, id)
+		}
+	}
+}
@@ -46,15 +46,26 @@ func newBackend() gofakes3.Backend {
 	}
 }
 
-// ListBuckets always returns the default bucket.
+// ListBuckets returns the buckets the user can access.
 func (b *s3Backend) ListBuckets(ctx context.Context) ([]gofakes3.BucketInfo, error) {
+	user, err := getUser(ctx)
+	if err != nil {
+		return nil, err
+	}
 	buckets, err := getAndParseBuckets()
 	if err != nil {
 		return nil, err
 	}
 	var response []gofakes3.BucketInfo
 	for _, b := range buckets {
-		node, _ := fs.Get(ctx, b.Path, &fs.GetArgs{})
+		if !user.CanAccessPath(utils.FixAndCleanPath(b.Path)) {
+			continue
+		}
+		node, err := fs.Get(ctx, b.Path, &fs.GetArgs{})
+		if err != nil {
+			log.Warnf("failed get bucket [%s]: %+v", b.Name, err)
+			continue
+		}
 		response = append(response, gofakes3.BucketInfo{
 			// Name:         gofakes3.URLEncode(b.Name),
 			Name:         b.Name,
@@ -66,7 +77,7 @@ func (b *s3Backend) ListBuckets(ctx context.Context) ([]gofakes3.BucketInfo, err
 
 // ListBucket lists the objects in the given bucket.
 func (b *s3Backend) ListBucket(ctx context.Context, bucketName string, prefix *gofakes3.Prefix, page gofakes3.ListBucketPage) (*gofakes3.ObjectList, error) {
-	bucket, err := getBucketByName(bucketName)
+	bucket, err := getBucketByName(ctx, bucketName)
 	if err != nil {
 		return nil, err
 	}
@@ -84,10 +95,21 @@ func (b *s3Backend) ListBucket(ctx context.Context, bucketName string, prefix *g
 		prefix.HasDelimiter = false
 	}
 
-	response := gofakes3.NewObjectList()
 	path, remaining := prefixParser(prefix)
+	if _, err := objectPath(ctx, bucket, path); err != nil {
+		return gofakes3.NewObjectList(), nil
+	}
+	if prefix.HasDelimiter && isPagedDir(bucketPath, path) {
+		// a single level can be listed page by page without listing the whole directory
+		response, err := b.entryListPaged(ctx, bucketPath, path, remaining, page)
+		if err == gofakes3.ErrNoSuchKey {
+			return gofakes3.NewObjectList(), nil
+		}
+		return response, err
+	}
 
-	err = b.entryListR(bucketPath, path, remaining, prefix.HasDelimiter, response)
+	response := gofakes3.NewObjectList()
+	err = b.entryListR(ctx, bucketPath, path, remaining, prefix.HasDelimiter, response)
 	if err == gofakes3.ErrNoSuchKey {
 		// AWS just returns an empty list
 		response = gofakes3.NewObjectList()
@@ -102,15 +124,19 @@ func (b *s3Backend) ListBucket(ctx context.Context, bucketName string, prefix *g
 //
 // Note that the metadata is not supported yet.
 func (b *s3Backend) HeadObject(ctx context.Context, bucketName, objectName string) (*gofakes3.Object, error) {
-	bucket, err := getBucketByName(bucketName)
+	bucket, err := getBucketByName(ctx, bucketName)
 	if err != nil {
 		return nil, err
 	}
-	bucketPath := bucket.Path
-
-	fp := path.Join(bucketPath, objectName)
-	fmeta, _ := op.GetNearestMeta(fp)
-	node, err := fs.Get(context.WithValue(ctx, conf.MetaKey, fmeta), fp, &fs.GetArgs{})
+	fp, err := objectPath(ctx, bucket, objectName)
+	if err != nil {
+		return nil, err
+	}
+	metaCtx, ok := canAccess(ctx, fp)
+	if !ok {
+		return nil, gofakes3.KeyNotFound(objectName)
+	}
+	node, err := fs.Get(metaCtx, fp, &fs.GetArgs{})
 	if err != nil {
 		return nil, gofakes3.KeyNotFound(objectName)
 	}
@@ -145,15 +171,19 @@ func (b *s3Backend) HeadObject(ctx context.Context, bucketName, objectName strin
 
 // GetObject fetchs the object from the filesystem.
 func (b *s3Backend) GetObject(ctx context.Context, bucketName, objectName string, rangeRequest *gofakes3.ObjectRangeRequest) (s3Obj *gofakes3.Object, err error) {
-	bucket, err := getBucketByName(bucketName)
+	bucket, err := getBucketByName(ctx, bucketName)
 	if err != nil {
 		return nil, err
 	}
-	bucketPath := bucket.Path
-
-	fp := path.Join(bucketPath, objectName)
-	fmeta, _ := op.GetNearestMeta(fp)
-	node, err := fs.Get(context.WithValue(ctx, conf.MetaKey, fmeta), fp, &fs.GetArgs{})
+	fp, err := objectPath(ctx, bucket, objectName)
+	if err != nil {
+		return nil, err
+	}
+	metaCtx, ok := canAccess(ctx, fp)
+	if !ok {
+		return nil, gofakes3.KeyNotFound(objectName)
+	}
+	node, err := fs.Get(metaCtx, fp, &fs.GetArgs{})
 	if err != nil {
 		return nil, gofakes3.KeyNotFound(objectName)
 	}
@@ -232,7 +262,7 @@ func (b *s3Backend) PutObject(
 	meta map[string]string,
 	input io.Reader, size int64,
 ) (result gofakes3.PutObjectResult, err error) {
-	bucket, err := getBucketByName(bucketName)
+	bucket, err := getBucketByName(ctx, bucketName)
 	if err != nil {
 		return result, err
 	}
@@ -241,7 +271,10 @@ func (b *s3Backend) PutObject(
 	isDir := strings.HasSuffix(objectName, "/")
 	log.Debugf("isDir: %v", isDir)
 
-	fp := path.Join(bucketPath, objectName)
+	fp, err := objectPath(ctx, bucket, objectName)
+	if err != nil {
+		return result, err
+	}
 	log.Debugf("fp: %s, bucketPath: %s, objectName: %s", fp, bucketPath, objectName)
 
 	var reqPath string
@@ -251,6 +284,9 @@ func (b *s3Backend) PutObject(
 		reqPath = path.Dir(fp)
 	}
 	log.Debugf("reqPath: %s", reqPath)
+	if !canWrite(ctx, path.Dir(fp)) {
+		return result, clientErr(errors.WithStack(errs.PermissionDenied))
+	}
 	fmeta, _ := op.GetNearestMeta(fp)
 	ctx = context.WithValue(ctx, conf.MetaKey, fmeta)
 
@@ -296,6 +332,9 @@ func (b *s3Backend) PutObject(
 	if setting.GetBool(conf.IgnoreSystemFiles) && utils.IsSystemFile(obj.Name) {
 		return result, errs.IgnoredSystemFile
 	}
+	if err = fs.CheckQuota(ctx, fp, size); err != nil {
+		return result, clientErr(err)
+	}
 	stream := &stream.FileStream{
 		Obj:      &obj,
 		Reader:   input,
@@ -304,7 +343,7 @@ func (b *s3Backend) PutObject(
 
 	err = fs.PutDirectly(ctx, reqPath, stream)
 	if err != nil {
-		return result, err
+		return result, clientErr(err)
 	}
 
 	// if err := stream.Close(); err != nil {
@@ -345,13 +384,18 @@ func (b *s3Backend) DeleteObject(ctx context.Context, bucketName, objectName str
 
 // deleteObject deletes the object from the filesystem.
 func (b *s3Backend) deleteObject(ctx context.Context, bucketName, objectName string) error {
-	bucket, err := getBucketByName(bucketName)
+	bucket, err := getBucketByName(ctx, bucketName)
 	if err != nil {
 		return err
 	}
-	bucketPath := bucket.Path
+	if user, _ := ctx.Value(conf.UserKey).(*model.User); !user.CanRemove() {
+		return clientErr(errors.WithStack(errs.PermissionDenied))
+	}
 
-	fp := path.Join(bucketPath, objectName)
+	fp, err := objectPath(ctx, bucket, objectName)
+	if err != nil {
+		return err
+	}
 	fmeta, _ := op.GetNearestMeta(fp)
 	// S3 does not report an error when attemping to delete a key that does not exist, so
 	// we need to skip IsNotExist errors.
@@ -359,7 +403,9 @@ func (b *s3Backend) deleteObject(ctx context.Context, bucketName, objectName str
 		return err
 	}
 
-	fs.Remove(ctx, fp)
+	if err := fs.Remove(ctx, fp); err != nil && !errs.IsObjectNotFound(err) {
+		return clientErr(err)
+	}
 	return nil
 }
 
@@ -375,16 +421,13 @@ func (b *s3Backend) DeleteBucket(ctx context.Context, name string) error {
 
 // BucketExists checks if the bucket exists.
 func (b *s3Backend) BucketExists(ctx context.Context, name string) (exists bool, err error) {
-	buckets, err := getAndParseBuckets()
-	if err != nil {
-		return false, err
-	}
-	for _, b := range buckets {
-		if b.Name == name {
-			return true, nil
+	if _, err = getBucketByName(ctx, name); err != nil {
+		if gofakes3.HasErrorCode(err, gofakes3.ErrNoSuchBucket) {
+			return false, nil
 		}
+		return false, err
 	}
-	return false, nil
+	return true, nil
 }
 
 // CopyObject copy specified object from srcKey to dstKey.
@@ -394,13 +437,14 @@ func (b *s3Backend) CopyObject(ctx context.Context, srcBucket, srcKey, dstBucket
 		return result, nil
 	}
 
-	srcB, err := getBucketByName(srcBucket)
+	srcB, err := getBucketByName(ctx, srcBucket)
+	if err != nil {
+		return result, err
+	}
+	srcFp, err := objectPath(ctx, srcB, srcKey)
 	if err != nil {
 		return result, err
 	}
-	srcBucketPath := srcB.Path
-
-	srcFp := path.Join(srcBucketPath, srcKey)
 	fmeta, _ := op.GetNearestMeta(srcFp)
 	srcNode, err := fs.Get(context.WithValue(ctx, conf.MetaKey, fmeta), srcFp, &fs.GetArgs{})
 
@@ -3,18 +3,24 @@
 package s3
 
 import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
 	"path"
+	"slices"
 	"strings"
 	"time"
 
+	"github.com/OpenListTeam/OpenList/v4/internal/fs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/itsHenry35/gofakes3"
 	log "github.com/sirupsen/logrus"
 )
 
-func (b *s3Backend) entryListR(bucket, fdPath, name string, addPrefix bool, response *gofakes3.ObjectList) error {
+func (b *s3Backend) entryListR(ctx context.Context, bucket, fdPath, name string, addPrefix bool, response *gofakes3.ObjectList) error {
 	fp := path.Join(bucket, fdPath)
 
-	dirEntries, err := getDirEntries(fp)
+	dirEntries, err := getDirEntries(ctx, fp)
 	if err != nil {
 		return err
 	}
@@ -50,7 +56,7 @@ func (b *s3Backend) entryListR(bucket, fdPath, name string, addPrefix bool, resp
 				response.AddPrefix(objectPath)
 				continue
 			}
-			err := b.entryListR(bucket, path.Join(fdPath, object), "", false, response)
+			err := b.entryListR(ctx, bucket, path.Join(fdPath, object), "", false, response)
 			if err != nil {
 				return err
 			}
@@ -68,3 +74,148 @@ func (b *s3Backend) entryListR(bucket, fdPath, name string, addPrefix bool, resp
 	}
 	return nil
 }
+
+func isPagedDir(bucket, fdPath string) bool {
+	return fs.IsPagedList(path.Join(bucket, fdPath))
+}
+
+// pageMarkerPrefix marks the NextMarker of a paged listing, which carries the cursor of
+// the storage rather than a key, as keys are listed in the order of the storage
+const pageMarkerPrefix = "openlist-page:"
+
+// pageMarker is where a paged listing continues: the entry at Skip in the page at Cursor,
+// with the pages listed by Limit entries so that the cursor points to the same entries
+type pageMarker struct {
+	Cursor string `json:"c,omitempty"`
+	Skip   int    `json:"s,omitempty"`
+	Limit  int    `json:"l"`
+}
+
+func (m pageMarker) String() string {
+	data, _ := json.Marshal(m)
+	return pageMarkerPrefix + base64.RawURLEncoding.EncodeToString(data)
+}
+
+func parsePageMarker(s string) (pageMarker, bool) {
+	var m pageMarker
+	enc, ok := strings.CutPrefix(s, pageMarkerPrefix)
+	if !ok {
+		return m, false
+	}
+	data, err := base64.RawURLEncoding.DecodeString(enc)
+	if err != nil || json.Unmarshal(data, &m) != nil || m.Limit <= 0 || m.Skip < 0 {
+		return m, false
+	}
+	return m, true
+}
+
+// entryListPaged lists a single level of the bucket for a delimiter request.
+// The directory is listed page by page, and the listing stops as soon as the
+// requested page of keys is filled. Keys keep the order of the storage, and the
+// NextMarker carries the cursor of the storage, so each request continues where
+// the previous one stopped. A marker that is a key, like start-after, is applied
+// lexicographically to the whole level by entryListAfter instead
+func (b *s3Backend) entryListPaged(ctx context.Context, bucket, fdPath, name string, page gofakes3.ListBucketPage) (*gofakes3.ObjectList, error) {
+	fp := path.Join(bucket, fdPath)
+	ctx, ok := canAccess(ctx, fp)
+	if !ok {
+		return nil, gofakes3.ErrNoSuchKey
+	}
+	fi, err := fs.Get(ctx, fp, &fs.GetArgs{})
+	if err != nil || !fi.IsDir() {
+		return nil, gofakes3.ErrNoSuchKey
+	}
+
+	maxKeys := int(page.MaxKeys)
+	if maxKeys <= 0 {
+		maxKeys = 1000
+	}
+	marker := pageMarker{Limit: maxKeys}
+	if page.HasMarker {
+		if marker, ok = parsePageMarker(page.Marker); !ok {
+			return b.entryListAfter(ctx, fp, fdPath, name, page.Marker, maxKeys)
+		}
+	}
+	response := gofakes3.NewObjectList()
+	count, empty := 0, true
+	for {
+		objs, err := fs.ListPage(ctx, fp, &fs.ListPageArgs{Cursor: marker.Cursor, Limit: marker.Limit})
+		if err != nil {
+			return nil, err
+		}
+		for i := min(marker.Skip, len(objs.Objs)); i < len(objs.Objs); i++ {
+			entry := objs.Objs[i]
+			if rootPathEntry(ctx, fp, entry) {
+				continue
+			}
+			empty = false
+			if !strings.HasPrefix(entry.GetName(), name) {
+				continue
+			}
+			if count >= maxKeys {
+				response.IsTruncated = true
+				response.NextMarker = pageMarker{Cursor: marker.Cursor, Skip: i, Limit: marker.Limit}.String()
+				return response, nil
+			}
+			addEntry(response, fdPath, entry)
+			count++
+		}
+		if objs.Next == "" {
+			break
+		}
+		marker.Cursor, marker.Skip = objs.Next, 0
+	}
+	// workaround as s3 can't have empty files in directories, useful in deletions
+	if empty && !page.HasMarker {
+		response.Add(&gofakes3.Content{
+			Key:          path.Join(fdPath, emptyObjectName),
+			LastModified: gofakes3.NewContentTime(time.Now()),
+			ETag:         getFileHash(nil),
+			Size:         0,
+			StorageClass: gofakes3.StorageStandard,
+		})
+	}
+	return response, nil
+}
+
+// entryListAfter lists the keys of a single level after the marker key in lexicographic
+// order, which needs the whole level to be listed
+func (b *s3Backend) entryListAfter(ctx context.Context, fp, fdPath, name, marker string, maxKeys int) (*gofakes3.ObjectList, error) {
+	dirEntries, err := getDirEntries(ctx, fp)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]model.Obj, 0, len(dirEntries))
+	for _, entry := range dirEntries {
+		if strings.HasPrefix(entry.GetName(), name) && path.Join(fdPath, entry.GetName()) > marker {
+			entries = append(entries, entry)
+		}
+	}
+	slices.SortFunc(entries, func(a, b model.Obj) int { return strings.Compare(a.GetName(), b.GetName()) })
+	response := gofakes3.NewObjectList()
+	if len(entries) > maxKeys {
+		entries = entries[:maxKeys]
+		response.IsTruncated = true
+		response.NextMarker = path.Join(fdPath, entries[maxKeys-1].GetName())
+	}
+	for _, entry := range entries {
+		addEntry(response, fdPath, entry)
+	}
+	return response, nil
+}
+
+// addEntry adds entry of the dir fdPath as a common prefix if it's a dir, or as an object
+func addEntry(response *gofakes3.ObjectList, fdPath string, entry model.Obj) {
+	objectPath := path.Join(fdPath, entry.GetName())
+	if entry.IsDir() {
+		response.AddPrefix(objectPath)
+		return
+	}
+	response.Add(&gofakes3.Content{
+		Key:          objectPath,
+		LastModified: gofakes3.NewContentTime(entry.ModTime()),
+		ETag:         getFileHash(entry),
+		Size:         entry.GetSize(),
+		StorageClass: gofakes3.StorageStandard,
+	})
+}
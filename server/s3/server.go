@@ -7,6 +7,7 @@ import (
 	"math/rand"
 	"net/http"
 
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
 	"github.com/itsHenry35/gofakes3"
 )
 
@@ -19,9 +20,14 @@ func NewServer(ctx context.Context) (h http.Handler, err error) {
 		gofakes3.WithLogger(newLogger),
 		gofakes3.WithRequestID(rand.Uint64()),
 		gofakes3.WithoutVersioning(),
-		gofakes3.WithV4Auth(authlistResolver()),
+		gofakes3.WithV4Auth(map[string]string{}),
 		gofakes3.WithIntegrityCheck(true), // Check Content-MD5 if supplied
 	)
 
-	return faker.Server(), nil
+	keys := &authKeys{faker: faker}
+	keys.reload()
+	op.RegisterSettingChangingCallback(keys.reload)
+	op.RegisterS3KeysChangingCallback(keys.reload)
+
+	return withUser(faker.Server()), nil
 }
@@ -5,6 +5,7 @@ package s3
 import (
 	"context"
 	"encoding/json"
+	stdpath "path"
 	"strings"
 
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
@@ -13,7 +14,10 @@ import (
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/internal/op"
 	"github.com/OpenListTeam/OpenList/v4/internal/setting"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
 	"github.com/itsHenry35/gofakes3"
+	"github.com/pkg/errors"
 )
 
 type Bucket struct {
@@ -29,23 +33,87 @@ func getAndParseBuckets() ([]Bucket, error) {
 	return res, err
 }
 
-func getBucketByName(name string) (Bucket, error) {
+// getUser returns the user the request runs as
+func getUser(ctx context.Context) (*model.User, error) {
+	user, _ := ctx.Value(conf.UserKey).(*model.User)
+	if user == nil || user.Disabled {
+		return nil, clientErr(errors.WithStack(errs.PermissionDenied))
+	}
+	return user, nil
+}
+
+// getBucketByName returns the bucket if its path can be accessed by the user of ctx
+func getBucketByName(ctx context.Context, name string) (Bucket, error) {
+	user, err := getUser(ctx)
+	if err != nil {
+		return Bucket{}, err
+	}
 	buckets, err := getAndParseBuckets()
 	if err != nil {
 		return Bucket{}, err
 	}
 	for _, b := range buckets {
-		if b.Name == name {
+		if b.Name == name && user.CanAccessPath(utils.FixAndCleanPath(b.Path)) {
 			return b, nil
 		}
 	}
 	return Bucket{}, gofakes3.BucketNotFound(name)
 }
 
-func getDirEntries(path string) ([]model.Obj, error) {
-	ctx := context.Background()
+// objectPath joins the key to the path of the bucket. Keys that resolve out of the bucket,
+// e.g. by "..", or to a path the user of ctx can't access are refused as not found
+func objectPath(ctx context.Context, bucket Bucket, key string) (string, error) {
+	user, err := getUser(ctx)
+	if err != nil {
+		return "", err
+	}
+	fp := stdpath.Join(bucket.Path, key)
+	if !utils.IsSubPath(bucket.Path, fp) || !user.CanAccessPath(utils.FixAndCleanPath(fp)) {
+		return "", gofakes3.KeyNotFound(key)
+	}
+	return fp, nil
+}
+
+// canAccess checks the meta of path for the user of ctx, and returns the ctx with the meta
+func canAccess(ctx context.Context, path string) (context.Context, bool) {
+	user, _ := ctx.Value(conf.UserKey).(*model.User)
 	meta, _ := op.GetNearestMeta(path)
-	fi, err := fs.Get(context.WithValue(ctx, conf.MetaKey, meta), path, &fs.GetArgs{})
+	return context.WithValue(ctx, conf.MetaKey, meta), user != nil && common.CanAccess(user, meta, path, "")
+}
+
+// canWrite reports whether the user of ctx can write in the dir by the permission or the meta
+func canWrite(ctx context.Context, dir string) bool {
+	user, _ := ctx.Value(conf.UserKey).(*model.User)
+	if user == nil {
+		return false
+	}
+	meta, _ := op.GetNearestMeta(dir)
+	return user.CanWrite() || common.CanWrite(meta, dir)
+}
+
+// clientErr turns a quota or permission error into an s3 error, since gofakes3 has no status
+// for them they're reported as an invalid request rather than an internal error to retry
+func clientErr(err error) error {
+	if errors.Is(err, errs.QuotaExceeded) || errors.Is(err, errs.PermissionDenied) {
+		return gofakes3.ErrorMessage(gofakes3.ErrInvalidArgument, err.Error())
+	}
+	return err
+}
+
+// rootPathEntry reports whether obj listed in dir is the folder of the root paths of the user
+// shown in the base path, which is a name in the paths of the user rather than a real child of dir
+func rootPathEntry(ctx context.Context, dir string, obj model.Obj) bool {
+	user, _ := ctx.Value(conf.UserKey).(*model.User)
+	return user != nil && len(user.GroupRoots()) > 0 && utils.PathEqual(user.BasePath, dir) &&
+		model.ObjHasMask(obj, model.Virtual) && obj.GetName() == model.GroupRootsDir
+}
+
+func getDirEntries(ctx context.Context, path string) ([]model.Obj, error) {
+	ctx, ok := canAccess(ctx, path)
+	if !ok {
+		return nil, gofakes3.ErrNoSuchKey
+	}
+	fi, err := fs.Get(ctx, path, &fs.GetArgs{})
 	if errs.IsNotFoundError(err) {
 		return nil, gofakes3.ErrNoSuchKey
 	} else if err != nil {
@@ -56,12 +124,18 @@ func getDirEntries(path string) ([]model.Obj, error) {
 		return nil, gofakes3.ErrNoSuchKey
 	}
 
-	dirEntries, err := fs.List(context.WithValue(ctx, conf.MetaKey, meta), path, &fs.ListArgs{})
+	dirEntries, err := fs.List(ctx, path, &fs.ListArgs{})
 	if err != nil {
 		return nil, err
 	}
 
-	return dirEntries, nil
+	res := make([]model.Obj, 0, len(dirEntries))
+	for _, entry := range dirEntries {
+		if !rootPathEntry(ctx, path, entry) {
+			res = append(res, entry)
+		}
+	}
+	return res, nil
 }
 
 // func getFileHashByte(node interface{}) []byte {
@@ -149,14 +223,3 @@ func prefixParser(p *gofakes3.Prefix) (path, remaining string) {
 // 		rmdirRecursive(dir, VFS)
 // 	}
 // }
-
-func authlistResolver() map[string]string {
-	s3accesskeyid := setting.GetStr(conf.S3AccessKeyId)
-	s3secretaccesskey := setting.GetStr(conf.S3SecretAccessKey)
-	if s3accesskeyid == "" && s3secretaccesskey == "" {
-		return nil
-	}
-	authList := make(map[string]string)
-	authList[s3accesskeyid] = s3secretaccesskey
-	return authList
-}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/OpenListTeam/OpenList/v4/drivers/base"
+	"github.com/OpenListTeam/OpenList/v4/internal/audit"
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/internal/op"
@@ -62,8 +63,17 @@ func (d *SftpDriver) GetConfig() *sftpd.Config {
 	return d.config
 }
 
+// accessTokenExtension passes the access token a client logs in with to GetFileSystem
+const accessTokenExtension = "openlist-access-token"
+
 func (d *SftpDriver) GetFileSystem(sc *ssh.ServerConn) (sftpd.FileSystem, error) {
-	userObj, err := op.GetUserByName(sc.User())
+	var userObj *model.User
+	var err error
+	if sc.Permissions != nil && sc.Permissions.Extensions[accessTokenExtension] != "" {
+		userObj, err = op.AuthAccessToken(sc.User(), sc.Permissions.Extensions[accessTokenExtension], model.TokenSftp)
+	} else {
+		userObj, err = op.GetUserByName(sc.User())
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -71,6 +81,7 @@ func (d *SftpDriver) GetFileSystem(sc *ssh.ServerConn) (sftpd.FileSystem, error)
 	ctx = context.WithValue(ctx, conf.UserKey, userObj)
 	ctx = context.WithValue(ctx, conf.MetaPassKey, "")
 	ctx = context.WithValue(ctx, conf.ClientIPKey, sc.RemoteAddr().String())
+	ctx = context.WithValue(ctx, conf.ProtocolKey, model.TokenSftp)
 	ctx = context.WithValue(ctx, conf.ProxyHeaderKey, d.proxyHeader)
 	return &sftp.DriverAdapter{FtpDriver: ftp.NewAferoAdapter(ctx)}, nil
 }
@@ -89,6 +100,9 @@ func (d *SftpDriver) NoClientAuth(conn ssh.ConnMetadata) (*ssh.Permissions, erro
 	if guest.Disabled || !guest.CanFTPAccess() {
 		return nil, errors.New("user is not allowed to access via SFTP")
 	}
+	if err = op.CheckIP(guest, model.TokenSftp, utils.HostIP(conn.RemoteAddr().String())); err != nil {
+		return nil, err
+	}
 	return nil, nil
 }
 
@@ -100,13 +114,29 @@ func (d *SftpDriver) PasswordAuth(conn ssh.ConnMetadata, password []byte) (*ssh.
 		return nil, errors.New("Too many unsuccessful sign-in attempts have been made using an incorrect username or password, Try again later.")
 	}
 	pass := string(password)
+	if op.IsAccessToken(pass) {
+		userObj, err := op.AuthAccessToken(conn.User(), pass, model.TokenSftp)
+		if err == nil && !userObj.CanFTPAccess() {
+			err = errors.New("user is not allowed to access via SFTP")
+		}
+		if err == nil {
+			err = op.CheckIP(userObj, model.TokenSftp, utils.HostIP(ip))
+		}
+		if err != nil {
+			model.LoginCache.Set(ip, count+1)
+			return nil, err
+		}
+		model.LoginCache.Del(ip)
+		// the file system is restricted by the token
+		return &ssh.Permissions{Extensions: map[string]string{accessTokenExtension: pass}}, nil
+	}
 	userObj, err := op.GetUserByName(conn.User())
 	if err == nil {
 		err = userObj.ValidateRawPassword(pass)
 		if err != nil && setting.GetBool(conf.LdapLoginEnabled) && userObj.AllowLdap {
 			err = common.HandleLdapLogin(conn.User(), pass)
 		}
-	} else if setting.GetBool(conf.LdapLoginEnabled) && model.CanFTPAccess(int32(setting.GetInt(conf.LdapDefaultPermission, 0))) {
+	} else if setting.GetBool(conf.LdapLoginEnabled) && model.CanFTPAccess(common.LdapDefaultPermission()) {
 		userObj, err = tryLdapLoginAndRegister(conn.User(), pass)
 	}
 	if err != nil {
@@ -117,6 +147,9 @@ func (d *SftpDriver) PasswordAuth(conn ssh.ConnMetadata, password []byte) (*ssh.
 		model.LoginCache.Set(ip, count+1)
 		return nil, errors.New("user is not allowed to access via SFTP")
 	}
+	if err = op.CheckIP(userObj, model.TokenSftp, utils.HostIP(ip)); err != nil {
+		return nil, err
+	}
 	model.LoginCache.Del(ip)
 	return nil, nil
 }
@@ -129,6 +162,9 @@ func (d *SftpDriver) PublicKeyAuth(conn ssh.ConnMetadata, key ssh.PublicKey) (*s
 	if userObj.Disabled || !userObj.CanFTPAccess() {
 		return nil, errors.New("user is not allowed to access via SFTP")
 	}
+	if err = op.CheckIP(userObj, model.TokenSftp, utils.HostIP(conn.RemoteAddr().String())); err != nil {
+		return nil, err
+	}
 	keys, _, err := op.GetSSHPublicKeyByUserId(userObj.ID, 1, -1)
 	if err != nil {
 		return nil, err
@@ -154,7 +190,12 @@ func (d *SftpDriver) AuthLogCallback(conn ssh.ConnMetadata, method string, err e
 		utils.Log.Infof("[SFTP] %s(%s) logged in via %s", conn.User(), ip, method)
 	} else if method != "none" {
 		utils.Log.Infof("[SFTP] %s(%s) tries logging in via %s but with error: %s", conn.User(), ip, method, err)
+	} else {
+		return
 	}
+	ctx := context.WithValue(context.Background(), conf.ClientIPKey, ip)
+	ctx = context.WithValue(ctx, conf.ProtocolKey, model.TokenSftp)
+	audit.Login(ctx, conn.User(), method, err)
 }
 
 func (d *SftpDriver) GetBanner(_ ssh.ConnMetadata) string {
@@ -28,7 +28,7 @@ func WebDav(dav *gin.RouterGroup) {
 			log.Errorf("%s %s %+v", request.Method, request.URL.Path, err)
 		},
 	}
-	dav.Use(WebDAVAuth)
+	dav.Use(middlewares.ClientInfo(model.TokenWebdav), WebDAVAuth)
 	uploadLimiter := middlewares.UploadRateLimiter(stream.ClientUploadLimit)
 	downloadLimiter := middlewares.DownloadRateLimiter(stream.ClientDownloadLimit)
 	dav.Any("/*path", uploadLimiter, downloadLimiter, ServeWebDAV)
@@ -47,6 +47,18 @@ func ServeWebDAV(c *gin.Context) {
 	handler.ServeHTTP(c.Writer, c.Request)
 }
 
+// serveGuestOptions lets the OPTIONS requests failing to auth go on as the guest,
+// from the ips the guest is allowed to use webdav from
+func serveGuestOptions(c *gin.Context, guest *model.User, ip string) {
+	if err := op.CheckIP(guest, model.TokenWebdav, ip); err != nil {
+		c.Status(http.StatusForbidden)
+		c.Abort()
+		return
+	}
+	common.GinWithValue(c, conf.UserKey, guest)
+	c.Next()
+}
+
 func WebDAVAuth(c *gin.Context) {
 	// check count of login
 	ip := c.ClientIP()
@@ -54,8 +66,7 @@ func WebDAVAuth(c *gin.Context) {
 	count, cok := model.LoginCache.Get(ip)
 	if cok && count >= model.DefaultMaxAuthRetries {
 		if c.Request.Method == "OPTIONS" {
-			common.GinWithValue(c, conf.UserKey, guest)
-			c.Next()
+			serveGuestOptions(c, guest, ip)
 			return
 		}
 		c.Status(http.StatusTooManyRequests)
@@ -70,7 +81,10 @@ func WebDAVAuth(c *gin.Context) {
 		if strings.HasPrefix(bt, "Bearer") {
 			bt = strings.TrimPrefix(bt, "Bearer ")
 			token := setting.GetStr(conf.Token)
-			if token != "" && subtle.ConstantTimeCompare([]byte(bt), []byte(token)) == 1 {
+			// an access token is checked like the password of its user below
+			ok = op.IsAccessToken(bt)
+			password = bt
+			if !ok && token != "" && subtle.ConstantTimeCompare([]byte(bt), []byte(token)) == 1 {
 				admin, err := op.GetAdmin()
 				if err != nil {
 					log.Errorf("[webdav auth] failed get admin user: %+v", err)
@@ -78,14 +92,20 @@ func WebDAVAuth(c *gin.Context) {
 					c.Abort()
 					return
 				}
+				if err := op.CheckIP(admin, model.TokenWebdav, ip); err != nil {
+					c.Status(http.StatusForbidden)
+					c.Abort()
+					return
+				}
 				common.GinWithValue(c, conf.UserKey, admin)
 				c.Next()
 				return
 			}
 		}
+	}
+	if !ok {
 		if c.Request.Method == "OPTIONS" {
-			common.GinWithValue(c, conf.UserKey, guest)
-			c.Next()
+			serveGuestOptions(c, guest, ip)
 			return
 		}
 		c.Writer.Header()["WWW-Authenticate"] = []string{`Basic realm="openlist"`}
@@ -96,8 +116,7 @@ func WebDAVAuth(c *gin.Context) {
 	user, ok := tryLogin(username, password)
 	if !ok {
 		if c.Request.Method == "OPTIONS" {
-			common.GinWithValue(c, conf.UserKey, guest)
-			c.Next()
+			serveGuestOptions(c, guest, ip)
 			return
 		}
 		model.LoginCache.Set(ip, count+1)
@@ -109,14 +128,18 @@ func WebDAVAuth(c *gin.Context) {
 	model.LoginCache.Del(ip)
 	if user.Disabled || !user.CanWebdavRead() {
 		if c.Request.Method == "OPTIONS" {
-			common.GinWithValue(c, conf.UserKey, guest)
-			c.Next()
+			serveGuestOptions(c, guest, ip)
 			return
 		}
 		c.Status(http.StatusForbidden)
 		c.Abort()
 		return
 	}
+	if err := op.CheckIP(user, model.TokenWebdav, ip); err != nil {
+		c.Status(http.StatusForbidden)
+		c.Abort()
+		return
+	}
 	if (c.Request.Method == "PUT" || c.Request.Method == "MKCOL") && (!user.CanWebdavManage() || !user.CanWrite()) {
 		c.Status(http.StatusForbidden)
 		c.Abort()
@@ -147,13 +170,17 @@ func WebDAVAuth(c *gin.Context) {
 }
 
 func tryLogin(username, password string) (*model.User, bool) {
+	if op.IsAccessToken(password) {
+		user, err := op.AuthAccessToken(username, password, model.TokenWebdav)
+		return user, err == nil
+	}
 	user, err := op.GetUserByName(username)
 	if err == nil {
 		err = user.ValidateRawPassword(password)
 		if err != nil && setting.GetBool(conf.LdapLoginEnabled) && user.AllowLdap {
 			err = common.HandleLdapLogin(username, password)
 		}
-	} else if setting.GetBool(conf.LdapLoginEnabled) && model.CanWebdavRead(int32(setting.GetInt(conf.LdapDefaultPermission, 0))) {
+	} else if setting.GetBool(conf.LdapLoginEnabled) && model.CanWebdavRead(common.LdapDefaultPermission()) {
 		user, err = tryLdapLoginAndRegister(username, password)
 	}
 	return user, err == nil
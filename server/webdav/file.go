@@ -6,11 +6,13 @@ package webdav
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"path"
 	"path/filepath"
 
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
 	"github.com/OpenListTeam/OpenList/v4/internal/fs"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/internal/op"
@@ -29,46 +31,50 @@ func slashClean(name string) string {
 //
 // See section 9.9.4 for when various HTTP status codes apply.
 func moveFiles(ctx context.Context, src, dst string, overwrite bool) (status int, err error) {
-	srcDir := path.Dir(src)
-	dstDir := path.Dir(dst)
-	srcName := path.Base(src)
-	dstName := path.Base(dst)
 	user := ctx.Value(conf.UserKey).(*model.User)
-	if srcDir != dstDir && !user.CanMove() {
+	if path.Dir(src) != path.Dir(dst) && !user.CanMove() {
 		return http.StatusForbidden, nil
 	}
-	if srcName != dstName && !user.CanRename() {
+	if path.Base(src) != path.Base(dst) && !user.CanRename() {
 		return http.StatusForbidden, nil
 	}
-	if srcDir == dstDir {
-		err = fs.Rename(ctx, src, dstName)
-	} else {
-		_, err = fs.Move(context.WithValue(ctx, conf.NoTaskKey, struct{}{}), src, dstDir)
-		if err != nil {
-			return http.StatusInternalServerError, err
-		}
-		if srcName != dstName {
-			err = fs.Rename(ctx, path.Join(dstDir, srcName), dstName)
-		}
-	}
-	if err != nil {
-		return http.StatusInternalServerError, err
-	}
-	// TODO if there are no files copy, should return 204
-	return http.StatusCreated, nil
+	return transferFiles(ctx, src, dst, overwrite, true)
 }
 
 // copyFiles copies files and/or directories from src to dst.
 //
 // See section 9.8.5 for when various HTTP status codes apply.
 func copyFiles(ctx context.Context, src, dst string, overwrite bool) (status int, err error) {
-	dstDir := path.Dir(dst)
-	_, err = fs.Copy(context.WithValue(ctx, conf.NoTaskKey, struct{}{}), src, dstDir)
-	if err != nil {
-		return http.StatusInternalServerError, err
+	return transferFiles(ctx, src, dst, overwrite, false)
+}
+
+// transferFiles moves or copies src to dst, applying the Overwrite header to an existing dst:
+// without it the request fails with 412 as section 9.8.4 requires, with it dst is replaced
+// by fs.TransferTo, which only removes it once the object took its place
+func transferFiles(ctx context.Context, src, dst string, overwrite, move bool) (int, error) {
+	status := http.StatusCreated
+	if _, err := fs.Get(ctx, dst, &fs.GetArgs{NoLog: true}); err == nil {
+		if !overwrite {
+			return http.StatusPreconditionFailed, nil
+		}
+		status = http.StatusNoContent
+	}
+	if err := fs.TransferTo(ctx, src, dst, move); err != nil {
+		return transferErrStatus(err), err
+	}
+	return status, nil
+}
+
+func transferErrStatus(err error) int {
+	switch {
+	case errors.Is(err, errs.QuotaExceeded):
+		return StatusInsufficientStorage
+	case errs.IsObjectNotFound(err):
+		return http.StatusNotFound
+	case errors.Is(err, errs.PermissionDenied):
+		return http.StatusForbidden
 	}
-	// TODO if there are no files copy, should return 204
-	return http.StatusCreated, nil
+	return http.StatusInternalServerError
 }
 
 // walkFS traverses filesystem fs starting at name up to depth levels.
@@ -92,32 +98,27 @@ func walkFS(ctx context.Context, depth int, name string, info model.Obj, walkFn
 		depth = 0
 	}
 	meta, _ := op.GetNearestMeta(name)
-	// Read directory names.
-	objs, err := fs.List(context.WithValue(ctx, conf.MetaKey, meta), name, &fs.ListArgs{})
-	//f, err := fs.OpenFile(ctx, name, os.O_RDONLY, 0)
-	//if err != nil {
-	//	return walkFn(name, info, err)
-	//}
-	//fileInfos, err := f.Readdir(0)
-	//f.Close()
-	if err != nil {
-		return walkFn(name, info, err)
-	}
-
-	for _, fileInfo := range objs {
-		filename := path.Join(name, fileInfo.GetName())
-		if err != nil {
-			if err := walkFn(filename, fileInfo, err); err != nil && err != filepath.SkipDir {
-				return err
-			}
-		} else {
-			err = walkFS(ctx, depth, filename, fileInfo, walkFn)
+	// Read directory names page by page, so that the responses of huge
+	// directories are written while they are still being listed.
+	var walkErr error
+	err = fs.WalkPages(context.WithValue(ctx, conf.MetaKey, meta), name, &fs.ListPageArgs{}, func(objs []model.Obj) error {
+		for _, fileInfo := range objs {
+			filename := path.Join(name, fileInfo.GetName())
+			err := walkFS(ctx, depth, filename, fileInfo, walkFn)
 			if err != nil {
 				if !fileInfo.IsDir() || err != filepath.SkipDir {
+					walkErr = err
 					return err
 				}
 			}
 		}
+		return nil
+	})
+	if walkErr != nil {
+		return walkErr
+	}
+	if err != nil {
+		return walkFn(name, info, err)
 	}
 	return nil
 }
@@ -0,0 +1,106 @@
+package webdav
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	_ "github.com/OpenListTeam/OpenList/v4/drivers/local"
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func init() {
+	dB, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
+	if err != nil {
+		panic("failed to connect database")
+	}
+	conf.Conf = conf.DefaultConfig("data")
+	db.Init(dB)
+}
+
+func TestTransferFiles(t *testing.T) {
+	root := t.TempDir()
+	addition, _ := json.Marshal(map[string]string{"root_folder_path": root})
+	ctx := context.Background()
+	id, err := op.CreateStorage(ctx, model.Storage{Driver: "Local", MountPath: "/dav", Addition: string(addition)})
+	if err != nil {
+		t.Fatalf("failed to create storage: %+v", err)
+	}
+	defer op.DeleteStorageById(ctx, id)
+	write := func(name, content string) {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(root, name)), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("a.txt", "a")
+	write("old.txt", "old")
+	write("sub/old.txt", "old")
+	ctx = context.WithValue(ctx, conf.UserKey, &model.User{Username: "admin", Role: model.ADMIN, Permission: 0xffff})
+
+	tests := []struct {
+		name      string
+		move      bool
+		src, dst  string
+		overwrite bool
+		status    int
+		// the expected contents of the files after it, "" for a missing file
+		files map[string]string
+	}{
+		{"copy to a new name", false, "/dav/a.txt", "/dav/b.txt", true, http.StatusCreated,
+			map[string]string{"a.txt": "a", "b.txt": "a"}},
+		{"copy over an existing target", false, "/dav/b.txt", "/dav/old.txt", true, http.StatusNoContent,
+			map[string]string{"b.txt": "a", "old.txt": "a"}},
+		{"copy without overwrite", false, "/dav/a.txt", "/dav/sub/old.txt", false, http.StatusPreconditionFailed,
+			map[string]string{"a.txt": "a", "sub/old.txt": "old"}},
+		{"move to a new name", true, "/dav/b.txt", "/dav/sub/c.txt", true, http.StatusCreated,
+			map[string]string{"b.txt": "", "sub/c.txt": "a"}},
+		{"move over an existing target", true, "/dav/sub/c.txt", "/dav/old.txt", true, http.StatusNoContent,
+			map[string]string{"sub/c.txt": "", "old.txt": "a"}},
+		{"rename over an existing target", true, "/dav/old.txt", "/dav/a.txt", true, http.StatusNoContent,
+			map[string]string{"old.txt": "", "a.txt": "a"}},
+		{"copy a missing file", false, "/dav/missing.txt", "/dav/sub/d.txt", true, http.StatusNotFound,
+			map[string]string{"sub/d.txt": "", "sub/old.txt": "old"}},
+	}
+	for _, test := range tests {
+		var status int
+		if test.move {
+			status, err = moveFiles(ctx, test.src, test.dst, test.overwrite)
+		} else {
+			status, err = copyFiles(ctx, test.src, test.dst, test.overwrite)
+		}
+		if status != test.status {
+			t.Errorf("%s: expected status %d, got %d %+v", test.name, test.status, status, err)
+		}
+		for name, content := range test.files {
+			data, err := os.ReadFile(filepath.Join(root, name))
+			if content == "" {
+				if err == nil {
+					t.Errorf("%s: expected %s to be missing", test.name, name)
+				}
+			} else if string(data) != content {
+				t.Errorf("%s: expected %s to be %q, got %q %v", test.name, name, content, data, err)
+			}
+		}
+	}
+	err = filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err == nil && (strings.HasPrefix(d.Name(), ".openlist_stage_") || strings.HasPrefix(d.Name(), ".openlist_replaced_")) {
+			t.Errorf("expected no staging object left, got %s", path)
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -90,6 +90,9 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	if status != 0 && errors.Is(err, errs.PermissionDenied) {
+		status = http.StatusForbidden
+	}
 	if status != 0 {
 		w.WriteHeader(status)
 		if status != http.StatusNoContent {
@@ -363,6 +366,9 @@ func (h *Handler) handlePut(w http.ResponseWriter, r *http.Request) (status int,
 	if setting.GetBool(conf.IgnoreSystemFiles) && utils.IsSystemFile(obj.Name) {
 		return http.StatusForbidden, errs.IgnoredSystemFile
 	}
+	if err = fs.CheckQuota(ctx, reqPath, size); err != nil {
+		return StatusInsufficientStorage, err
+	}
 	fsStream := &stream.FileStream{
 		Obj:      &obj,
 		Reader:   r.Body,
@@ -375,6 +381,9 @@ func (h *Handler) handlePut(w http.ResponseWriter, r *http.Request) (status int,
 	if errs.IsNotFoundError(err) {
 		return http.StatusNotFound, err
 	}
+	if errors.Is(err, errs.QuotaExceeded) {
+		return StatusInsufficientStorage, err
+	}
 
 	// TODO(rost): Returning 405 Method Not Allowed might not be appropriate.
 	if err != nil {
@@ -518,7 +527,7 @@ func (h *Handler) handleCopyMove(w http.ResponseWriter, r *http.Request) (status
 			return http.StatusBadRequest, errInvalidDepth
 		}
 	}
-	return moveFiles(ctx, src, dst, r.Header.Get("Overwrite") == "T")
+	return moveFiles(ctx, src, dst, r.Header.Get("Overwrite") != "F")
 }
 
 func (h *Handler) handleLock(w http.ResponseWriter, r *http.Request) (retStatus int, retErr error) {
@@ -701,7 +710,7 @@ func (h *Handler) handlePropfind(w http.ResponseWriter, r *http.Request) (status
 		if err != nil {
 			return err
 		}
-		href := path.Join(h.Prefix, strings.TrimPrefix(reqPath, user.BasePath))
+		href := path.Join(h.Prefix, user.RelPath(reqPath))
 		if href != "/" && info.IsDir() {
 			href += "/"
 		}